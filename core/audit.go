@@ -0,0 +1,313 @@
+package core
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/akutz/gofig"
+	"golang.org/x/net/context"
+
+	"github.com/emccode/rexray/core/audit"
+	"github.com/emccode/rexray/util"
+)
+
+var (
+	auditLogs    = map[string]*audit.Log{}
+	auditLogsRWL sync.RWMutex
+)
+
+// AuditLogPath returns the path to the audit log defined by the
+// configuration property rexray.audit.path, or the file audit.log in the
+// REX-Ray log directory if the property is not set.
+func AuditLogPath(config gofig.Config) string {
+	if config != nil {
+		if p := config.GetString("rexray.audit.path"); p != "" {
+			return p
+		}
+	}
+	return util.LogFilePath("audit.log")
+}
+
+// getAuditLog returns the audit log defined by the configuration. The log is
+// opened once per path and shared by all of the REX-Ray instances in the
+// process.
+func getAuditLog(config gofig.Config) (*audit.Log, error) {
+	path := AuditLogPath(config)
+
+	auditLogsRWL.RLock()
+	l, ok := auditLogs[path]
+	auditLogsRWL.RUnlock()
+	if ok {
+		return l, nil
+	}
+
+	auditLogsRWL.Lock()
+	defer auditLogsRWL.Unlock()
+
+	if l, ok := auditLogs[path]; ok {
+		return l, nil
+	}
+
+	l, err := audit.Open(path,
+		int64(config.GetInt("rexray.audit.maxSize"))*1024*1024,
+		config.GetInt("rexray.audit.maxFiles"))
+	if err != nil {
+		return nil, err
+	}
+	auditLogs[path] = l
+	return l, nil
+}
+
+type auditor struct {
+	rexray *RexRay
+	typ    string
+
+	// name returns the name of the driver that serves a call with the
+	// context
+	name func(ctx context.Context) string
+}
+
+// record writes a record of an operation that began at the provided time and
+// completed with the provided error to the audit log. A failure to write the
+// record is logged but does not fail the operation.
+func (a *auditor) record(
+	ctx context.Context,
+	op string,
+	start time.Time,
+	err error,
+	args map[string]interface{}) {
+
+	config := a.rexray.GetConfig()
+	if !config.GetBool("rexray.audit.enabled") {
+		return
+	}
+
+	r := &audit.Record{
+		Time:      start.UTC(),
+		RequestID: RequestID(ctx),
+		Caller:    GetCaller(ctx),
+		Type:      a.typ,
+		Driver:    a.name(ctx),
+		Op:        op,
+		Args:      args,
+		Result:    audit.ResultSuccess,
+		Duration:  time.Since(start).String(),
+	}
+	if err != nil {
+		r.Result = audit.ResultFailure
+		r.Error = err.Error()
+	}
+
+	l, lerr := getAuditLog(config)
+	if lerr == nil {
+		lerr = l.Write(r)
+	}
+	if lerr != nil {
+		Logger(ctx).WithFields(log.Fields{
+			"op":    op,
+			"path":  AuditLogPath(config),
+			"error": lerr,
+		}).Error("error writing audit record")
+	}
+}
+
+// auditedODM writes a record of each mutating call to an OS driver manager to
+// the audit log.
+type auditedODM struct {
+	OSDriverManager
+	a *auditor
+}
+
+func auditOSDriverManager(
+	r *RexRay,
+	m OSDriverManager, name func(context.Context) string) OSDriverManager {
+	return &auditedODM{m, &auditor{r, "os", name}}
+}
+
+func (m *auditedODM) Unmount(
+	ctx context.Context, mountPoint string) (err error) {
+	defer func(t time.Time) {
+		m.a.record(ctx, "Unmount", t, err, map[string]interface{}{
+			"mountPoint": mountPoint,
+		})
+	}(time.Now())
+	return m.OSDriverManager.Unmount(ctx, mountPoint)
+}
+
+func (m *auditedODM) Mount(
+	ctx context.Context,
+	device, target, mountOptions, mountLabel string) (err error) {
+	defer func(t time.Time) {
+		m.a.record(ctx, "Mount", t, err, map[string]interface{}{
+			"device":       device,
+			"target":       target,
+			"mountOptions": mountOptions,
+			"mountLabel":   mountLabel,
+		})
+	}(time.Now())
+	return m.OSDriverManager.Mount(
+		ctx, device, target, mountOptions, mountLabel)
+}
+
+func (m *auditedODM) Format(
+	ctx context.Context,
+	deviceName, fsType string, overwriteFs bool) (err error) {
+	defer func(t time.Time) {
+		m.a.record(ctx, "Format", t, err, map[string]interface{}{
+			"deviceName":  deviceName,
+			"fsType":      fsType,
+			"overwriteFs": overwriteFs,
+		})
+	}(time.Now())
+	return m.OSDriverManager.Format(ctx, deviceName, fsType, overwriteFs)
+}
+
+// auditedSDM writes a record of each mutating call to a storage driver
+// manager to the audit log.
+type auditedSDM struct {
+	StorageDriverManager
+	a *auditor
+}
+
+func auditStorageDriverManager(
+	r *RexRay,
+	m StorageDriverManager,
+	name func(context.Context) string) StorageDriverManager {
+	return &auditedSDM{m, &auditor{r, "storage", name}}
+}
+
+func (m *auditedSDM) CreateSnapshot(
+	ctx context.Context,
+	runAsync bool,
+	snapshotName, volumeID, description string) (
+	snaps []*Snapshot, err error) {
+	defer func(t time.Time) {
+		m.a.record(ctx, "CreateSnapshot", t, err, map[string]interface{}{
+			"runAsync":     runAsync,
+			"snapshotName": snapshotName,
+			"volumeID":     volumeID,
+			"description":  description,
+		})
+	}(time.Now())
+	return m.StorageDriverManager.CreateSnapshot(
+		ctx, runAsync, snapshotName, volumeID, description)
+}
+
+func (m *auditedSDM) RemoveSnapshot(
+	ctx context.Context, snapshotID string) (err error) {
+	defer func(t time.Time) {
+		m.a.record(ctx, "RemoveSnapshot", t, err, map[string]interface{}{
+			"snapshotID": snapshotID,
+		})
+	}(time.Now())
+	return m.StorageDriverManager.RemoveSnapshot(ctx, snapshotID)
+}
+
+func (m *auditedSDM) CreateVolume(
+	ctx context.Context,
+	runAsync bool,
+	volumeName, volumeID, snapshotID, volumeType string,
+	IOPS, size int64,
+	availabilityZone string) (vol *Volume, err error) {
+	defer func(t time.Time) {
+		args := map[string]interface{}{
+			"runAsync":         runAsync,
+			"volumeName":       volumeName,
+			"volumeID":         volumeID,
+			"snapshotID":       snapshotID,
+			"volumeType":       volumeType,
+			"iops":             IOPS,
+			"size":             size,
+			"availabilityZone": availabilityZone,
+		}
+		if vol != nil && volumeID == "" {
+			args["volumeID"] = vol.VolumeID
+		}
+		m.a.record(ctx, "CreateVolume", t, err, args)
+	}(time.Now())
+	return m.StorageDriverManager.CreateVolume(
+		ctx, runAsync, volumeName, volumeID, snapshotID, volumeType,
+		IOPS, size, availabilityZone)
+}
+
+func (m *auditedSDM) CloneVolume(
+	ctx context.Context,
+	sourceVolumeID, newName string,
+	opts VolumeOpts) (vol *Volume, err error) {
+	defer func(t time.Time) {
+		args := map[string]interface{}{
+			"sourceVolumeID": sourceVolumeID,
+			"volumeName":     newName,
+			"opts":           opts,
+		}
+		if vol != nil {
+			args["volumeID"] = vol.VolumeID
+		}
+		m.a.record(ctx, "CloneVolume", t, err, args)
+	}(time.Now())
+	return m.StorageDriverManager.CloneVolume(
+		ctx, sourceVolumeID, newName, opts)
+}
+
+func (m *auditedSDM) RemoveVolume(
+	ctx context.Context, volumeID string) (err error) {
+	defer func(t time.Time) {
+		m.a.record(ctx, "RemoveVolume", t, err, map[string]interface{}{
+			"volumeID": volumeID,
+		})
+	}(time.Now())
+	return m.StorageDriverManager.RemoveVolume(ctx, volumeID)
+}
+
+func (m *auditedSDM) AttachVolume(
+	ctx context.Context,
+	runAsync bool,
+	volumeID, instanceID string, force bool) (
+	atts []*VolumeAttachment, err error) {
+	defer func(t time.Time) {
+		m.a.record(ctx, "AttachVolume", t, err, map[string]interface{}{
+			"runAsync":   runAsync,
+			"volumeID":   volumeID,
+			"instanceID": instanceID,
+			"force":      force,
+		})
+	}(time.Now())
+	return m.StorageDriverManager.AttachVolume(
+		ctx, runAsync, volumeID, instanceID, force)
+}
+
+func (m *auditedSDM) DetachVolume(
+	ctx context.Context,
+	runAsync bool, volumeID, instanceID string, force bool) (err error) {
+	defer func(t time.Time) {
+		m.a.record(ctx, "DetachVolume", t, err, map[string]interface{}{
+			"runAsync":   runAsync,
+			"volumeID":   volumeID,
+			"instanceID": instanceID,
+			"force":      force,
+		})
+	}(time.Now())
+	return m.StorageDriverManager.DetachVolume(
+		ctx, runAsync, volumeID, instanceID, force)
+}
+
+func (m *auditedSDM) CopySnapshot(
+	ctx context.Context,
+	runAsync bool, volumeID, snapshotID, snapshotName,
+	destinationSnapshotName, destinationRegion string) (
+	snap *Snapshot, err error) {
+	defer func(t time.Time) {
+		m.a.record(ctx, "CopySnapshot", t, err, map[string]interface{}{
+			"runAsync":                runAsync,
+			"volumeID":                volumeID,
+			"snapshotID":              snapshotID,
+			"snapshotName":            snapshotName,
+			"destinationSnapshotName": destinationSnapshotName,
+			"destinationRegion":       destinationRegion,
+		})
+	}(time.Now())
+	return m.StorageDriverManager.CopySnapshot(
+		ctx, runAsync, volumeID, snapshotID, snapshotName,
+		destinationSnapshotName, destinationRegion)
+}
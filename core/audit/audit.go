@@ -0,0 +1,238 @@
+// Package audit provides an append-only log of the mutating storage
+// operations performed by REX-Ray, written as JSON lines and rotated by size.
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// CallerCLI is the type of a caller that is a user of the REX-Ray CLI.
+	CallerCLI = "cli"
+
+	// CallerDocker is the type of a caller that is the Docker engine,
+	// calling a REX-Ray Docker volume plug-in.
+	CallerDocker = "docker"
+
+	// CallerAPI is the type of a caller that is a client of the REX-Ray
+	// service's admin API.
+	CallerAPI = "api"
+)
+
+const (
+	// ResultSuccess is the result of an operation that succeeded.
+	ResultSuccess = "success"
+
+	// ResultFailure is the result of an operation that failed.
+	ResultFailure = "failure"
+)
+
+// Caller identifies the party that requested an operation.
+type Caller struct {
+
+	// The type of the caller; cli, docker, or api.
+	Type string `json:"type"`
+
+	// The name of the user that ran the CLI.
+	User string `json:"user,omitempty"`
+
+	// The ID of the user that ran the CLI.
+	UID string `json:"uid,omitempty"`
+
+	// The remote address of an API client.
+	Address string `json:"address,omitempty"`
+
+	// The identity reported by the client, such as the user of the CLI that
+	// sent an API request or the name of the Docker module.
+	Client string `json:"client,omitempty"`
+}
+
+// String returns a short description of the caller.
+func (c *Caller) String() string {
+	if c == nil {
+		return ""
+	}
+	s := c.Type
+	if c.User != "" {
+		s = fmt.Sprintf("%s:%s(uid=%s)", s, c.User, c.UID)
+	}
+	if c.Address != "" {
+		s = fmt.Sprintf("%s@%s", s, c.Address)
+	}
+	if c.Client != "" {
+		s = fmt.Sprintf("%s[%s]", s, c.Client)
+	}
+	return s
+}
+
+// Record describes a mutating operation.
+type Record struct {
+
+	// The time at which the operation started.
+	Time time.Time `json:"time"`
+
+	// The ID of the request that caused the operation.
+	RequestID string `json:"requestID,omitempty"`
+
+	// The party that requested the operation.
+	Caller *Caller `json:"caller,omitempty"`
+
+	// The type of the driver manager that performed the operation; os or
+	// storage.
+	Type string `json:"type"`
+
+	// The name of the driver that performed the operation.
+	Driver string `json:"driver,omitempty"`
+
+	// The operation, ex. DetachVolume.
+	Op string `json:"op"`
+
+	// The operation's arguments.
+	Args map[string]interface{} `json:"args,omitempty"`
+
+	// The result of the operation; success or failure.
+	Result string `json:"result"`
+
+	// The error if the operation failed.
+	Error string `json:"error,omitempty"`
+
+	// The amount of time the operation took.
+	Duration string `json:"duration"`
+}
+
+// Log is an append-only log of records. When the log's file reaches its
+// maximum size it is renamed with the suffix .1, previously rotated files are
+// renamed with the next higher suffix, and the oldest files are removed so
+// that no more than the maximum number of rotated files are kept.
+type Log struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+
+	m    sync.Mutex
+	f    *os.File
+	size int64
+}
+
+// Open opens the log at the provided path, creating the file if it does not
+// exist. A maxSize of zero disables rotation.
+func Open(path string, maxSize int64, maxFiles int) (*Log, error) {
+	l := &Log{path: path, maxSize: maxSize, maxFiles: maxFiles}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Path returns the path to the log's file.
+func (l *Log) Path() string {
+	return l.path
+}
+
+func (l *Log) open() error {
+	f, err := os.OpenFile(
+		l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0640)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	l.f = f
+	l.size = fi.Size()
+	return nil
+}
+
+// Write appends the record to the log.
+func (l *Log) Write(r *Record) error {
+	buf, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	buf = append(buf, '\n')
+
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	if err := l.reopen(); err != nil {
+		return err
+	}
+
+	if l.maxSize > 0 && l.size > 0 && l.size+int64(len(buf)) > l.maxSize {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := l.f.Write(buf)
+	l.size += int64(n)
+	if err != nil {
+		return err
+	}
+	return l.f.Sync()
+}
+
+// reopen opens the log's file if it is not open or if it has been rotated by
+// another process, since more than one REX-Ray process may write to the same
+// log.
+func (l *Log) reopen() error {
+	if l.f != nil {
+		fi, err := os.Stat(l.path)
+		ofi, oerr := l.f.Stat()
+		if err == nil && oerr == nil && os.SameFile(fi, ofi) {
+			l.size = ofi.Size()
+			return nil
+		}
+		l.f.Close()
+		l.f = nil
+	}
+	return l.open()
+}
+
+func (l *Log) rotate() error {
+	if err := l.f.Close(); err != nil {
+		return err
+	}
+	l.f = nil
+
+	if l.maxFiles < 1 {
+		if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return l.open()
+	}
+
+	os.Remove(rotatedPath(l.path, l.maxFiles))
+	for i := l.maxFiles - 1; i > 0; i-- {
+		err := os.Rename(rotatedPath(l.path, i), rotatedPath(l.path, i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(l.path, rotatedPath(l.path, 1)); err != nil {
+		return err
+	}
+	return l.open()
+}
+
+// Close closes the log's file.
+func (l *Log) Close() error {
+	l.m.Lock()
+	defer l.m.Unlock()
+	if l.f == nil {
+		return nil
+	}
+	err := l.f.Close()
+	l.f = nil
+	return err
+}
+
+func rotatedPath(path string, i int) string {
+	return fmt.Sprintf("%s.%d", path, i)
+}
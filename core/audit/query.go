@@ -0,0 +1,259 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filter selects records. An empty filter selects all records.
+type Filter struct {
+
+	// Since selects the records of the operations that started at or after
+	// this time.
+	Since time.Time
+
+	// Until selects the records of the operations that started before this
+	// time.
+	Until time.Time
+
+	// Ops selects the records of these operations.
+	Ops []string
+
+	// Volume selects the records of the operations whose volumeID,
+	// volumeName, or sourceVolumeID argument is this value.
+	Volume string
+
+	// Caller selects the records of the operations requested by a caller
+	// whose type, user, UID, or client is this value.
+	Caller string
+
+	// Failed selects the records of the operations that failed.
+	Failed bool
+}
+
+// volumeArgs are the names of the arguments matched by Filter.Volume.
+var volumeArgs = []string{"volumeID", "volumeName", "sourceVolumeID"}
+
+// Match returns a flag indicating whether or not the filter selects the
+// record.
+func (f *Filter) Match(r *Record) bool {
+	if f == nil {
+		return true
+	}
+
+	if !f.Since.IsZero() && r.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !r.Time.Before(f.Until) {
+		return false
+	}
+	if f.Failed && r.Result != ResultFailure {
+		return false
+	}
+
+	if len(f.Ops) > 0 {
+		ok := false
+		for _, op := range f.Ops {
+			if strings.EqualFold(op, r.Op) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+
+	if f.Volume != "" {
+		ok := false
+		for _, k := range volumeArgs {
+			if v, _ := r.Args[k].(string); v == f.Volume {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+
+	if f.Caller != "" {
+		c := r.Caller
+		if c == nil || (f.Caller != c.Type &&
+			f.Caller != c.User &&
+			f.Caller != c.UID &&
+			f.Caller != c.Client) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Files returns the paths of the log's file and its rotated files that
+// exist, ordered from the oldest to the newest.
+func Files(path string) ([]string, error) {
+	matches, err := filepath.Glob(path + ".*")
+	if err != nil {
+		return nil, err
+	}
+
+	var indices []int
+	for _, m := range matches {
+		i, err := strconv.Atoi(strings.TrimPrefix(m, path+"."))
+		if err != nil || i < 1 {
+			continue
+		}
+		indices = append(indices, i)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(indices)))
+
+	var paths []string
+	for _, i := range indices {
+		paths = append(paths, rotatedPath(path, i))
+	}
+	if _, err := os.Stat(path); err == nil {
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// Query invokes fn for each of the log's records selected by the filter,
+// from the oldest to the newest. Lines that are not valid records are
+// skipped.
+func Query(path string, f *Filter, fn func(r *Record) error) error {
+	paths, err := Files(path)
+	if err != nil {
+		return err
+	}
+	for _, p := range paths {
+		if err := queryFile(p, f, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func queryFile(path string, f *Filter, fn func(r *Record) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+	_, err = scan(file, f, fn)
+	return err
+}
+
+// scan invokes fn for each of the complete lines read from the reader that
+// is a record selected by the filter, and returns the number of bytes
+// consumed by those lines.
+func scan(rd io.Reader, f *Filter, fn func(r *Record) error) (int64, error) {
+	var n int64
+	br := bufio.NewReader(rd)
+	for {
+		line, err := br.ReadBytes('\n')
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		n += int64(len(line))
+
+		r := &Record{}
+		if json.Unmarshal(line, r) != nil || !f.Match(r) {
+			continue
+		}
+		if err := fn(r); err != nil {
+			return n, err
+		}
+	}
+}
+
+// Tail returns the last n of the log's records that are selected by the
+// filter, ordered from the oldest to the newest.
+func Tail(path string, n int, f *Filter) ([]*Record, error) {
+	var records []*Record
+	err := Query(path, f, func(r *Record) error {
+		records = append(records, r)
+		if len(records) > n {
+			records = records[1:]
+		}
+		return nil
+	})
+	return records, err
+}
+
+// Follow invokes fn for each record selected by the filter as it is appended
+// to the log, polling the log at the provided interval, until the stop
+// channel is closed. Follow reopens the log's file when it is rotated.
+func Follow(
+	path string,
+	f *Filter,
+	interval time.Duration,
+	stop <-chan struct{},
+	fn func(r *Record) error) error {
+
+	var (
+		file   *os.File
+		offset int64
+	)
+
+	if fi, err := os.Stat(path); err == nil {
+		offset = fi.Size()
+	}
+
+	defer func() {
+		if file != nil {
+			file.Close()
+		}
+	}()
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		fi, err := os.Stat(path)
+		if err == nil {
+			if file != nil {
+				ofi, err := file.Stat()
+				if err != nil || !os.SameFile(fi, ofi) || fi.Size() < offset {
+					file.Close()
+					file = nil
+					offset = 0
+				}
+			}
+			if file == nil {
+				if file, err = os.Open(path); err != nil {
+					return err
+				}
+			}
+			if _, err := file.Seek(offset, os.SEEK_SET); err != nil {
+				return err
+			}
+			n, err := scan(file, f, fn)
+			offset += n
+			if err != nil {
+				return err
+			}
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+
+		select {
+		case <-stop:
+			return nil
+		case <-t.C:
+		}
+	}
+}
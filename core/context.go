@@ -0,0 +1,225 @@
+package core
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/akutz/gofig"
+	"golang.org/x/net/context"
+
+	"github.com/emccode/rexray/core/audit"
+	"github.com/emccode/rexray/core/errors"
+)
+
+// RequestIDField is the name of the log field that holds a request ID.
+const RequestIDField = "requestID"
+
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	callerKey
+	storageDriverKey
+	volumeOptsKey
+	operationReceiverKey
+)
+
+// WithRequestID returns a copy of the parent context that carries the
+// provided request ID.
+func WithRequestID(parent context.Context, requestID string) context.Context {
+	return context.WithValue(parent, requestIDKey, requestID)
+}
+
+// RequestID returns the request ID carried by the context, or an empty string
+// if the context does not carry one.
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if v, ok := ctx.Value(requestIDKey).(string); ok {
+		return v
+	}
+	return ""
+}
+
+// WithCaller returns a copy of the parent context that carries the provided
+// identity of the party that requested an operation.
+func WithCaller(parent context.Context, caller *audit.Caller) context.Context {
+	return context.WithValue(parent, callerKey, caller)
+}
+
+// GetCaller returns the caller carried by the context, or nil if the context
+// does not carry one.
+func GetCaller(ctx context.Context) *audit.Caller {
+	if ctx == nil {
+		return nil
+	}
+	if v, ok := ctx.Value(callerKey).(*audit.Caller); ok {
+		return v
+	}
+	return nil
+}
+
+// WithStorageDriverName returns a copy of the parent context that selects
+// the storage driver, or instance of a storage driver, ex. scaleio/prod, with
+// the configured driver name.
+func WithStorageDriverName(
+	parent context.Context, driverName string) context.Context {
+	return context.WithValue(parent, storageDriverKey, driverName)
+}
+
+// StorageDriverName returns the name of the storage driver selected by the
+// context, or an empty string if the context does not select one.
+func StorageDriverName(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if v, ok := ctx.Value(storageDriverKey).(string); ok {
+		return v
+	}
+	return ""
+}
+
+// WithVolumeOpts returns a copy of the parent context that carries the options
+// of a volume that is being created. Storage drivers read the options that are
+// not parameters of CreateVolume, ex. encrypted, with GetVolumeOpts.
+func WithVolumeOpts(parent context.Context, opts VolumeOpts) context.Context {
+	return context.WithValue(parent, volumeOptsKey, opts)
+}
+
+// GetVolumeOpts returns the volume options carried by the context, or nil if
+// the context does not carry any.
+func GetVolumeOpts(ctx context.Context) VolumeOpts {
+	if ctx == nil {
+		return nil
+	}
+	if v, ok := ctx.Value(volumeOptsKey).(VolumeOpts); ok {
+		return v
+	}
+	return nil
+}
+
+// GetVolumeOpt returns the value of the volume option carried by the context.
+// The option's name is not case sensitive.
+func GetVolumeOpt(ctx context.Context, name string) (string, bool) {
+	for k, v := range GetVolumeOpts(ctx) {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// WithOperationReceiver returns a copy of the parent context whose
+// asynchronous storage calls pass the operations they start to the provided
+// function. An asynchronous DetachVolume only returns an error, so this is how
+// its caller learns the ID of the operation.
+func WithOperationReceiver(
+	parent context.Context, f func(op *Operation)) context.Context {
+	return context.WithValue(parent, operationReceiverKey, f)
+}
+
+// getOperationReceiver returns the operation receiver carried by the context,
+// or nil if the context does not carry one.
+func getOperationReceiver(ctx context.Context) func(op *Operation) {
+	if ctx == nil {
+		return nil
+	}
+	if v, ok := ctx.Value(operationReceiverKey).(func(op *Operation)); ok {
+		return v
+	}
+	return nil
+}
+
+// Logger returns a log entry that includes the request ID carried by the
+// context. Every log entry written while servicing a request should be
+// created with this function so that the entries can be correlated.
+func Logger(ctx context.Context) *log.Entry {
+	e := log.NewEntry(log.StandardLogger())
+	if id := RequestID(ctx); id != "" {
+		return e.WithField(RequestIDField, id)
+	}
+	return e
+}
+
+// RequestError returns the provided error annotated with the request ID
+// carried by the context so the ID may be returned to the caller. The error
+// is returned unchanged if it is nil, if the context does not carry a request
+// ID, or if the error is already annotated.
+func RequestError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*errors.RequestError); ok {
+		return err
+	}
+	id := RequestID(ctx)
+	if id == "" {
+		return err
+	}
+	return &errors.RequestError{RequestID: id, Err: err}
+}
+
+// NewRequestID returns a new, random request ID.
+func NewRequestID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return time.Now().UTC().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(buf)
+}
+
+// NewContext returns a context derived from the parent context that carries
+// the provided request ID, or a new request ID if the provided one is empty.
+// The context's deadline is defined by the configuration property
+// rexray.request.timeout. The returned cancel function should be called as
+// soon as the request completes in order to release the context's resources.
+func NewContext(
+	parent context.Context,
+	config gofig.Config,
+	requestID string) (context.Context, context.CancelFunc) {
+
+	if parent == nil {
+		parent = context.Background()
+	}
+
+	if requestID == "" {
+		requestID = NewRequestID()
+	}
+
+	ctx := WithRequestID(parent, requestID)
+
+	if timeout := requestTimeout(config); timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+func requestTimeout(config gofig.Config) time.Duration {
+	return getDuration(config, "rexray.request.timeout")
+}
+
+// getDuration returns the duration defined by the configuration property, or
+// zero if the property is not set or is not a valid duration.
+func getDuration(config gofig.Config, key string) time.Duration {
+	if config == nil {
+		return 0
+	}
+	v := config.GetString(key)
+	if v == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"key":   key,
+			"value": v,
+			"error": err,
+		}).Warn("invalid duration")
+		return 0
+	}
+	return d
+}
@@ -13,12 +13,17 @@ func init() {
 	initDrivers()
 	gofig.SetGlobalConfigPath(util.EtcDirPath())
 	gofig.SetUserConfigPath(fmt.Sprintf("%s/.rexray", gotil.HomeDir()))
-	gofig.Register(globalRegistration())
-	gofig.Register(driverRegistration())
+	RegisterConfigSchema(globalRegistration())
+	RegisterConfigSchema(driverRegistration())
+	RegisterConfigSchema(eventsRegistration())
+	RegisterConfigSchema(auditRegistration())
+	RegisterConfigSchema(operationsRegistration())
+	RegisterConfigSchema(reconcileRegistration())
+	RegisterConfigSchema(secretsRegistration())
 }
 
-func globalRegistration() *gofig.Registration {
-	r := gofig.NewRegistration("Global")
+func globalRegistration() *ConfigSchema {
+	r := NewConfigSchema("Global")
 	r.Yaml(`
 rexray:
     host: tcp://:7979
@@ -30,11 +35,21 @@ rexray:
 	r.Key(gofig.String, "l", "warn",
 		"The log level (error, warn, info, debug)", "rexray.logLevel",
 		"logLevel")
+	r.Key(gofig.String, "", "10m",
+		"The maximum amount of time to service a request",
+		"rexray.request.timeout")
+	r.Key(gofig.String, "", "5s",
+		"The maximum amount of time a driver health check may take",
+		"rexray.health.timeout")
+	r.Key(gofig.String, "", "30s",
+		"The amount of time for which a driver health check's result is "+
+			"reused",
+		"rexray.health.interval")
 	return r
 }
 
-func driverRegistration() *gofig.Registration {
-	r := gofig.NewRegistration("Driver")
+func driverRegistration() *ConfigSchema {
+	r := NewConfigSchema("Driver")
 	r.Yaml(`
 rexray:
     osDrivers:
@@ -53,5 +68,83 @@ rexray:
 	r.Key(gofig.String, "", "docker",
 		"The volume drivers to consider", "rexray.volumeDrivers",
 		"volumeDrivers")
+	r.Key(gofig.Bool, "", false,
+		"A flag indicating whether or not an invalid configuration or a "+
+			"driver that fails to initialize is fatal",
+		"rexray.strict")
+	return r
+}
+
+func eventsRegistration() *ConfigSchema {
+	r := NewConfigSchema("Events")
+	r.Key(gofig.String, "", "",
+		"The URLs to which volume lifecycle events are posted",
+		"rexray.events.webhooks")
+	r.Key(gofig.String, "", "",
+		"The types of the events posted to the webhooks",
+		"rexray.events.webhook.types")
+	r.Key(gofig.String, "", "",
+		"The IDs or names of the volumes whose events are posted to the "+
+			"webhooks",
+		"rexray.events.webhook.volumes")
+	r.Key(gofig.String, "", "10s",
+		"The maximum amount of time to post an event to a webhook",
+		"rexray.events.webhook.timeout")
+	return r
+}
+
+func auditRegistration() *ConfigSchema {
+	r := NewConfigSchema("Audit")
+	r.Key(gofig.Bool, "", true,
+		"A flag indicating whether or not mutating operations are audited",
+		"rexray.audit.enabled")
+	r.Key(gofig.String, "", "",
+		"The path to the audit log; defaults to audit.log in the log directory",
+		"rexray.audit.path")
+	r.Key(gofig.Int, "", 100,
+		"The size, in megabytes, at which the audit log is rotated",
+		"rexray.audit.maxSize")
+	r.Key(gofig.Int, "", 10,
+		"The number of rotated audit logs to keep",
+		"rexray.audit.maxFiles")
+	return r
+}
+
+func operationsRegistration() *ConfigSchema {
+	r := NewConfigSchema("Operations")
+	r.Key(gofig.String, "", "24h",
+		"The amount of time for which finished operations are kept",
+		"rexray.operations.ttl")
+	r.Key(gofig.Int, "", 100,
+		"The number of finished operations to keep",
+		"rexray.operations.max")
+	return r
+}
+
+func reconcileRegistration() *ConfigSchema {
+	r := NewConfigSchema("Reconcile")
+	r.Key(gofig.String, "", "",
+		"The interval at which the daemon reconciles the instance; "+
+			"reconciliation is disabled if not set",
+		"rexray.reconcile.interval")
+	r.Key(gofig.Bool, "", false,
+		"A flag indicating whether or not the daemon repairs the drift "+
+			"it finds when reconciling",
+		"rexray.reconcile.fix")
+	return r
+}
+
+func secretsRegistration() *ConfigSchema {
+	r := NewConfigSchema("Secrets")
+	r.Key(gofig.String, "", "",
+		"The address of the Vault server; defaults to VAULT_ADDR",
+		"rexray.secrets.vault.address")
+	r.Key(gofig.String, "", "",
+		"The token used to authenticate with Vault; defaults to VAULT_TOKEN",
+		"rexray.secrets.vault.token")
+	r.Key(gofig.String, "", "10s",
+		"The maximum amount of time to read a secret from Vault",
+		"rexray.secrets.vault.timeout")
+	r.Secret("rexray.secrets.vault.token")
 	return r
 }
@@ -1,5 +1,11 @@
 package core
 
+import (
+	"strings"
+
+	"github.com/akutz/gofig"
+)
+
 var (
 	driverCtors map[string]NewDriver
 )
@@ -39,3 +45,72 @@ func DriverNames() <-chan string {
 	}()
 	return c
 }
+
+// DriverInstanceSeparator separates the name of a registered driver from the
+// name of one of its instances, ex. scaleio/prod.
+const DriverInstanceSeparator = "/"
+
+// ParseDriverName returns the name of the registered driver and the name of
+// the instance of the driver that are identified by a configured driver name.
+// For example, the driver name scaleio/prod identifies the instance prod of
+// the driver scaleio. The instance name is empty if the driver name does not
+// identify an instance.
+func ParseDriverName(name string) (driverName, instanceName string) {
+	parts := strings.SplitN(name, DriverInstanceSeparator, 2)
+	if len(parts) == 1 {
+		return name, ""
+	}
+	return parts[0], parts[1]
+}
+
+// getDriverCtor returns the constructor of the registered driver with the
+// name. Driver names are case-insensitive, ex. scaleio is the driver ScaleIO.
+func getDriverCtor(driverName string) (NewDriver, bool) {
+	for n, ctor := range driverCtors {
+		if strings.EqualFold(n, driverName) {
+			return ctor, true
+		}
+	}
+	return nil, false
+}
+
+// getDriver returns the driver with the configured driver name. A new driver
+// is constructed for each named instance of a registered driver. Driver
+// names are case-insensitive.
+func (r *RexRay) getDriver(name string) (Driver, bool) {
+	driverName, instanceName := ParseDriverName(name)
+	if instanceName == "" {
+		for n, d := range r.drivers {
+			if strings.EqualFold(n, driverName) {
+				return d, true
+			}
+		}
+		return nil, false
+	}
+	ctor, ok := getDriverCtor(driverName)
+	if !ok {
+		return nil, false
+	}
+	return ctor(), true
+}
+
+// driverConfig returns the configuration with which the driver with the
+// configured driver name is initialized. An instance of a driver reads its
+// properties from the subtree named after the instance, and the properties
+// that are not set in the subtree from the root of the configuration.
+func driverConfig(config gofig.Config, name string) gofig.Config {
+	if _, instanceName := ParseDriverName(name); instanceName != "" {
+		return ScopeConfig(config, instanceName)
+	}
+	return config
+}
+
+// driverName returns the name by which the driver with the configured driver
+// name is reported; the driver's name, or the configured name of an instance
+// of the driver.
+func driverName(name string, d Driver) string {
+	if _, instanceName := ParseDriverName(name); instanceName != "" {
+		return name
+	}
+	return d.Name()
+}
@@ -3,9 +3,11 @@ package core
 import (
 	"bytes"
 	"strings"
+	"sync"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/docker/docker/pkg/mount"
+	"golang.org/x/net/context"
 
 	"github.com/emccode/rexray/core/errors"
 )
@@ -19,19 +21,19 @@ type OSDriver interface {
 	Driver
 
 	// Shows the existing mount points
-	GetMounts(string, string) (MountInfoArray, error)
+	GetMounts(context.Context, string, string) (MountInfoArray, error)
 
 	// Check whether path is mounted or not
-	Mounted(string) (bool, error)
+	Mounted(context.Context, string) (bool, error)
 
 	// Unmount based on a path
-	Unmount(string) error
+	Unmount(context.Context, string) error
 
 	// Mount based on a device, target, options, label
-	Mount(string, string, string, string) error
+	Mount(context.Context, string, string, string, string) error
 
 	// Format a device with a FS type
-	Format(string, string, bool) error
+	Format(context.Context, string, string, bool) error
 }
 
 // OSDriverManager acts as both a OSDriverManager and as an aggregate of OS
@@ -47,10 +49,26 @@ type OSDriverManager interface {
 type odm struct {
 	rexray  *RexRay
 	drivers map[string]OSDriver
+	rwl     sync.RWMutex
+}
+
+func (r *odm) getDrivers() map[string]OSDriver {
+	r.rwl.RLock()
+	defer r.rwl.RUnlock()
+	return r.drivers
+}
+
+// setDrivers replaces the manager's drivers. The map is never modified after
+// it is set so that in-flight calls may finish with the drivers they started
+// with.
+func (r *odm) setDrivers(drivers map[string]OSDriver) {
+	r.rwl.Lock()
+	defer r.rwl.Unlock()
+	r.drivers = drivers
 }
 
 func (r *odm) Init(rexray *RexRay) error {
-	if len(r.drivers) == 0 {
+	if len(r.getDrivers()) == 0 {
 		return errors.ErrNoOSDrivers
 	}
 	return nil
@@ -58,23 +76,49 @@ func (r *odm) Init(rexray *RexRay) error {
 
 func (r *odm) Name() string {
 	var b bytes.Buffer
-	for d := range r.Drivers() {
+	for n, d := range r.getDrivers() {
 		if b.Len() > 0 {
 			b.WriteString(" ")
 		}
-		b.WriteString(d.Name())
+		b.WriteString(driverName(n, d))
 	}
 	return b.String()
 }
 
+// driver returns the OS driver that serves the manager's calls, the first of
+// the enabled OS drivers, and the name by which the driver is reported.
+func (r *odm) driver() (OSDriver, string, error) {
+	drivers := r.getDrivers()
+	for _, n := range r.rexray.GetConfig().GetStringSlice("rexray.osDrivers") {
+		for dn, d := range drivers {
+			if strings.EqualFold(dn, n) {
+				return d, driverName(dn, d), nil
+			}
+		}
+	}
+	for n, d := range drivers {
+		return d, driverName(n, d), nil
+	}
+	return nil, "", errors.ErrNoOSDetected
+}
+
+// reportedName returns the name of the OS driver that serves the calls, or
+// the name of the manager if there is none.
+func (r *odm) reportedName(ctx context.Context) string {
+	if _, n, err := r.driver(); err == nil {
+		return n
+	}
+	return r.Name()
+}
+
 func (r *odm) Drivers() <-chan OSDriver {
 	c := make(chan OSDriver)
 	go func() {
-		if len(r.drivers) == 0 {
+		if len(r.getDrivers()) == 0 {
 			close(c)
 			return
 		}
-		for _, v := range r.drivers {
+		for _, v := range r.getDrivers() {
 			c <- v
 		}
 		close(c)
@@ -83,53 +127,60 @@ func (r *odm) Drivers() <-chan OSDriver {
 }
 
 func (r *odm) GetMounts(
+	ctx context.Context,
 	deviceName, mountPoint string) (MountInfoArray, error) {
-	for _, d := range r.drivers {
-		log.WithFields(log.Fields{
-			"deviceName": deviceName,
-			"mountPoint": mountPoint,
-			"driverName": d.Name()}).Info("getting mounts")
-		mounts, err := d.GetMounts(deviceName, mountPoint)
-		if err != nil {
-			return nil, err
-		}
-		return mounts, nil
+	d, _, err := r.driver()
+	if err != nil {
+		return nil, err
+	}
+	Logger(ctx).WithFields(log.Fields{
+		"deviceName": deviceName,
+		"mountPoint": mountPoint,
+		"driverName": d.Name()}).Info("getting mounts")
+	mounts, err := d.GetMounts(ctx, deviceName, mountPoint)
+	if err != nil {
+		return nil, err
 	}
-	return nil, errors.ErrNoOSDetected
+	return mounts, nil
 }
 
-func (r *odm) Mounted(mountPoint string) (bool, error) {
-	for _, d := range r.drivers {
-		log.WithFields(log.Fields{
-			"mountPoint": mountPoint,
-			"driverName": d.Name()}).Info("checking filesystem mount")
-		return d.Mounted(mountPoint)
+func (r *odm) Mounted(
+	ctx context.Context, mountPoint string) (bool, error) {
+	d, _, err := r.driver()
+	if err != nil {
+		return false, err
 	}
-	return false, errors.ErrNoOSDetected
+	Logger(ctx).WithFields(log.Fields{
+		"mountPoint": mountPoint,
+		"driverName": d.Name()}).Info("checking filesystem mount")
+	return d.Mounted(ctx, mountPoint)
 }
 
-func (r *odm) Unmount(mountPoint string) error {
-	for _, d := range r.drivers {
-		log.WithFields(log.Fields{
-			"mountPoint": mountPoint,
-			"driverName": d.Name()}).Info("unmounting filesystem")
-		return d.Unmount(mountPoint)
+func (r *odm) Unmount(ctx context.Context, mountPoint string) error {
+	d, _, err := r.driver()
+	if err != nil {
+		return err
 	}
-	return errors.ErrNoOSDetected
+	Logger(ctx).WithFields(log.Fields{
+		"mountPoint": mountPoint,
+		"driverName": d.Name()}).Info("unmounting filesystem")
+	return d.Unmount(ctx, mountPoint)
 }
 
 func (r *odm) Mount(
+	ctx context.Context,
 	device, target, mountOptions, mountLabel string) error {
-	for _, d := range r.drivers {
-		log.WithFields(log.Fields{
-			"device":       device,
-			"target":       target,
-			"mountOptions": mountOptions,
-			"mountLabel":   mountLabel,
-			"driverName":   d.Name()}).Info("mounting filesystem")
-		return d.Mount(device, target, mountOptions, mountLabel)
+	d, _, err := r.driver()
+	if err != nil {
+		return err
 	}
-	return errors.ErrNoOSDetected
+	Logger(ctx).WithFields(log.Fields{
+		"device":       device,
+		"target":       target,
+		"mountOptions": mountOptions,
+		"mountLabel":   mountLabel,
+		"driverName":   d.Name()}).Info("mounting filesystem")
+	return d.Mount(ctx, device, target, mountOptions, mountLabel)
 }
 
 func (r *odm) isNfsDevice(device string) bool {
@@ -137,19 +188,21 @@ func (r *odm) isNfsDevice(device string) bool {
 }
 
 func (r *odm) Format(
+	ctx context.Context,
 	deviceName, fsType string, overwriteFs bool) error {
-	for _, d := range r.drivers {
-		log.WithFields(log.Fields{
-			"deviceName":  deviceName,
-			"fsType":      fsType,
-			"overwriteFs": overwriteFs,
-			"driverName":  d.Name()}).Info(
-			"formatting if blank or overwriteFs specified")
-		if r.isNfsDevice(deviceName) {
-			return nil
-		}
-
-		return d.Format(deviceName, fsType, overwriteFs)
+	d, _, err := r.driver()
+	if err != nil {
+		return err
 	}
-	return errors.ErrNoOSDetected
+	Logger(ctx).WithFields(log.Fields{
+		"deviceName":  deviceName,
+		"fsType":      fsType,
+		"overwriteFs": overwriteFs,
+		"driverName":  d.Name()}).Info(
+		"formatting if blank or overwriteFs specified")
+	if r.isNfsDevice(deviceName) {
+		return nil
+	}
+
+	return d.Format(ctx, deviceName, fsType, overwriteFs)
 }
@@ -2,9 +2,17 @@ package core
 
 import (
 	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
 	"sync"
 
+	log "github.com/Sirupsen/logrus"
+	"github.com/akutz/goof"
+	"golang.org/x/net/context"
+
 	"github.com/emccode/rexray/core/errors"
+	"github.com/emccode/rexray/core/events"
 )
 
 // BlockDevice provides information about a block-storage device.
@@ -71,6 +79,10 @@ type Snapshot struct {
 
 	// The status of the snapshot.
 	Status string
+
+	// The ID of the operation started by the asynchronous call that returned
+	// the snapshot.
+	OperationID string `json:",omitempty"`
 }
 
 // Volume provides information about a storage volume.
@@ -97,11 +109,28 @@ type Volume struct {
 	// The size of the volume.
 	Size string
 
+	// A flag indicating whether or not the volume is encrypted.
+	Encrypted bool
+
+	// A flag indicating whether or not the volume may be attached to more
+	// than one instance.
+	MultiAttach bool
+
+	// A flag indicating whether or not the volume is thin provisioned.
+	ThinProvisioned bool
+
+	// The volume bandwidth limit, in KB/s.
+	BandwidthLimit int64
+
 	// The name of the network on which the volume resides.
 	NetworkName string
 
 	// The volume's attachments.
 	Attachments []*VolumeAttachment
+
+	// The ID of the operation started by the asynchronous call that returned
+	// the volume.
+	OperationID string `json:",omitempty"`
 }
 
 // VolumeAttachment provides information about an object attached to a
@@ -121,6 +150,10 @@ type VolumeAttachment struct {
 
 	// The status of the attachment.
 	Status string
+
+	// The ID of the operation started by the asynchronous call that returned
+	// the attachment.
+	OperationID string `json:",omitempty"`
 }
 
 // StorageDriver is the interface implemented by types that provide storage
@@ -129,69 +162,94 @@ type StorageDriver interface {
 	Driver
 
 	// GetVolumeMapping lists the block devices that are attached to the
-	GetVolumeMapping() ([]*BlockDevice, error)
+	GetVolumeMapping(ctx context.Context) ([]*BlockDevice, error)
 
 	// GetInstance retrieves the local instance.
-	GetInstance() (*Instance, error)
+	GetInstance(ctx context.Context) (*Instance, error)
 
 	// GetVolume returns all volumes for the instance based on either volumeID
 	// or volumeName that are available to the instance.
-	GetVolume(volumeID, volumeName string) ([]*Volume, error)
+	GetVolume(
+		ctx context.Context, volumeID, volumeName string) ([]*Volume, error)
 
 	// GetVolumeAttach returns the attachment details based on volumeID or
 	// volumeName where the volume is currently attached.
-	GetVolumeAttach(volumeID, instanceID string) ([]*VolumeAttachment, error)
+	GetVolumeAttach(
+		ctx context.Context,
+		volumeID, instanceID string) ([]*VolumeAttachment, error)
 
 	// CreateSnapshot is a synch/async operation that returns snapshots that
 	// have been performed based on supplying a snapshotName, source volumeID,
 	// and optional description.
 	CreateSnapshot(
+		ctx context.Context,
 		runAsync bool,
 		snapshotName, volumeID, description string) ([]*Snapshot, error)
 
 	// GetSnapshot returns a list of snapshots for a volume based on volumeID,
 	// snapshotID, or snapshotName.
-	GetSnapshot(volumeID, snapshotID, snapshotName string) ([]*Snapshot, error)
+	GetSnapshot(
+		ctx context.Context,
+		volumeID, snapshotID, snapshotName string) ([]*Snapshot, error)
 
 	// RemoveSnapshot will remove a snapshot based on the snapshotID.
-	RemoveSnapshot(snapshotID string) error
+	RemoveSnapshot(ctx context.Context, snapshotID string) error
 
 	// CreateVolume is sync/async and will create an return a new/existing
 	// Volume based on volumeID/snapshotID with a name of volumeName and a size
 	// in GB.  Optionally based on the storage driver, a volumeType, IOPS, and
 	// availabilityZone could be defined.
 	CreateVolume(
+		ctx context.Context,
 		runAsync bool,
 		volumeName, volumeID, snapshotID, volumeType string,
 		IOPS, size int64,
 		availabilityZone string) (*Volume, error)
 
 	// RemoveVolume will remove a volume based on volumeID.
-	RemoveVolume(volumeID string) error
+	RemoveVolume(ctx context.Context, volumeID string) error
 
 	// GetDeviceNextAvailable return a device path that will retrieve the next
 	// available disk device that can be used.
-	GetDeviceNextAvailable() (string, error)
+	GetDeviceNextAvailable(ctx context.Context) (string, error)
 
 	// AttachVolume returns a list of VolumeAttachments is sync/async that will
 	// attach a volume to an instance based on volumeID and instanceID.
 	AttachVolume(
-		runAsync bool, volumeID, instanceID string, force bool) ([]*VolumeAttachment, error)
+		ctx context.Context,
+		runAsync bool,
+		volumeID, instanceID string, force bool) ([]*VolumeAttachment, error)
 
 	// DetachVolume is sync/async that will detach the volumeID from the local
 	// instance or the instanceID.
-	DetachVolume(runAsync bool, volumeID string, instanceID string, force bool) error
+	DetachVolume(
+		ctx context.Context,
+		runAsync bool, volumeID string, instanceID string, force bool) error
 
 	// CopySnapshot is a sync/async and returns a snapshot that will copy a
 	// snapshot based on volumeID/snapshotID/snapshotName and create a new
 	// snapshot of desinationSnapshotName in the destinationRegion location.
 	CopySnapshot(
+		ctx context.Context,
 		runAsync bool, volumeID, snapshotID, snapshotName,
 		destinationSnapshotName, destinationRegion string) (*Snapshot, error)
+
+	// CloneVolume is a sync operation that creates a new volume named newName
+	// from the volume with the ID sourceVolumeID. The optional opts may be
+	// used to specify the volumetype, iops, size, and availabilityzone of the
+	// new volume, although not all drivers honor them.
+	CloneVolume(
+		ctx context.Context,
+		sourceVolumeID, newName string, opts VolumeOpts) (*Volume, error)
 }
 
 // StorageDriverManager acts as both a StorageDriverManager and as an aggregate
-// of storage drivers, providing batch methods.
+// of storage drivers, providing batch methods. Each asynchronous call to the
+// manager starts an operation that calls the storage driver in the background
+// and returns without waiting for it. The call returns what it knows of the
+// volume, attachments, or snapshots it requested, with the operation's ID as
+// their OperationID; an asynchronous DetachVolume passes its operation to the
+// receiver of the context, if any. See WithOperationReceiver.
 type StorageDriverManager interface {
 	StorageDriver
 
@@ -200,16 +258,32 @@ type StorageDriverManager interface {
 	Drivers() <-chan StorageDriver
 
 	// GetInstances gets the instance for each of the configured drivers.
-	GetInstances() ([]*Instance, error)
+	GetInstances(ctx context.Context) ([]*Instance, error)
 }
 
 type sdm struct {
 	rexray  *RexRay
 	drivers map[string]StorageDriver
+	rwl     sync.RWMutex
+}
+
+func (r *sdm) getDrivers() map[string]StorageDriver {
+	r.rwl.RLock()
+	defer r.rwl.RUnlock()
+	return r.drivers
+}
+
+// setDrivers replaces the manager's drivers. The map is never modified after
+// it is set so that in-flight calls may finish with the drivers they started
+// with.
+func (r *sdm) setDrivers(drivers map[string]StorageDriver) {
+	r.rwl.Lock()
+	defer r.rwl.Unlock()
+	r.drivers = drivers
 }
 
 func (r *sdm) Init(rexray *RexRay) error {
-	if len(r.drivers) == 0 {
+	if len(r.getDrivers()) == 0 {
 		return errors.ErrNoStorageDrivers
 	}
 	return nil
@@ -217,23 +291,94 @@ func (r *sdm) Init(rexray *RexRay) error {
 
 func (r *sdm) Name() string {
 	var b bytes.Buffer
-	for d := range r.Drivers() {
+	for n, d := range r.getDrivers() {
 		if b.Len() > 0 {
 			b.WriteString(" ")
 		}
-		b.WriteString(d.Name())
+		b.WriteString(driverName(n, d))
 	}
 	return b.String()
 }
 
+// driver returns the storage driver selected by the context, or the first of
+// the enabled storage drivers if the context does not select one, and the
+// name by which the driver is reported.
+func (r *sdm) driver(ctx context.Context) (StorageDriver, string, error) {
+	drivers := r.getDrivers()
+
+	if n := StorageDriverName(ctx); n != "" {
+		name, d, ok := findStorageDriver(drivers, n)
+		if !ok {
+			return nil, "", goof.WithField(
+				"driverName", n, "unknown storage driver")
+		}
+		return d, driverName(name, d), nil
+	}
+
+	for _, n := range r.rexray.GetConfig().GetStringSlice(
+		"rexray.storageDrivers") {
+		if n, d, ok := findStorageDriver(drivers, n); ok {
+			return d, driverName(n, d), nil
+		}
+	}
+	for n, d := range drivers {
+		return d, driverName(n, d), nil
+	}
+	return nil, "", errors.ErrNoStorageDetected
+}
+
+// reportedName returns the name of the storage driver that serves a call with
+// the context, or the name of the manager if there is none.
+func (r *sdm) reportedName(ctx context.Context) string {
+	if _, n, err := r.driver(ctx); err == nil {
+		return n
+	}
+	return r.Name()
+}
+
+// selectedDrivers returns the storage driver selected by the context, or all
+// of the storage drivers if the context does not select one.
+func (r *sdm) selectedDrivers(
+	ctx context.Context) (map[string]StorageDriver, error) {
+
+	drivers := r.getDrivers()
+
+	n := StorageDriverName(ctx)
+	if n == "" {
+		return drivers, nil
+	}
+	name, d, ok := findStorageDriver(drivers, n)
+	if !ok {
+		return nil, goof.WithField("driverName", n, "unknown storage driver")
+	}
+	return map[string]StorageDriver{name: d}, nil
+}
+
+// findStorageDriver returns the storage driver with the case-insensitive
+// name and the name by which it is enabled.
+func findStorageDriver(
+	drivers map[string]StorageDriver,
+	name string) (string, StorageDriver, bool) {
+
+	if d, ok := drivers[name]; ok {
+		return name, d, true
+	}
+	for n, d := range drivers {
+		if strings.EqualFold(n, name) {
+			return n, d, true
+		}
+	}
+	return "", nil, false
+}
+
 func (r *sdm) Drivers() <-chan StorageDriver {
 	c := make(chan StorageDriver)
 	go func() {
-		if len(r.drivers) == 0 {
+		if len(r.getDrivers()) == 0 {
 			close(c)
 			return
 		}
-		for _, v := range r.drivers {
+		for _, v := range r.getDrivers() {
 			c <- v
 		}
 		close(c)
@@ -243,10 +388,15 @@ func (r *sdm) Drivers() <-chan StorageDriver {
 
 // GetVolumeMapping performs storage introspection and
 // returns a listing of block devices from the guest
-func (r *sdm) GetVolumeMapping() ([]*BlockDevice, error) {
+func (r *sdm) GetVolumeMapping(ctx context.Context) ([]*BlockDevice, error) {
+	drivers, err := r.selectedDrivers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	var allBlockDevices []*BlockDevice
-	for _, driver := range r.drivers {
-		blockDevices, err := driver.GetVolumeMapping()
+	for _, driver := range drivers {
+		blockDevices, err := driver.GetVolumeMapping(ctx)
 		if err != nil {
 			return []*BlockDevice{}, err
 		}
@@ -266,23 +416,28 @@ func (r *sdm) GetVolumeMapping() ([]*BlockDevice, error) {
 
 }
 
-func (r *sdm) GetInstances() ([]*Instance, error) {
+func (r *sdm) GetInstances(ctx context.Context) ([]*Instance, error) {
 	cI := make(chan *Instance)
 	cE := make(chan error)
 	defer close(cI)
 	defer close(cE)
 
+	drivers, err := r.selectedDrivers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	done := make(chan int)
 	var wg sync.WaitGroup
 
-	wg.Add(len(r.drivers))
+	wg.Add(len(drivers))
 	go func() {
-		for _, d := range r.drivers {
+		for _, d := range drivers {
 			go func(d StorageDriver) {
 				defer wg.Done()
 				var e error
 				var i *Instance
-				i, e = d.GetInstance()
+				i, e = d.GetInstance(ctx)
 				if e != nil {
 					cE <- e
 				} else {
@@ -314,100 +469,386 @@ func (r *sdm) GetInstances() ([]*Instance, error) {
 	}
 }
 
-func (r *sdm) GetInstance() (*Instance, error) {
-	for _, d := range r.drivers {
-		return d.GetInstance()
+func (r *sdm) GetInstance(ctx context.Context) (*Instance, error) {
+	d, _, err := r.driver(ctx)
+	if err != nil {
+		return nil, err
 	}
-	return nil, errors.ErrNoStorageDetected
+	return d.GetInstance(ctx)
 }
 
-func (r *sdm) GetVolume(volumeID, volumeName string) ([]*Volume, error) {
-	for _, d := range r.drivers {
-		return d.GetVolume(volumeID, volumeName)
+func (r *sdm) GetVolume(
+	ctx context.Context, volumeID, volumeName string) ([]*Volume, error) {
+	d, _, err := r.driver(ctx)
+	if err != nil {
+		return nil, err
 	}
-	return nil, errors.ErrNoStorageDetected
+	return d.GetVolume(ctx, volumeID, volumeName)
 }
 
-func (r *sdm) GetSnapshot(volumeID, snapshotID, snapshotName string) ([]*Snapshot, error) {
-	for _, d := range r.drivers {
-		return d.GetSnapshot(volumeID, snapshotID, snapshotName)
+// start starts an asynchronous call as an operation, which calls the storage
+// driver synchronously in the background so that the operation completes when
+// the call does. The returned flag is false if the call is not started as an
+// operation, in which case the caller calls the storage driver itself.
+func (r *sdm) start(
+	ctx context.Context,
+	runAsync bool,
+	req *OperationRequest) (*Operation, bool, error) {
+
+	if !runAsync || r.rexray.opm == nil {
+		return nil, false, nil
+	}
+
+	op, err := r.rexray.opm.Start(ctx, req)
+	if err != nil {
+		return nil, true, err
+	}
+
+	Logger(ctx).WithFields(log.Fields{
+		"id":   op.ID,
+		"type": op.Type,
+	}).Info("started asynchronous operation")
+
+	if f := getOperationReceiver(ctx); f != nil {
+		f(op)
 	}
-	return nil, errors.ErrNoStorageDetected
+
+	return op, true, nil
 }
 
-func (r *sdm) CreateSnapshot(runAsync bool,
+func (r *sdm) GetSnapshot(
+	ctx context.Context,
+	volumeID, snapshotID, snapshotName string) ([]*Snapshot, error) {
+	d, _, err := r.driver(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return d.GetSnapshot(ctx, volumeID, snapshotID, snapshotName)
+}
+
+func (r *sdm) CreateSnapshot(ctx context.Context, runAsync bool,
 	snapshotName, volumeID, description string) ([]*Snapshot, error) {
-	for _, d := range r.drivers {
-		return d.CreateSnapshot(runAsync, snapshotName, volumeID, description)
+	d, dn, err := r.driver(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if op, ok, err := r.start(ctx, runAsync, &OperationRequest{
+		Type:         OperationCreateSnapshot,
+		SnapshotName: snapshotName,
+		VolumeID:     volumeID,
+		Description:  description,
+	}); ok {
+		if err != nil {
+			return nil, err
+		}
+		return []*Snapshot{{
+			Name:        snapshotName,
+			VolumeID:    volumeID,
+			Description: description,
+			Status:      string(op.State),
+			OperationID: op.ID,
+		}}, nil
+	}
+	snapshots, err := d.CreateSnapshot(
+		ctx, runAsync, snapshotName, volumeID, description)
+	e := &events.Event{
+		Type:         events.SnapshotCreated,
+		Driver:       dn,
+		VolumeID:     volumeID,
+		SnapshotName: snapshotName,
+	}
+	if len(snapshots) > 0 {
+		e.SnapshotID = snapshots[0].SnapshotID
 	}
-	return nil, errors.ErrNoStorageDetected
+	publish(ctx, e, err)
+	return snapshots, err
 }
 
-func (r *sdm) RemoveSnapshot(snapshotID string) error {
-	for _, d := range r.drivers {
-		return d.RemoveSnapshot(snapshotID)
+func (r *sdm) RemoveSnapshot(ctx context.Context, snapshotID string) error {
+	d, dn, err := r.driver(ctx)
+	if err != nil {
+		return err
 	}
-	return errors.ErrNoStorageDetected
+	err = d.RemoveSnapshot(ctx, snapshotID)
+	publish(ctx, &events.Event{
+		Type:       events.SnapshotRemoved,
+		Driver:     dn,
+		SnapshotID: snapshotID,
+	}, err)
+	return err
 }
 
-func (r *sdm) CreateVolume(runAsync bool,
+func (r *sdm) CreateVolume(ctx context.Context, runAsync bool,
 	volumeName, volumeID, snapshotID, volumeType string,
 	IOPS, size int64, availabilityZone string) (*Volume, error) {
-	for _, d := range r.drivers {
-		return d.CreateVolume(
-			runAsync, volumeName, volumeID, snapshotID, volumeType,
-			IOPS, size, availabilityZone)
+	d, dn, err := r.driver(ctx)
+	if err != nil {
+		return nil, err
 	}
-	return nil, errors.ErrNoStorageDetected
+	if op, ok, err := r.start(ctx, runAsync, &OperationRequest{
+		Type:             OperationCreateVolume,
+		VolumeName:       volumeName,
+		VolumeID:         volumeID,
+		SnapshotID:       snapshotID,
+		VolumeType:       volumeType,
+		IOPS:             IOPS,
+		Size:             size,
+		AvailabilityZone: availabilityZone,
+		Opts:             GetVolumeOpts(ctx),
+	}); ok {
+		if err != nil {
+			return nil, err
+		}
+		return &Volume{
+			Name:             volumeName,
+			AvailabilityZone: availabilityZone,
+			Status:           string(op.State),
+			VolumeType:       volumeType,
+			IOPS:             IOPS,
+			OperationID:      op.ID,
+		}, nil
+	}
+	volume, err := d.CreateVolume(
+		ctx, runAsync, volumeName, volumeID, snapshotID, volumeType,
+		IOPS, size, availabilityZone)
+	e := &events.Event{
+		Type:       events.VolumeCreated,
+		Driver:     dn,
+		VolumeName: volumeName,
+		SnapshotID: snapshotID,
+	}
+	if volume != nil {
+		e.VolumeID = volume.VolumeID
+	}
+	publish(ctx, e, err)
+	return volume, err
 }
 
-func (r *sdm) RemoveVolume(volumeID string) error {
-	for _, d := range r.drivers {
-		return d.RemoveVolume(volumeID)
+func (r *sdm) RemoveVolume(ctx context.Context, volumeID string) error {
+	d, dn, err := r.driver(ctx)
+	if err != nil {
+		return err
 	}
-	return errors.ErrNoStorageDetected
+	err = d.RemoveVolume(ctx, volumeID)
+	publish(ctx, &events.Event{
+		Type:     events.VolumeRemoved,
+		Driver:   dn,
+		VolumeID: volumeID,
+	}, err)
+	return err
 }
 
 func (r *sdm) AttachVolume(
+	ctx context.Context,
 	runAsync bool,
 	volumeID, instanceID string, force bool) ([]*VolumeAttachment, error) {
-	for _, d := range r.drivers {
-		return d.AttachVolume(runAsync, volumeID, instanceID, force)
+	d, dn, err := r.driver(ctx)
+	if err != nil {
+		return nil, err
 	}
-	return nil, errors.ErrNoStorageDetected
+	if op, ok, err := r.start(ctx, runAsync, &OperationRequest{
+		Type:       OperationAttachVolume,
+		VolumeID:   volumeID,
+		InstanceID: instanceID,
+		Force:      force,
+	}); ok {
+		if err != nil {
+			return nil, err
+		}
+		return []*VolumeAttachment{{
+			VolumeID:    volumeID,
+			InstanceID:  instanceID,
+			Status:      string(op.State),
+			OperationID: op.ID,
+		}}, nil
+	}
+	attachments, err := d.AttachVolume(
+		ctx, runAsync, volumeID, instanceID, force)
+	publish(ctx, &events.Event{
+		Type:       events.VolumeAttached,
+		Driver:     dn,
+		VolumeID:   volumeID,
+		InstanceID: instanceID,
+	}, err)
+	return attachments, err
 }
 
 func (r *sdm) DetachVolume(
+	ctx context.Context,
 	runAsync bool,
 	volumeID, instanceID string, force bool) error {
-	for _, d := range r.drivers {
-		return d.DetachVolume(runAsync, volumeID, instanceID, force)
+	d, dn, err := r.driver(ctx)
+	if err != nil {
+		return err
+	}
+	if _, ok, err := r.start(ctx, runAsync, &OperationRequest{
+		Type:       OperationDetachVolume,
+		VolumeID:   volumeID,
+		InstanceID: instanceID,
+		Force:      force,
+	}); ok {
+		return err
 	}
-	return errors.ErrNoStorageDetected
+	err = d.DetachVolume(ctx, runAsync, volumeID, instanceID, force)
+	publish(ctx, &events.Event{
+		Type:       events.VolumeDetached,
+		Driver:     dn,
+		VolumeID:   volumeID,
+		InstanceID: instanceID,
+	}, err)
+	return err
 }
 
 func (r *sdm) GetVolumeAttach(
+	ctx context.Context,
 	volumeID, instanceID string) ([]*VolumeAttachment, error) {
-	for _, d := range r.drivers {
-		return d.GetVolumeAttach(volumeID, instanceID)
+	d, _, err := r.driver(ctx)
+	if err != nil {
+		return nil, err
 	}
-	return nil, errors.ErrNoStorageDetected
+	return d.GetVolumeAttach(ctx, volumeID, instanceID)
 }
 
 func (r *sdm) CopySnapshot(
+	ctx context.Context,
 	runAsync bool,
 	volumeID, snapshotID, snapshotName,
 	targetSnapshotName, targetRegion string) (*Snapshot, error) {
-	for _, d := range r.drivers {
-		return d.CopySnapshot(runAsync, volumeID, snapshotID, snapshotName,
-			targetSnapshotName, targetRegion)
+	d, dn, err := r.driver(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if op, ok, err := r.start(ctx, runAsync, &OperationRequest{
+		Type:               OperationCopySnapshot,
+		VolumeID:           volumeID,
+		SnapshotID:         snapshotID,
+		SnapshotName:       snapshotName,
+		TargetSnapshotName: targetSnapshotName,
+		TargetRegion:       targetRegion,
+	}); ok {
+		if err != nil {
+			return nil, err
+		}
+		return &Snapshot{
+			Name:        targetSnapshotName,
+			VolumeID:    volumeID,
+			Status:      string(op.State),
+			OperationID: op.ID,
+		}, nil
 	}
-	return nil, errors.ErrNoStorageDetected
+	snapshot, err := d.CopySnapshot(ctx, runAsync, volumeID, snapshotID,
+		snapshotName, targetSnapshotName, targetRegion)
+	e := &events.Event{
+		Type:         events.SnapshotCreated,
+		Driver:       dn,
+		VolumeID:     volumeID,
+		SnapshotName: targetSnapshotName,
+	}
+	if snapshot != nil {
+		e.SnapshotID = snapshot.SnapshotID
+	}
+	publish(ctx, e, err)
+	return snapshot, err
+}
+
+func (r *sdm) GetDeviceNextAvailable(ctx context.Context) (string, error) {
+	d, _, err := r.driver(ctx)
+	if err != nil {
+		return "", err
+	}
+	return d.GetDeviceNextAvailable(ctx)
+}
+
+func (r *sdm) CloneVolume(
+	ctx context.Context,
+	sourceVolumeID, newName string, opts VolumeOpts) (*Volume, error) {
+	d, dn, err := r.driver(ctx)
+	if err != nil {
+		return nil, err
+	}
+	volume, err := d.CloneVolume(ctx, sourceVolumeID, newName, opts)
+	e := &events.Event{
+		Type:       events.VolumeCreated,
+		Driver:     dn,
+		VolumeName: newName,
+	}
+	if volume != nil {
+		e.VolumeID = volume.VolumeID
+	}
+	publish(ctx, e, err)
+	return volume, err
+}
+
+// CloneVolumeFromSnapshot clones a volume by creating a temporary snapshot of
+// the source volume, creating the new volume from that snapshot, and then
+// removing the temporary snapshot. It is used by storage drivers that do not
+// have a native means of cloning a volume.
+func CloneVolumeFromSnapshot(
+	ctx context.Context,
+	d StorageDriver,
+	sourceVolumeID, newName string,
+	opts VolumeOpts) (*Volume, error) {
+
+	fields := map[string]interface{}{
+		"provider":       d.Name(),
+		"sourceVolumeID": sourceVolumeID,
+		"newName":        newName,
+	}
+
+	if sourceVolumeID == "" {
+		return nil, errors.ErrMissingVolumeID
+	}
+
+	iops, err := cloneVolumeOptInt64(opts, "iops")
+	if err != nil {
+		return nil, goof.WithFieldsE(fields, "invalid iops", err)
+	}
+
+	size, err := cloneVolumeOptInt64(opts, "size")
+	if err != nil {
+		return nil, goof.WithFieldsE(fields, "invalid size", err)
+	}
+
+	snapshotName := fmt.Sprintf("clone-%s-%s", sourceVolumeID, newName)
+	snapshots, err := d.CreateSnapshot(
+		ctx, false, snapshotName, sourceVolumeID, "")
+	if err != nil {
+		return nil, goof.WithFieldsE(fields, "error creating clone snapshot", err)
+	}
+	if len(snapshots) == 0 {
+		return nil, goof.WithFields(fields, "no clone snapshot returned")
+	}
+
+	snapshotID := snapshots[0].SnapshotID
+	fields["snapshotID"] = snapshotID
+
+	volume, createErr := d.CreateVolume(
+		WithVolumeOpts(ctx, opts), false, newName, "", snapshotID,
+		opts["volumetype"], iops, size, opts["availabilityzone"])
+
+	if err := d.RemoveSnapshot(ctx, snapshotID); err != nil {
+		if createErr != nil {
+			Logger(ctx).WithFields(fields).WithField("error", err).Warn(
+				"error removing clone snapshot")
+		} else {
+			return nil, goof.WithFieldsE(
+				fields, "error removing clone snapshot", err)
+		}
+	}
+
+	if createErr != nil {
+		return nil, goof.WithFieldsE(
+			fields, "error creating volume from clone snapshot", createErr)
+	}
+
+	Logger(ctx).WithFields(fields).Debug("cloned volume from snapshot")
+	return volume, nil
 }
 
-func (r *sdm) GetDeviceNextAvailable() (string, error) {
-	for _, d := range r.drivers {
-		return d.GetDeviceNextAvailable()
+func cloneVolumeOptInt64(opts VolumeOpts, key string) (int64, error) {
+	v, ok := opts[key]
+	if !ok || v == "" {
+		return 0, nil
 	}
-	return "", errors.ErrNoStorageDetected
+	return strconv.ParseInt(v, 10, 64)
 }
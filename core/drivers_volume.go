@@ -4,9 +4,17 @@ import (
 	"bytes"
 	log "github.com/Sirupsen/logrus"
 	"github.com/emccode/rexray/core/errors"
+	"github.com/emccode/rexray/core/events"
+	"golang.org/x/net/context"
+	"strings"
 	"sync"
 )
 
+// StorageDriverOpt is the name of the volume option that selects the storage
+// driver, or instance of a storage driver, ex. scaleio/prod, with which a
+// volume is created.
+const StorageDriverOpt = "storageDriver"
+
 // VolumeOpts is a map of options used when creating a new volume
 type VolumeOpts map[string]string
 
@@ -19,33 +27,39 @@ type VolumeDriver interface {
 	// or volumeID.  If a overwriteFs boolean is specified it will overwrite
 	// the FS based on newFsType if it is detected that there is no FS present.
 	Mount(
+		ctx context.Context,
 		volumeName, volumeID string,
 		overwriteFs bool, newFsType string, preempt bool) (string, error)
 
 	// Unmount will unmount the specified volume by volumeName or volumeID.
-	Unmount(volumeName, volumeID string) error
+	Unmount(ctx context.Context, volumeName, volumeID string) error
 
 	// Path will return the mounted path of the volumeName or volumeID.
-	Path(volumeName, volumeID string) (string, error)
+	Path(ctx context.Context, volumeName, volumeID string) (string, error)
 
 	// Create will create a new volume with the volumeName and opts.
-	Create(volumeName string, opts VolumeOpts) error
+	Create(ctx context.Context, volumeName string, opts VolumeOpts) error
 
 	// Remove will remove a volume of volumeName.
-	Remove(volumeName string) error
+	Remove(ctx context.Context, volumeName string) error
 
 	// Attach will attach a volume based on volumeName to the instance of
 	// instanceID.
-	Attach(volumeName, instanceID string, force bool) (string, error)
+	Attach(
+		ctx context.Context,
+		volumeName, instanceID string, force bool) (string, error)
 
 	// Detach will detach a volume based on volumeName to the instance of
 	// instanceID.
-	Detach(volumeName, instanceID string, force bool) error
+	Detach(
+		ctx context.Context,
+		volumeName, instanceID string, force bool) error
 
 	// NetworkName will return an identifier of a volume that is relevant when
 	// corelating a local device to a device that is the volumeName to the
 	// local instanceID.
-	NetworkName(volumeName, instanceID string) (string, error)
+	NetworkName(
+		ctx context.Context, volumeName, instanceID string) (string, error)
 }
 
 // VolumeDriverManager acts as both a VolumeDriver and as an aggregate of
@@ -58,24 +72,44 @@ type VolumeDriverManager interface {
 	Drivers() <-chan VolumeDriver
 
 	// UnmountAll unmounts all volumes.
-	UnmountAll() error
+	UnmountAll(ctx context.Context) error
 
 	// RemoveAll removes all volumes.
-	RemoveAll() error
+	RemoveAll(ctx context.Context) error
 
 	// DetachAll detaches all volumes attached to the instance of instanceID.
-	DetachAll(instanceID string) error
+	DetachAll(ctx context.Context, instanceID string) error
 }
 
 type vdm struct {
 	rexray       *RexRay
 	drivers      map[string]VolumeDriver
+	rwl          sync.RWMutex
 	m            sync.Mutex
 	mapUsedCount map[string]*int
+
+	// mounting are the number of in-flight mounts and attaches of each
+	// volume, which the reconciler does not repair
+	mounting map[string]int
+}
+
+func (r *vdm) getDrivers() map[string]VolumeDriver {
+	r.rwl.RLock()
+	defer r.rwl.RUnlock()
+	return r.drivers
+}
+
+// setDrivers replaces the manager's drivers. The map is never modified after
+// it is set so that in-flight calls may finish with the drivers they started
+// with.
+func (r *vdm) setDrivers(drivers map[string]VolumeDriver) {
+	r.rwl.Lock()
+	defer r.rwl.Unlock()
+	r.drivers = drivers
 }
 
 func (r *vdm) Init(rexray *RexRay) error {
-	if len(r.drivers) == 0 {
+	if len(r.getDrivers()) == 0 {
 		return errors.ErrNoVolumeDrivers
 	}
 	r.mapUsedCount = make(map[string]*int)
@@ -84,23 +118,51 @@ func (r *vdm) Init(rexray *RexRay) error {
 
 func (r *vdm) Name() string {
 	var b bytes.Buffer
-	for d := range r.Drivers() {
+	for n, d := range r.getDrivers() {
 		if b.Len() > 0 {
 			b.WriteString(" ")
 		}
-		b.WriteString(d.Name())
+		b.WriteString(driverName(n, d))
 	}
 	return b.String()
 }
 
+// driver returns the volume driver that serves the manager's calls, the first
+// of the enabled volume drivers, and the name by which the driver is
+// reported.
+func (r *vdm) driver() (VolumeDriver, string, error) {
+	drivers := r.getDrivers()
+	for _, n := range r.rexray.GetConfig().GetStringSlice(
+		"rexray.volumeDrivers") {
+		for dn, d := range drivers {
+			if strings.EqualFold(dn, n) {
+				return d, driverName(dn, d), nil
+			}
+		}
+	}
+	for n, d := range drivers {
+		return d, driverName(n, d), nil
+	}
+	return nil, "", errors.ErrNoVolumesDetected
+}
+
+// reportedName returns the name of the volume driver that serves the calls, or
+// the name of the manager if there is none.
+func (r *vdm) reportedName(ctx context.Context) string {
+	if _, n, err := r.driver(); err == nil {
+		return n
+	}
+	return r.Name()
+}
+
 func (r *vdm) Drivers() <-chan VolumeDriver {
 	c := make(chan VolumeDriver)
 	go func() {
-		if len(r.drivers) == 0 {
+		if len(r.getDrivers()) == 0 {
 			close(c)
 			return
 		}
-		for _, v := range r.drivers {
+		for _, v := range r.getDrivers() {
 			c <- v
 		}
 		close(c)
@@ -109,73 +171,76 @@ func (r *vdm) Drivers() <-chan VolumeDriver {
 }
 
 // UnmountAll unmounts all volumes.
-func (r *vdm) UnmountAll() error {
-	for range r.drivers {
+func (r *vdm) UnmountAll(ctx context.Context) error {
+	for range r.getDrivers() {
 		return nil
 	}
 	return errors.ErrNoVolumesDetected
 }
 
 // RemoveAll removes all volumes.
-func (r *vdm) RemoveAll() error {
-	for range r.drivers {
+func (r *vdm) RemoveAll(ctx context.Context) error {
+	for range r.getDrivers() {
 		return nil
 	}
 	return errors.ErrNoVolumesDetected
 }
 
 // DetachAll detaches all volumes attached to the instance of instanceID.
-func (r *vdm) DetachAll(instanceID string) error {
-	for range r.drivers {
+func (r *vdm) DetachAll(ctx context.Context, instanceID string) error {
+	for range r.getDrivers() {
 		return nil
 	}
 	return errors.ErrNoVolumesDetected
 }
 
-func (r *vdm) countUse(volumeName string) {
+func (r *vdm) countUse(ctx context.Context, volumeName string) {
 	r.m.Lock()
 	if c, ok := r.mapUsedCount[volumeName]; ok {
 		*c++
-		log.WithFields(log.Fields{
+		r.recordMounts()
+		Logger(ctx).WithFields(log.Fields{
 			"volumeName": volumeName,
 			"count":      *c,
 		}).Info("set count to")
 		r.m.Unlock()
 	} else {
 		r.m.Unlock()
-		r.countInit(volumeName)
-		r.countUse(volumeName)
+		r.countInit(ctx, volumeName)
+		r.countUse(ctx, volumeName)
 	}
 }
 
-func (r *vdm) countInit(volumeName string) {
+func (r *vdm) countInit(ctx context.Context, volumeName string) {
 	r.m.Lock()
 	defer r.m.Unlock()
 
 	var c int
 	c = 0
 	r.mapUsedCount[volumeName] = &c
-	log.WithFields(log.Fields{
+	r.recordMounts()
+	Logger(ctx).WithFields(log.Fields{
 		"volumeName": volumeName,
 		"count":      c,
 	}).Info("initialized count")
 }
 
-func (r *vdm) countRelease(volumeName string) {
+func (r *vdm) countRelease(ctx context.Context, volumeName string) {
 	r.m.Lock()
 	defer r.m.Unlock()
 	if c, ok := r.mapUsedCount[volumeName]; ok {
 		*c--
-		log.WithFields(log.Fields{
+		r.recordMounts()
+		Logger(ctx).WithFields(log.Fields{
 			"volumeName": volumeName,
 			"count":      *c,
 		}).Info("released count")
 	}
 }
 
-func (r *vdm) countExists(volumeName string) bool {
+func (r *vdm) countExists(ctx context.Context, volumeName string) bool {
 	_, exists := r.mapUsedCount[volumeName]
-	log.WithFields(log.Fields{
+	Logger(ctx).WithFields(log.Fields{
 		"volumeName": volumeName,
 		"exists":     exists,
 	}).Info("status of count")
@@ -183,116 +248,297 @@ func (r *vdm) countExists(volumeName string) bool {
 	return exists
 }
 
-func (r *vdm) countReset(volumeName string) bool {
+func (r *vdm) countReset(ctx context.Context, volumeName string) bool {
 	r.m.Lock()
 	defer r.m.Unlock()
 
 	c, _ := r.mapUsedCount[volumeName]
 	if c != nil && *c < 2 {
-		log.WithFields(log.Fields{
+		Logger(ctx).WithFields(log.Fields{
 			"volumeName": volumeName,
 			"count":      *c,
 		}).Info("count reset")
 		*c = 0
+		r.recordMounts()
 		return true
 	}
 	return false
 }
 
+// recordMounts records the number of volume mounts that are in use. The
+// caller must hold the manager's lock.
+func (r *vdm) recordMounts() {
+	var n int
+	for _, c := range r.mapUsedCount {
+		if *c > 0 {
+			n += *c
+		}
+	}
+	volumeMounts.Set(float64(n), r.reportedName(context.Background()))
+}
+
+// usedCounts returns a copy of the number of times each volume is in use.
+func (r *vdm) usedCounts() map[string]int {
+	r.m.Lock()
+	defer r.m.Unlock()
+	counts := map[string]int{}
+	for k, v := range r.mapUsedCount {
+		counts[k] = *v
+	}
+	return counts
+}
+
+// beginMount records an in-flight mount or attach of the volume and returns
+// the function that records its end.
+func (r *vdm) beginMount(volumeName string) func() {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if r.mounting == nil {
+		r.mounting = map[string]int{}
+	}
+	r.mounting[volumeName]++
+	return func() {
+		r.m.Lock()
+		defer r.m.Unlock()
+		if r.mounting[volumeName]--; r.mounting[volumeName] < 1 {
+			delete(r.mounting, volumeName)
+		}
+	}
+}
+
+// isMounting returns a flag indicating whether or not the volume is being
+// mounted or attached.
+func (r *vdm) isMounting(volumeName string) bool {
+	r.m.Lock()
+	defer r.m.Unlock()
+	return r.mounting[volumeName] > 0
+}
+
+// setUsedCount sets the number of times a volume is in use.
+func (r *vdm) setUsedCount(ctx context.Context, volumeName string, count int) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if r.mapUsedCount == nil {
+		r.mapUsedCount = map[string]*int{}
+	}
+	r.mapUsedCount[volumeName] = &count
+	r.recordMounts()
+	Logger(ctx).WithFields(log.Fields{
+		"volumeName": volumeName,
+		"count":      count,
+	}).Info("set count")
+}
+
 // Mount will return a mount point path when specifying either a volumeName
 // or volumeID.  If a overwriteFs boolean is specified it will overwrite
 // the FS based on newFsType if it is detected that there is no FS present.
 func (r *vdm) Mount(
+	ctx context.Context,
 	volumeName, volumeID string,
 	overwriteFs bool, newFsType string, preempt bool) (string, error) {
-	for _, d := range r.drivers {
-		if !preempt {
-			preempt = r.preempt()
-		}
+	ctx, volumeName = r.selectStorageDriver(ctx, volumeName)
+	defer r.beginMount(volumeName)()
+	d, _, err := r.driver()
+	if err != nil {
+		return "", err
+	}
+	if !preempt {
+		preempt = r.preempt()
+	}
 
-		mp, err := d.Mount(volumeName, volumeID, overwriteFs, newFsType, preempt)
-		if err != nil {
-			return "", err
-		}
+	mp, err := d.Mount(
+		ctx, volumeName, volumeID, overwriteFs, newFsType, preempt)
+	publish(ctx, &events.Event{
+		Type:       events.VolumeMounted,
+		Driver:     d.Name(),
+		VolumeID:   volumeID,
+		VolumeName: volumeName,
+		MountPoint: mp,
+	}, err)
+	if err != nil {
+		return "", err
+	}
 
-		r.countUse(volumeName)
+	r.countUse(ctx, volumeName)
 
-		return mp, nil
-	}
-	return "", errors.ErrNoVolumesDetected
+	return mp, nil
 }
 
 // Unmount will unmount the specified volume by volumeName or volumeID.
-func (r *vdm) Unmount(volumeName, volumeID string) error {
-	for _, d := range r.drivers {
-		if r.ignoreUsedCount() || r.countReset(volumeName) || !r.countExists(volumeName) {
-			r.countInit(volumeName)
-			return d.Unmount(volumeName, volumeID)
-		} else {
-			r.countRelease(volumeName)
-			return nil
-		}
+func (r *vdm) Unmount(ctx context.Context, volumeName, volumeID string) error {
+	ctx, volumeName = r.selectStorageDriver(ctx, volumeName)
+	d, _, err := r.driver()
+	if err != nil {
+		return err
 	}
-	return errors.ErrNoVolumesDetected
+	if r.ignoreUsedCount() ||
+		r.countReset(ctx, volumeName) ||
+		!r.countExists(ctx, volumeName) {
+		r.countInit(ctx, volumeName)
+		err = d.Unmount(ctx, volumeName, volumeID)
+		publish(ctx, &events.Event{
+			Type:       events.VolumeUnmounted,
+			Driver:     d.Name(),
+			VolumeID:   volumeID,
+			VolumeName: volumeName,
+		}, err)
+		return err
+	}
+	r.countRelease(ctx, volumeName)
+	return nil
 }
 
 // Path will return the mounted path of the volumeName or volumeID.
-func (r *vdm) Path(volumeName, volumeID string) (string, error) {
-	for _, d := range r.drivers {
-		return d.Path(volumeName, volumeID)
+func (r *vdm) Path(
+	ctx context.Context, volumeName, volumeID string) (string, error) {
+	ctx, volumeName = r.selectStorageDriver(ctx, volumeName)
+	d, _, err := r.driver()
+	if err != nil {
+		return "", err
 	}
-	return "", errors.ErrNoVolumesDetected
+	return d.Path(ctx, volumeName, volumeID)
 }
 
 // Create will create a new volume with the volumeName and opts.
-func (r *vdm) Create(volumeName string, opts VolumeOpts) error {
-	for _, d := range r.drivers {
-		r.countInit(volumeName)
-		return d.Create(volumeName, opts)
+func (r *vdm) Create(
+	ctx context.Context, volumeName string, opts VolumeOpts) error {
+	ctx, volumeName = r.selectStorageDriver(ctx, volumeName)
+	for k, v := range opts {
+		if strings.EqualFold(k, StorageDriverOpt) {
+			ctx = WithStorageDriverName(ctx, v)
+		}
 	}
-	return errors.ErrNoVolumesDetected
+	ctx = WithVolumeOpts(ctx, opts)
+	d, _, err := r.driver()
+	if err != nil {
+		return err
+	}
+	r.countInit(ctx, volumeName)
+	return d.Create(ctx, volumeName, opts)
 }
 
 // Remove will remove a volume of volumeName.
-func (r *vdm) Remove(volumeName string) error {
-	for _, d := range r.drivers {
-		return d.Remove(volumeName)
+func (r *vdm) Remove(ctx context.Context, volumeName string) error {
+	ctx, volumeName = r.selectStorageDriver(ctx, volumeName)
+	d, _, err := r.driver()
+	if err != nil {
+		return err
 	}
-	return errors.ErrNoVolumesDetected
+	return d.Remove(ctx, volumeName)
 }
 
 // Attach will attach a volume based on volumeName to the instance of
 // instanceID.
-func (r *vdm) Attach(volumeName, instanceID string, force bool) (string, error) {
-	for _, d := range r.drivers {
-		return d.Attach(volumeName, instanceID, force)
+func (r *vdm) Attach(
+	ctx context.Context,
+	volumeName, instanceID string, force bool) (string, error) {
+	ctx, volumeName = r.selectStorageDriver(ctx, volumeName)
+	defer r.beginMount(volumeName)()
+	d, _, err := r.driver()
+	if err != nil {
+		return "", err
 	}
-	return "", errors.ErrNoVolumesDetected
+	return d.Attach(ctx, volumeName, instanceID, force)
 }
 
 // Detach will detach a volume based on volumeName to the instance of
 // instanceID.
-func (r *vdm) Detach(volumeName, instanceID string, force bool) error {
-	for _, d := range r.drivers {
-		return d.Detach(volumeName, instanceID, force)
+func (r *vdm) Detach(
+	ctx context.Context,
+	volumeName, instanceID string, force bool) error {
+	ctx, volumeName = r.selectStorageDriver(ctx, volumeName)
+	d, _, err := r.driver()
+	if err != nil {
+		return err
 	}
-	return errors.ErrNoVolumesDetected
+	return d.Detach(ctx, volumeName, instanceID, force)
 }
 
 // NetworkName will return an identifier of a volume that is relevant when
 // corelating a local device to a device that is the volumeName to the
 // local instanceID.
-func (r *vdm) NetworkName(volumeName, instanceID string) (string, error) {
-	for _, d := range r.drivers {
-		return d.NetworkName(volumeName, instanceID)
+func (r *vdm) NetworkName(
+	ctx context.Context, volumeName, instanceID string) (string, error) {
+	ctx, volumeName = r.selectStorageDriver(ctx, volumeName)
+	d, _, err := r.driver()
+	if err != nil {
+		return "", err
+	}
+	return d.NetworkName(ctx, volumeName, instanceID)
+}
+
+// selectStorageDriver returns a context that selects the storage driver
+// named by the volume name's prefix, ex. scaleio/prod for the volume name
+// scaleio/prod/vol1, and the volume name without the prefix. If the volume
+// name has no such prefix, the context selects the storage driver that has
+// the volume, as returned by locateStorageDriver.
+func (r *vdm) selectStorageDriver(
+	ctx context.Context, volumeName string) (context.Context, string) {
+
+	if r.rexray == nil || r.rexray.sdm == nil {
+		return ctx, volumeName
+	}
+
+	var driverName string
+	for n := range r.rexray.sdm.getDrivers() {
+		if strings.HasPrefix(volumeName, n+DriverInstanceSeparator) &&
+			len(n) > len(driverName) {
+			driverName = n
+		}
+	}
+	if driverName == "" {
+		return r.locateStorageDriver(ctx, volumeName), volumeName
+	}
+
+	return WithStorageDriverName(ctx, driverName),
+		volumeName[len(driverName)+len(DriverInstanceSeparator):]
+}
+
+// locateStorageDriver returns a context that selects the first storage
+// driver, in the order of rexray.storageDrivers, that has a volume with the
+// name. A volume created with the storageDriver volume option is thereby
+// found by the requests that only name the volume, such as Docker's mount
+// requests. The context is returned unchanged if it already selects a
+// storage driver, if only one storage driver is enabled, or if no storage
+// driver has the volume.
+func (r *vdm) locateStorageDriver(
+	ctx context.Context, volumeName string) context.Context {
+
+	if volumeName == "" || StorageDriverName(ctx) != "" {
+		return ctx
+	}
+
+	drivers := r.rexray.sdm.getDrivers()
+	if len(drivers) < 2 {
+		return ctx
+	}
+
+	for _, n := range r.rexray.GetConfig().GetStringSlice(
+		"rexray.storageDrivers") {
+		n, d, ok := findStorageDriver(drivers, n)
+		if !ok {
+			continue
+		}
+		vols, err := d.GetVolume(ctx, "", volumeName)
+		if err != nil {
+			Logger(ctx).WithFields(log.Fields{
+				"driverName": n,
+				"volumeName": volumeName,
+				"error":      err,
+			}).Warn("error locating volume")
+			continue
+		}
+		if len(vols) > 0 {
+			return WithStorageDriverName(ctx, n)
+		}
 	}
-	return "", errors.ErrNoVolumesDetected
+	return ctx
 }
 
 func (r *vdm) preempt() bool {
-	return r.rexray.Config.GetBool("rexray.volume.mount.preempt")
+	return r.rexray.GetConfig().GetBool("rexray.volume.mount.preempt")
 }
 
 func (r *vdm) ignoreUsedCount() bool {
-	return r.rexray.Config.GetBool("rexray.volume.unmount.ignoreusedcount")
+	return r.rexray.GetConfig().GetBool("rexray.volume.unmount.ignoreusedcount")
 }
@@ -1,6 +1,8 @@
 package errors
 
 import (
+	"reflect"
+
 	"github.com/akutz/goof"
 )
 
@@ -85,6 +87,22 @@ const (
 	// ErrCodeRunAsyncFromVolume is the error code for when an asynchronous
 	// create volume is received.
 	ErrCodeRunAsyncFromVolume
+
+	// ErrCodeOperationNotFound is the error code for when an operation
+	// cannot be found.
+	ErrCodeOperationNotFound
+
+	// ErrCodeUnknownOperationType is the error code for when an operation of
+	// an unknown or unsupported type is requested.
+	ErrCodeUnknownOperationType
+
+	// ErrCodeTimeout is the error code for when an operation does not
+	// complete before its timeout elapses.
+	ErrCodeTimeout
+
+	// ErrCodeCanceled is the error code for when an operation is canceled
+	// before it completes.
+	ErrCodeCanceled
 )
 
 var (
@@ -158,11 +176,45 @@ var (
 	// ErrRunAsyncFromVolume is the error for when an asynchronous
 	// create volume is received.
 	ErrRunAsyncFromVolume = ErrRexRay(ErrCodeRunAsyncFromVolume)
+
+	// ErrOperationNotFound is the error for when an operation cannot be
+	// found.
+	ErrOperationNotFound = ErrRexRay(ErrCodeOperationNotFound)
+
+	// ErrUnknownOperationType is the error for when an operation of an
+	// unknown or unsupported type is requested.
+	ErrUnknownOperationType = ErrRexRay(ErrCodeUnknownOperationType)
 )
 
+var errCodes = map[error]RexRayErrCode{}
+
 // ErrRexRay creates a new instance of a RexRayErr with a given error code.
 func ErrRexRay(code RexRayErrCode) error {
-	return goof.New(errCodeToString(code))
+	err := goof.New(errCodeToString(code))
+	errCodes[err] = code
+	return err
+}
+
+// Code returns the error code of the provided error. ErrCodeUnknown is
+// returned if the error is nil or was not created with an error code.
+func Code(err error) RexRayErrCode {
+	switch terr := err.(type) {
+	case nil:
+		return ErrCodeUnknown
+	case interface {
+		Code() RexRayErrCode
+	}:
+		return terr.Code()
+	case *RequestError:
+		return Code(terr.Err)
+	}
+	if !reflect.TypeOf(err).Comparable() {
+		return ErrCodeUnknown
+	}
+	if code, ok := errCodes[err]; ok {
+		return code
+	}
+	return ErrCodeUnknown
 }
 
 // Error returns the string version of the error code.
@@ -185,7 +237,8 @@ func errCodeToString(code RexRayErrCode) string {
 		ErrCodeMultipleVolumesReturned:
 		return errCodeMultiToString(code)
 	case ErrCodeUnknownOS,
-		ErrCodeUnknownFileSystem:
+		ErrCodeUnknownFileSystem,
+		ErrCodeUnknownOperationType:
 		return errCodeUnknownToString(code)
 	case ErrCodeMissingVolumeID:
 		return "missing volume ID"
@@ -193,6 +246,12 @@ func errCodeToString(code RexRayErrCode) string {
 		return "getting local volume mounts"
 	case ErrCodeRunAsyncFromVolume:
 		return "cannot create volume from volume and run asynchronously"
+	case ErrCodeOperationNotFound:
+		return "operation not found"
+	case ErrCodeTimeout:
+		return "timed out"
+	case ErrCodeCanceled:
+		return "canceled"
 	case ErrCodeNotImplemented:
 		return "not implemented"
 	default:
@@ -253,6 +312,8 @@ func errCodeUnknownToString(code RexRayErrCode) string {
 		return "unknown OS"
 	case ErrCodeUnknownFileSystem:
 		return "unknown file system"
+	case ErrCodeUnknownOperationType:
+		return "unknown operation type"
 	default:
 		return "unknown error"
 	}
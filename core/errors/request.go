@@ -0,0 +1,24 @@
+package errors
+
+import "fmt"
+
+// RequestError is an error annotated with the ID of the request that caused
+// it.
+type RequestError struct {
+
+	// RequestID is the ID of the request.
+	RequestID string
+
+	// Err is the underlying error.
+	Err error
+}
+
+// Error returns the error's message.
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("%v (requestID=%s)", e.Err, e.RequestID)
+}
+
+// Cause returns the underlying error.
+func (e *RequestError) Cause() error {
+	return e.Err
+}
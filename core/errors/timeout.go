@@ -0,0 +1,60 @@
+package errors
+
+import (
+	"fmt"
+	"time"
+)
+
+// TimeoutError is the error returned when an operation does not complete
+// before its timeout elapses.
+type TimeoutError struct {
+
+	// Op is the name of the operation that timed out.
+	Op string
+
+	// Timeout is the amount of time the operation was given to complete.
+	Timeout time.Duration
+}
+
+// Error returns the error's message.
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf(
+		"%s %s after %v", e.Op, errCodeToString(ErrCodeTimeout), e.Timeout)
+}
+
+// Code returns the error's code.
+func (e *TimeoutError) Code() RexRayErrCode {
+	return ErrCodeTimeout
+}
+
+// CanceledError is the error returned when an operation is canceled before it
+// completes.
+type CanceledError struct {
+
+	// Op is the name of the operation that was canceled.
+	Op string
+}
+
+// Error returns the error's message.
+func (e *CanceledError) Error() string {
+	return fmt.Sprintf("%s %s", e.Op, errCodeToString(ErrCodeCanceled))
+}
+
+// Code returns the error's code.
+func (e *CanceledError) Code() RexRayErrCode {
+	return ErrCodeCanceled
+}
+
+// IsTimeout returns a flag indicating whether or not the provided error is a
+// TimeoutError.
+func IsTimeout(err error) bool {
+	_, ok := err.(*TimeoutError)
+	return ok
+}
+
+// IsCanceled returns a flag indicating whether or not the provided error is a
+// CanceledError.
+func IsCanceled(err error) bool {
+	_, ok := err.(*CanceledError)
+	return ok
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	log "github.com/Sirupsen/logrus"
+	"github.com/akutz/gofig"
+	"golang.org/x/net/context"
+
+	"github.com/emccode/rexray/core/events"
+)
+
+// publish publishes the event to the default bus. If err is not nil an Error
+// event that describes the failed change is published instead.
+func publish(ctx context.Context, e *events.Event, err error) {
+	e.RequestID = RequestID(ctx)
+	if err != nil {
+		e.Op = e.Type
+		e.Type = events.Error
+		e.Error = err.Error()
+	}
+	events.Default.Publish(e)
+}
+
+// StartEventWebhooks starts a webhook for each of the URLs defined by the
+// configuration property rexray.events.webhooks. The events the webhooks post
+// are selected by the rexray.events.webhook.types and
+// rexray.events.webhook.volumes properties.
+func StartEventWebhooks(config gofig.Config) []*events.Webhook {
+
+	urls := config.GetStringSlice("rexray.events.webhooks")
+	if len(urls) == 0 {
+		return nil
+	}
+
+	f := &events.Filter{
+		Volumes: config.GetStringSlice("rexray.events.webhook.volumes"),
+	}
+	for _, t := range config.GetStringSlice("rexray.events.webhook.types") {
+		f.Types = append(f.Types, events.Type(t))
+	}
+
+	timeout := getDuration(config, "rexray.events.webhook.timeout")
+
+	var hooks []*events.Webhook
+	for _, url := range urls {
+		if url == "" {
+			continue
+		}
+		w := events.NewWebhook(url, f, timeout)
+		w.Start(events.Default)
+		hooks = append(hooks, w)
+		log.WithField("url", url).Info("started event webhook")
+	}
+	return hooks
+}
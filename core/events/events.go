@@ -0,0 +1,241 @@
+// Package events provides an in-process bus on which the REX-Ray core
+// publishes the changes it makes to the lifecycle of volumes and snapshots.
+package events
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/emccode/rexray/core/metrics"
+)
+
+// Type is the type of an event.
+type Type string
+
+const (
+	// VolumeCreated is the type of the event published when a volume is
+	// created.
+	VolumeCreated Type = "volume.created"
+
+	// VolumeRemoved is the type of the event published when a volume is
+	// removed.
+	VolumeRemoved Type = "volume.removed"
+
+	// VolumeAttached is the type of the event published when a volume is
+	// attached to an instance.
+	VolumeAttached Type = "volume.attached"
+
+	// VolumeDetached is the type of the event published when a volume is
+	// detached from an instance.
+	VolumeDetached Type = "volume.detached"
+
+	// VolumeMounted is the type of the event published when a volume is
+	// mounted.
+	VolumeMounted Type = "volume.mounted"
+
+	// VolumeUnmounted is the type of the event published when a volume is
+	// unmounted.
+	VolumeUnmounted Type = "volume.unmounted"
+
+	// SnapshotCreated is the type of the event published when a snapshot is
+	// created.
+	SnapshotCreated Type = "snapshot.created"
+
+	// SnapshotRemoved is the type of the event published when a snapshot is
+	// removed.
+	SnapshotRemoved Type = "snapshot.removed"
+
+	// Error is the type of the event published when one of the changes above
+	// fails.
+	Error Type = "error"
+)
+
+// Types are the known event types.
+var Types = []Type{
+	VolumeCreated,
+	VolumeRemoved,
+	VolumeAttached,
+	VolumeDetached,
+	VolumeMounted,
+	VolumeUnmounted,
+	SnapshotCreated,
+	SnapshotRemoved,
+	Error,
+}
+
+// Event describes a change to the lifecycle of a volume or snapshot.
+type Event struct {
+
+	// The ID of the event. IDs increase with each event published to a bus.
+	ID uint64 `json:"id"`
+
+	// The type of the event.
+	Type Type `json:"type"`
+
+	// The time at which the event was published.
+	Time time.Time `json:"time"`
+
+	// The ID of the request that caused the event.
+	RequestID string `json:"requestID,omitempty"`
+
+	// The name of the driver that made the change.
+	Driver string `json:"driver,omitempty"`
+
+	// The ID of the volume.
+	VolumeID string `json:"volumeID,omitempty"`
+
+	// The name of the volume.
+	VolumeName string `json:"volumeName,omitempty"`
+
+	// The ID of the snapshot.
+	SnapshotID string `json:"snapshotID,omitempty"`
+
+	// The name of the snapshot.
+	SnapshotName string `json:"snapshotName,omitempty"`
+
+	// The ID of the instance to or from which the volume is attached or
+	// detached.
+	InstanceID string `json:"instanceID,omitempty"`
+
+	// The path at which the volume is mounted.
+	MountPoint string `json:"mountPoint,omitempty"`
+
+	// The type of the change that failed. Set only for Error events.
+	Op Type `json:"op,omitempty"`
+
+	// The error that caused the change to fail. Set only for Error events.
+	Error string `json:"error,omitempty"`
+}
+
+// Filter selects the events a subscriber receives. An empty filter selects
+// all events.
+type Filter struct {
+
+	// Volumes are the IDs or names of the volumes whose events are selected.
+	Volumes []string
+
+	// Types are the types of the events that are selected. An Error event is
+	// selected by the type Error or by the type of the change that failed.
+	Types []Type
+}
+
+// Match returns a flag indicating whether or not the filter selects the
+// event.
+func (f *Filter) Match(e *Event) bool {
+	if f == nil {
+		return true
+	}
+
+	if len(f.Types) > 0 {
+		ok := false
+		for _, t := range f.Types {
+			if t == e.Type || (e.Type == Error && t == e.Op) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+
+	if len(f.Volumes) > 0 {
+		ok := false
+		for _, v := range f.Volumes {
+			if v != "" && (v == e.VolumeID || v == e.VolumeName) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// SubscriptionBufferSize is the number of events a subscription buffers
+// before further events are dropped.
+const SubscriptionBufferSize = 256
+
+var droppedEvents = metrics.Default.Counter(
+	"rexray_events_dropped_total",
+	"The number of events dropped because a subscriber was too slow.")
+
+// Default is the bus to which the events for the current process are
+// published.
+var Default = NewBus()
+
+// Bus delivers published events to its subscribers.
+type Bus struct {
+	m      sync.RWMutex
+	nextID uint64
+	subs   map[*Subscription]bool
+}
+
+// NewBus returns a new bus without any subscribers.
+func NewBus() *Bus {
+	return &Bus{subs: map[*Subscription]bool{}}
+}
+
+// Publish assigns the event an ID and the current time and delivers it to
+// the subscribers whose filters select it. Publish never blocks; an event is
+// dropped for a subscriber whose buffer is full.
+func (b *Bus) Publish(e *Event) {
+	e.ID = atomic.AddUint64(&b.nextID, 1)
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+
+	b.m.RLock()
+	defer b.m.RUnlock()
+
+	for s := range b.subs {
+		if !s.filter.Match(e) {
+			continue
+		}
+		select {
+		case s.c <- e:
+		default:
+			droppedEvents.Inc()
+		}
+	}
+}
+
+// Subscribe returns a new subscription to the events selected by the filter.
+// The subscription must be closed when it is no longer used.
+func (b *Bus) Subscribe(f *Filter) *Subscription {
+	c := make(chan *Event, SubscriptionBufferSize)
+	s := &Subscription{C: c, c: c, bus: b, filter: f}
+
+	b.m.Lock()
+	b.subs[s] = true
+	b.m.Unlock()
+
+	return s
+}
+
+// Subscription is a subscriber's registration with a bus.
+type Subscription struct {
+
+	// C receives the subscribed events. It is closed when the subscription
+	// is closed.
+	C <-chan *Event
+
+	c      chan *Event
+	bus    *Bus
+	filter *Filter
+	once   sync.Once
+}
+
+// Close removes the subscription from its bus and closes its channel.
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.bus.m.Lock()
+		delete(s.bus.subs, s)
+		s.bus.m.Unlock()
+		close(s.c)
+	})
+}
@@ -0,0 +1,120 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// ContentTypeSSE is the content type of a stream of server-sent events.
+	ContentTypeSSE = "text/event-stream"
+
+	// ContentTypeNDJSON is the content type of a stream of newline-delimited
+	// JSON.
+	ContentTypeNDJSON = "application/x-ndjson"
+)
+
+// KeepAliveInterval is the interval at which a comment is written to a
+// stream of server-sent events when no events are received, so that clients
+// and proxies do not consider the stream idle.
+var KeepAliveInterval = 30 * time.Second
+
+// Handler returns an HTTP handler that streams the bus's events to the
+// client until the client closes the connection.
+//
+// The events are streamed as server-sent events if the query parameter
+// format is sse or the request's Accept header is text/event-stream, and as
+// newline-delimited JSON otherwise. The query parameters volume and type
+// filter the events by volume ID or name and by event type. Both parameters
+// may be repeated or have comma-separated values.
+func Handler(b *Bus) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(
+				w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+
+		var closed <-chan bool
+		if cn, ok := w.(http.CloseNotifier); ok {
+			closed = cn.CloseNotify()
+		}
+
+		q := req.URL.Query()
+		f := &Filter{Volumes: queryValues(q["volume"])}
+		for _, t := range queryValues(q["type"]) {
+			f.Types = append(f.Types, Type(t))
+		}
+
+		sse := q.Get("format") == "sse" ||
+			(q.Get("format") == "" &&
+				strings.Contains(req.Header.Get("Accept"), ContentTypeSSE))
+
+		sub := b.Subscribe(f)
+		defer sub.Close()
+
+		if sse {
+			w.Header().Set("Content-Type", ContentTypeSSE)
+		} else {
+			w.Header().Set("Content-Type", ContentTypeNDJSON)
+		}
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		keepAlive := time.NewTicker(KeepAliveInterval)
+		defer keepAlive.Stop()
+
+		enc := json.NewEncoder(w)
+		for {
+			select {
+			case <-closed:
+				return
+			case <-keepAlive.C:
+				if sse {
+					if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+						return
+					}
+					flusher.Flush()
+				}
+			case e, ok := <-sub.C:
+				if !ok {
+					return
+				}
+				if sse {
+					buf, err := json.Marshal(e)
+					if err != nil {
+						continue
+					}
+					if _, err := fmt.Fprintf(w,
+						"id: %d\nevent: %s\ndata: %s\n\n",
+						e.ID, e.Type, buf); err != nil {
+						return
+					}
+				} else if err := enc.Encode(e); err != nil {
+					return
+				}
+				flusher.Flush()
+			}
+		}
+	})
+}
+
+// queryValues splits each of the values on commas and returns the non-empty
+// results.
+func queryValues(values []string) []string {
+	var result []string
+	for _, v := range values {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				result = append(result, s)
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,97 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/akutz/goof"
+	"golang.org/x/net/context"
+	"golang.org/x/net/context/ctxhttp"
+)
+
+// Webhook is a sink that POSTs each of the events it receives, as JSON, to a
+// URL.
+type Webhook struct {
+
+	// URL is the URL to which the events are posted.
+	URL string
+
+	// Filter selects the events that are posted.
+	Filter *Filter
+
+	// Client is the HTTP client used to post the events.
+	Client *http.Client
+
+	sub    *Subscription
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewWebhook returns a new webhook that posts the events selected by the
+// filter to the URL, waiting no longer than the timeout for each post.
+func NewWebhook(url string, f *Filter, timeout time.Duration) *Webhook {
+	return &Webhook{
+		URL:    url,
+		Filter: f,
+		Client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Start subscribes the webhook to the bus and posts the events it receives
+// until the webhook is stopped.
+func (w *Webhook) Start(b *Bus) {
+	var ctx context.Context
+	ctx, w.cancel = context.WithCancel(context.Background())
+	w.sub = b.Subscribe(w.Filter)
+	w.done = make(chan struct{})
+	go func() {
+		defer close(w.done)
+		for e := range w.sub.C {
+			// the events that are received once the webhook is stopped
+			// are dropped
+			if ctx.Err() != nil {
+				continue
+			}
+			if err := w.post(ctx, e); err != nil && ctx.Err() == nil {
+				log.WithFields(log.Fields{
+					"url":     w.URL,
+					"eventID": e.ID,
+					"error":   err,
+				}).Warn("error posting event to webhook")
+			}
+		}
+	}()
+}
+
+// Stop unsubscribes the webhook from its bus, cancels the post that is in
+// progress, and drops the events that have been received but not yet posted.
+func (w *Webhook) Stop() {
+	if w.sub == nil {
+		return
+	}
+	w.cancel()
+	w.sub.Close()
+	<-w.done
+}
+
+func (w *Webhook) post(ctx context.Context, e *Event) error {
+	buf, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	res, err := ctxhttp.Post(ctx, w.Client,
+		w.URL, "application/json; charset=UTF-8", bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return goof.WithField("status", res.Status, "webhook rejected event")
+	}
+	return nil
+}
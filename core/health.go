@@ -0,0 +1,216 @@
+package core
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"golang.org/x/net/context"
+
+	"github.com/emccode/rexray/core/errors"
+)
+
+// HealthChecker is the interface implemented by drivers that are able to
+// verify their connectivity to the platform or storage provider they manage.
+// Implementing this interface is optional.
+type HealthChecker interface {
+
+	// HealthCheck returns an error if the driver cannot communicate with its
+	// underlying platform or storage provider.
+	HealthCheck(ctx context.Context) error
+}
+
+// DriverHealth describes the health of a driver.
+type DriverHealth struct {
+
+	// The name of the driver.
+	Name string `json:"name"`
+
+	// The type of the driver; os, volume, or storage.
+	Type string `json:"type"`
+
+	// A flag indicating whether or not the driver is healthy.
+	Healthy bool `json:"healthy"`
+
+	// A flag indicating whether or not the driver implements a health check.
+	// A driver that does not is always considered healthy.
+	Checked bool `json:"checked"`
+
+	// The error returned by the health check if the driver is unhealthy.
+	Error string `json:"error,omitempty"`
+
+	// The time of the last driver call that completed without error.
+	LastSuccess *time.Time `json:"lastSuccess,omitempty"`
+
+	// The time elapsed since the last driver call that completed without
+	// error.
+	SinceLastSuccess string `json:"sinceLastSuccess,omitempty"`
+}
+
+var (
+	lastSuccess    = map[string]time.Time{}
+	lastSuccessRWL sync.RWMutex
+)
+
+// healthCache holds the results of the drivers' health checks so that probes
+// of the platform's health do not check each driver, which may log in to its
+// storage provider, more often than the configuration property
+// rexray.health.interval.
+type healthCache struct {
+	sync.Mutex
+	results map[string]*healthResult
+}
+
+type healthResult struct {
+	driver Driver
+	time   time.Time
+	err    error
+}
+
+// get returns the result of the driver's last health check if it is no older
+// than the interval, or nil if it must be checked again.
+func (c *healthCache) get(
+	key string, d Driver, interval time.Duration) *healthResult {
+
+	c.Lock()
+	defer c.Unlock()
+	res, ok := c.results[key]
+	if !ok || res.driver != d || time.Since(res.time) >= interval {
+		return nil
+	}
+	return res
+}
+
+func (c *healthCache) set(key string, d Driver, err error) {
+	c.Lock()
+	defer c.Unlock()
+	if c.results == nil {
+		c.results = map[string]*healthResult{}
+	}
+	c.results[key] = &healthResult{driver: d, time: time.Now(), err: err}
+}
+
+func recordSuccess(typ, driver string) {
+	lastSuccessRWL.Lock()
+	lastSuccess[typ+"/"+driver] = time.Now().UTC()
+	lastSuccessRWL.Unlock()
+}
+
+func getLastSuccess(typ, driver string) (time.Time, bool) {
+	lastSuccessRWL.RLock()
+	defer lastSuccessRWL.RUnlock()
+	t, ok := lastSuccess[typ+"/"+driver]
+	return t, ok
+}
+
+// Health checks the health of each of the initialized drivers. Each driver
+// that implements HealthChecker is given the amount of time defined by the
+// configuration property rexray.health.timeout to complete its check. The
+// result of a driver's check is reused for the amount of time defined by the
+// configuration property rexray.health.interval, and a reinitialized driver
+// is always checked.
+func (r *RexRay) Health(ctx context.Context) []*DriverHealth {
+
+	var drivers []Driver
+	var names, types []string
+
+	add := func(typ, n string, d Driver) {
+		drivers = append(drivers, d)
+		names = append(names, driverName(n, d))
+		types = append(types, typ)
+	}
+
+	if r.odm != nil {
+		for n, d := range r.odm.getDrivers() {
+			add("os", n, d)
+		}
+	}
+	if r.vdm != nil {
+		for n, d := range r.vdm.getDrivers() {
+			add("volume", n, d)
+		}
+	}
+	if r.sdm != nil {
+		for n, d := range r.sdm.getDrivers() {
+			add("storage", n, d)
+		}
+	}
+
+	timeout := getDuration(r.GetConfig(), "rexray.health.timeout")
+	interval := getDuration(r.GetConfig(), "rexray.health.interval")
+
+	health := make([]*DriverHealth, len(drivers))
+	wg := &sync.WaitGroup{}
+
+	for i, d := range drivers {
+		h := &DriverHealth{Name: names[i], Type: types[i], Healthy: true}
+		health[i] = h
+
+		if t, ok := getLastSuccess(h.Type, h.Name); ok {
+			h.LastSuccess = &t
+			h.SinceLastSuccess = time.Since(t).String()
+		}
+
+		hc, ok := d.(HealthChecker)
+		if !ok {
+			continue
+		}
+		h.Checked = true
+
+		key := h.Type + "/" + h.Name
+		if res := r.health.get(key, d, interval); res != nil {
+			if res.err != nil {
+				h.Healthy = false
+				h.Error = res.err.Error()
+			}
+			continue
+		}
+
+		wg.Add(1)
+		go func(d Driver, hc HealthChecker, h *DriverHealth, key string) {
+			defer wg.Done()
+			err := checkHealth(ctx, hc, timeout)
+			r.health.set(key, d, err)
+			if err != nil {
+				h.Healthy = false
+				h.Error = err.Error()
+				Logger(ctx).WithFields(log.Fields{
+					"driverName": h.Name,
+					"error":      err,
+				}).Warn("driver health check failed")
+			}
+		}(d, hc, h, key)
+	}
+
+	wg.Wait()
+	return health
+}
+
+// checkHealth runs the health check and returns its result, or an error if
+// the check does not complete before the context is done or the timeout
+// elapses. A driver's health check may not honor the context, so the check is
+// abandoned rather than awaited once the context is done.
+func checkHealth(
+	ctx context.Context, hc HealthChecker, timeout time.Duration) error {
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- hc.HealthCheck(ctx)
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return &errors.TimeoutError{Op: "health check", Timeout: timeout}
+		}
+		return &errors.CanceledError{Op: "health check"}
+	}
+}
@@ -0,0 +1,364 @@
+package core
+
+import (
+	"strconv"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/emccode/rexray/core/errors"
+	"github.com/emccode/rexray/core/metrics"
+)
+
+var (
+	driverCalls = metrics.Default.Counter(
+		"rexray_driver_calls_total",
+		"The number of driver method calls.",
+		"type", "driver", "method")
+
+	driverErrors = metrics.Default.Counter(
+		"rexray_driver_errors_total",
+		"The number of driver method calls that returned an error, by the "+
+			"error's REX-Ray error code.",
+		"type", "driver", "method", "code")
+
+	driverLatency = metrics.Default.Histogram(
+		"rexray_driver_call_duration_seconds",
+		"The time taken by driver method calls that attach, detach, and "+
+			"create volumes, create snapshots, and format and mount devices.",
+		metrics.DefaultBuckets,
+		"type", "driver", "method")
+
+	volumeMounts = metrics.Default.Gauge(
+		"rexray_volume_mounts",
+		"The number of volume mounts that are in use, by volume driver.",
+		"driver")
+)
+
+// timedMethods are the methods for which the latency is recorded.
+var timedMethods = map[string]bool{
+	"AttachVolume":   true,
+	"DetachVolume":   true,
+	"CreateVolume":   true,
+	"CreateSnapshot": true,
+	"Format":         true,
+	"Mount":          true,
+}
+
+type instrument struct {
+	typ string
+
+	// name returns the name of the driver that serves a call with the
+	// context
+	name func(ctx context.Context) string
+}
+
+// observe records a call to a driver method that began at the provided time
+// and completed with the provided error.
+func (i *instrument) observe(
+	ctx context.Context, method string, start time.Time, err error) {
+	driver := i.name(ctx)
+	driverCalls.Inc(i.typ, driver, method)
+	if err == nil {
+		recordSuccess(i.typ, driver)
+	} else {
+		driverErrors.Inc(
+			i.typ, driver, method, strconv.Itoa(int(errors.Code(err))))
+	}
+	if timedMethods[method] {
+		driverLatency.Observe(
+			time.Since(start).Seconds(), i.typ, driver, method)
+	}
+}
+
+// instrumentedODM records metrics for the calls to an OS driver manager.
+type instrumentedODM struct {
+	OSDriverManager
+	i *instrument
+}
+
+func instrumentOSDriverManager(
+	m OSDriverManager, name func(context.Context) string) OSDriverManager {
+	return &instrumentedODM{m, &instrument{"os", name}}
+}
+
+func (m *instrumentedODM) GetMounts(
+	ctx context.Context, deviceName, mountPoint string) (
+	mounts MountInfoArray, err error) {
+	defer func(t time.Time) { m.i.observe(ctx, "GetMounts", t, err) }(time.Now())
+	return m.OSDriverManager.GetMounts(ctx, deviceName, mountPoint)
+}
+
+func (m *instrumentedODM) Mounted(
+	ctx context.Context, mountPoint string) (mounted bool, err error) {
+	defer func(t time.Time) { m.i.observe(ctx, "Mounted", t, err) }(time.Now())
+	return m.OSDriverManager.Mounted(ctx, mountPoint)
+}
+
+func (m *instrumentedODM) Unmount(
+	ctx context.Context, mountPoint string) (err error) {
+	defer func(t time.Time) { m.i.observe(ctx, "Unmount", t, err) }(time.Now())
+	return m.OSDriverManager.Unmount(ctx, mountPoint)
+}
+
+func (m *instrumentedODM) Mount(
+	ctx context.Context,
+	device, target, mountOptions, mountLabel string) (err error) {
+	defer func(t time.Time) { m.i.observe(ctx, "Mount", t, err) }(time.Now())
+	return m.OSDriverManager.Mount(
+		ctx, device, target, mountOptions, mountLabel)
+}
+
+func (m *instrumentedODM) Format(
+	ctx context.Context,
+	deviceName, fsType string, overwriteFs bool) (err error) {
+	defer func(t time.Time) { m.i.observe(ctx, "Format", t, err) }(time.Now())
+	return m.OSDriverManager.Format(ctx, deviceName, fsType, overwriteFs)
+}
+
+// instrumentedVDM records metrics for the calls to a volume driver manager.
+type instrumentedVDM struct {
+	VolumeDriverManager
+	i *instrument
+}
+
+func instrumentVolumeDriverManager(
+	m VolumeDriverManager,
+	name func(context.Context) string) VolumeDriverManager {
+	return &instrumentedVDM{m, &instrument{"volume", name}}
+}
+
+func (m *instrumentedVDM) Mount(
+	ctx context.Context,
+	volumeName, volumeID string,
+	overwriteFs bool, newFsType string, preempt bool) (
+	mountPath string, err error) {
+	defer func(t time.Time) { m.i.observe(ctx, "Mount", t, err) }(time.Now())
+	return m.VolumeDriverManager.Mount(
+		ctx, volumeName, volumeID, overwriteFs, newFsType, preempt)
+}
+
+func (m *instrumentedVDM) Unmount(
+	ctx context.Context, volumeName, volumeID string) (err error) {
+	defer func(t time.Time) { m.i.observe(ctx, "Unmount", t, err) }(time.Now())
+	return m.VolumeDriverManager.Unmount(ctx, volumeName, volumeID)
+}
+
+func (m *instrumentedVDM) Path(
+	ctx context.Context, volumeName, volumeID string) (
+	mountPath string, err error) {
+	defer func(t time.Time) { m.i.observe(ctx, "Path", t, err) }(time.Now())
+	return m.VolumeDriverManager.Path(ctx, volumeName, volumeID)
+}
+
+func (m *instrumentedVDM) Create(
+	ctx context.Context, volumeName string, opts VolumeOpts) (err error) {
+	defer func(t time.Time) { m.i.observe(ctx, "Create", t, err) }(time.Now())
+	return m.VolumeDriverManager.Create(ctx, volumeName, opts)
+}
+
+func (m *instrumentedVDM) Remove(
+	ctx context.Context, volumeName string) (err error) {
+	defer func(t time.Time) { m.i.observe(ctx, "Remove", t, err) }(time.Now())
+	return m.VolumeDriverManager.Remove(ctx, volumeName)
+}
+
+func (m *instrumentedVDM) Attach(
+	ctx context.Context, volumeName, instanceID string, force bool) (
+	deviceName string, err error) {
+	defer func(t time.Time) { m.i.observe(ctx, "Attach", t, err) }(time.Now())
+	return m.VolumeDriverManager.Attach(ctx, volumeName, instanceID, force)
+}
+
+func (m *instrumentedVDM) Detach(
+	ctx context.Context,
+	volumeName, instanceID string, force bool) (err error) {
+	defer func(t time.Time) { m.i.observe(ctx, "Detach", t, err) }(time.Now())
+	return m.VolumeDriverManager.Detach(ctx, volumeName, instanceID, force)
+}
+
+func (m *instrumentedVDM) NetworkName(
+	ctx context.Context, volumeName, instanceID string) (
+	networkName string, err error) {
+	defer func(t time.Time) {
+		m.i.observe(ctx, "NetworkName", t, err)
+	}(time.Now())
+	return m.VolumeDriverManager.NetworkName(ctx, volumeName, instanceID)
+}
+
+func (m *instrumentedVDM) UnmountAll(ctx context.Context) (err error) {
+	defer func(t time.Time) {
+		m.i.observe(ctx, "UnmountAll", t, err)
+	}(time.Now())
+	return m.VolumeDriverManager.UnmountAll(ctx)
+}
+
+func (m *instrumentedVDM) RemoveAll(ctx context.Context) (err error) {
+	defer func(t time.Time) { m.i.observe(ctx, "RemoveAll", t, err) }(time.Now())
+	return m.VolumeDriverManager.RemoveAll(ctx)
+}
+
+func (m *instrumentedVDM) DetachAll(
+	ctx context.Context, instanceID string) (err error) {
+	defer func(t time.Time) { m.i.observe(ctx, "DetachAll", t, err) }(time.Now())
+	return m.VolumeDriverManager.DetachAll(ctx, instanceID)
+}
+
+// instrumentedSDM records metrics for the calls to a storage driver manager.
+type instrumentedSDM struct {
+	StorageDriverManager
+	i *instrument
+}
+
+func instrumentStorageDriverManager(
+	m StorageDriverManager,
+	name func(context.Context) string) StorageDriverManager {
+	return &instrumentedSDM{m, &instrument{"storage", name}}
+}
+
+func (m *instrumentedSDM) GetVolumeMapping(
+	ctx context.Context) (bds []*BlockDevice, err error) {
+	defer func(t time.Time) {
+		m.i.observe(ctx, "GetVolumeMapping", t, err)
+	}(time.Now())
+	return m.StorageDriverManager.GetVolumeMapping(ctx)
+}
+
+func (m *instrumentedSDM) GetInstance(
+	ctx context.Context) (inst *Instance, err error) {
+	defer func(t time.Time) {
+		m.i.observe(ctx, "GetInstance", t, err)
+	}(time.Now())
+	return m.StorageDriverManager.GetInstance(ctx)
+}
+
+func (m *instrumentedSDM) GetInstances(
+	ctx context.Context) (insts []*Instance, err error) {
+	defer func(t time.Time) {
+		m.i.observe(ctx, "GetInstances", t, err)
+	}(time.Now())
+	return m.StorageDriverManager.GetInstances(ctx)
+}
+
+func (m *instrumentedSDM) GetVolume(
+	ctx context.Context,
+	volumeID, volumeName string) (vols []*Volume, err error) {
+	defer func(t time.Time) { m.i.observe(ctx, "GetVolume", t, err) }(time.Now())
+	return m.StorageDriverManager.GetVolume(ctx, volumeID, volumeName)
+}
+
+func (m *instrumentedSDM) GetVolumeAttach(
+	ctx context.Context,
+	volumeID, instanceID string) (atts []*VolumeAttachment, err error) {
+	defer func(t time.Time) {
+		m.i.observe(ctx, "GetVolumeAttach", t, err)
+	}(time.Now())
+	return m.StorageDriverManager.GetVolumeAttach(ctx, volumeID, instanceID)
+}
+
+func (m *instrumentedSDM) CreateSnapshot(
+	ctx context.Context,
+	runAsync bool,
+	snapshotName, volumeID, description string) (
+	snaps []*Snapshot, err error) {
+	defer func(t time.Time) {
+		m.i.observe(ctx, "CreateSnapshot", t, err)
+	}(time.Now())
+	return m.StorageDriverManager.CreateSnapshot(
+		ctx, runAsync, snapshotName, volumeID, description)
+}
+
+func (m *instrumentedSDM) GetSnapshot(
+	ctx context.Context,
+	volumeID, snapshotID, snapshotName string) (
+	snaps []*Snapshot, err error) {
+	defer func(t time.Time) {
+		m.i.observe(ctx, "GetSnapshot", t, err)
+	}(time.Now())
+	return m.StorageDriverManager.GetSnapshot(
+		ctx, volumeID, snapshotID, snapshotName)
+}
+
+func (m *instrumentedSDM) RemoveSnapshot(
+	ctx context.Context, snapshotID string) (err error) {
+	defer func(t time.Time) {
+		m.i.observe(ctx, "RemoveSnapshot", t, err)
+	}(time.Now())
+	return m.StorageDriverManager.RemoveSnapshot(ctx, snapshotID)
+}
+
+func (m *instrumentedSDM) CreateVolume(
+	ctx context.Context,
+	runAsync bool,
+	volumeName, volumeID, snapshotID, volumeType string,
+	IOPS, size int64,
+	availabilityZone string) (vol *Volume, err error) {
+	defer func(t time.Time) {
+		m.i.observe(ctx, "CreateVolume", t, err)
+	}(time.Now())
+	return m.StorageDriverManager.CreateVolume(
+		ctx, runAsync, volumeName, volumeID, snapshotID, volumeType,
+		IOPS, size, availabilityZone)
+}
+
+func (m *instrumentedSDM) CloneVolume(
+	ctx context.Context,
+	sourceVolumeID, newName string,
+	opts VolumeOpts) (vol *Volume, err error) {
+	defer func(t time.Time) {
+		m.i.observe(ctx, "CloneVolume", t, err)
+	}(time.Now())
+	return m.StorageDriverManager.CloneVolume(
+		ctx, sourceVolumeID, newName, opts)
+}
+
+func (m *instrumentedSDM) RemoveVolume(
+	ctx context.Context, volumeID string) (err error) {
+	defer func(t time.Time) {
+		m.i.observe(ctx, "RemoveVolume", t, err)
+	}(time.Now())
+	return m.StorageDriverManager.RemoveVolume(ctx, volumeID)
+}
+
+func (m *instrumentedSDM) GetDeviceNextAvailable(
+	ctx context.Context) (deviceName string, err error) {
+	defer func(t time.Time) {
+		m.i.observe(ctx, "GetDeviceNextAvailable", t, err)
+	}(time.Now())
+	return m.StorageDriverManager.GetDeviceNextAvailable(ctx)
+}
+
+func (m *instrumentedSDM) AttachVolume(
+	ctx context.Context,
+	runAsync bool,
+	volumeID, instanceID string, force bool) (
+	atts []*VolumeAttachment, err error) {
+	defer func(t time.Time) {
+		m.i.observe(ctx, "AttachVolume", t, err)
+	}(time.Now())
+	return m.StorageDriverManager.AttachVolume(
+		ctx, runAsync, volumeID, instanceID, force)
+}
+
+func (m *instrumentedSDM) DetachVolume(
+	ctx context.Context,
+	runAsync bool, volumeID, instanceID string, force bool) (err error) {
+	defer func(t time.Time) {
+		m.i.observe(ctx, "DetachVolume", t, err)
+	}(time.Now())
+	return m.StorageDriverManager.DetachVolume(
+		ctx, runAsync, volumeID, instanceID, force)
+}
+
+func (m *instrumentedSDM) CopySnapshot(
+	ctx context.Context,
+	runAsync bool, volumeID, snapshotID, snapshotName,
+	destinationSnapshotName, destinationRegion string) (
+	snap *Snapshot, err error) {
+	defer func(t time.Time) {
+		m.i.observe(ctx, "CopySnapshot", t, err)
+	}(time.Now())
+	return m.StorageDriverManager.CopySnapshot(
+		ctx, runAsync, volumeID, snapshotID, snapshotName,
+		destinationSnapshotName, destinationRegion)
+}
@@ -0,0 +1,384 @@
+// Package metrics provides counters, gauges, and histograms that are exposed
+// in the Prometheus text exposition format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the content type of the Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4"
+
+// DefaultBuckets are the default upper bounds, in seconds, of the buckets of
+// a histogram that measures latency. Storage operations such as creating a
+// volume or a snapshot may take several minutes, so the buckets extend to ten
+// minutes.
+var DefaultBuckets = []float64{
+	.05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600}
+
+// Default is the registry to which the metrics for the current process are
+// registered.
+var Default = NewRegistry()
+
+// Registry is a collection of metrics.
+type Registry struct {
+	m       sync.RWMutex
+	metrics map[string]metric
+}
+
+type metric interface {
+	write(w *bufio.Writer)
+}
+
+// NewRegistry returns a new, empty registry.
+func NewRegistry() *Registry {
+	return &Registry{metrics: map[string]metric{}}
+}
+
+// Counter registers and returns a new counter with the provided name, help
+// text, and label names. If a metric with the same name is already registered
+// and it is a counter then the existing counter is returned.
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	return r.register(name, func() metric {
+		return &Counter{vec: newVec(name, help, "counter", labels)}
+	}).(*Counter)
+}
+
+// Gauge registers and returns a new gauge with the provided name, help text,
+// and label names. If a metric with the same name is already registered and
+// it is a gauge then the existing gauge is returned.
+func (r *Registry) Gauge(name, help string, labels ...string) *Gauge {
+	return r.register(name, func() metric {
+		return &Gauge{vec: newVec(name, help, "gauge", labels)}
+	}).(*Gauge)
+}
+
+// Histogram registers and returns a new histogram with the provided name,
+// help text, bucket upper bounds, and label names. If a metric with the same
+// name is already registered and it is a histogram then the existing
+// histogram is returned.
+func (r *Registry) Histogram(
+	name, help string, buckets []float64, labels ...string) *Histogram {
+
+	return r.register(name, func() metric {
+		b := make([]float64, len(buckets))
+		copy(b, buckets)
+		sort.Float64s(b)
+		return &Histogram{
+			vec:     newVec(name, help, "histogram", labels),
+			buckets: b,
+		}
+	}).(*Histogram)
+}
+
+func (r *Registry) register(name string, ctor func() metric) metric {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if m, ok := r.metrics[name]; ok {
+		return m
+	}
+	m := ctor()
+	r.metrics[name] = m
+	return m
+}
+
+// WriteTo writes the registry's metrics to the writer in the Prometheus text
+// exposition format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.m.RLock()
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	metrics := make([]metric, len(names))
+	for i, name := range names {
+		metrics[i] = r.metrics[name]
+	}
+	r.m.RUnlock()
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, m := range metrics {
+		m.write(bw)
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// Handler returns an HTTP handler that serves the registry's metrics.
+func Handler(r *Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		r.WriteTo(w)
+	})
+}
+
+// Counter is a metric whose value only increases.
+type Counter struct {
+	*vec
+}
+
+// Inc increments the value of the counter with the provided label values.
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds the provided, non-negative amount to the value of the counter
+// with the provided label values.
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		return
+	}
+	c.with(labelValues, func(s *series) { s.value += v })
+}
+
+// Value returns the value of the counter with the provided label values.
+func (c *Counter) Value(labelValues ...string) float64 {
+	return c.value(labelValues)
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.writeHeader(w)
+	c.each(func(s *series) {
+		writeSample(w, c.name, c.labels, s.labelValues, "", "", s.value)
+	})
+}
+
+// Gauge is a metric whose value may increase or decrease.
+type Gauge struct {
+	*vec
+}
+
+// Set sets the value of the gauge with the provided label values.
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	g.with(labelValues, func(s *series) { s.value = v })
+}
+
+// Add adds the provided amount to the value of the gauge with the provided
+// label values.
+func (g *Gauge) Add(v float64, labelValues ...string) {
+	g.with(labelValues, func(s *series) { s.value += v })
+}
+
+// Delete removes the gauge with the provided label values.
+func (g *Gauge) Delete(labelValues ...string) {
+	g.m.Lock()
+	delete(g.series, seriesKey(labelValues))
+	g.m.Unlock()
+}
+
+// Value returns the value of the gauge with the provided label values.
+func (g *Gauge) Value(labelValues ...string) float64 {
+	return g.value(labelValues)
+}
+
+func (g *Gauge) write(w *bufio.Writer) {
+	g.writeHeader(w)
+	g.each(func(s *series) {
+		writeSample(w, g.name, g.labels, s.labelValues, "", "", s.value)
+	})
+}
+
+// Histogram is a metric that counts observations in buckets.
+type Histogram struct {
+	*vec
+	buckets []float64
+}
+
+// Observe adds an observation to the histogram with the provided label
+// values.
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	h.with(labelValues, func(s *series) {
+		if s.counts == nil {
+			s.counts = make([]uint64, len(h.buckets))
+		}
+		for i, b := range h.buckets {
+			if v <= b {
+				s.counts[i]++
+			}
+		}
+		s.count++
+		s.value += v
+	})
+}
+
+// Count returns the number of observations made by the histogram with the
+// provided label values.
+func (h *Histogram) Count(labelValues ...string) uint64 {
+	h.m.RLock()
+	defer h.m.RUnlock()
+	if s, ok := h.series[seriesKey(labelValues)]; ok {
+		return s.count
+	}
+	return 0
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.writeHeader(w)
+	h.each(func(s *series) {
+		for i, b := range h.buckets {
+			var n uint64
+			if s.counts != nil {
+				n = s.counts[i]
+			}
+			writeSample(w, h.name+"_bucket", h.labels, s.labelValues,
+				"le", formatFloat(b), float64(n))
+		}
+		writeSample(w, h.name+"_bucket", h.labels, s.labelValues,
+			"le", "+Inf", float64(s.count))
+		writeSample(
+			w, h.name+"_sum", h.labels, s.labelValues, "", "", s.value)
+		writeSample(w, h.name+"_count", h.labels, s.labelValues, "", "",
+			float64(s.count))
+	})
+}
+
+type vec struct {
+	name   string
+	help   string
+	typ    string
+	labels []string
+	m      sync.RWMutex
+	series map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+	count       uint64
+	counts      []uint64
+}
+
+func newVec(name, help, typ string, labels []string) *vec {
+	return &vec{
+		name:   name,
+		help:   help,
+		typ:    typ,
+		labels: labels,
+		series: map[string]*series{},
+	}
+}
+
+func seriesKey(labelValues []string) string {
+	return strings.Join(labelValues, "\xff")
+}
+
+func (v *vec) with(labelValues []string, f func(s *series)) {
+	if len(labelValues) != len(v.labels) {
+		panic(fmt.Sprintf(
+			"metric %s: expected %d label values, got %d",
+			v.name, len(v.labels), len(labelValues)))
+	}
+	k := seriesKey(labelValues)
+	v.m.Lock()
+	defer v.m.Unlock()
+	s, ok := v.series[k]
+	if !ok {
+		lv := make([]string, len(labelValues))
+		copy(lv, labelValues)
+		s = &series{labelValues: lv}
+		v.series[k] = s
+	}
+	f(s)
+}
+
+func (v *vec) value(labelValues []string) float64 {
+	v.m.RLock()
+	defer v.m.RUnlock()
+	if s, ok := v.series[seriesKey(labelValues)]; ok {
+		return s.value
+	}
+	return 0
+}
+
+func (v *vec) each(f func(s *series)) {
+	v.m.RLock()
+	defer v.m.RUnlock()
+	keys := make([]string, 0, len(v.series))
+	for k := range v.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		f(v.series[k])
+	}
+}
+
+func (v *vec) writeHeader(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", v.name, escapeHelp(v.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", v.name, v.typ)
+}
+
+func writeSample(
+	w *bufio.Writer,
+	name string,
+	labels, labelValues []string,
+	extraLabel, extraValue string,
+	value float64) {
+
+	w.WriteString(name)
+	if len(labels) > 0 || extraLabel != "" {
+		w.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", l, escapeLabelValue(labelValues[i]))
+		}
+		if extraLabel != "" {
+			if len(labels) > 0 {
+				w.WriteByte(',')
+			}
+			fmt.Fprintf(w, "%s=\"%s\"", extraLabel, extraValue)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpReplacer  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpReplacer.Replace(s)
+}
+
+func escapeLabelValue(s string) string {
+	return labelReplacer.Replace(s)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,603 @@
+package core
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/akutz/goof"
+	"github.com/akutz/gotil"
+	"golang.org/x/net/context"
+
+	"github.com/emccode/rexray/core/audit"
+	"github.com/emccode/rexray/core/errors"
+	"github.com/emccode/rexray/util"
+)
+
+// OperationState is the state of an asynchronous operation.
+type OperationState string
+
+const (
+	// OperationPending is the state of an operation that has been accepted
+	// but has not yet started.
+	OperationPending OperationState = "pending"
+
+	// OperationRunning is the state of an operation that is executing.
+	OperationRunning OperationState = "running"
+
+	// OperationSucceeded is the state of an operation that completed without
+	// error.
+	OperationSucceeded OperationState = "succeeded"
+
+	// OperationFailed is the state of an operation that completed with an
+	// error or was interrupted.
+	OperationFailed OperationState = "failed"
+)
+
+const (
+	// OperationCreateVolume is the type of an operation that creates a volume.
+	OperationCreateVolume = "volume.create"
+
+	// OperationAttachVolume is the type of an operation that attaches a
+	// volume.
+	OperationAttachVolume = "volume.attach"
+
+	// OperationDetachVolume is the type of an operation that detaches a
+	// volume.
+	OperationDetachVolume = "volume.detach"
+
+	// OperationCreateSnapshot is the type of an operation that creates a
+	// snapshot.
+	OperationCreateSnapshot = "snapshot.create"
+
+	// OperationCopySnapshot is the type of an operation that copies a
+	// snapshot.
+	OperationCopySnapshot = "snapshot.copy"
+)
+
+// OperationRequest describes an asynchronous storage operation.
+type OperationRequest struct {
+
+	// The type of the operation.
+	Type string
+
+	// The name of the volume.
+	VolumeName string
+
+	// The volume ID.
+	VolumeID string
+
+	// The snapshot ID.
+	SnapshotID string
+
+	// The name of the snapshot.
+	SnapshotName string
+
+	// A description of the snapshot.
+	Description string
+
+	// The name of the copy of the snapshot.
+	TargetSnapshotName string `json:",omitempty"`
+
+	// The region to which the snapshot is copied.
+	TargetRegion string `json:",omitempty"`
+
+	// The volume type.
+	VolumeType string
+
+	// The volume IOPs.
+	IOPS int64
+
+	// The size of the volume.
+	Size int64
+
+	// The availability zone for which the volume is available.
+	AvailabilityZone string
+
+	// The ID of the instance to which the volume is attached or detached.
+	InstanceID string
+
+	// A flag indicating whether or not to force an attach or detach.
+	Force bool
+
+	// The options of the volume that is created, ex. encrypted.
+	Opts VolumeOpts `json:",omitempty"`
+
+	// The name of the storage driver, or instance of a storage driver, that
+	// performs the operation. The default storage driver is used if the name
+	// is empty.
+	StorageDriver string `json:",omitempty"`
+}
+
+// Operation provides information about an asynchronous storage operation.
+type Operation struct {
+
+	// The operation's ID.
+	ID string
+
+	// The ID of the request that started the operation.
+	RequestID string `json:",omitempty"`
+
+	// The party that requested the operation.
+	Caller *audit.Caller `json:",omitempty"`
+
+	// The type of the operation.
+	Type string
+
+	// The state of the operation.
+	State OperationState
+
+	// The request from which the operation was created.
+	Request *OperationRequest
+
+	// The result of the operation if it succeeded.
+	Result interface{} `json:",omitempty"`
+
+	// The error message if the operation failed.
+	Error string `json:",omitempty"`
+
+	// The ID of the process executing the operation.
+	PID int
+
+	// The time at which the operation was created.
+	CreatedTime time.Time
+
+	// The time at which the operation started executing.
+	StartedTime time.Time
+
+	// The time at which the operation completed.
+	CompletedTime time.Time
+}
+
+// Done returns a flag indicating whether or not the operation has completed.
+func (o *Operation) Done() bool {
+	return o.State == OperationSucceeded || o.State == OperationFailed
+}
+
+// OperationManager starts and tracks asynchronous storage operations. The
+// operations are persisted to disk so that their status may be queried after
+// the process that started them has exited.
+type OperationManager interface {
+
+	// Start starts a new operation and returns it without waiting for it to
+	// complete. The operation is associated with the request ID carried by
+	// the context, or with its own ID if the context does not carry one.
+	Start(ctx context.Context, req *OperationRequest) (*Operation, error)
+
+	// Get gets the operation with the provided ID.
+	Get(id string) (*Operation, error)
+
+	// List gets all of the known operations, ordered by their creation time.
+	List() ([]*Operation, error)
+
+	// Wait blocks until the operation with the provided ID completes or until
+	// the context is done, in which case the operation is returned along with
+	// the context's error.
+	Wait(ctx context.Context, id string) (*Operation, error)
+}
+
+type opm struct {
+	rexray *RexRay
+	m      sync.RWMutex
+	ops    map[string]*Operation
+}
+
+func newOperationManager(r *RexRay) *opm {
+	return &opm{
+		rexray: r,
+		ops:    map[string]*Operation{},
+	}
+}
+
+func (o *opm) Start(
+	ctx context.Context, req *OperationRequest) (*Operation, error) {
+
+	if req == nil {
+		return nil, errors.ErrUnknownOperationType
+	}
+
+	switch req.Type {
+	case OperationCreateVolume,
+		OperationAttachVolume,
+		OperationDetachVolume,
+		OperationCreateSnapshot,
+		OperationCopySnapshot:
+	default:
+		return nil, goof.WithFieldE(
+			"type", req.Type, "error starting operation",
+			errors.ErrUnknownOperationType)
+	}
+
+	op, err := o.newOperation(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := o.save(op); err != nil {
+		return nil, err
+	}
+
+	Logger(ctx).WithFields(log.Fields{
+		"id":   op.ID,
+		"type": op.Type,
+	}).Debug("started operation")
+
+	go o.run(op)
+
+	return o.copy(op), nil
+}
+
+// newOperation returns a new, pending operation for the request and prunes
+// the finished operations that are no longer kept.
+func (o *opm) newOperation(
+	ctx context.Context, req *OperationRequest) (*Operation, error) {
+
+	id, err := newOperationID()
+	if err != nil {
+		return nil, err
+	}
+
+	requestID := RequestID(ctx)
+	if requestID == "" {
+		requestID = id
+	}
+
+	if req.StorageDriver == "" {
+		req.StorageDriver = StorageDriverName(ctx)
+	}
+
+	o.prune()
+
+	return &Operation{
+		ID:          id,
+		RequestID:   requestID,
+		Caller:      GetCaller(ctx),
+		Type:        req.Type,
+		State:       OperationPending,
+		Request:     req,
+		PID:         os.Getpid(),
+		CreatedTime: time.Now().UTC(),
+	}, nil
+}
+
+func (o *opm) run(op *Operation) {
+
+	o.update(op, func() {
+		op.State = OperationRunning
+		op.StartedTime = time.Now().UTC()
+	})
+
+	ctx, cancel := NewContext(
+		context.Background(), o.rexray.GetConfig(), op.RequestID)
+	defer cancel()
+
+	if op.Caller != nil {
+		ctx = WithCaller(ctx, op.Caller)
+	}
+	if op.Request.StorageDriver != "" {
+		ctx = WithStorageDriverName(ctx, op.Request.StorageDriver)
+	}
+
+	result, err := o.exec(ctx, op.Request)
+	o.complete(ctx, op, result, err)
+}
+
+// complete records the result of the operation. A finished operation is
+// read from disk from then on, so it is no longer kept in memory.
+func (o *opm) complete(
+	ctx context.Context, op *Operation, result interface{}, err error) {
+
+	o.update(op, func() {
+		op.CompletedTime = time.Now().UTC()
+		if err != nil {
+			op.State = OperationFailed
+			op.Error = err.Error()
+		} else {
+			op.State = OperationSucceeded
+			op.Result = result
+		}
+	})
+
+	o.m.Lock()
+	delete(o.ops, op.ID)
+	o.m.Unlock()
+
+	fields := log.Fields{
+		"id":    op.ID,
+		"type":  op.Type,
+		"state": op.State,
+	}
+	if err != nil {
+		fields["error"] = err
+	}
+	Logger(ctx).WithFields(fields).Debug("completed operation")
+}
+
+func (o *opm) exec(
+	ctx context.Context, req *OperationRequest) (interface{}, error) {
+
+	if o.rexray.Storage == nil {
+		return nil, errors.ErrNoStorageDrivers
+	}
+
+	s := o.rexray.Storage
+
+	switch req.Type {
+	case OperationCreateVolume:
+		return s.CreateVolume(
+			WithVolumeOpts(ctx, req.Opts), false, req.VolumeName, req.VolumeID, req.SnapshotID,
+			req.VolumeType, req.IOPS, req.Size, req.AvailabilityZone)
+	case OperationAttachVolume:
+		return s.AttachVolume(
+			ctx, false, req.VolumeID, req.InstanceID, req.Force)
+	case OperationDetachVolume:
+		return nil, s.DetachVolume(
+			ctx, false, req.VolumeID, req.InstanceID, req.Force)
+	case OperationCreateSnapshot:
+		return s.CreateSnapshot(
+			ctx, false, req.SnapshotName, req.VolumeID, req.Description)
+	case OperationCopySnapshot:
+		return s.CopySnapshot(
+			ctx, false, req.VolumeID, req.SnapshotID, req.SnapshotName,
+			req.TargetSnapshotName, req.TargetRegion)
+	}
+
+	return nil, errors.ErrUnknownOperationType
+}
+
+func (o *opm) update(op *Operation, f func()) {
+	o.m.Lock()
+	f()
+	o.m.Unlock()
+
+	if err := o.save(op); err != nil {
+		log.WithFields(log.Fields{
+			"id":    op.ID,
+			"error": err,
+		}).Error("error saving operation")
+	}
+}
+
+func (o *opm) Get(id string) (*Operation, error) {
+
+	if id == "" || filepath.Base(id) != id {
+		return nil, goof.WithFieldE(
+			"id", id, "error getting operation", errors.ErrOperationNotFound)
+	}
+
+	o.m.RLock()
+	op, ok := o.ops[id]
+	if ok {
+		op = o.copyNoLock(op)
+	}
+	o.m.RUnlock()
+
+	if ok {
+		return op, nil
+	}
+
+	return o.load(o.filePath(id))
+}
+
+func (o *opm) List() ([]*Operation, error) {
+
+	fileNames, err := filepath.Glob(filepath.Join(o.dirPath(), "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var ops []*Operation
+	for _, f := range fileNames {
+		id := strings.TrimSuffix(filepath.Base(f), ".json")
+		op, err := o.Get(id)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"path":  f,
+				"error": err,
+			}).Warn("error reading operation")
+			continue
+		}
+		ops = append(ops, op)
+	}
+
+	sort.Sort(operationsByCreatedTime(ops))
+	return ops, nil
+}
+
+func (o *opm) Wait(ctx context.Context, id string) (*Operation, error) {
+
+	t := time.NewTicker(500 * time.Millisecond)
+	defer t.Stop()
+
+	for {
+		op, err := o.Get(id)
+		if err != nil {
+			return nil, err
+		}
+
+		if op.Done() {
+			return op, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return op, goof.WithFieldE(
+				"id", id, "error waiting for operation", ctx.Err())
+		case <-t.C:
+		}
+	}
+}
+
+func (o *opm) save(op *Operation) error {
+
+	o.m.Lock()
+	defer o.m.Unlock()
+
+	o.ops[op.ID] = op
+
+	buf, err := json.MarshalIndent(op, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(o.dirPath(), 0755); err != nil {
+		return err
+	}
+
+	// write to a temporary file and rename it so that readers in other
+	// processes never observe a partially written operation
+	tmp := o.filePath(op.ID) + ".tmp"
+	if err := ioutil.WriteFile(tmp, buf, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, o.filePath(op.ID))
+}
+
+// prune removes the finished operations that completed longer ago than the
+// configuration property rexray.operations.ttl, and the oldest finished
+// operations in excess of rexray.operations.max.
+func (o *opm) prune() {
+
+	config := o.rexray.GetConfig()
+	ttl := getDuration(config, "rexray.operations.ttl")
+	max := 0
+	if config != nil {
+		max = config.GetInt("rexray.operations.max")
+	}
+	if ttl <= 0 && max <= 0 {
+		return
+	}
+
+	fileNames, err := filepath.Glob(filepath.Join(o.dirPath(), "*.json"))
+	if err != nil {
+		return
+	}
+
+	var done []*Operation
+	for _, f := range fileNames {
+		op, err := o.load(f)
+		if err != nil || !op.Done() {
+			continue
+		}
+		done = append(done, op)
+	}
+
+	sort.Sort(operationsByCompletedTime(done))
+
+	cutoff := time.Now().Add(-ttl)
+	for i, op := range done {
+		if (ttl <= 0 || !completedTime(op).Before(cutoff)) &&
+			(max <= 0 || len(done)-i <= max) {
+			continue
+		}
+		if err := os.Remove(o.filePath(op.ID)); err != nil &&
+			!os.IsNotExist(err) {
+			log.WithFields(log.Fields{
+				"id":    op.ID,
+				"error": err,
+			}).Warn("error removing operation")
+		}
+	}
+}
+
+func (o *opm) load(path string) (*Operation, error) {
+
+	if !gotil.FileExists(path) {
+		return nil, goof.WithFieldE(
+			"path", path, "error loading operation",
+			errors.ErrOperationNotFound)
+	}
+
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	op := &Operation{}
+	if err := json.Unmarshal(buf, op); err != nil {
+		return nil, goof.WithFieldE(
+			"path", path, "error unmarshalling operation", err)
+	}
+
+	// an incomplete operation whose process no longer exists will never
+	// complete, so report it as failed
+	if !op.Done() && !isProcessRunning(op.PID) {
+		op.State = OperationFailed
+		op.Error = "operation interrupted"
+	}
+
+	return op, nil
+}
+
+func (o *opm) dirPath() string {
+	return util.LibFilePath("operations")
+}
+
+func (o *opm) filePath(id string) string {
+	return filepath.Join(o.dirPath(), id+".json")
+}
+
+func (o *opm) copy(op *Operation) *Operation {
+	o.m.RLock()
+	defer o.m.RUnlock()
+	return o.copyNoLock(op)
+}
+
+func (o *opm) copyNoLock(op *Operation) *Operation {
+	c := *op
+	return &c
+}
+
+func newOperationID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", goof.WithError("error generating operation ID", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func isProcessRunning(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return p.Signal(syscall.Signal(0)) == nil
+}
+
+// completedTime returns the time at which the operation completed, or, for
+// an operation that was interrupted, the time at which it was created.
+func completedTime(op *Operation) time.Time {
+	if op.CompletedTime.IsZero() {
+		return op.CreatedTime
+	}
+	return op.CompletedTime
+}
+
+type operationsByCompletedTime []*Operation
+
+func (o operationsByCompletedTime) Len() int      { return len(o) }
+func (o operationsByCompletedTime) Swap(i, j int) { o[i], o[j] = o[j], o[i] }
+func (o operationsByCompletedTime) Less(i, j int) bool {
+	return completedTime(o[i]).Before(completedTime(o[j]))
+}
+
+type operationsByCreatedTime []*Operation
+
+func (o operationsByCreatedTime) Len() int      { return len(o) }
+func (o operationsByCreatedTime) Swap(i, j int) { o[i], o[j] = o[j], o[i] }
+func (o operationsByCreatedTime) Less(i, j int) bool {
+	return o[i].CreatedTime.Before(o[j].CreatedTime)
+}
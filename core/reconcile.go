@@ -0,0 +1,473 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/akutz/gotil"
+	"golang.org/x/net/context"
+
+	"github.com/emccode/rexray/util"
+)
+
+const (
+	// DriftAttachedNotMounted is the type of the drift that occurs when a
+	// volume is attached to the instance but its device is not mounted.
+	DriftAttachedNotMounted = "attachedNotMounted"
+
+	// DriftStaleMount is the type of the drift that occurs when a mount point
+	// in the REX-Ray volumes directory refers to a device that no longer
+	// exists, or when a directory in the REX-Ray volumes directory is not a
+	// mount point for an attached volume.
+	DriftStaleMount = "staleMount"
+
+	// DriftUsedCount is the type of the drift that occurs when the number of
+	// times the volume manager believes a volume is in use disagrees with
+	// whether or not the volume is mounted.
+	DriftUsedCount = "usedCount"
+)
+
+const (
+	// RepairRemount is the repair that mounts an attached volume that is in
+	// use at its mount point in the REX-Ray volumes directory.
+	RepairRemount = "remount"
+
+	// RepairDetach is the repair that detaches an attached volume that is
+	// not in use and removes its empty mount point.
+	RepairDetach = "detach"
+
+	// RepairUnmount is the repair that unmounts a stale mount point and
+	// removes its directory.
+	RepairUnmount = "unmount"
+
+	// RepairRemoveDir is the repair that removes an empty directory that is
+	// not a mount point.
+	RepairRemoveDir = "removeDir"
+
+	// RepairSetUsedCount is the repair that sets the number of times a
+	// volume is in use to agree with whether or not it is mounted.
+	RepairSetUsedCount = "setUsedCount"
+)
+
+// ReconcileOptions are the options that govern a reconciliation.
+type ReconcileOptions struct {
+
+	// Fix indicates whether or not to repair the drift that is found.
+	Fix bool
+
+	// CheckUsedCounts indicates whether or not to compare the number of times
+	// the volume manager believes each volume is in use with the mounts. The
+	// counts are kept in memory by the process that services the Docker
+	// volume driver, so they should only be checked by that process.
+	CheckUsedCounts bool
+}
+
+// Drift describes an inconsistency between the provider's view of the
+// volumes attached to the instance and the host's view of them.
+type Drift struct {
+
+	// The type of the drift.
+	Type string `json:"type"`
+
+	// A description of the drift.
+	Description string `json:"description"`
+
+	// The ID of the volume.
+	VolumeID string `json:"volumeID,omitempty"`
+
+	// The name of the volume.
+	VolumeName string `json:"volumeName,omitempty"`
+
+	// The name of the device.
+	DeviceName string `json:"deviceName,omitempty"`
+
+	// The mount point.
+	MountPoint string `json:"mountPoint,omitempty"`
+
+	// The number of times the volume manager believes the volume is in use.
+	UsedCount int `json:"usedCount,omitempty"`
+
+	// The repair that resolves the drift.
+	Repair string `json:"repair"`
+
+	// A flag indicating whether or not the drift was repaired.
+	Repaired bool `json:"repaired"`
+
+	// The error that prevented the drift from being repaired.
+	Error string `json:"error,omitempty"`
+}
+
+// ReconcileReport describes the result of a reconciliation.
+type ReconcileReport struct {
+
+	// The ID of the instance that was reconciled.
+	InstanceID string `json:"instanceID"`
+
+	// The time at which the reconciliation started.
+	Time time.Time `json:"time"`
+
+	// A flag indicating whether or not repairs were attempted.
+	Fix bool `json:"fix"`
+
+	// The drift that was found.
+	Drift []*Drift `json:"drift"`
+}
+
+// Reconcile compares the volumes the storage driver reports as attached to
+// this instance with the devices mounted on the host and, optionally, with
+// the number of times the volume manager believes each volume is in use. Each
+// inconsistency is reported and, if opts.Fix is set, repaired.
+func (r *RexRay) Reconcile(
+	ctx context.Context, opts *ReconcileOptions) (*ReconcileReport, error) {
+
+	if opts == nil {
+		opts = &ReconcileOptions{}
+	}
+
+	report := &ReconcileReport{
+		Time:  time.Now().UTC(),
+		Fix:   opts.Fix,
+		Drift: []*Drift{},
+	}
+
+	inst, err := r.Storage.GetInstance(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report.InstanceID = inst.InstanceID
+
+	bds, err := r.Storage.GetVolumeMapping(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	mounts, err := r.OS.GetMounts(ctx, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	mountsByDevice := map[string][]string{}
+	for _, m := range mounts {
+		mountsByDevice[m.Source] = append(mountsByDevice[m.Source], m.Mountpoint)
+	}
+
+	volsDir := util.VolumesDirPath()
+
+	// the volumes the volume manager knows, and their used counts if they
+	// are checked
+	var known, counts map[string]int
+	if r.vdm != nil {
+		known = r.vdm.usedCounts()
+	}
+	if opts.CheckUsedCounts {
+		counts = known
+	}
+
+	roots := rootDisks(mounts)
+
+	// the names of the attached volumes and the mount points that belong to
+	// them
+	attached := map[string]bool{}
+	expected := map[string]bool{}
+
+	for _, bd := range bds {
+		if bd.VolumeID == "" {
+			continue
+		}
+
+		atts, err := r.Storage.GetVolumeAttach(
+			ctx, bd.VolumeID, inst.InstanceID)
+		if err != nil {
+			return nil, err
+		}
+		if len(atts) == 0 {
+			continue
+		}
+
+		deviceName := atts[0].DeviceName
+		if deviceName == "" {
+			deviceName = bd.DeviceName
+		}
+
+		volumeName := ""
+		vols, err := r.Storage.GetVolume(ctx, bd.VolumeID, "")
+		if err != nil {
+			return nil, err
+		}
+		if len(vols) > 0 {
+			volumeName = vols[0].Name
+		}
+
+		if volumeName == "" {
+			continue
+		}
+		mountPoint := filepath.Join(volsDir, volumeName)
+		attached[volumeName] = true
+		expected[mountPoint] = true
+
+		// only the volumes REX-Ray manages are reconciled; the volumes with
+		// a mount point in the volumes directory or that the volume manager
+		// counts, and never the disks of the root file systems or the
+		// volumes that are being mounted
+		if r.isMounting(volumeName) ||
+			roots[diskName(deviceName)] || roots[diskName(bd.DeviceName)] {
+			continue
+		}
+		if _, ok := known[volumeName]; !ok && !isDir(mountPoint) {
+			continue
+		}
+
+		if len(mountsByDevice[deviceName]) > 0 {
+			if counts != nil && counts[volumeName] < 1 &&
+				gotil.StringInSlice(mountPoint, mountsByDevice[deviceName]) {
+				report.Drift = append(report.Drift, &Drift{
+					Type:        DriftUsedCount,
+					Description: "volume is mounted but is not counted as in use",
+					VolumeID:    bd.VolumeID,
+					VolumeName:  volumeName,
+					DeviceName:  deviceName,
+					MountPoint:  mountPoint,
+					UsedCount:   counts[volumeName],
+					Repair:      RepairSetUsedCount,
+				})
+			}
+			continue
+		}
+
+		d := &Drift{
+			Type:        DriftAttachedNotMounted,
+			Description: "volume is attached but its device is not mounted",
+			VolumeID:    bd.VolumeID,
+			VolumeName:  volumeName,
+			DeviceName:  deviceName,
+			MountPoint:  mountPoint,
+			Repair:      RepairDetach,
+		}
+		if counts != nil && counts[volumeName] > 0 {
+			d.UsedCount = counts[volumeName]
+			d.Repair = RepairRemount
+		}
+		report.Drift = append(report.Drift, d)
+	}
+
+	for _, m := range mounts {
+		if !isInDir(volsDir, m.Mountpoint) || !isLocalDevice(m.Source) {
+			continue
+		}
+		if _, err := os.Stat(m.Source); !os.IsNotExist(err) {
+			continue
+		}
+		report.Drift = append(report.Drift, &Drift{
+			Type:        DriftStaleMount,
+			Description: "mount point's device no longer exists",
+			DeviceName:  m.Source,
+			MountPoint:  m.Mountpoint,
+			Repair:      RepairUnmount,
+		})
+		expected[m.Mountpoint] = true
+	}
+
+	mounted := map[string]bool{}
+	for _, m := range mounts {
+		mounted[m.Mountpoint] = true
+	}
+	if infos, err := readDir(volsDir); err == nil {
+		for _, fi := range infos {
+			p := filepath.Join(volsDir, fi.Name())
+			if !fi.IsDir() || mounted[p] || expected[p] ||
+				r.isMounting(fi.Name()) {
+				continue
+			}
+			report.Drift = append(report.Drift, &Drift{
+				Type:        DriftStaleMount,
+				Description: "directory is not a mount point for an attached volume",
+				VolumeName:  fi.Name(),
+				MountPoint:  p,
+				Repair:      RepairRemoveDir,
+			})
+		}
+	}
+
+	for name, count := range counts {
+		if count < 1 || attached[name] || r.isMounting(name) {
+			continue
+		}
+		report.Drift = append(report.Drift, &Drift{
+			Type:        DriftUsedCount,
+			Description: "volume is counted as in use but is not attached",
+			VolumeName:  name,
+			UsedCount:   count,
+			Repair:      RepairSetUsedCount,
+		})
+	}
+
+	for _, d := range report.Drift {
+		Logger(ctx).WithFields(log.Fields{
+			"type":       d.Type,
+			"volumeID":   d.VolumeID,
+			"volumeName": d.VolumeName,
+			"deviceName": d.DeviceName,
+			"mountPoint": d.MountPoint,
+			"repair":     d.Repair,
+		}).Warn(d.Description)
+
+		if !opts.Fix {
+			continue
+		}
+
+		if err := r.repair(ctx, inst.InstanceID, d); err != nil {
+			d.Error = err.Error()
+			Logger(ctx).WithFields(log.Fields{
+				"type":   d.Type,
+				"repair": d.Repair,
+				"error":  err,
+			}).Error("error repairing drift")
+			continue
+		}
+		d.Repaired = true
+	}
+
+	return report, nil
+}
+
+func (r *RexRay) repair(ctx context.Context, instanceID string, d *Drift) error {
+	switch d.Repair {
+	case RepairRemount:
+		if err := os.MkdirAll(d.MountPoint, 0755); err != nil {
+			return err
+		}
+		return r.OS.Mount(ctx, d.DeviceName, d.MountPoint, "", "")
+	case RepairDetach:
+		if err := r.Storage.DetachVolume(
+			ctx, false, d.VolumeID, instanceID, false); err != nil {
+			return err
+		}
+		if err := os.Remove(d.MountPoint); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	case RepairUnmount:
+		if err := r.OS.Unmount(ctx, d.MountPoint); err != nil {
+			return err
+		}
+		return os.Remove(d.MountPoint)
+	case RepairRemoveDir:
+		return os.Remove(d.MountPoint)
+	case RepairSetUsedCount:
+		if d.UsedCount > 0 {
+			r.vdm.setUsedCount(ctx, d.VolumeName, 0)
+		} else {
+			r.vdm.setUsedCount(ctx, d.VolumeName, 1)
+		}
+		return nil
+	}
+	return nil
+}
+
+// StartReconciler reconciles the instance at the interval defined by the
+// configuration property rexray.reconcile.interval until the stop channel is
+// closed. The drift that is found is repaired if the property
+// rexray.reconcile.fix is set. The reconciler is not started if the interval
+// is not set.
+func (r *RexRay) StartReconciler(stop <-chan struct{}) {
+
+	interval := getDuration(r.GetConfig(), "rexray.reconcile.interval")
+	if interval <= 0 {
+		return
+	}
+
+	opts := &ReconcileOptions{
+		Fix:             r.GetConfig().GetBool("rexray.reconcile.fix"),
+		CheckUsedCounts: true,
+	}
+
+	log.WithFields(log.Fields{
+		"interval": interval,
+		"fix":      opts.Fix,
+	}).Info("started reconciler")
+
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-t.C:
+			}
+
+			ctx, cancel := NewContext(context.Background(), r.GetConfig(), "")
+			if _, err := r.Reconcile(ctx, opts); err != nil {
+				Logger(ctx).WithField("error", err).Error("error reconciling")
+			}
+			cancel()
+		}
+	}()
+}
+
+// isMounting returns a flag indicating whether or not the volume is being
+// mounted or attached by the volume manager.
+func (r *RexRay) isMounting(volumeName string) bool {
+	return r.vdm != nil && r.vdm.isMounting(volumeName)
+}
+
+// rootMountPoints are the mount points of the file systems whose disks the
+// reconciler never repairs.
+var rootMountPoints = []string{"/", "/boot", "/boot/efi"}
+
+// rootDisks returns the names of the disks of the root file systems.
+func rootDisks(mounts MountInfoArray) map[string]bool {
+	disks := map[string]bool{}
+	for _, m := range mounts {
+		if isLocalDevice(m.Source) &&
+			gotil.StringInSlice(m.Mountpoint, rootMountPoints) {
+			disks[diskName(m.Source)] = true
+		}
+	}
+	return disks
+}
+
+// diskName returns the name of the disk of a device, without its partition,
+// so that the names the provider and the host give a disk compare equal, ex.
+// sda for /dev/sda1 and /dev/xvda1, and nvme0n1 for /dev/nvme0n1p1.
+func diskName(device string) string {
+	n := filepath.Base(device)
+	if strings.HasPrefix(n, "nvme") {
+		if i := strings.LastIndex(n, "p"); i > 0 && i < len(n)-1 &&
+			strings.Trim(n[i+1:], "0123456789") == "" {
+			n = n[:i]
+		}
+		return n
+	}
+	if strings.HasPrefix(n, "xvd") {
+		n = "sd" + n[len("xvd"):]
+	}
+	return strings.TrimRight(n, "0123456789")
+}
+
+func isDir(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.IsDir()
+}
+
+func isInDir(dir, path string) bool {
+	return strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// isLocalDevice returns a flag indicating whether or not the source of a
+// mount is a local device, as opposed to a network share such as NFS.
+func isLocalDevice(source string) bool {
+	return strings.HasPrefix(source, "/")
+}
+
+func readDir(dir string) ([]os.FileInfo, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return f.Readdir(-1)
+}
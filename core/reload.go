@@ -0,0 +1,284 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/akutz/gofig"
+	"github.com/akutz/goof"
+	"github.com/akutz/gotil"
+
+	"github.com/emccode/rexray/core/errors"
+	"github.com/emccode/rexray/core/secrets"
+)
+
+// ReloadReport describes how a configuration reload changed the drivers.
+type ReloadReport struct {
+
+	// Unchanged are the names of the drivers whose configuration did not
+	// change and that continue to be used.
+	Unchanged []string `json:"unchanged,omitempty"`
+
+	// Reinitialized are the names of the drivers whose configuration changed
+	// and that were replaced with newly initialized drivers.
+	Reinitialized []string `json:"reinitialized,omitempty"`
+
+	// Added are the names of the drivers that were enabled by the reload.
+	Added []string `json:"added,omitempty"`
+
+	// Removed are the names of the drivers that were disabled by the reload.
+	Removed []string `json:"removed,omitempty"`
+
+	// Failed are the drivers that could not be initialized. A driver that
+	// fails to be reinitialized continues to be used with its previous
+	// configuration.
+	Failed []*DriverValidation `json:"failed,omitempty"`
+}
+
+// Reload replaces the platform's configuration. The drivers enabled by the
+// new configuration whose properties changed, including the properties that
+// belong to no driver, are initialized again and swapped for the drivers in
+// use. Operations that are in progress when the
+// drivers are swapped finish with the drivers with which they started.
+//
+// The secrets resolved for the previous configuration are no longer redacted
+// once every driver uses the new configuration.
+//
+// If the configuration's secrets cannot be resolved, or if the new
+// configuration does not leave at least one driver of each type, the reload is
+// refused and the platform continues to use its current configuration. When
+// rexray.strict is true the configuration is also validated and a driver that
+// cannot be initialized refuses the reload.
+func (r *RexRay) Reload(config gofig.Config) (*ReloadReport, error) {
+
+	r.reloadLock.Lock()
+	defer r.reloadLock.Unlock()
+
+	if r.odm == nil || r.vdm == nil || r.sdm == nil {
+		return nil, goof.New("drivers not initialized")
+	}
+
+	strict := config.GetBool("rexray.strict")
+
+	resolved, vs, errs := resolveSecrets(config)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	if strict {
+		if errs := ValidateConfig(config); len(errs) > 0 {
+			return nil, errs
+		}
+		for _, dv := range r.unknownDrivers(config) {
+			return nil, goof.WithFields(goof.Fields{
+				"driverName": dv.Name,
+				"driverType": dv.Type,
+			}, dv.Error)
+		}
+	}
+
+	oldConfig := r.resolved
+	if oldConfig == nil {
+		oldConfig = r.GetConfig()
+	}
+
+	current := newDriverMaps()
+	for n, d := range r.odm.getDrivers() {
+		current.add(n, d)
+	}
+	for n, d := range r.vdm.getDrivers() {
+		current.add(n, d)
+	}
+	for n, d := range r.sdm.getDrivers() {
+		current.add(n, d)
+	}
+
+	dm := newDriverMaps()
+	rep := &ReloadReport{}
+
+	for _, dk := range driverKeys {
+		for _, n := range config.GetStringSlice(dk.key) {
+			nd, ok := r.getDriver(n)
+			if !ok || driverType(nd) != dk.typ {
+				continue
+			}
+
+			d, enabled := current.get(n)
+			if enabled && !driverConfigChanged(n, oldConfig, resolved) {
+				dm.add(n, d)
+				rep.Unchanged = append(rep.Unchanged, n)
+				continue
+			}
+
+			// a new driver is initialized so that the driver in use is
+			// unaffected if the initialization fails
+			if driverName, instanceName := ParseDriverName(
+				n); instanceName == "" {
+				ctor, _ := getDriverCtor(driverName)
+				nd = ctor()
+			}
+			g := r.generation(driverConfig(resolved, n))
+			if err := nd.Init(g); err != nil {
+				if strict {
+					return nil, goof.WithFieldE(
+						"driverName", n, "error initializing driver", err)
+				}
+				log.WithFields(log.Fields{
+					"driverName": n,
+					"error":      err}).Warn("error reinitializing driver")
+				rep.Failed = append(rep.Failed, &DriverValidation{
+					Name:  n,
+					Type:  dk.typ,
+					Error: err.Error(),
+				})
+				if enabled {
+					dm.add(n, d)
+				}
+				continue
+			}
+
+			dm.add(n, nd)
+			if enabled {
+				rep.Reinitialized = append(rep.Reinitialized, n)
+			} else {
+				rep.Added = append(rep.Added, n)
+			}
+		}
+	}
+
+	for _, n := range current.names() {
+		if _, ok := dm.get(n); !ok {
+			rep.Removed = append(rep.Removed, n)
+		}
+	}
+
+	switch {
+	case len(dm.os) == 0:
+		return nil, errors.ErrNoOSDrivers
+	case len(dm.volume) == 0:
+		return nil, errors.ErrNoVolumeDrivers
+	case len(dm.storage) == 0:
+		return nil, errors.ErrNoStorageDrivers
+	}
+
+	r.odm.setDrivers(dm.os)
+	r.vdm.setDrivers(dm.volume)
+	r.sdm.setDrivers(dm.storage)
+	r.setConfig(config)
+	r.resolved = resolved
+
+	// the secrets of the previous configuration are forgotten unless a
+	// driver that failed to reinitialize continues to use them
+	if len(rep.Failed) == 0 {
+		secrets.Reset(vs...)
+	}
+
+	log.WithFields(log.Fields{
+		"unchanged":     rep.Unchanged,
+		"reinitialized": rep.Reinitialized,
+		"added":         rep.Added,
+		"removed":       rep.Removed,
+		"failed":        len(rep.Failed),
+	}).Info("reloaded configuration")
+
+	return rep, nil
+}
+
+// driverConfigChanged returns a flag indicating whether or not any of the
+// properties of the schemas that belong to the driver, or of the schemas that
+// belong to no driver, differ between the two configurations. Drivers read the
+// latter, ex. rexray.waiter.timeout, through their own configuration, so a
+// driver that is not reinitialized when one of them changes never sees the
+// change. The lists of enabled drivers are not compared since enabling or
+// disabling a driver does not affect the others.
+func driverConfigChanged(name string, oldConfig, newConfig gofig.Config) bool {
+	oldConfig = driverConfig(oldConfig, name)
+	newConfig = driverConfig(newConfig, name)
+	driverName, _ := ParseDriverName(name)
+	for _, s := range ConfigSchemas() {
+		if len(s.Drivers) > 0 &&
+			!gotil.StringInSlice(driverName, s.Drivers) {
+			continue
+		}
+		for _, k := range s.Keys {
+			if isDriverKey(k.Name) {
+				continue
+			}
+			if fmt.Sprint(oldConfig.Get(k.Name)) !=
+				fmt.Sprint(newConfig.Get(k.Name)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isDriverKey returns a flag indicating whether or not the property is one
+// of the lists of enabled drivers.
+func isDriverKey(key string) bool {
+	for _, dk := range driverKeys {
+		if strings.EqualFold(dk.key, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// driverMaps are the initialized drivers of each type, by name.
+type driverMaps struct {
+	os      map[string]OSDriver
+	volume  map[string]VolumeDriver
+	storage map[string]StorageDriver
+}
+
+func newDriverMaps() *driverMaps {
+	return &driverMaps{
+		os:      map[string]OSDriver{},
+		volume:  map[string]VolumeDriver{},
+		storage: map[string]StorageDriver{},
+	}
+}
+
+func (m *driverMaps) add(name string, d Driver) {
+	switch td := d.(type) {
+	case OSDriver:
+		m.os[name] = td
+	case VolumeDriver:
+		m.volume[name] = td
+	case StorageDriver:
+		m.storage[name] = td
+	}
+}
+
+// get returns the driver with the case-insensitive name.
+func (m *driverMaps) get(name string) (Driver, bool) {
+	for n, d := range m.os {
+		if strings.EqualFold(n, name) {
+			return d, true
+		}
+	}
+	for n, d := range m.volume {
+		if strings.EqualFold(n, name) {
+			return d, true
+		}
+	}
+	if _, d, ok := findStorageDriver(m.storage, name); ok {
+		return d, true
+	}
+	return nil, false
+}
+
+func (m *driverMaps) names() []string {
+	var names []string
+	for n := range m.os {
+		names = append(names, n)
+	}
+	for n := range m.volume {
+		names = append(names, n)
+	}
+	for n := range m.storage {
+		names = append(names, n)
+	}
+	return names
+}
@@ -1,19 +1,30 @@
 package core
 
 import (
+	"sync"
+
 	log "github.com/Sirupsen/logrus"
 
 	"github.com/akutz/gofig"
-	"github.com/akutz/gotil"
+	"github.com/akutz/goof"
 )
 
 // RexRay is the library's entrance type and storage management platform.
 type RexRay struct {
-	Config  gofig.Config
-	OS      OSDriverManager
-	Volume  VolumeDriverManager
-	Storage StorageDriverManager
-	drivers map[string]Driver
+	Config     gofig.Config
+	OS         OSDriverManager
+	Volume     VolumeDriverManager
+	Storage    StorageDriverManager
+	Operations OperationManager
+	drivers    map[string]Driver
+	opm        *opm
+	odm        *odm
+	vdm        *vdm
+	sdm        *sdm
+	configRWL  sync.RWMutex
+	reloadLock sync.Mutex
+	resolved   gofig.Config
+	health     healthCache
 }
 
 // New creates a new REX-Ray instance and configures it with the
@@ -28,6 +39,8 @@ func New(conf gofig.Config) *RexRay {
 		Config:  conf,
 		drivers: map[string]Driver{},
 	}
+	r.opm = newOperationManager(r)
+	r.Operations = r.opm
 
 	for name, ctor := range driverCtors {
 		r.drivers[name] = ctor()
@@ -40,10 +53,6 @@ func New(conf gofig.Config) *RexRay {
 // InitDrivers initializes the drivers for the REX-Ray platform.
 func (r *RexRay) InitDrivers() error {
 
-	od := map[string]OSDriver{}
-	vd := map[string]VolumeDriver{}
-	sd := map[string]StorageDriver{}
-
 	log.Info(r.Config.Get("rexray.osDrivers"))
 	log.Info(r.Config.Get("rexray.volumeDrivers"))
 	log.Info(r.Config.Get("rexray.storageDrivers"))
@@ -58,56 +67,84 @@ func (r *RexRay) InitDrivers() error {
 		"storageDrivers": storDrivers,
 	}).Debug("core get drivers")
 
-	for n, d := range r.drivers {
-		switch td := d.(type) {
-		case OSDriver:
-			if gotil.StringInSlice(n, osDrivers) {
-				if err := d.Init(r); err != nil {
-					log.WithFields(log.Fields{
-						"driverName": n,
-						"error":      err}).Debug("error initializing driver")
-					continue
-				}
-				od[n] = td
-			}
-		case VolumeDriver:
-			if gotil.StringInSlice(n, volDrivers) {
-				if err := d.Init(r); err != nil {
-					log.WithFields(log.Fields{
-						"driverName": n,
-						"error":      err}).Debug("error initializing driver")
-					continue
-				}
-				vd[n] = td
-			}
-		case StorageDriver:
-			if gotil.StringInSlice(n, storDrivers) {
-				if err := d.Init(r); err != nil {
-					log.WithFields(log.Fields{
-						"driverName": n,
-						"error":      err}).Debug("error initializing driver")
-					continue
-				}
-				sd[n] = td
-			}
+	strict := r.Config.GetBool("rexray.strict")
+
+	resolved, _, errs := resolveSecrets(r.Config)
+	if len(errs) > 0 {
+		if strict || resolved == nil {
+			return errs
+		}
+		for _, e := range errs {
+			log.WithFields(log.Fields{
+				"keys":  e.Keys,
+				"error": e.Message}).Warn("error resolving secret")
 		}
 	}
 
-	r.OS = &odm{
-		rexray:  r,
-		drivers: od,
+	if strict {
+		if errs := ValidateConfig(r.Config); len(errs) > 0 {
+			return errs
+		}
+		for _, dv := range r.unknownDrivers(r.Config) {
+			return goof.WithFields(goof.Fields{
+				"driverName": dv.Name,
+				"driverType": dv.Type,
+			}, dv.Error)
+		}
 	}
 
-	r.Volume = &vdm{
-		rexray:  r,
-		drivers: vd,
+	enabled := map[string][]string{
+		driverTypeOS:      osDrivers,
+		driverTypeVolume:  volDrivers,
+		driverTypeStorage: storDrivers,
 	}
 
-	r.Storage = &sdm{
-		rexray:  r,
-		drivers: sd,
+	r.odm = &odm{rexray: r}
+	r.OS = instrumentOSDriverManager(
+		auditOSDriverManager(r, r.odm, r.odm.reportedName),
+		r.odm.reportedName)
+
+	r.vdm = &vdm{rexray: r}
+	r.Volume = instrumentVolumeDriverManager(r.vdm, r.vdm.reportedName)
+
+	r.sdm = &sdm{rexray: r}
+	r.Storage = instrumentStorageDriverManager(
+		auditStorageDriverManager(r, r.sdm, r.sdm.reportedName),
+		r.sdm.reportedName)
+
+	// the drivers are initialized with copies of the platform so that a
+	// reload can replace the platform's configuration without changing the
+	// configuration seen by the drivers it does not reinitialize, so that
+	// each instance of a driver sees its own configuration, and so that only
+	// the drivers see the resolved secrets
+	r.resolved = resolved
+	dm := newDriverMaps()
+
+	for _, dk := range driverKeys {
+		for _, n := range enabled[dk.typ] {
+			d, ok := r.getDriver(n)
+			if !ok || driverType(d) != dk.typ {
+				continue
+			}
+			g := r.generation(driverConfig(resolved, n))
+			if err := d.Init(g); err != nil {
+				if strict {
+					return goof.WithFieldE(
+						"driverName", n, "error initializing driver", err)
+				}
+				log.WithFields(log.Fields{
+					"driverName": n,
+					"error":      err}).Warn("error initializing driver")
+				continue
+			}
+			dm.add(n, d)
+		}
 	}
 
+	r.odm.setDrivers(dm.os)
+	r.vdm.setDrivers(dm.volume)
+	r.sdm.setDrivers(dm.storage)
+
 	if err := r.OS.Init(r); err != nil {
 		return err
 	}
@@ -123,6 +160,37 @@ func (r *RexRay) InitDrivers() error {
 	return nil
 }
 
+// GetConfig returns the platform's configuration. Unlike the Config field,
+// GetConfig is safe to call while the configuration is being reloaded.
+func (r *RexRay) GetConfig() gofig.Config {
+	r.configRWL.RLock()
+	defer r.configRWL.RUnlock()
+	return r.Config
+}
+
+func (r *RexRay) setConfig(config gofig.Config) {
+	r.configRWL.Lock()
+	defer r.configRWL.Unlock()
+	r.Config = config
+}
+
+// generation returns a copy of the platform with the configuration that is
+// given to the drivers when they are initialized.
+func (r *RexRay) generation(config gofig.Config) *RexRay {
+	return &RexRay{
+		Config:     config,
+		OS:         r.OS,
+		Volume:     r.Volume,
+		Storage:    r.Storage,
+		Operations: r.Operations,
+		drivers:    r.drivers,
+		opm:        r.opm,
+		odm:        r.odm,
+		vdm:        r.vdm,
+		sdm:        r.sdm,
+	}
+}
+
 // DriverNames returns a list of the registered driver names.
 func (r *RexRay) DriverNames() <-chan string {
 	c := make(chan string)
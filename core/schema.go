@@ -0,0 +1,318 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/akutz/gofig"
+	"github.com/akutz/goof"
+	"github.com/akutz/gotil"
+)
+
+var (
+	configSchemas    []*ConfigSchema
+	configSchemasRWL sync.RWMutex
+)
+
+// ConfigSchema describes the configuration properties of a component. A
+// schema wraps the gofig registration that declares the properties, and adds
+// the constraints that are checked by ValidateConfig.
+type ConfigSchema struct {
+
+	// Name is the name of the component.
+	Name string
+
+	// Drivers are the names of the drivers to which the schema belongs. The
+	// schema is only validated if one of the drivers is enabled, or if there
+	// are no drivers.
+	Drivers []string
+
+	// Keys are the schema's properties.
+	Keys []*ConfigKey
+
+	// Required are the sets of properties of which at least one must be set.
+	Required [][]string
+
+	// Exclusive are the sets of properties of which at most one may be set.
+	Exclusive [][]string
+
+	// Secrets are the properties whose values are redacted when the
+	// configuration is printed.
+	Secrets []string
+
+	reg *gofig.Registration
+}
+
+// ConfigKey describes a configuration property.
+type ConfigKey struct {
+
+	// Name is the name of the property.
+	Name string
+
+	// Type is the type of the property's value.
+	Type gofig.KeyType
+
+	// Default is the property's default value.
+	Default interface{}
+}
+
+// ConfigError describes a configuration property that violates its schema.
+type ConfigError struct {
+
+	// Schema is the name of the schema that was violated.
+	Schema string `json:"schema"`
+
+	// Keys are the names of the properties that violate the schema.
+	Keys []string `json:"keys"`
+
+	// Message describes the violation.
+	Message string `json:"message"`
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("%s: %s: %s",
+		e.Schema, strings.Join(e.Keys, ", "), e.Message)
+}
+
+// ConfigErrors is a list of configuration errors.
+type ConfigErrors []*ConfigError
+
+func (e ConfigErrors) Error() string {
+	var b bytes.Buffer
+	b.WriteString("invalid configuration")
+	for _, ce := range e {
+		b.WriteString("; ")
+		b.WriteString(ce.Error())
+	}
+	return b.String()
+}
+
+// NewConfigSchema returns a new schema for the named component and the drivers
+// to which it belongs.
+func NewConfigSchema(name string, drivers ...string) *ConfigSchema {
+	return &ConfigSchema{
+		Name:    name,
+		Drivers: drivers,
+		reg:     gofig.NewRegistration(name),
+	}
+}
+
+// Yaml sets the YAML example of the schema's gofig registration.
+func (s *ConfigSchema) Yaml(y string) {
+	s.reg.Yaml(y)
+}
+
+// Key declares a property with the gofig registration and adds it to the
+// schema. The first of the keys is the name of the property.
+func (s *ConfigSchema) Key(
+	keyType gofig.KeyType,
+	short string,
+	defVal interface{},
+	description string,
+	keys ...string) {
+
+	s.reg.Key(keyType, short, defVal, description, keys...)
+	s.Keys = append(s.Keys,
+		&ConfigKey{Name: keys[0], Type: keyType, Default: defVal})
+}
+
+// Require requires at least one of the properties to be set.
+func (s *ConfigSchema) Require(keys ...string) {
+	s.Required = append(s.Required, keys)
+}
+
+// Exclude permits at most one of the properties to be set.
+func (s *ConfigSchema) Exclude(keys ...string) {
+	s.Exclusive = append(s.Exclusive, keys)
+}
+
+// Secret marks the properties as secrets.
+func (s *ConfigSchema) Secret(keys ...string) {
+	s.Secrets = append(s.Secrets, keys...)
+}
+
+// Registration returns the schema's gofig registration.
+func (s *ConfigSchema) Registration() *gofig.Registration {
+	return s.reg
+}
+
+// RegisterConfigSchema registers the schema and its gofig registration.
+func RegisterConfigSchema(s *ConfigSchema) {
+	configSchemasRWL.Lock()
+	defer configSchemasRWL.Unlock()
+	configSchemas = append(configSchemas, s)
+	gofig.Register(s.reg)
+}
+
+// ConfigSchemas returns the registered schemas.
+func ConfigSchemas() []*ConfigSchema {
+	configSchemasRWL.RLock()
+	defer configSchemasRWL.RUnlock()
+	return append([]*ConfigSchema{}, configSchemas...)
+}
+
+// ValidateConfig checks the configuration against the registered schemas of
+// the enabled drivers and of the components that do not belong to a driver.
+// The schemas of a driver are checked once for each enabled instance of the
+// driver, against the instance's configuration. A nil value is returned if
+// the configuration is valid.
+func ValidateConfig(config gofig.Config) ConfigErrors {
+	var errs ConfigErrors
+
+	enabled := enabledDriverNames(config)
+
+	for _, s := range ConfigSchemas() {
+		if s.enabled(enabled) {
+			errs = append(errs, s.validate(config)...)
+		}
+
+		for _, n := range enabled {
+			driverName, instanceName := ParseDriverName(n)
+			if instanceName == "" ||
+				!gotil.StringInSlice(driverName, s.Drivers) {
+				continue
+			}
+			for _, e := range s.validate(driverConfig(config, n)) {
+				e.Schema = fmt.Sprintf("%s (%s)", e.Schema, n)
+				errs = append(errs, e)
+			}
+		}
+	}
+
+	return errs
+}
+
+func (s *ConfigSchema) enabled(enabled []string) bool {
+	if len(s.Drivers) == 0 {
+		return true
+	}
+	for _, d := range s.Drivers {
+		if gotil.StringInSlice(d, enabled) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ConfigSchema) validate(config gofig.Config) ConfigErrors {
+	var errs ConfigErrors
+
+	newErr := func(msg string, keys ...string) {
+		errs = append(errs, &ConfigError{
+			Schema:  s.Name,
+			Keys:    keys,
+			Message: msg,
+		})
+	}
+
+	for _, k := range s.Keys {
+		if err := checkConfigType(k.Type, config.Get(k.Name)); err != nil {
+			newErr(err.Error(), k.Name)
+		}
+	}
+
+	for _, keys := range s.Required {
+		if s.countSetKeys(config, keys) > 0 {
+			continue
+		}
+		if len(keys) == 1 {
+			newErr("required", keys...)
+		} else {
+			newErr("one is required", keys...)
+		}
+	}
+
+	for _, keys := range s.Exclusive {
+		if s.countSetKeys(config, keys) > 1 {
+			newErr("only one may be set", keys...)
+		}
+	}
+
+	return errs
+}
+
+// checkConfigType returns an error if the value cannot be read as the type.
+func checkConfigType(keyType gofig.KeyType, v interface{}) error {
+	if v == nil {
+		return nil
+	}
+
+	switch keyType {
+	case gofig.Int:
+		switch tv := v.(type) {
+		case int, int8, int16, int32, int64,
+			uint, uint8, uint16, uint32, uint64:
+			return nil
+		case float32:
+			if float32(int64(tv)) == tv {
+				return nil
+			}
+		case float64:
+			if float64(int64(tv)) == tv {
+				return nil
+			}
+		case string:
+			if _, err := strconv.Atoi(tv); err == nil || tv == "" {
+				return nil
+			}
+		}
+		return goof.Newf("invalid integer %v", v)
+	case gofig.Bool:
+		switch tv := v.(type) {
+		case bool:
+			return nil
+		case string:
+			if _, err := strconv.ParseBool(tv); err == nil || tv == "" {
+				return nil
+			}
+		}
+		return goof.Newf("invalid boolean %v", v)
+	}
+
+	return nil
+}
+
+// countSetKeys returns the number of the properties that are set. A property
+// whose value is its registered default is not set.
+func (s *ConfigSchema) countSetKeys(config gofig.Config, keys []string) int {
+	defaults := map[string]string{}
+	for _, k := range s.Keys {
+		if k.Default != nil {
+			defaults[strings.ToLower(k.Name)] = fmt.Sprint(k.Default)
+		}
+	}
+
+	n := 0
+	for _, k := range keys {
+		v := config.GetString(k)
+		if v == "" {
+			continue
+		}
+		if d, ok := defaults[strings.ToLower(k)]; ok && v == d {
+			continue
+		}
+		n++
+	}
+	return n
+}
+
+// enabledDriverNames returns the names of the enabled OS, volume, and storage
+// drivers.
+func enabledDriverNames(config gofig.Config) []string {
+	var names []string
+	for _, dk := range driverKeys {
+		names = append(names, config.GetStringSlice(dk.key)...)
+	}
+	return names
+}
+
+// driverKeys are the properties that enable the OS, volume, and storage
+// drivers, in that order.
+var driverKeys = []struct{ typ, key string }{
+	{driverTypeOS, "rexray.osDrivers"},
+	{driverTypeVolume, "rexray.volumeDrivers"},
+	{driverTypeStorage, "rexray.storageDrivers"},
+}
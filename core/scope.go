@@ -0,0 +1,131 @@
+package core
+
+import (
+	"strings"
+
+	"github.com/akutz/gofig"
+)
+
+// ScopeConfig returns a view of the configuration in which each property is
+// read from the subtree named by the scope, ex. the property scaleio.endpoint
+// is read from prod.scaleio.endpoint for the scope prod. A property that is
+// not set in the subtree is read from the root of the configuration.
+// Properties that are set with the view are set in the subtree. The view's
+// keys and environment variables are those of the root with the subtree's
+// properties in place of the root's; the other methods, such as AllSettings
+// and ToJSON, are not scoped.
+func ScopeConfig(config gofig.Config, scope string) gofig.Config {
+	return &scopedConfig{Config: config, scope: scope}
+}
+
+type scopedConfig struct {
+	gofig.Config
+	scope string
+}
+
+func (c *scopedConfig) key(k string) string {
+	if sk := c.scope + "." + k; c.Config.IsSet(sk) {
+		return sk
+	}
+	return k
+}
+
+func (c *scopedConfig) Copy() (gofig.Config, error) {
+	config, err := c.Config.Copy()
+	if err != nil {
+		return nil, err
+	}
+	return ScopeConfig(config, c.scope), nil
+}
+
+func (c *scopedConfig) Get(k string) interface{} {
+	return c.Config.Get(c.key(k))
+}
+
+func (c *scopedConfig) GetString(k string) string {
+	return c.Config.GetString(c.key(k))
+}
+
+func (c *scopedConfig) GetBool(k string) bool {
+	return c.Config.GetBool(c.key(k))
+}
+
+func (c *scopedConfig) GetStringSlice(k string) []string {
+	return c.Config.GetStringSlice(c.key(k))
+}
+
+func (c *scopedConfig) GetInt(k string) int {
+	return c.Config.GetInt(c.key(k))
+}
+
+func (c *scopedConfig) IsSet(k string) bool {
+	return c.Config.IsSet(c.scope+"."+k) || c.Config.IsSet(k)
+}
+
+func (c *scopedConfig) Set(k string, v interface{}) {
+	c.Config.Set(c.scope+"."+k, v)
+}
+
+// subtree returns the properties set in the scope's subtree, keyed by their
+// names relative to the subtree.
+func (c *scopedConfig) subtree() map[string]string {
+	prefix := strings.ToLower(c.scope) + "."
+	keys := map[string]string{}
+	for _, k := range c.Config.AllKeys() {
+		if strings.HasPrefix(strings.ToLower(k), prefix) {
+			keys[k[len(prefix):]] = k
+		}
+	}
+	return keys
+}
+
+func (c *scopedConfig) AllKeys() []string {
+	sub := c.subtree()
+	prefix := strings.ToLower(c.scope) + "."
+	var keys []string
+	for _, k := range c.Config.AllKeys() {
+		if strings.HasPrefix(strings.ToLower(k), prefix) {
+			continue
+		}
+		if _, ok := sub[k]; ok {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	for k := range sub {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func (c *scopedConfig) EnvVars() []string {
+	vals := map[string]string{}
+	for k, sk := range c.subtree() {
+		vals[envVarName(k)] = c.Config.GetString(sk)
+	}
+
+	prefix := strings.ToUpper(c.scope) + "_"
+	var evs []string
+	for _, ev := range c.Config.EnvVars() {
+		parts := strings.SplitN(ev, "=", 2)
+		name := strings.ToUpper(parts[0])
+		if strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if v, ok := vals[name]; ok {
+			evs = append(evs, parts[0]+"="+v)
+			delete(vals, name)
+			continue
+		}
+		evs = append(evs, ev)
+	}
+	for name, v := range vals {
+		evs = append(evs, name+"="+v)
+	}
+	return evs
+}
+
+// envVarName returns the name of the environment variable of the property.
+func envVarName(key string) string {
+	return strings.ToUpper(strings.Replace(key, ".", "_", -1))
+}
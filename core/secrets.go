@@ -0,0 +1,196 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/akutz/gofig"
+
+	"github.com/emccode/rexray/core/secrets"
+)
+
+// vaultKeys are the properties that configure the Vault secrets provider.
+// They are resolved before the other properties so that the Vault token may
+// itself be a reference to a file or environment variable.
+var vaultKeys = []string{
+	"rexray.secrets.vault.address",
+	"rexray.secrets.vault.token",
+}
+
+func init() {
+	log.AddHook(&redactHook{})
+}
+
+// resolveSecrets returns a copy of the configuration in which each value
+// that is a secret reference, ex. secret://file/etc/rexray/password, is
+// replaced with the secret to which it refers, along with the resolved
+// secrets. The configuration itself is not modified, so that the secrets are
+// only seen by the drivers initialized with the copy.
+func resolveSecrets(config gofig.Config) (gofig.Config, []string, ConfigErrors) {
+	c, err := config.Copy()
+	if err != nil {
+		return nil, nil, ConfigErrors{&ConfigError{
+			Schema:  "Secrets",
+			Message: err.Error(),
+		}}
+	}
+
+	providers := map[string]secrets.Provider{
+		"file": secrets.File,
+		"env":  secrets.Env,
+	}
+
+	vs, errs := resolveSecretKeys(c, providers, vaultKeys)
+
+	address := c.GetString("rexray.secrets.vault.address")
+	if address == "" {
+		address = os.Getenv("VAULT_ADDR")
+	}
+	token := c.GetString("rexray.secrets.vault.token")
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+	if token != "" {
+		secrets.Remember(token)
+		vs = append(vs, token)
+	}
+	providers["vault"] = secrets.NewVault(address, token,
+		getDuration(c, "rexray.secrets.vault.timeout"))
+
+	kvs, kerrs := resolveSecretKeys(c, providers, c.AllKeys())
+	return c, append(vs, kvs...), append(errs, kerrs...)
+}
+
+func resolveSecretKeys(
+	config gofig.Config,
+	providers map[string]secrets.Provider,
+	keys []string) ([]string, ConfigErrors) {
+
+	var (
+		vs   []string
+		errs ConfigErrors
+	)
+	for _, k := range keys {
+		v := config.GetString(k)
+		if !secrets.IsRef(v) {
+			continue
+		}
+
+		s, err := secrets.Resolve(providers, v)
+		if err != nil {
+			errs = append(errs, &ConfigError{
+				Schema:  "Secrets",
+				Keys:    []string{k},
+				Message: err.Error(),
+			})
+			continue
+		}
+
+		config.Set(k, s)
+		vs = append(vs, s)
+
+		log.WithField("key", k).Debug("resolved secret")
+	}
+	return vs, errs
+}
+
+// isSecretKey returns a flag indicating whether or not the property is
+// declared as a secret by its schema.
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range ConfigSchemas() {
+		for _, sk := range s.Secrets {
+			if strings.ToLower(sk) == key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isRedacted returns a flag indicating whether or not the property's value
+// should be redacted. Secret references are not redacted.
+func isRedacted(key, value string) bool {
+	if value == "" || secrets.IsRef(value) {
+		return false
+	}
+	return isSecretKey(key) || secrets.IsSecret(value)
+}
+
+// RedactConfig returns a copy of the configuration whose secrets are
+// replaced with a redacted value.
+func RedactConfig(config gofig.Config) (gofig.Config, error) {
+	c, err := config.Copy()
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range c.AllKeys() {
+		if isRedacted(k, c.GetString(k)) {
+			c.Set(k, secrets.Redacted)
+		}
+	}
+	return c, nil
+}
+
+// RedactEnvVars returns the configuration's environment variables with the
+// values of its secrets replaced with a redacted value.
+func RedactEnvVars(config gofig.Config) []string {
+	redacted := map[string]bool{}
+	for _, k := range config.AllKeys() {
+		if isRedacted(k, config.GetString(k)) {
+			redacted[envVarName(k)] = true
+		}
+	}
+
+	evs := config.EnvVars()
+	for i, ev := range evs {
+		parts := strings.SplitN(ev, "=", 2)
+		if len(parts) == 2 && redacted[strings.ToUpper(parts[0])] {
+			evs[i] = parts[0] + "=" + secrets.Redacted
+		}
+	}
+	return evs
+}
+
+// redactHook is a log hook that redacts the resolved secrets from log
+// messages and fields.
+type redactHook struct{}
+
+func (h *redactHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (h *redactHook) Fire(entry *log.Entry) error {
+	entry.Message = secrets.Redact(entry.Message)
+
+	// the entry's fields may be shared with other entries
+	var data log.Fields
+	for k, v := range entry.Data {
+		var s string
+		switch tv := v.(type) {
+		case string:
+			s = tv
+		case error:
+			s = tv.Error()
+		case fmt.Stringer:
+			s = tv.String()
+		default:
+			continue
+		}
+		if rs := secrets.Redact(s); rs != s {
+			if data == nil {
+				data = log.Fields{}
+				for dk, dv := range entry.Data {
+					data[dk] = dv
+				}
+			}
+			data[k] = rs
+		}
+	}
+	if data != nil {
+		entry.Data = data
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package secrets
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/akutz/goof"
+)
+
+const (
+	// Prefix is the prefix of a secret reference. A reference has the form
+	// secret://<provider>/<path>.
+	Prefix = "secret://"
+
+	// Redacted is the value that replaces a redacted secret.
+	Redacted = "******"
+
+	// MinRedactLength is the length of the shortest secret that Redact
+	// replaces. Shorter secrets are too likely to occur in unrelated text.
+	MinRedactLength = 6
+)
+
+var (
+	values    = map[string]bool{}
+	redacted  []string
+	valuesRWL sync.RWMutex
+)
+
+// Provider is the interface implemented by types that look up secrets.
+type Provider interface {
+
+	// Secret returns the secret at the path.
+	Secret(path string) (string, error)
+}
+
+// ProviderFunc is a function that implements the Provider interface.
+type ProviderFunc func(path string) (string, error)
+
+// Secret returns the secret at the path.
+func (f ProviderFunc) Secret(path string) (string, error) {
+	return f(path)
+}
+
+// File is the provider that reads the secret from the file at the path, which
+// is relative to the root directory. Trailing newlines are removed from the
+// secret.
+var File = ProviderFunc(func(path string) (string, error) {
+	buf, err := ioutil.ReadFile(filepath.Join("/", path))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(buf), "\r\n"), nil
+})
+
+// Env is the provider that reads the secret from the environment variable
+// named by the path.
+var Env = ProviderFunc(func(path string) (string, error) {
+	v, ok := os.LookupEnv(path)
+	if !ok {
+		return "", goof.WithField("name", path, "environment variable not set")
+	}
+	return v, nil
+})
+
+// IsRef returns a flag indicating whether or not the value is a secret
+// reference.
+func IsRef(v string) bool {
+	return strings.HasPrefix(v, Prefix)
+}
+
+// Resolve returns the secret to which the reference refers using the provider
+// with the reference's provider name. The secret is remembered so that it may
+// be redacted by Redact.
+func Resolve(providers map[string]Provider, ref string) (string, error) {
+	if !IsRef(ref) {
+		return "", goof.New("invalid secret reference")
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(ref, Prefix), "/", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return "", goof.WithField("ref", ref, "invalid secret reference")
+	}
+
+	p, ok := providers[parts[0]]
+	if !ok {
+		return "", goof.WithField("provider", parts[0], "unknown secret provider")
+	}
+
+	v, err := p.Secret(parts[1])
+	if err != nil {
+		return "", goof.WithFieldE("ref", ref, "error resolving secret", err)
+	}
+
+	Remember(v)
+	return v, nil
+}
+
+// Remember remembers the secret so that it may be redacted by Redact.
+func Remember(v string) {
+	if v == "" {
+		return
+	}
+	valuesRWL.Lock()
+	defer valuesRWL.Unlock()
+	if !values[v] {
+		values[v] = true
+		sortRedacted()
+	}
+}
+
+// Reset forgets the remembered secrets and remembers the provided secrets
+// instead.
+func Reset(vs ...string) {
+	valuesRWL.Lock()
+	defer valuesRWL.Unlock()
+	values = map[string]bool{}
+	for _, v := range vs {
+		if v != "" {
+			values[v] = true
+		}
+	}
+	sortRedacted()
+}
+
+// sortRedacted lists the remembered secrets that Redact replaces, longest
+// first, so that a secret that contains another secret is replaced whole.
+func sortRedacted() {
+	redacted = nil
+	for v := range values {
+		if len(v) >= MinRedactLength {
+			redacted = append(redacted, v)
+		}
+	}
+	sort.Sort(byLength(redacted))
+}
+
+type byLength []string
+
+func (b byLength) Len() int      { return len(b) }
+func (b byLength) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byLength) Less(i, j int) bool {
+	if len(b[i]) != len(b[j]) {
+		return len(b[i]) > len(b[j])
+	}
+	return b[i] < b[j]
+}
+
+// Redact replaces each of the remembered secrets in the string with Redacted.
+// Secrets shorter than MinRedactLength are not replaced.
+func Redact(s string) string {
+	valuesRWL.RLock()
+	defer valuesRWL.RUnlock()
+	for _, v := range redacted {
+		s = strings.Replace(s, v, Redacted, -1)
+	}
+	return s
+}
+
+// IsSecret returns a flag indicating whether or not the value is a
+// remembered secret.
+func IsSecret(v string) bool {
+	valuesRWL.RLock()
+	defer valuesRWL.RUnlock()
+	return values[v]
+}
@@ -0,0 +1,96 @@
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/akutz/goof"
+)
+
+// Vault is the provider that reads secrets from HashiCorp Vault's HTTP API.
+// The path of a Vault secret has the form <path>#<key>, where the path is the
+// path of the secret in Vault, ex. secret/rexray, and the key is the name of
+// the secret's field. The fields of both version 1 and version 2 key/value
+// secrets engines are supported.
+type Vault struct {
+
+	// Address is the address of the Vault server, ex. https://vault:8200.
+	Address string
+
+	// Token is the token used to authenticate with Vault.
+	Token string
+
+	// Client is the HTTP client used to read secrets.
+	Client *http.Client
+}
+
+// NewVault returns a new Vault provider.
+func NewVault(address, token string, timeout time.Duration) *Vault {
+	return &Vault{
+		Address: strings.TrimRight(address, "/"),
+		Token:   token,
+		Client:  &http.Client{Timeout: timeout},
+	}
+}
+
+// Secret returns the value of the key of the Vault secret at the path.
+func (v *Vault) Secret(path string) (string, error) {
+	parts := strings.SplitN(path, "#", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return "", goof.WithField("path", path, "missing vault secret key")
+	}
+	path, key := strings.Trim(parts[0], "/"), parts[1]
+
+	if v.Address == "" {
+		return "", goof.New("vault address not set")
+	}
+
+	req, err := http.NewRequest("GET", v.Address+"/v1/"+path, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", v.Token)
+
+	res, err := v.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", goof.WithFields(goof.Fields{
+			"path":   path,
+			"status": res.StatusCode,
+		}, "error reading vault secret")
+	}
+
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&secret); err != nil {
+		return "", goof.WithFieldE("path", path, "invalid vault secret", err)
+	}
+
+	data := secret.Data
+	if _, ok := data[key]; !ok {
+		// version 2 key/value secrets nest the secret's fields
+		if nested, ok := data["data"].(map[string]interface{}); ok {
+			data = nested
+		}
+	}
+
+	val, ok := data[key]
+	if !ok || val == nil {
+		return "", goof.WithFields(goof.Fields{
+			"path": path,
+			"key":  key,
+		}, "vault secret key not found")
+	}
+	if s, ok := val.(string); ok {
+		return s, nil
+	}
+	return fmt.Sprintf("%v", val), nil
+}
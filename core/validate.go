@@ -0,0 +1,120 @@
+package core
+
+import "github.com/akutz/gofig"
+
+const (
+	driverTypeOS      = "os"
+	driverTypeVolume  = "volume"
+	driverTypeStorage = "storage"
+)
+
+// DriverValidation is the result of initializing an enabled driver.
+type DriverValidation struct {
+
+	// Name is the name of the driver.
+	Name string `json:"name"`
+
+	// Type is the type of the driver; os, volume, or storage.
+	Type string `json:"type"`
+
+	// Passed indicates whether or not the driver was initialized.
+	Passed bool `json:"passed"`
+
+	// Error is the error that prevented the driver from being initialized.
+	Error string `json:"error,omitempty"`
+}
+
+// Validation is the result of validating the configuration and the drivers
+// it enables.
+type Validation struct {
+
+	// Config are the configuration properties that violate their schemas.
+	Config ConfigErrors `json:"config"`
+
+	// Drivers are the results of initializing the enabled drivers.
+	Drivers []*DriverValidation `json:"drivers"`
+}
+
+// Valid returns a flag indicating whether or not the configuration is valid
+// and all of the enabled drivers were initialized.
+func (v *Validation) Valid() bool {
+	if len(v.Config) > 0 {
+		return false
+	}
+	for _, dv := range v.Drivers {
+		if !dv.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate resolves the configuration's secrets, checks the configuration
+// against the registered schemas, and tries to initialize each of the enabled
+// drivers. Unlike InitDrivers, Validate
+// does not stop at the first failure, and it does not make the drivers
+// available to the driver managers.
+func (r *RexRay) Validate() *Validation {
+	resolved, _, errs := resolveSecrets(r.Config)
+	v := &Validation{
+		Config:  errs,
+		Drivers: r.unknownDrivers(r.Config),
+	}
+	v.Config = append(v.Config, ValidateConfig(r.Config)...)
+	if resolved == nil {
+		return v
+	}
+
+	for _, dk := range driverKeys {
+		for _, n := range r.Config.GetStringSlice(dk.key) {
+			d, ok := r.getDriver(n)
+			if !ok || driverType(d) != dk.typ {
+				continue
+			}
+			dv := &DriverValidation{Name: n, Type: dk.typ, Passed: true}
+			g := r.generation(driverConfig(resolved, n))
+			if err := d.Init(g); err != nil {
+				dv.Passed = false
+				dv.Error = err.Error()
+			}
+			v.Drivers = append(v.Drivers, dv)
+		}
+	}
+
+	return v
+}
+
+// unknownDrivers returns a failed validation for each driver enabled by the
+// configuration that is not registered as a driver of its type.
+func (r *RexRay) unknownDrivers(config gofig.Config) []*DriverValidation {
+	var dvs []*DriverValidation
+	for _, dk := range driverKeys {
+		for _, n := range config.GetStringSlice(dk.key) {
+			if n == "" {
+				continue
+			}
+			if d, ok := r.getDriver(n); ok && driverType(d) == dk.typ {
+				continue
+			}
+			dvs = append(dvs, &DriverValidation{
+				Name:  n,
+				Type:  dk.typ,
+				Error: "unknown " + dk.typ + " driver",
+			})
+		}
+	}
+	return dvs
+}
+
+// driverType returns the type of the driver; os, volume, or storage.
+func driverType(d Driver) string {
+	switch d.(type) {
+	case OSDriver:
+		return driverTypeOS
+	case VolumeDriver:
+		return driverTypeVolume
+	case StorageDriver:
+		return driverTypeStorage
+	}
+	return ""
+}
@@ -0,0 +1,269 @@
+// Package waiter provides a common means for storage drivers to poll a
+// provider until an operation completes, with a bounded amount of time,
+// exponential backoff with jitter, and cancellation.
+package waiter
+
+import (
+	"math/rand"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/akutz/gofig"
+	"golang.org/x/net/context"
+
+	"github.com/emccode/rexray/core"
+	"github.com/emccode/rexray/core/errors"
+)
+
+const (
+	// OpAttach is the name of the operation that waits for a volume to
+	// attach.
+	OpAttach = "attach"
+
+	// OpDetach is the name of the operation that waits for a volume to
+	// detach.
+	OpDetach = "detach"
+
+	// OpCreateVolume is the name of the operation that waits for a volume to
+	// be created.
+	OpCreateVolume = "createVolume"
+
+	// OpCreateSnapshot is the name of the operation that waits for a snapshot
+	// to be created.
+	OpCreateSnapshot = "createSnapshot"
+
+	// OpMount is the name of the operation that waits for a volume's device
+	// to appear on the local host.
+	OpMount = "mount"
+
+	// OpJob is the name of the operation that waits for a provider's job or
+	// long-running operation to complete.
+	OpJob = "job"
+)
+
+const (
+	defaultTimeout     = 5 * time.Minute
+	defaultInterval    = time.Second
+	defaultMaxInterval = 30 * time.Second
+	defaultMultiplier  = 2.0
+	defaultJitter      = 0.2
+)
+
+func init() {
+	core.RegisterConfigSchema(configRegistration())
+}
+
+// Options are the options that govern how a wait is performed.
+type Options struct {
+
+	// Op is the name of the operation, used in log and error messages.
+	Op string
+
+	// Timeout is the maximum amount of time to wait. A value of zero waits
+	// until the context is canceled.
+	Timeout time.Duration
+
+	// Interval is the amount of time to wait before the first retry.
+	Interval time.Duration
+
+	// MaxInterval is the upper bound of the interval between retries.
+	MaxInterval time.Duration
+
+	// Multiplier is the factor by which the interval grows after each retry.
+	Multiplier float64
+
+	// Jitter is the fraction, between 0 and 1, by which each interval is
+	// randomly adjusted in order to avoid synchronized retries.
+	Jitter float64
+}
+
+// Func is a function polled by Until. It returns true when the operation is
+// complete. A non-nil error aborts the wait.
+type Func func() (bool, error)
+
+// poll is the result of a call to a Func.
+type poll struct {
+	done bool
+	err  error
+}
+
+// NewOptions returns the options for the provided operation as defined by
+// the configuration. The timeout for an operation may be specified with
+// the key rexray.waiter.timeouts.<op>, and falls back to
+// rexray.waiter.timeout.
+func NewOptions(config gofig.Config, op string) *Options {
+	o := &Options{
+		Op:          op,
+		Timeout:     defaultTimeout,
+		Interval:    defaultInterval,
+		MaxInterval: defaultMaxInterval,
+		Multiplier:  defaultMultiplier,
+		Jitter:      defaultJitter,
+	}
+
+	if config == nil {
+		return o
+	}
+
+	o.Timeout = getDuration(config, "rexray.waiter.timeout", o.Timeout)
+	o.Timeout = getDuration(config, "rexray.waiter.timeouts."+op, o.Timeout)
+	o.Interval = getDuration(config, "rexray.waiter.interval", o.Interval)
+	o.MaxInterval = getDuration(
+		config, "rexray.waiter.maxInterval", o.MaxInterval)
+
+	return o
+}
+
+// Until calls f until it returns true, returns an error, the options'
+// timeout elapses, or the context is canceled. A *errors.TimeoutError is
+// returned when the timeout elapses and a *errors.CanceledError is returned
+// when the context is canceled.
+//
+// The timeout and the context also bound each call to f, which may block on
+// a provider that does not respond. A call that is still running when Until
+// returns is abandoned, and its result is discarded.
+func Until(ctx context.Context, opts *Options, f Func) error {
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if opts == nil {
+		opts = NewOptions(nil, "")
+	}
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	for attempt := 1; ; attempt++ {
+
+		if err := ctx.Err(); err != nil {
+			return waitErr(ctx, opts)
+		}
+
+		polled := make(chan poll, 1)
+		go func() {
+			done, err := f()
+			polled <- poll{done, err}
+		}()
+
+		var p poll
+		select {
+		case <-ctx.Done():
+			return waitErr(ctx, opts)
+		case p = <-polled:
+		}
+		if p.err != nil {
+			return p.err
+		}
+		if p.done {
+			return nil
+		}
+
+		sleep := jitter(interval, opts.Jitter)
+
+		core.Logger(ctx).WithFields(log.Fields{
+			"op":      opts.Op,
+			"attempt": attempt,
+			"sleep":   sleep,
+		}).Debug("waiting for operation")
+
+		t := time.NewTimer(sleep)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return waitErr(ctx, opts)
+		case <-t.C:
+		}
+
+		interval = next(interval, opts)
+	}
+}
+
+func waitErr(ctx context.Context, opts *Options) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return &errors.TimeoutError{Op: opts.Op, Timeout: opts.Timeout}
+	}
+	return &errors.CanceledError{Op: opts.Op}
+}
+
+func next(interval time.Duration, opts *Options) time.Duration {
+	m := opts.Multiplier
+	if m < 1 {
+		m = 1
+	}
+	interval = time.Duration(float64(interval) * m)
+	if opts.MaxInterval > 0 && interval > opts.MaxInterval {
+		interval = opts.MaxInterval
+	}
+	return interval
+}
+
+func jitter(d time.Duration, j float64) time.Duration {
+	if j <= 0 {
+		return d
+	}
+	if j > 1 {
+		j = 1
+	}
+	delta := j * float64(d)
+	return time.Duration(float64(d) - delta + rand.Float64()*2*delta)
+}
+
+func getDuration(
+	config gofig.Config, key string, defaultVal time.Duration) time.Duration {
+	v := config.GetString(key)
+	if v == "" {
+		return defaultVal
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"key":   key,
+			"value": v,
+			"error": err,
+		}).Warn("invalid duration")
+		return defaultVal
+	}
+	return d
+}
+
+func configRegistration() *core.ConfigSchema {
+	r := core.NewConfigSchema("Waiter")
+	r.Key(gofig.String, "", "5m",
+		"The default amount of time to wait for a storage operation",
+		"rexray.waiter.timeout")
+	r.Key(gofig.String, "", "1s",
+		"The initial amount of time between storage operation polls",
+		"rexray.waiter.interval")
+	r.Key(gofig.String, "", "30s",
+		"The maximum amount of time between storage operation polls",
+		"rexray.waiter.maxInterval")
+	r.Key(gofig.String, "", "",
+		"The amount of time to wait for a volume to attach",
+		"rexray.waiter.timeouts.attach")
+	r.Key(gofig.String, "", "",
+		"The amount of time to wait for a volume to detach",
+		"rexray.waiter.timeouts.detach")
+	r.Key(gofig.String, "", "",
+		"The amount of time to wait for a volume to be created",
+		"rexray.waiter.timeouts.createVolume")
+	r.Key(gofig.String, "", "",
+		"The amount of time to wait for a snapshot to be created",
+		"rexray.waiter.timeouts.createSnapshot")
+	r.Key(gofig.String, "", "10s",
+		"The amount of time to wait for an attached volume's device",
+		"rexray.waiter.timeouts.mount")
+	r.Key(gofig.String, "", "",
+		"The amount of time to wait for a provider job to complete",
+		"rexray.waiter.timeouts.job")
+	return r
+}
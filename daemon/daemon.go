@@ -57,3 +57,8 @@ func Start(host string, init chan error, stop <-chan os.Signal) {
 		log.Info("Service received stop signal")
 	}
 }
+
+// Reload reloads the configuration of the daemon's modules.
+func Reload() error {
+	return module.ReloadModules()
+}
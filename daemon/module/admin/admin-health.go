@@ -0,0 +1,32 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/emccode/rexray/daemon/module"
+)
+
+// healthzHandler reports the health of the service. The response status is
+// always OK so long as the service is able to respond.
+func (m *mod) healthzHandler(w http.ResponseWriter, req *http.Request) {
+	ctx, cancel := module.NewRequestContext(m.r.GetConfig(), w, req)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	writeJSON(w, module.NewHealthReport(ctx, m.r))
+}
+
+// readyzHandler reports the health of the service. The response status is
+// ServiceUnavailable if the service is not ready.
+func (m *mod) readyzHandler(w http.ResponseWriter, req *http.Request) {
+	ctx, cancel := module.NewRequestContext(m.r.GetConfig(), w, req)
+	defer cancel()
+
+	hr := module.NewHealthReport(ctx, m.r)
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if !hr.Ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	writeJSON(w, hr)
+}
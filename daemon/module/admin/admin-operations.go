@@ -0,0 +1,102 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/gorilla/mux"
+	"golang.org/x/net/context"
+
+	"github.com/emccode/rexray/core"
+	"github.com/emccode/rexray/daemon/module"
+)
+
+func (m *mod) operationsGetHandler(w http.ResponseWriter, req *http.Request) {
+	ops, err := m.r.Operations.List()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getJSONError("Error listing operations", err))
+		log.Printf("Error listing operations ERR: %v\n", err)
+		return
+	}
+
+	writeJSON(w, ops)
+}
+
+func (m *mod) operationsPostHandler(w http.ResponseWriter, req *http.Request) {
+	ctx := core.WithRequestID(
+		context.Background(), req.Header.Get(module.RequestIDHeader))
+	ctx = core.WithCaller(ctx, module.RequestCaller(req))
+	if n := req.Header.Get(module.StorageDriverHeader); n != "" {
+		ctx = core.WithStorageDriverName(ctx, n)
+	}
+
+	opReq := &core.OperationRequest{}
+	if err := json.NewDecoder(req.Body).Decode(opReq); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getJSONError("Error unmarshalling operation json", err))
+		core.Logger(ctx).Printf(
+			"Error unmarshalling operation json ERR: %v\n", err)
+		return
+	}
+
+	core.Logger(ctx).WithFields(log.Fields{
+		"type":       opReq.Type,
+		"volumeId":   opReq.VolumeID,
+		"volumeName": opReq.VolumeName,
+		"instanceId": opReq.InstanceID,
+	}).Debug("received operation post request")
+
+	op, err := m.r.Operations.Start(ctx, opReq)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write(getJSONError(
+			"Error starting operation", core.RequestError(ctx, err)))
+		core.Logger(ctx).Printf("Error starting operation ERR: %v\n", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	writeJSON(w, op)
+}
+
+func (m *mod) operationsHandler(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	switch req.Method {
+	case "GET":
+		m.operationsGetHandler(w, req)
+	case "POST":
+		m.operationsPostHandler(w, req)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func (m *mod) operationHandler(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	id := mux.Vars(req)["id"]
+	op, err := m.r.Operations.Get(id)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(getJSONError("Unknown operation id", err))
+		log.Printf("Unknown operation id ERR: %v\n", err)
+		return
+	}
+
+	writeJSON(w, op)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	jsonBuf, jsonBufErr := json.MarshalIndent(v, "", "  ")
+	if jsonBufErr != nil {
+		w.Write(getJSONError("Error marshalling object to json", jsonBufErr))
+		log.Printf("Error marshalling object to json ERR: %v\n", jsonBufErr)
+		return
+	}
+
+	if _, writeErr := w.Write(jsonBuf); writeErr != nil {
+		log.Printf("Error writing json buffer ERR: %v", writeErr)
+	}
+}
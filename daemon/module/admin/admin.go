@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+	"sync"
 	"time"
 
 	log "github.com/Sirupsen/logrus"
@@ -16,6 +17,9 @@ import (
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 
+	"github.com/emccode/rexray/core"
+	"github.com/emccode/rexray/core/events"
+	"github.com/emccode/rexray/core/metrics"
 	"github.com/emccode/rexray/daemon/module"
 )
 
@@ -26,10 +30,15 @@ const (
 )
 
 type mod struct {
-	id   int32
-	name string
-	addr string
-	desc string
+	id    int32
+	r     *core.RexRay
+	cfg   gofig.Config
+	name  string
+	addr  string
+	desc  string
+	s     *module.Server
+	hooks []*events.Webhook
+	lock  sync.Mutex
 }
 
 type jsonError struct {
@@ -48,6 +57,7 @@ func init() {
 func newModule(id int32, config *module.Config) (module.Module, error) {
 	return &mod{
 		id:   id,
+		cfg:  config.Config,
 		name: modName,
 		desc: modDescription,
 		addr: config.Address,
@@ -310,6 +320,26 @@ func moduleInstStartHandler(w http.ResponseWriter, req *http.Request) {
 	w.Write(jsonBuf)
 }
 
+// reloadHandler reloads the configuration of the module instances and
+// responds with the module instances.
+func reloadHandler(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	if req.Method != "POST" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := module.ReloadModules(); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write(getJSONError("Error reloading configuration", err))
+		log.Printf("Error reloading configuration ERR: %v\n", err)
+		return
+	}
+
+	moduleInstGetHandler(w, req)
+}
+
 func getJSONError(msg string, err error) []byte {
 	buf, marshalErr := json.MarshalIndent(
 		&jsonError{
@@ -326,14 +356,39 @@ func (m *mod) Start() error {
 	stdOut := log.StandardLogger().Writer()
 	stdErr := log.StandardLogger().Writer()
 
+	// the admin module remains available without storage, in which case
+	// operations that require a storage driver fail individually
+	var err error
+	if m.r, err = module.Platform(m.cfg); err != nil {
+		log.WithField("error", err).Warn(
+			"admin module error initializing drivers")
+	}
+
 	r := mux.NewRouter()
 
+	r.Handle("/r/operations",
+		handlers.LoggingHandler(stdOut,
+			module.RequestIDHandler(http.HandlerFunc(m.operationsHandler))))
+	r.Handle("/r/operations/{id}",
+		handlers.LoggingHandler(stdOut,
+			module.RequestIDHandler(http.HandlerFunc(m.operationHandler))))
 	r.Handle("/r/module/instances",
-		handlers.LoggingHandler(stdOut, http.HandlerFunc(moduleInstHandler)))
+		handlers.LoggingHandler(stdOut,
+			module.RequestIDHandler(http.HandlerFunc(moduleInstHandler))))
 	r.Handle("/r/module/instances/{id}/start",
-		handlers.LoggingHandler(stdOut, http.HandlerFunc(moduleInstStartHandler)))
+		handlers.LoggingHandler(stdOut,
+			module.RequestIDHandler(http.HandlerFunc(moduleInstStartHandler))))
 	r.Handle("/r/module/types",
-		handlers.LoggingHandler(stdOut, http.HandlerFunc(moduleTypeHandler)))
+		handlers.LoggingHandler(stdOut,
+			module.RequestIDHandler(http.HandlerFunc(moduleTypeHandler))))
+	r.Handle("/r/reload",
+		handlers.LoggingHandler(stdOut,
+			module.RequestIDHandler(http.HandlerFunc(reloadHandler))))
+
+	r.Handle("/metrics", metrics.Handler(metrics.Default))
+	r.HandleFunc("/healthz", m.healthzHandler)
+	r.HandleFunc("/readyz", m.readyzHandler)
+	r.Handle("/events", events.Handler(events.Default))
 
 	r.Handle("/images/rexray-banner-logo.svg",
 		handlers.LoggingHandler(stdOut, http.HandlerFunc(imagesHandler)))
@@ -345,34 +400,57 @@ func (m *mod) Start() error {
 	r.Handle("/",
 		handlers.LoggingHandler(stdOut, http.HandlerFunc(indexHandler)))
 
-	_, addr, parseAddrErr := gotil.ParseAddress(m.Address())
-	if parseAddrErr != nil {
-		return parseAddrErr
+	// the event stream is long-lived, so responses are not subject to a
+	// write timeout
+	m.s = &module.Server{
+		Server: &http.Server{
+			Handler:        r,
+			ReadTimeout:    10 * time.Second,
+			MaxHeaderBytes: 1 << 20,
+			ErrorLog:       golog.New(stdErr, "", 0),
+		},
 	}
 
-	s := &http.Server{
-		Addr:           addr,
-		Handler:        r,
-		ReadTimeout:    10 * time.Second,
-		WriteTimeout:   10 * time.Second,
-		MaxHeaderBytes: 1 << 20,
-		ErrorLog:       golog.New(stdErr, "", 0),
+	if err := m.s.Listen(m.Address()); err != nil {
+		return err
 	}
 
-	go func() {
-		defer stdOut.Close()
-		defer stdErr.Close()
+	m.lock.Lock()
+	m.hooks = core.StartEventWebhooks(m.r.GetConfig())
+	m.lock.Unlock()
 
-		sErr := s.ListenAndServe()
-		if sErr != nil {
-			panic(sErr)
-		}
-	}()
+	return nil
+}
+
+// Reload reloads the module's configuration, restarts the event webhooks,
+// and rebinds the module if its address changed.
+func (m *mod) Reload(config *module.Config) error {
+	if _, err := m.r.Reload(config.Config); err != nil {
+		return err
+	}
+
+	if err := m.s.Listen(config.Address); err != nil {
+		return err
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.addr = config.Address
+	for _, w := range m.hooks {
+		w.Stop()
+	}
+	m.hooks = core.StartEventWebhooks(m.r.GetConfig())
 
 	return nil
 }
 
 func (m *mod) Stop() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, w := range m.hooks {
+		w.Stop()
+	}
+	m.hooks = nil
 	return nil
 }
 
@@ -385,5 +463,7 @@ func (m *mod) Description() string {
 }
 
 func (m *mod) Address() string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
 	return m.addr
 }
@@ -0,0 +1,89 @@
+package module
+
+import (
+	"net/http"
+
+	"github.com/akutz/gofig"
+	"golang.org/x/net/context"
+
+	"github.com/emccode/rexray/core"
+	"github.com/emccode/rexray/core/audit"
+)
+
+// RequestIDHeader is the name of the HTTP header from which a module reads
+// the ID of an incoming request and to which it writes the ID in the response.
+const RequestIDHeader = "X-Request-Id"
+
+// CallerHeader is the name of the HTTP header in which a client reports the
+// identity of the party on whose behalf it sends a request, such as the user
+// of the REX-Ray CLI. The identity is recorded in the audit log as reported
+// and is not verified.
+const CallerHeader = "X-Rexray-Caller"
+
+// StorageDriverHeader is the name of the HTTP header that selects the storage
+// driver, or instance of a storage driver, ex. scaleio/prod, that services a
+// request.
+const StorageDriverHeader = "X-Rexray-Storage-Driver"
+
+// NewRequestContext returns a context for servicing an HTTP request. The
+// context carries the request ID from the request's X-Request-Id header, or
+// a new request ID if the header is not set, carries the caller returned by
+// RequestCaller, has the deadline defined by the configuration, and is
+// canceled if the client closes the connection before the request completes.
+// The context selects the storage driver named by the request's
+// X-Rexray-Storage-Driver header, if set. The request ID is also written to
+// the response's X-Request-Id header. The returned cancel function must be
+// called when the request completes.
+func NewRequestContext(
+	config gofig.Config,
+	w http.ResponseWriter,
+	req *http.Request) (context.Context, context.CancelFunc) {
+
+	ctx, cancel := core.NewContext(
+		context.Background(), config, req.Header.Get(RequestIDHeader))
+
+	w.Header().Set(RequestIDHeader, core.RequestID(ctx))
+	ctx = core.WithCaller(ctx, RequestCaller(req))
+	if n := req.Header.Get(StorageDriverHeader); n != "" {
+		ctx = core.WithStorageDriverName(ctx, n)
+	}
+
+	if cn, ok := w.(http.CloseNotifier); ok {
+		closed := cn.CloseNotify()
+		go func() {
+			select {
+			case <-closed:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	return ctx, cancel
+}
+
+// RequestCaller returns the identity of the API client that sent the
+// request.
+func RequestCaller(req *http.Request) *audit.Caller {
+	return &audit.Caller{
+		Type:    audit.CallerAPI,
+		Address: req.RemoteAddr,
+		Client:  req.Header.Get(CallerHeader),
+	}
+}
+
+// RequestIDHandler returns a handler that ensures every request has an ID
+// before invoking the provided handler. The ID is read from the request's
+// X-Request-Id header, or generated if the header is not set, and is written
+// to the response's X-Request-Id header.
+func RequestIDHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		id := req.Header.Get(RequestIDHeader)
+		if id == "" {
+			id = core.NewRequestID()
+			req.Header.Set(RequestIDHeader, id)
+		}
+		w.Header().Set(RequestIDHeader, id)
+		h.ServeHTTP(w, req)
+	})
+}
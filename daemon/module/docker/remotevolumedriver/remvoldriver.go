@@ -3,19 +3,20 @@ package remotevolumedriver
 import (
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"net"
 	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sync"
 	"time"
 
 	"github.com/akutz/gofig"
 	"github.com/akutz/goof"
 	"github.com/akutz/gotil"
+	"golang.org/x/net/context"
 
 	"github.com/emccode/rexray/core"
+	"github.com/emccode/rexray/core/audit"
 	"github.com/emccode/rexray/daemon/module"
 )
 
@@ -31,10 +32,13 @@ const (
 type mod struct {
 	id   int32
 	r    *core.RexRay
+	cfg  gofig.Config
 	name string
 	addr string
 	desc string
 	stor string
+	s    *module.Server
+	lock sync.Mutex
 }
 
 func init() {
@@ -72,7 +76,7 @@ func (m *mod) ID() int32 {
 func newModule(id int32, cfg *module.Config) (module.Module, error) {
 	return &mod{
 		id:   id,
-		r:    core.New(cfg.Config),
+		cfg:  cfg.Config,
 		name: modName,
 		desc: modDescription,
 		addr: cfg.Address,
@@ -93,7 +97,7 @@ type pluginRequest struct {
 
 func (m *mod) Start() error {
 
-	proto, addr, parseAddrErr := gotil.ParseAddress(m.Address())
+	proto, _, parseAddrErr := gotil.ParseAddress(m.Address())
 	if parseAddrErr != nil {
 		return parseAddrErr
 	}
@@ -110,70 +114,44 @@ func (m *mod) Start() error {
 		return goof.WithField("protocol", proto, "invalid protocol")
 	}
 
-	if err := m.r.InitDrivers(); err != nil {
+	r, err := module.Platform(m.cfg)
+	if err != nil {
 		return goof.WithFieldsE(goof.Fields{
 			"m":   m,
-			"m.r": m.r,
+			"m.r": r,
 		}, "error initializing drivers", err)
 	}
-
-	if err := os.MkdirAll("/etc/docker/plugins", 0755); err != nil {
-		return err
+	m.r = r
+
+	// the volume operations may outlast a write timeout, and are instead
+	// bounded by the request timeout
+	m.s = &module.Server{
+		Server: &http.Server{
+			Handler:        m.buildMux(),
+			ReadTimeout:    10 * time.Second,
+			MaxHeaderBytes: 1 << 20,
+		},
+		SpecFile: "/etc/docker/plugins/rexray.spec",
 	}
 
-	var specPath string
-	var startFunc func() error
-
-	mux := m.buildMux()
-
-	if proto == "unix" {
-		sockFile := addr
-		sockFileDir := filepath.Dir(sockFile)
-		mkSockFileDirErr := os.MkdirAll(sockFileDir, 0755)
-		if mkSockFileDirErr != nil {
-			return mkSockFileDirErr
-		}
-
-		_ = os.RemoveAll(sockFile)
-
-		specPath = m.Address()
-		startFunc = func() error {
-			l, lErr := net.Listen("unix", sockFile)
-			if lErr != nil {
-				return lErr
-			}
-			defer l.Close()
-			defer os.Remove(sockFile)
+	return m.s.Listen(m.Address())
+}
 
-			return http.Serve(l, mux)
-		}
-	} else {
-		specPath = addr
-		startFunc = func() error {
-			s := &http.Server{
-				Addr:           addr,
-				Handler:        mux,
-				ReadTimeout:    10 * time.Second,
-				WriteTimeout:   10 * time.Second,
-				MaxHeaderBytes: 1 << 20,
-			}
-			return s.ListenAndServe()
-		}
+// Reload reloads the module's configuration and rebinds the module if its
+// address changed.
+func (m *mod) Reload(config *module.Config) error {
+	if _, err := m.r.Reload(config.Config); err != nil {
+		return err
 	}
 
-	go func() {
-		sErr := startFunc()
-		if sErr != nil {
-			panic(sErr)
-		}
-	}()
-
-	writeSpecErr := ioutil.WriteFile(
-		"/etc/docker/plugins/rexray.spec", []byte(specPath), 0644)
-	if writeSpecErr != nil {
-		return writeSpecErr
+	if err := m.s.Listen(config.Address); err != nil {
+		return err
 	}
 
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.addr = config.Address
+
 	return nil
 }
 
@@ -190,9 +168,24 @@ func (m *mod) Description() string {
 }
 
 func (m *mod) Address() string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
 	return m.addr
 }
 
+// newRequestContext returns a context for servicing a request from the Docker
+// engine.
+func (m *mod) newRequestContext(
+	w http.ResponseWriter,
+	r *http.Request) (context.Context, context.CancelFunc) {
+
+	ctx, cancel := module.NewRequestContext(m.r.GetConfig(), w, r)
+	return core.WithCaller(ctx, &audit.Caller{
+		Type:   audit.CallerDocker,
+		Client: m.name,
+	}), cancel
+}
+
 func (m *mod) buildMux() *http.ServeMux {
 
 	mux := http.NewServeMux()
@@ -203,14 +196,17 @@ func (m *mod) buildMux() *http.ServeMux {
 	})
 
 	mux.HandleFunc("/RemoteVolumeDriver.Create", func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := m.newRequestContext(w, r)
+		defer cancel()
+
 		var pr pluginRequest
 		if err := json.NewDecoder(r.Body).Decode(&pr); err != nil {
-			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", err.Error()), 500)
+			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", core.RequestError(ctx, err).Error()), 500)
 			return
 		}
-		err := m.r.Volume.Create(pr.Name, pr.Opts)
+		err := m.r.Volume.Create(ctx, pr.Name, pr.Opts)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", err.Error()), 500)
+			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", core.RequestError(ctx, err).Error()), 500)
 			return
 		}
 
@@ -219,15 +215,18 @@ func (m *mod) buildMux() *http.ServeMux {
 	})
 
 	mux.HandleFunc("/RemoteVolumeDriver.Remove", func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := m.newRequestContext(w, r)
+		defer cancel()
+
 		var pr pluginRequest
 		if err := json.NewDecoder(r.Body).Decode(&pr); err != nil {
-			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", err.Error()), 500)
+			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", core.RequestError(ctx, err).Error()), 500)
 			return
 		}
 
-		err := m.r.Volume.Remove(pr.Name)
+		err := m.r.Volume.Remove(ctx, pr.Name)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", err.Error()), 500)
+			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", core.RequestError(ctx, err).Error()), 500)
 			return
 		}
 
@@ -236,20 +235,23 @@ func (m *mod) buildMux() *http.ServeMux {
 	})
 
 	mux.HandleFunc("/RemoteVolumeDriver.NetworkName", func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := m.newRequestContext(w, r)
+		defer cancel()
+
 		var pr pluginRequest
 		if err := json.NewDecoder(r.Body).Decode(&pr); err != nil {
-			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", err.Error()), 500)
+			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", core.RequestError(ctx, err).Error()), 500)
 			return
 		}
 
 		if pr.InstanceID == "" {
-			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", goof.New("Missing InstanceID").Error()), 500)
+			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", core.RequestError(ctx, goof.New("Missing InstanceID")).Error()), 500)
 			return
 		}
 
-		networkName, err := m.r.Volume.NetworkName(pr.Name, pr.InstanceID)
+		networkName, err := m.r.Volume.NetworkName(ctx, pr.Name, pr.InstanceID)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", err.Error()), 500)
+			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", core.RequestError(ctx, err).Error()), 500)
 			return
 		}
 
@@ -258,20 +260,23 @@ func (m *mod) buildMux() *http.ServeMux {
 	})
 
 	mux.HandleFunc("/RemoteVolumeDriver.Attach", func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := m.newRequestContext(w, r)
+		defer cancel()
+
 		var pr pluginRequest
 		if err := json.NewDecoder(r.Body).Decode(&pr); err != nil {
-			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", err.Error()), 500)
+			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", core.RequestError(ctx, err).Error()), 500)
 			return
 		}
 
 		if pr.InstanceID == "" {
-			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", goof.New("Missing InstanceID").Error()), 500)
+			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", core.RequestError(ctx, goof.New("Missing InstanceID")).Error()), 500)
 			return
 		}
 
-		networkName, err := m.r.Volume.Attach(pr.Name, pr.InstanceID, false)
+		networkName, err := m.r.Volume.Attach(ctx, pr.Name, pr.InstanceID, false)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", err.Error()), 500)
+			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", core.RequestError(ctx, err).Error()), 500)
 			return
 		}
 
@@ -280,20 +285,23 @@ func (m *mod) buildMux() *http.ServeMux {
 	})
 
 	mux.HandleFunc("/RemoteVolumeDriver.Detach", func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := m.newRequestContext(w, r)
+		defer cancel()
+
 		var pr pluginRequest
 		if err := json.NewDecoder(r.Body).Decode(&pr); err != nil {
-			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", err.Error()), 500)
+			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", core.RequestError(ctx, err).Error()), 500)
 			return
 		}
 
 		if pr.InstanceID == "" {
-			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", goof.New("Missing InstanceID").Error()), 500)
+			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", core.RequestError(ctx, goof.New("Missing InstanceID")).Error()), 500)
 			return
 		}
 
-		err := m.r.Volume.Detach(pr.Name, pr.InstanceID, false)
+		err := m.r.Volume.Detach(ctx, pr.Name, pr.InstanceID, false)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", err.Error()), 500)
+			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", core.RequestError(ctx, err).Error()), 500)
 			return
 		}
 
@@ -3,20 +3,20 @@ package volumedriver
 import (
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"net"
 	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sync"
 	"time"
 
-	log "github.com/Sirupsen/logrus"
 	"github.com/akutz/gofig"
 	"github.com/akutz/goof"
 	"github.com/akutz/gotil"
+	"golang.org/x/net/context"
 
 	"github.com/emccode/rexray/core"
+	"github.com/emccode/rexray/core/audit"
 	"github.com/emccode/rexray/daemon/module"
 )
 
@@ -30,9 +30,13 @@ const (
 type mod struct {
 	id   int32
 	r    *core.RexRay
+	cfg  gofig.Config
 	name string
 	addr string
 	desc string
+	stop chan struct{}
+	s    *module.Server
+	lock sync.Mutex
 }
 
 func init() {
@@ -61,10 +65,11 @@ func (m *mod) ID() int32 {
 func newMod(id int32, cfg *module.Config) (module.Module, error) {
 	return &mod{
 		id:   id,
-		r:    core.New(cfg.Config),
+		cfg:  cfg.Config,
 		name: modName,
 		desc: modDescription,
 		addr: cfg.Address,
+		stop: make(chan struct{}),
 	}, nil
 }
 
@@ -83,7 +88,7 @@ type pluginRequest struct {
 
 func (m *mod) Start() error {
 
-	proto, addr, parseAddrErr := gotil.ParseAddress(m.Address())
+	proto, _, parseAddrErr := gotil.ParseAddress(m.Address())
 	if parseAddrErr != nil {
 		return parseAddrErr
 	}
@@ -100,74 +105,71 @@ func (m *mod) Start() error {
 		return goof.WithField("protocol", proto, "invalid protocol")
 	}
 
-	if err := m.r.InitDrivers(); err != nil {
+	r, err := module.Platform(m.cfg)
+	if err != nil {
 		return goof.WithFieldsE(goof.Fields{
 			"m":   m,
-			"m.r": m.r,
+			"m.r": r,
 		}, "error initializing drivers", err)
 	}
+	m.r = r
 
-	if err := os.MkdirAll("/etc/docker/plugins", 0755); err != nil {
-		return err
+	m.lock.Lock()
+	if m.stop == nil {
+		m.stop = make(chan struct{})
+	}
+	m.r.StartReconciler(m.stop)
+	m.lock.Unlock()
+
+	// the volume operations may outlast a write timeout, and are instead
+	// bounded by the request timeout
+	m.s = &module.Server{
+		Server: &http.Server{
+			Handler:        m.buildMux(),
+			ReadTimeout:    10 * time.Second,
+			MaxHeaderBytes: 1 << 20,
+		},
+		SpecFile: "/etc/docker/plugins/rexray.spec",
 	}
 
-	var specPath string
-	var startFunc func() error
-
-	mux := m.buildMux()
-
-	if proto == "unix" {
-		sockFile := addr
-		sockFileDir := filepath.Dir(sockFile)
-		mkSockFileDirErr := os.MkdirAll(sockFileDir, 0755)
-		if mkSockFileDirErr != nil {
-			return mkSockFileDirErr
-		}
-
-		_ = os.RemoveAll(sockFile)
+	return m.s.Listen(m.Address())
+}
 
-		specPath = m.Address()
-		startFunc = func() error {
-			l, lErr := net.Listen("unix", sockFile)
-			if lErr != nil {
-				return lErr
-			}
-			defer l.Close()
-			defer os.Remove(sockFile)
+// Reload reloads the module's configuration and rebinds the module if its
+// address changed.
+func (m *mod) Reload(config *module.Config) error {
+	if _, err := m.r.Reload(config.Config); err != nil {
+		return err
+	}
 
-			return http.Serve(l, mux)
-		}
-	} else {
-		specPath = addr
-		startFunc = func() error {
-			s := &http.Server{
-				Addr:           addr,
-				Handler:        mux,
-				ReadTimeout:    10 * time.Second,
-				WriteTimeout:   10 * time.Second,
-				MaxHeaderBytes: 1 << 20,
-			}
-			return s.ListenAndServe()
-		}
+	if err := m.s.Listen(config.Address); err != nil {
+		return err
 	}
 
-	go func() {
-		sErr := startFunc()
-		if sErr != nil {
-			panic(sErr)
-		}
-	}()
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.addr = config.Address
 
-	writeSpecErr := ioutil.WriteFile(
-		"/etc/docker/plugins/rexray.spec", []byte(specPath), 0644)
-	if writeSpecErr != nil {
-		return writeSpecErr
+	// restart the reconciler with the reloaded interval unless the module
+	// is stopped
+	if m.stop != nil {
+		close(m.stop)
+		m.stop = make(chan struct{})
+		m.r.StartReconciler(m.stop)
 	}
 
 	return nil
 }
 
+// Stop stops the module's reconciler. The stop channel is nil once it is
+// closed, so stopping the module again is a no-op.
 func (m *mod) Stop() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.stop != nil {
+		close(m.stop)
+		m.stop = nil
+	}
 	return nil
 }
 
@@ -180,9 +182,24 @@ func (m *mod) Description() string {
 }
 
 func (m *mod) Address() string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
 	return m.addr
 }
 
+// newRequestContext returns a context for servicing a request from the Docker
+// engine.
+func (m *mod) newRequestContext(
+	w http.ResponseWriter,
+	r *http.Request) (context.Context, context.CancelFunc) {
+
+	ctx, cancel := module.NewRequestContext(m.r.GetConfig(), w, r)
+	return core.WithCaller(ctx, &audit.Caller{
+		Type:   audit.CallerDocker,
+		Client: m.name,
+	}), cancel
+}
+
 func (m *mod) buildMux() *http.ServeMux {
 
 	mux := http.NewServeMux()
@@ -193,18 +210,21 @@ func (m *mod) buildMux() *http.ServeMux {
 	})
 
 	mux.HandleFunc("/VolumeDriver.Create", func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := m.newRequestContext(w, r)
+		defer cancel()
+
 		var pr pluginRequest
 		if err := json.NewDecoder(r.Body).Decode(&pr); err != nil {
-			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", err.Error()), 500)
-			log.WithField("error", err).Error("/VolumeDriver.Create: error decoding json")
+			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", core.RequestError(ctx, err).Error()), 500)
+			core.Logger(ctx).WithField("error", err).Error("/VolumeDriver.Create: error decoding json")
 			return
 		}
 
-		err := m.r.Volume.Create(pr.Name, pr.Opts)
+		err := m.r.Volume.Create(ctx, pr.Name, pr.Opts)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", err.Error()), 500)
-			log.WithField("error", err.Error()).Error("/VolumeDriver.Create: error creating volume")
-			log.Error(err)
+			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", core.RequestError(ctx, err).Error()), 500)
+			core.Logger(ctx).WithField("error", err.Error()).Error("/VolumeDriver.Create: error creating volume")
+			core.Logger(ctx).Error(err)
 			return
 		}
 
@@ -213,18 +233,21 @@ func (m *mod) buildMux() *http.ServeMux {
 	})
 
 	mux.HandleFunc("/VolumeDriver.Remove", func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := m.newRequestContext(w, r)
+		defer cancel()
+
 		var pr pluginRequest
 		if err := json.NewDecoder(r.Body).Decode(&pr); err != nil {
-			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", err.Error()), 500)
-			log.WithField("error", err).Error("/VolumeDriver.Remove: error decoding json")
+			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", core.RequestError(ctx, err).Error()), 500)
+			core.Logger(ctx).WithField("error", err).Error("/VolumeDriver.Remove: error decoding json")
 			return
 		}
 
-		err := m.r.Volume.Remove(pr.Name)
+		err := m.r.Volume.Remove(ctx, pr.Name)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", err.Error()), 500)
-			log.WithField("error", err.Error()).Error("/VolumeDriver.Remove: error removing volume")
-			log.Error(err)
+			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", core.RequestError(ctx, err).Error()), 500)
+			core.Logger(ctx).WithField("error", err.Error()).Error("/VolumeDriver.Remove: error removing volume")
+			core.Logger(ctx).Error(err)
 			return
 		}
 
@@ -233,18 +256,21 @@ func (m *mod) buildMux() *http.ServeMux {
 	})
 
 	mux.HandleFunc("/VolumeDriver.Path", func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := m.newRequestContext(w, r)
+		defer cancel()
+
 		var pr pluginRequest
 		if err := json.NewDecoder(r.Body).Decode(&pr); err != nil {
-			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", err.Error()), 500)
-			log.WithField("error", err).Error("/VolumeDriver.Path: error decoding json")
+			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", core.RequestError(ctx, err).Error()), 500)
+			core.Logger(ctx).WithField("error", err).Error("/VolumeDriver.Path: error decoding json")
 			return
 		}
 
-		mountPath, err := m.r.Volume.Path(pr.Name, "")
+		mountPath, err := m.r.Volume.Path(ctx, pr.Name, "")
 		if err != nil {
-			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", err.Error()), 500)
-			log.WithField("error", err.Error()).Error("/VolumeDriver.Path: error returning path")
-			log.Error(err)
+			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", core.RequestError(ctx, err).Error()), 500)
+			core.Logger(ctx).WithField("error", err.Error()).Error("/VolumeDriver.Path: error returning path")
+			core.Logger(ctx).Error(err)
 			return
 		}
 
@@ -253,18 +279,21 @@ func (m *mod) buildMux() *http.ServeMux {
 	})
 
 	mux.HandleFunc("/VolumeDriver.Mount", func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := m.newRequestContext(w, r)
+		defer cancel()
+
 		var pr pluginRequest
 		if err := json.NewDecoder(r.Body).Decode(&pr); err != nil {
-			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", err.Error()), 500)
-			log.WithField("error", err).Error("/VolumeDriver.Mount: error decoding json")
+			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", core.RequestError(ctx, err).Error()), 500)
+			core.Logger(ctx).WithField("error", err).Error("/VolumeDriver.Mount: error decoding json")
 			return
 		}
 
-		mountPath, err := m.r.Volume.Mount(pr.Name, "", false, "", false)
+		mountPath, err := m.r.Volume.Mount(ctx, pr.Name, "", false, "", false)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", err.Error()), 500)
-			log.WithField("error", err.Error()).Error("/VolumeDriver.Mount: error mounting volume")
-			log.Error(err)
+			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", core.RequestError(ctx, err).Error()), 500)
+			core.Logger(ctx).WithField("error", err.Error()).Error("/VolumeDriver.Mount: error mounting volume")
+			core.Logger(ctx).Error(err)
 			return
 		}
 
@@ -273,18 +302,21 @@ func (m *mod) buildMux() *http.ServeMux {
 	})
 
 	mux.HandleFunc("/VolumeDriver.Unmount", func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := m.newRequestContext(w, r)
+		defer cancel()
+
 		var pr pluginRequest
 		if err := json.NewDecoder(r.Body).Decode(&pr); err != nil {
-			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", err.Error()), 500)
-			log.WithField("error", err).Error("/VolumeDriver.Unmount: error decoding json")
+			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", core.RequestError(ctx, err).Error()), 500)
+			core.Logger(ctx).WithField("error", err).Error("/VolumeDriver.Unmount: error decoding json")
 			return
 		}
 
-		err := m.r.Volume.Unmount(pr.Name, "")
+		err := m.r.Volume.Unmount(ctx, pr.Name, "")
 		if err != nil {
-			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", err.Error()), 500)
-			log.WithField("error", err.Error()).Error("/VolumeDriver.Unmount: error unmounting volume")
-			log.Error(err)
+			http.Error(w, fmt.Sprintf("{\"Error\":\"%s\"}", core.RequestError(ctx, err).Error()), 500)
+			core.Logger(ctx).WithField("error", err.Error()).Error("/VolumeDriver.Unmount: error unmounting volume")
+			core.Logger(ctx).Error(err)
 			return
 		}
 
@@ -0,0 +1,74 @@
+package module
+
+import (
+	"sort"
+
+	"golang.org/x/net/context"
+
+	"github.com/emccode/rexray/core"
+)
+
+// HealthReport describes the health of the REX-Ray service.
+type HealthReport struct {
+
+	// A flag indicating whether or not the service is ready to service
+	// requests. The service is ready when at least one driver is initialized,
+	// all of the drivers are healthy, and all of the module instances are
+	// started.
+	Ready bool `json:"ready"`
+
+	// The health of each initialized driver.
+	Drivers []*core.DriverHealth `json:"drivers"`
+
+	// The state of each module instance.
+	Modules []*ModuleHealth `json:"modules"`
+}
+
+// ModuleHealth describes the state of a module instance.
+type ModuleHealth struct {
+	ID      int32  `json:"id"`
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	Started bool   `json:"started"`
+}
+
+// NewHealthReport checks the health of the drivers initialized by the
+// provided REX-Ray instance and of the module instances.
+func NewHealthReport(ctx context.Context, r *core.RexRay) *HealthReport {
+
+	hr := &HealthReport{
+		Drivers: r.Health(ctx),
+		Modules: []*ModuleHealth{},
+	}
+
+	hr.Ready = len(hr.Drivers) > 0
+	for _, d := range hr.Drivers {
+		if !d.Healthy {
+			hr.Ready = false
+		}
+	}
+
+	for mi := range Instances() {
+		mh := &ModuleHealth{
+			ID:      mi.ID,
+			Name:    mi.Name,
+			Started: mi.IsStarted,
+		}
+		if mi.Config != nil {
+			mh.Address = mi.Config.Address
+		}
+		if !mh.Started {
+			hr.Ready = false
+		}
+		hr.Modules = append(hr.Modules, mh)
+	}
+	sort.Sort(modulesByID(hr.Modules))
+
+	return hr
+}
+
+type modulesByID []*ModuleHealth
+
+func (m modulesByID) Len() int           { return len(m) }
+func (m modulesByID) Swap(i, j int)      { m[i], m[j] = m[j], m[i] }
+func (m modulesByID) Less(i, j int) bool { return m[i].ID < m[j].ID }
@@ -1,7 +1,9 @@
 package module
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -10,6 +12,9 @@ import (
 	"github.com/akutz/goof"
 
 	"github.com/akutz/gofig"
+
+	"github.com/emccode/rexray/core"
+	"github.com/emccode/rexray/core/metrics"
 )
 
 // Module is the interface to which types adhere in order to participate as
@@ -36,6 +41,16 @@ type Module interface {
 	Description() string
 }
 
+// Reloader is the interface implemented by modules that can reload their
+// configuration without being restarted.
+type Reloader interface {
+
+	// Reload replaces the module's configuration. If the configuration's
+	// address differs from the module's address, the module is rebound to
+	// the new address.
+	Reload(config *Config) error
+}
+
 // Init initializes the module.
 type Init func(id int32, config *Config) (Module, error)
 
@@ -50,6 +65,20 @@ var (
 	modInstancesRwl sync.RWMutex
 )
 
+var moduleUp = metrics.Default.Gauge(
+	"rexray_module_up",
+	"Whether or not a module instance is started (1) or not (0).",
+	"id", "name", "address")
+
+func setModuleUp(mod *Instance, up bool) {
+	var v float64
+	if up {
+		v = 1
+	}
+	moduleUp.Set(
+		v, fmt.Sprintf("%d", mod.ID), mod.Name, mod.Config.Address)
+}
+
 // GetModOptVal gets a module's option value.
 func GetModOptVal(opts map[string]string, key string) string {
 	if opts == nil {
@@ -64,6 +93,19 @@ type Config struct {
 	Config  gofig.Config `json:"config,omitempty"`
 }
 
+// MarshalJSON marshals the module's configuration with its secrets redacted.
+func (c *Config) MarshalJSON() ([]byte, error) {
+	type config Config
+	rc := config(*c)
+	if c.Config != nil {
+		var err error
+		if rc.Config, err = core.RedactConfig(c.Config); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(&rc)
+}
+
 // Type is a struct that describes a module type
 type Type struct {
 	ID               int32     `json:"id"`
@@ -83,6 +125,7 @@ type Instance struct {
 	Config      *Config `json:"config,omitempty"`
 	Description string  `json:"description"`
 	IsStarted   bool    `json:"started"`
+	isDefault   bool
 }
 
 func init() {
@@ -138,7 +181,13 @@ func InitializeDefaultModules() error {
 	for id, mt := range modTypes {
 		if mt.DefaultConfigs != nil {
 			for _, mc := range mt.DefaultConfigs {
-				_, initErr := InitializeModule(id, mc)
+				mi, initErr := InitializeModule(id, &Config{
+					Address: defaultAddress(mt, mc),
+					Config:  mc.Config,
+				})
+				if mi != nil {
+					mi.isDefault = true
+				}
 				if initErr != nil {
 					if mt.IgnoreFailOnInit {
 						log.WithField("error", initErr).Warn(
@@ -192,6 +241,7 @@ func InitializeModule(
 		Description: mod.Description(),
 	}
 	modInstances[modInstID] = modInst
+	setModuleUp(modInst, false)
 
 	lf["id"] = modInstID
 	log.WithFields(lf).Info("initialized module instance")
@@ -208,6 +258,15 @@ func RegisterModule(
 	modTypesRwl.Lock()
 	defer modTypesRwl.Unlock()
 
+	// the address of a module type's default instance may be overridden
+	if len(defaultConfigs) == 1 {
+		s := core.NewConfigSchema(name)
+		s.Key(gofig.String, "", defaultConfigs[0].Address,
+			"The address of the "+name+"'s default instance",
+			AddressKey(name))
+		core.RegisterConfigSchema(s)
+	}
+
 	modTypeID := atomic.AddInt32(&nextModTypeID, 1)
 	modTypes[modTypeID] = &Type{
 		ID:               modTypeID,
@@ -315,6 +374,7 @@ func StartModule(modInstID int32) error {
 	select {
 	case <-started:
 		mod.IsStarted = true
+		setModuleUp(mod, true)
 		log.WithFields(lf).Info("started module")
 	case <-timeout:
 		log.WithFields(lf).Debug("timed out while monitoring module start")
@@ -324,3 +384,78 @@ func StartModule(modInstID int32) error {
 
 	return nil
 }
+
+// AddressKey returns the configuration property that overrides the address
+// of the default instance of the module type, ex.
+// rexray.modules.adminmodule.address.
+func AddressKey(typeName string) string {
+	return fmt.Sprintf("rexray.modules.%s.address", strings.ToLower(typeName))
+}
+
+// defaultAddress returns the address of a default instance of the module type,
+// which is overridden by the configuration if the type has a single default
+// instance.
+func defaultAddress(mt *Type, mc *Config) string {
+	if len(mt.DefaultConfigs) != 1 {
+		return mc.Address
+	}
+	config := mc.Config
+	if config == nil {
+		config = gofig.New()
+	}
+	if addr := config.GetString(AddressKey(mt.Name)); addr != "" {
+		return addr
+	}
+	return mc.Address
+}
+
+// ReloadModules reloads the configuration of each started default module
+// instance that implements Reloader. An instance whose address is changed by
+// the configuration is rebound to its new address. The module instances
+// created with the admin API keep their configuration.
+func ReloadModules() error {
+	modInstancesRwl.Lock()
+	defer modInstancesRwl.Unlock()
+
+	var err error
+	for _, mod := range modInstances {
+		rl, ok := mod.Inst.(Reloader)
+		if !ok || !mod.IsStarted || !mod.isDefault {
+			continue
+		}
+
+		lf := log.Fields{
+			"id":       mod.ID,
+			"typeName": mod.Type.Name,
+		}
+
+		mc := &Config{Config: gofig.New()}
+		mc.Address = mod.Config.Address
+		if len(mod.Type.DefaultConfigs) == 1 {
+			mc.Address = defaultAddress(
+				mod.Type, &Config{
+					Address: mod.Type.DefaultConfigs[0].Address,
+					Config:  mc.Config,
+				})
+		}
+		lf["address"] = mc.Address
+
+		if rErr := rl.Reload(mc); rErr != nil {
+			log.WithFields(lf).WithField("error", rErr).Error(
+				"error reloading module")
+			if err == nil {
+				err = goof.WithFieldsE(lf, "error reloading module", rErr)
+			}
+			continue
+		}
+
+		if mc.Address != mod.Config.Address {
+			setModuleUp(mod, false)
+		}
+		mod.Config = mc
+		setModuleUp(mod, true)
+		log.WithFields(lf).Info("reloaded module")
+	}
+
+	return err
+}
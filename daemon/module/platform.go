@@ -0,0 +1,38 @@
+package module
+
+import (
+	"sync"
+
+	"github.com/akutz/gofig"
+
+	"github.com/emccode/rexray/core"
+)
+
+var (
+	platform     *core.RexRay
+	platformInit bool
+	platformLock sync.Mutex
+)
+
+// Platform returns the REX-Ray instance shared by the module instances. The
+// instance's drivers are initialized by the first module instance to start,
+// so each driver is initialized, and logs in to its storage provider, once
+// per service rather than once per module instance. If the drivers cannot be
+// initialized the error is returned along with the instance, and the next
+// call tries to initialize them again.
+func Platform(config gofig.Config) (*core.RexRay, error) {
+	platformLock.Lock()
+	defer platformLock.Unlock()
+
+	if platform == nil {
+		platform = core.New(config)
+	}
+	if platformInit {
+		return platform, nil
+	}
+	if err := platform.InitDrivers(); err != nil {
+		return platform, err
+	}
+	platformInit = true
+	return platform, nil
+}
@@ -0,0 +1,129 @@
+package module
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/akutz/gotil"
+)
+
+// Server serves a module's HTTP API at the module's address. A server may be
+// rebound to a new address without interrupting the requests that are in
+// progress.
+type Server struct {
+
+	// Server is the HTTP server used to serve the requests. Its address is
+	// ignored.
+	Server *http.Server
+
+	// SpecFile is the path of the Docker plugin spec file that is written
+	// with the server's address when the server is bound, if any.
+	SpecFile string
+
+	lock sync.Mutex
+	addr string
+	l    net.Listener
+}
+
+// Address returns the address to which the server is bound.
+func (s *Server) Address() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.addr
+}
+
+// Listen binds the server to the address, ex. tcp://:7979 or
+// unix:///run/docker/plugins/rexray.sock, and serves requests in the
+// background. If the server is already bound to another address, the
+// previous listener is closed once the server is bound to the new address.
+// The requests accepted by the previous listener are allowed to finish.
+func (s *Server) Listen(address string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.l != nil && s.addr == address {
+		return nil
+	}
+
+	proto, addr, err := gotil.ParseAddress(address)
+	if err != nil {
+		return err
+	}
+
+	if strings.EqualFold(proto, "unix") {
+		if err := os.MkdirAll(filepath.Dir(addr), 0755); err != nil {
+			return err
+		}
+		_ = os.RemoveAll(addr)
+	}
+
+	l, err := net.Listen(proto, addr)
+	if err != nil {
+		return err
+	}
+
+	if s.SpecFile != "" {
+		spec := address
+		if !strings.EqualFold(proto, "unix") {
+			spec = addr
+		}
+		if err := writeSpecFile(s.SpecFile, spec); err != nil {
+			l.Close()
+			return err
+		}
+	}
+
+	prev := s.l
+	s.l = l
+	s.addr = address
+
+	go func() {
+		if err := s.Server.Serve(l); err != nil && !s.isClosed(l) {
+			log.WithFields(log.Fields{
+				"address": address,
+				"error":   err,
+			}).Error("error serving module")
+		}
+	}()
+
+	if prev != nil {
+		prev.Close()
+		log.WithField("address", address).Info("rebound module")
+	}
+
+	return nil
+}
+
+// Close closes the server's listener. The requests that are in progress are
+// allowed to finish.
+func (s *Server) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.l == nil {
+		return nil
+	}
+	err := s.l.Close()
+	s.l = nil
+	return err
+}
+
+// isClosed returns a flag indicating whether or not the listener was closed
+// by the server.
+func (s *Server) isClosed(l net.Listener) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.l != l
+}
+
+func writeSpecFile(path, spec string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, []byte(spec), 0644)
+}
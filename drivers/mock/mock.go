@@ -54,7 +54,7 @@ func RegisterMockDrivers() {
 	core.RegisterDriver(MockOSDriverName, newOSDriver)
 	core.RegisterDriver(MockVolDriverName, newVolDriver)
 	core.RegisterDriver(MockStorDriverName, newStorDriver)
-	gofig.Register(mockRegistration())
+	core.RegisterConfigSchema(mockRegistration())
 }
 
 // RegisterBadMockDrivers registers the bad mock drivers.
@@ -65,8 +65,9 @@ func RegisterBadMockDrivers() {
 	core.RegisterDriver(BadMockStorDriverName, newBadStorDriver)
 }
 
-func mockRegistration() *gofig.Registration {
-	r := gofig.NewRegistration("Mock Provider")
+func mockRegistration() *core.ConfigSchema {
+	r := core.NewConfigSchema("Mock Provider",
+		MockOSDriverName, MockVolDriverName, MockStorDriverName)
 	r.Yaml(`mockProvider:
     userName: admin
     useCerts: true
@@ -77,5 +78,6 @@ func mockRegistration() *gofig.Registration {
 	r.Key(gofig.String, "", "", "", "mockProvider.password")
 	r.Key(gofig.Bool, "", false, "", "mockProvider.useCerts")
 	r.Key(gofig.Int, "", 16, "", "mockProvider.docker.minVolSize")
+	r.Secret("mockProvider.password")
 	return r
 }
@@ -2,6 +2,7 @@ package mock
 
 import (
 	"github.com/akutz/goof"
+	"golang.org/x/net/context"
 
 	"github.com/emccode/rexray/core"
 )
@@ -36,22 +37,24 @@ func (m *mockOSDriver) Name() string {
 	return m.name
 }
 
-func (m *mockOSDriver) GetMounts(string, string) (core.MountInfoArray, error) {
+func (m *mockOSDriver) GetMounts(
+	context.Context, string, string) (core.MountInfoArray, error) {
 	return nil, nil
 }
 
-func (m *mockOSDriver) Mounted(string) (bool, error) {
+func (m *mockOSDriver) Mounted(context.Context, string) (bool, error) {
 	return false, nil
 }
 
-func (m *mockOSDriver) Unmount(string) error {
+func (m *mockOSDriver) Unmount(context.Context, string) error {
 	return nil
 }
 
-func (m *mockOSDriver) Mount(string, string, string, string) error {
+func (m *mockOSDriver) Mount(
+	context.Context, string, string, string, string) error {
 	return nil
 }
 
-func (m *mockOSDriver) Format(string, string, bool) error {
+func (m *mockOSDriver) Format(context.Context, string, string, bool) error {
 	return nil
 }
@@ -2,6 +2,7 @@ package mock
 
 import (
 	"github.com/akutz/goof"
+	"golang.org/x/net/context"
 
 	"github.com/emccode/rexray/core"
 )
@@ -37,7 +38,8 @@ func (m *mockStorDriver) Name() string {
 	return m.name
 }
 
-func (m *mockStorDriver) GetVolumeMapping() ([]*core.BlockDevice, error) {
+func (m *mockStorDriver) GetVolumeMapping(
+	ctx context.Context) ([]*core.BlockDevice, error) {
 	return []*core.BlockDevice{&core.BlockDevice{
 		DeviceName:   "test",
 		ProviderName: m.name,
@@ -46,7 +48,8 @@ func (m *mockStorDriver) GetVolumeMapping() ([]*core.BlockDevice, error) {
 	}}, nil
 }
 
-func (m *mockStorDriver) GetInstance() (*core.Instance, error) {
+func (m *mockStorDriver) GetInstance(
+	ctx context.Context) (*core.Instance, error) {
 	return &core.Instance{
 		Name:         "test",
 		InstanceID:   "test",
@@ -55,6 +58,7 @@ func (m *mockStorDriver) GetInstance() (*core.Instance, error) {
 }
 
 func (m *mockStorDriver) GetVolume(
+	ctx context.Context,
 	volumeID, volumeName string) ([]*core.Volume, error) {
 	return []*core.Volume{&core.Volume{
 		Name:             "test",
@@ -64,26 +68,31 @@ func (m *mockStorDriver) GetVolume(
 }
 
 func (m *mockStorDriver) GetVolumeAttach(
+	ctx context.Context,
 	volumeID, instanceID string) ([]*core.VolumeAttachment, error) {
 	return nil, nil
 }
 
 func (m *mockStorDriver) CreateSnapshot(
+	ctx context.Context,
 	runAsync bool,
 	snapshotName, volumeID, description string) ([]*core.Snapshot, error) {
 	return nil, nil
 }
 
 func (m *mockStorDriver) GetSnapshot(
+	ctx context.Context,
 	volumeID, snapshotID, snapshotName string) ([]*core.Snapshot, error) {
 	return nil, nil
 }
 
-func (m *mockStorDriver) RemoveSnapshot(snapshotID string) error {
+func (m *mockStorDriver) RemoveSnapshot(
+	ctx context.Context, snapshotID string) error {
 	return nil
 }
 
 func (m *mockStorDriver) CreateVolume(
+	ctx context.Context,
 	runAsync bool,
 	volumeName, volumeID, snapshotID, volumeType string,
 	IOPS, size int64,
@@ -91,26 +100,42 @@ func (m *mockStorDriver) CreateVolume(
 	return nil, nil
 }
 
-func (m *mockStorDriver) RemoveVolume(volumeID string) error {
+func (m *mockStorDriver) RemoveVolume(
+	ctx context.Context, volumeID string) error {
 	return nil
 }
 
-func (m *mockStorDriver) GetDeviceNextAvailable() (string, error) {
+func (m *mockStorDriver) GetDeviceNextAvailable(
+	ctx context.Context) (string, error) {
 	return "", nil
 }
 
 func (m *mockStorDriver) AttachVolume(
+	ctx context.Context,
 	runAsync bool, volumeID, instanceID string, force bool) ([]*core.VolumeAttachment, error) {
 	return nil, nil
 }
 
 func (m *mockStorDriver) DetachVolume(
+	ctx context.Context,
 	runAsync bool, volumeID string, instanceID string, force bool) error {
 	return nil
 }
 
 func (m *mockStorDriver) CopySnapshot(
+	ctx context.Context,
 	runAsync bool, volumeID, snapshotID, snapshotName,
 	destinationSnapshotName, destinationRegion string) (*core.Snapshot, error) {
 	return nil, nil
 }
+
+func (m *mockStorDriver) CloneVolume(
+	ctx context.Context,
+	sourceVolumeID, newName string,
+	opts core.VolumeOpts) (*core.Volume, error) {
+	return &core.Volume{
+		Name:             newName,
+		VolumeID:         "test",
+		AvailabilityZone: "test",
+	}, nil
+}
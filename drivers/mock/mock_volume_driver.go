@@ -2,6 +2,7 @@ package mock
 
 import (
 	"github.com/akutz/goof"
+	"golang.org/x/net/context"
 
 	"github.com/emccode/rexray/core"
 )
@@ -40,36 +41,44 @@ func (m *mockVolDriver) Name() string {
 }
 
 func (m *mockVolDriver) Mount(
+	ctx context.Context,
 	volumeName, volumeID string,
 	overwriteFs bool, newFsType string, preempt bool) (string, error) {
 	return "", nil
 }
 
-func (m *mockVolDriver) Unmount(volumeName, volumeID string) error {
+func (m *mockVolDriver) Unmount(
+	ctx context.Context, volumeName, volumeID string) error {
 	return nil
 }
 
-func (m *mockVolDriver) Path(volumeName, volumeID string) (string, error) {
+func (m *mockVolDriver) Path(
+	ctx context.Context, volumeName, volumeID string) (string, error) {
 	return "", nil
 }
 
-func (m *mockVolDriver) Create(volumeName string, opts core.VolumeOpts) error {
+func (m *mockVolDriver) Create(
+	ctx context.Context, volumeName string, opts core.VolumeOpts) error {
 	return nil
 }
 
-func (m *mockVolDriver) Remove(volumeName string) error {
+func (m *mockVolDriver) Remove(ctx context.Context, volumeName string) error {
 	return nil
 }
 
-func (m *mockVolDriver) Attach(volumeName, instanceID string, force bool) (string, error) {
+func (m *mockVolDriver) Attach(
+	ctx context.Context,
+	volumeName, instanceID string, force bool) (string, error) {
 	return "", nil
 }
 
-func (m *mockVolDriver) Detach(volumeName, instanceID string, force bool) error {
+func (m *mockVolDriver) Detach(
+	ctx context.Context, volumeName, instanceID string, force bool) error {
 	return nil
 }
 
 func (m *mockVolDriver) NetworkName(
+	ctx context.Context,
 	volumeName, instanceID string) (string, error) {
 	return "", nil
 }
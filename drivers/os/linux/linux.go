@@ -13,16 +13,18 @@ import (
 	"github.com/akutz/goof"
 	"github.com/docker/docker/pkg/mount"
 	"github.com/opencontainers/runc/libcontainer/label"
+	"golang.org/x/net/context"
 
 	"github.com/emccode/rexray/core"
 	"github.com/emccode/rexray/core/errors"
+	"github.com/emccode/rexray/util"
 )
 
 const providerName = "linux"
 
 func init() {
 	core.RegisterDriver(providerName, newDriver)
-	gofig.Register(configRegistration())
+	core.RegisterConfigSchema(configRegistration())
 }
 
 type driver struct {
@@ -48,6 +50,7 @@ func (d *driver) Name() string {
 }
 
 func (d *driver) GetMounts(
+	ctx context.Context,
 	deviceName, mountPoint string) (core.MountInfoArray, error) {
 
 	mounts, err := mount.GetMounts()
@@ -70,11 +73,11 @@ func (d *driver) GetMounts(
 	return matchedMounts, nil
 }
 
-func (d *driver) Mounted(mountPoint string) (bool, error) {
+func (d *driver) Mounted(ctx context.Context, mountPoint string) (bool, error) {
 	return mount.Mounted(mountPoint)
 }
 
-func (d *driver) Unmount(mountPoint string) error {
+func (d *driver) Unmount(ctx context.Context, mountPoint string) error {
 	return mount.Unmount(mountPoint)
 }
 
@@ -82,9 +85,9 @@ func (d *driver) isNfsDevice(device string) bool {
 	return strings.Contains(device, ":")
 }
 
-func (d *driver) nfsMount(device, target string) error {
-	command := exec.Command("mount", device, target)
-	output, err := command.CombinedOutput()
+func (d *driver) nfsMount(ctx context.Context, device, target string) error {
+	output, err := util.CommandCombinedOutput(
+		ctx, exec.Command("mount", device, target))
 	if err != nil {
 		return goof.WithError(fmt.Sprintf("failed mounting: %s", output), err)
 	}
@@ -97,11 +100,12 @@ func (d *driver) fileModeMountPath() (fileMode os.FileMode) {
 }
 
 func (d *driver) Mount(
+	ctx context.Context,
 	device, target, mountOptions, mountLabel string) error {
 
 	if d.isNfsDevice(device) {
 
-		if err := d.nfsMount(device, target); err != nil {
+		if err := d.nfsMount(ctx, device, target); err != nil {
 			return err
 		}
 
@@ -134,6 +138,7 @@ func (d *driver) Mount(
 
 // Format will look for ext4/xfs and overwrite it is it doesn't exist
 func (d *driver) Format(
+	ctx context.Context,
 	deviceName, newFsType string, overwriteFs bool) error {
 
 	var fsDetected bool
@@ -146,7 +151,7 @@ func (d *driver) Format(
 		fsDetected = true
 	}
 
-	log.WithFields(log.Fields{
+	core.Logger(ctx).WithFields(log.Fields{
 		"fsDetected":  fsDetected,
 		"fsType":      fsType,
 		"deviceName":  deviceName,
@@ -156,13 +161,15 @@ func (d *driver) Format(
 	if overwriteFs || !fsDetected {
 		switch newFsType {
 		case "ext4":
-			if err := exec.Command("mkfs.ext4", "-F", deviceName).Run(); err != nil {
+			if err := util.RunCommand(
+				ctx, exec.Command("mkfs.ext4", "-F", deviceName)); err != nil {
 				return fmt.Errorf(
 					"Problem creating filesystem on %s with error %s",
 					deviceName, err)
 			}
 		case "xfs":
-			if err := exec.Command("mkfs.xfs", "-f", deviceName).Run(); err != nil {
+			if err := util.RunCommand(
+				ctx, exec.Command("mkfs.xfs", "-f", deviceName)); err != nil {
 				return fmt.Errorf(
 					"Problem creating filesystem on %s with error %s",
 					deviceName, err)
@@ -236,8 +243,8 @@ func (d *driver) volumeRootPath() string {
 	return d.r.Config.GetString("linux.volume.rootpath")
 }
 
-func configRegistration() *gofig.Registration {
-	r := gofig.NewRegistration("Linux")
+func configRegistration() *core.ConfigSchema {
+	r := core.NewConfigSchema("Linux", providerName)
 	r.Key(gofig.Int, "", 0700, "", "linux.volume.filemode")
 	r.Key(gofig.String, "", "/data", "", "linux.volume.rootpath")
 	return r
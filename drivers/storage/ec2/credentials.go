@@ -0,0 +1,307 @@
+package ec2
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/akutz/goof"
+	"github.com/goamz/goamz/aws"
+	"github.com/goamz/goamz/ec2"
+)
+
+const (
+	defaultMetadataURL = "http://169.254.169.254"
+
+	// credentialsRefreshWindow is how long before temporary credentials
+	// expire that they are refreshed.
+	credentialsRefreshWindow = 5 * time.Minute
+)
+
+// metadataClient is the HTTP client of the instance metadata service, which
+// is only reachable from EC2 instances.
+var metadataClient = &http.Client{Timeout: 2 * time.Second}
+
+// credentials are AWS credentials. Temporary credentials, such as those of an
+// instance's IAM role or an assumed role, have a session token and expire.
+type credentials struct {
+	accessKey  string
+	secretKey  string
+	token      string
+	expiration time.Time
+
+	// source is the provider of the credentials, ex. env.
+	source string
+}
+
+func (c *credentials) auth() aws.Auth {
+	return *aws.NewAuth(c.accessKey, c.secretKey, c.token, c.expiration)
+}
+
+// expiresWithin returns a flag indicating whether or not the credentials
+// expire within the duration. Long-term credentials never expire.
+func (c *credentials) expiresWithin(d time.Duration) bool {
+	return !c.expiration.IsZero() && time.Now().Add(d).After(c.expiration)
+}
+
+// getCredentials returns the credentials of the first provider in the chain
+// that has credentials: the aws.accessKey and aws.secretKey properties, the
+// AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables, the
+// profile of the shared credentials file, and the credentials of the
+// instance's IAM role. The credentials assume the role aws.roleARN, if set.
+func (d *driver) getCredentials() (*credentials, error) {
+	providers := []func() (*credentials, error){
+		d.configCredentials,
+		envCredentials,
+		d.sharedCredentials,
+		d.metadataCredentials,
+	}
+
+	var creds *credentials
+	for _, p := range providers {
+		var err error
+		if creds, err = p(); err != nil {
+			return nil, err
+		}
+		if creds != nil {
+			break
+		}
+	}
+	if creds == nil {
+		return nil, goof.WithFields(ef(), "no aws credentials found")
+	}
+
+	log.WithFields(eff(goof.Fields{
+		"source": creds.source,
+	})).Debug("found aws credentials")
+
+	if roleARN := d.r.Config.GetString("aws.roleARN"); roleARN != "" {
+		return d.assumeRole(creds, roleARN)
+	}
+	return creds, nil
+}
+
+// client returns the EC2 API, first refreshing the credentials if they are
+// about to expire.
+func (d *driver) client() *ec2.EC2 {
+	d.credsLock.Lock()
+	defer d.credsLock.Unlock()
+
+	if d.creds.expiresWithin(credentialsRefreshWindow) {
+		creds, err := d.getCredentials()
+		if err != nil {
+			log.WithFields(eff(goof.Fields{
+				"expiration": d.creds.expiration,
+				"error":      err,
+			})).Warn("error refreshing aws credentials")
+			return d.ec2Instance
+		}
+		d.creds = creds
+		d.ec2Instance = ec2.New(creds.auth(), d.region)
+	}
+
+	return d.ec2Instance
+}
+
+func (d *driver) configCredentials() (*credentials, error) {
+	accessKey := d.r.Config.GetString("aws.accessKey")
+	secretKey := d.r.Config.GetString("aws.secretKey")
+	if accessKey == "" || secretKey == "" {
+		return nil, nil
+	}
+	return &credentials{
+		accessKey: accessKey,
+		secretKey: secretKey,
+		source:    "config",
+	}, nil
+}
+
+func envCredentials() (*credentials, error) {
+	accessKey := getEnv("AWS_ACCESS_KEY_ID", "AWS_ACCESS_KEY")
+	secretKey := getEnv("AWS_SECRET_ACCESS_KEY", "AWS_SECRET_KEY")
+	if accessKey == "" || secretKey == "" {
+		return nil, nil
+	}
+	return &credentials{
+		accessKey: accessKey,
+		secretKey: secretKey,
+		token:     os.Getenv("AWS_SESSION_TOKEN"),
+		source:    "env",
+	}, nil
+}
+
+// getEnv returns the value of the first of the environment variables that is
+// set.
+func getEnv(names ...string) string {
+	for _, n := range names {
+		if v := os.Getenv(n); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// sharedCredentials returns the credentials of the profile aws.profile, or
+// AWS_PROFILE, or else default, in the shared credentials file
+// aws.credentialsFile, or AWS_SHARED_CREDENTIALS_FILE, or else
+// ~/.aws/credentials.
+func (d *driver) sharedCredentials() (*credentials, error) {
+	path := d.r.Config.GetString("aws.credentialsFile")
+	if path == "" {
+		path = os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	}
+	if path == "" {
+		home := os.Getenv("HOME")
+		if home == "" {
+			return nil, nil
+		}
+		path = filepath.Join(home, ".aws", "credentials")
+	}
+
+	profile := d.r.Config.GetString("aws.profile")
+	if profile == "" {
+		profile = getEnv("AWS_PROFILE", "AWS_DEFAULT_PROFILE")
+	}
+	explicit := profile != ""
+	if !explicit {
+		profile = "default"
+	}
+
+	fields := eff(goof.Fields{
+		"path":    path,
+		"profile": profile,
+	})
+
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) && !explicit {
+			return nil, nil
+		}
+		return nil, goof.WithFieldsE(
+			fields, "error opening aws credentials file", err)
+	}
+	defer f.Close()
+
+	values, err := parseProfile(f, profile)
+	if err != nil {
+		return nil, goof.WithFieldsE(
+			fields, "error reading aws credentials file", err)
+	}
+	if values == nil {
+		if explicit {
+			return nil, goof.WithFields(fields, "aws profile not found")
+		}
+		return nil, nil
+	}
+
+	if values["aws_access_key_id"] == "" ||
+		values["aws_secret_access_key"] == "" {
+		return nil, goof.WithFields(fields, "incomplete aws profile")
+	}
+
+	return &credentials{
+		accessKey: values["aws_access_key_id"],
+		secretKey: values["aws_secret_access_key"],
+		token:     values["aws_session_token"],
+		source:    "profile " + profile,
+	}, nil
+}
+
+// parseProfile returns the keys and values of the section of the INI file
+// with the profile's name, or nil if there is no such section.
+func parseProfile(r io.Reader, profile string) (map[string]string, error) {
+	var values map[string]string
+	var inProfile bool
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if line[0] == '[' && line[len(line)-1] == ']' {
+			inProfile = strings.TrimSpace(line[1:len(line)-1]) == profile
+			if inProfile && values == nil {
+				values = map[string]string{}
+			}
+			continue
+		}
+		if !inProfile {
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		values[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+
+	return values, s.Err()
+}
+
+// metadataCredentials returns the credentials of the IAM role of the
+// instance profile, or nil if the instance does not have a role.
+func (d *driver) metadataCredentials() (*credentials, error) {
+	const credsPath = "/latest/meta-data/iam/security-credentials/"
+
+	res, err := metadataClient.Get(d.metadataURL + credsPath)
+	if err != nil {
+		return nil, nil
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil || res.StatusCode != http.StatusOK {
+		return nil, nil
+	}
+
+	role := strings.TrimSpace(strings.SplitN(string(body), "\n", 2)[0])
+	if role == "" {
+		return nil, nil
+	}
+
+	fields := eff(goof.Fields{"role": role})
+
+	res, err = metadataClient.Get(d.metadataURL + credsPath + role)
+	if err != nil {
+		return nil, goof.WithFieldsE(
+			fields, "error getting instance role credentials", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		fields["status"] = res.StatusCode
+		return nil, goof.WithFields(
+			fields, "error getting instance role credentials")
+	}
+
+	var rc struct {
+		Code            string
+		AccessKeyID     string `json:"AccessKeyId"`
+		SecretAccessKey string
+		Token           string
+		Expiration      time.Time
+	}
+	if err := json.NewDecoder(res.Body).Decode(&rc); err != nil {
+		return nil, goof.WithFieldsE(
+			fields, "error decoding instance role credentials", err)
+	}
+	if rc.Code != "Success" {
+		fields["code"] = rc.Code
+		return nil, goof.WithFields(fields, "invalid instance role credentials")
+	}
+
+	return &credentials{
+		accessKey:  rc.AccessKeyID,
+		secretKey:  rc.SecretAccessKey,
+		token:      rc.Token,
+		expiration: rc.Expiration,
+		source:     "instance role " + role,
+	}, nil
+}
@@ -0,0 +1,274 @@
+package ec2
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akutz/gofig"
+
+	"github.com/emccode/rexray/core"
+)
+
+// metadataStub is a stub of the instance metadata service of an instance
+// with the IAM role role1.
+type metadataStub struct {
+	requests   int
+	expiration time.Time
+}
+
+func (s *metadataStub) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	const credsPath = "/latest/meta-data/iam/security-credentials/"
+	switch req.URL.Path {
+	case credsPath:
+		fmt.Fprint(w, "role1")
+	case credsPath + "role1":
+		s.requests++
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"Code":            "Success",
+			"AccessKeyId":     fmt.Sprintf("ASIA%d", s.requests),
+			"SecretAccessKey": "instanceSecret",
+			"Token":           "instanceToken",
+			"Expiration":      s.expiration,
+		})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// stsStub is a stub of STS that grants the role arn:aws:iam::1:role/r1 to
+// the instance role.
+type stsStub struct {
+	t *testing.T
+}
+
+func (s *stsStub) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	auth := req.Header.Get("Authorization")
+	if req.Method != "POST" ||
+		!strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=ASIA") ||
+		!strings.Contains(auth, "/us-east-1/sts/aws4_request") ||
+		req.Header.Get("X-Amz-Security-Token") != "instanceToken" {
+		s.t.Errorf("unexpected request %s %v", req.Method, req.Header)
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	req.ParseForm()
+	if req.Form.Get("Action") != "AssumeRole" ||
+		req.Form.Get("RoleArn") != "arn:aws:iam::1:role/r1" {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `<ErrorResponse><Error><Code>AccessDenied</Code>`+
+			`<Message>denied</Message></Error></ErrorResponse>`)
+		return
+	}
+
+	fmt.Fprintf(w, `<AssumeRoleResponse>
+  <AssumeRoleResult>
+    <Credentials>
+      <AccessKeyId>ASIAROLE</AccessKeyId>
+      <SecretAccessKey>roleSecret</SecretAccessKey>
+      <SessionToken>%s</SessionToken>
+      <Expiration>2030-01-01T00:00:00Z</Expiration>
+    </Credentials>
+  </AssumeRoleResult>
+</AssumeRoleResponse>`, req.Form.Get("RoleSessionName"))
+}
+
+func newTestCredentialsDriver(
+	t *testing.T) (*driver, *metadataStub, gofig.Config, func()) {
+
+	for _, n := range []string{
+		"AWS_ACCESS_KEY_ID", "AWS_ACCESS_KEY",
+		"AWS_SECRET_ACCESS_KEY", "AWS_SECRET_KEY", "AWS_SESSION_TOKEN",
+		"AWS_SHARED_CREDENTIALS_FILE", "AWS_PROFILE", "AWS_DEFAULT_PROFILE",
+	} {
+		os.Unsetenv(n)
+	}
+
+	dir, err := ioutil.TempDir("", "rexray-ec2-credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	md := &metadataStub{expiration: time.Now().Add(time.Hour)}
+	mdSrv := httptest.NewServer(md)
+	stsSrv := httptest.NewServer(&stsStub{t})
+
+	config := gofig.New()
+	config.Set("aws.credentialsFile", filepath.Join(dir, "credentials"))
+	d := &driver{
+		r:           core.New(config),
+		metadataURL: mdSrv.URL,
+		stsEndpoint: stsSrv.URL,
+	}
+
+	return d, md, config, func() {
+		mdSrv.Close()
+		stsSrv.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestCredentialsChain(t *testing.T) {
+	d, _, config, done := newTestCredentialsDriver(t)
+	defer done()
+
+	creds, err := d.getCredentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.accessKey != "ASIA1" || creds.token != "instanceToken" ||
+		creds.source != "instance role role1" {
+		t.Fatalf("unexpected credentials %+v", creds)
+	}
+
+	if err := ioutil.WriteFile(
+		config.GetString("aws.credentialsFile"), []byte(`
+[default]
+aws_access_key_id = AKIDDEFAULT
+aws_secret_access_key = defaultSecret
+
+[dev]
+aws_access_key_id=AKIDDEV
+aws_secret_access_key=devSecret
+aws_session_token=devToken
+`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if creds, err = d.getCredentials(); err != nil {
+		t.Fatal(err)
+	}
+	if creds.accessKey != "AKIDDEFAULT" || creds.source != "profile default" {
+		t.Fatalf("unexpected credentials %+v", creds)
+	}
+
+	os.Setenv("AWS_PROFILE", "dev")
+	defer os.Unsetenv("AWS_PROFILE")
+	if creds, err = d.getCredentials(); err != nil {
+		t.Fatal(err)
+	}
+	if creds.accessKey != "AKIDDEV" || creds.token != "devToken" {
+		t.Fatalf("unexpected credentials %+v", creds)
+	}
+
+	config.Set("aws.profile", "missing")
+	if _, err = d.getCredentials(); err == nil {
+		t.Fatal("found credentials of a missing profile")
+	}
+	config.Set("aws.profile", "")
+
+	os.Setenv("AWS_ACCESS_KEY_ID", "AKIDENV")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "envSecret")
+	defer os.Unsetenv("AWS_ACCESS_KEY_ID")
+	defer os.Unsetenv("AWS_SECRET_ACCESS_KEY")
+	if creds, err = d.getCredentials(); err != nil {
+		t.Fatal(err)
+	}
+	if creds.accessKey != "AKIDENV" || creds.source != "env" {
+		t.Fatalf("unexpected credentials %+v", creds)
+	}
+
+	config.Set("aws.accessKey", "AKIDCONFIG")
+	config.Set("aws.secretKey", "configSecret")
+	if creds, err = d.getCredentials(); err != nil {
+		t.Fatal(err)
+	}
+	if creds.accessKey != "AKIDCONFIG" || creds.source != "config" ||
+		!creds.expiration.IsZero() {
+		t.Fatalf("unexpected credentials %+v", creds)
+	}
+}
+
+func TestCredentialsNotFound(t *testing.T) {
+	d, _, _, done := newTestCredentialsDriver(t)
+	defer done()
+
+	d.metadataURL = "http://127.0.0.1:1"
+	if _, err := d.getCredentials(); err == nil {
+		t.Fatal("found credentials")
+	}
+}
+
+func TestAssumeRole(t *testing.T) {
+	d, _, config, done := newTestCredentialsDriver(t)
+	defer done()
+
+	config.Set("aws.roleARN", "arn:aws:iam::1:role/r1")
+	config.Set("aws.roleSessionName", "session1")
+	creds, err := d.getCredentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.accessKey != "ASIAROLE" || creds.secretKey != "roleSecret" ||
+		creds.token != "session1" ||
+		!creds.expiration.Equal(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected credentials %+v", creds)
+	}
+
+	config.Set("aws.roleARN", "arn:aws:iam::1:role/r2")
+	if _, err = d.getCredentials(); err == nil {
+		t.Fatal("assumed a denied role")
+	}
+}
+
+func TestCredentialsRefresh(t *testing.T) {
+	d, md, _, done := newTestCredentialsDriver(t)
+	defer done()
+
+	md.expiration = time.Now().Add(time.Minute)
+	var err error
+	if d.creds, err = d.getCredentials(); err != nil {
+		t.Fatal(err)
+	}
+
+	md.expiration = time.Now().Add(time.Hour)
+	if c := d.client(); c.Auth.AccessKey != "ASIA2" {
+		t.Fatalf("credentials not refreshed %s", c.Auth.AccessKey)
+	}
+	if c := d.client(); c.Auth.AccessKey != "ASIA2" {
+		t.Fatalf("unexpected credentials %s", c.Auth.AccessKey)
+	}
+	if md.requests != 2 {
+		t.Fatalf("unexpected metadata requests %d", md.requests)
+	}
+}
+
+func TestSignV4(t *testing.T) {
+	// the get-vanilla case of the AWS Signature Version 4 test suite
+	req, _ := http.NewRequest("GET", "https://example.amazonaws.com/", nil)
+	signV4(req, nil,
+		&credentials{
+			accessKey: "AKIDEXAMPLE",
+			secretKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		},
+		"us-east-1", "service",
+		time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	exp := "AWS4-HMAC-SHA256 " +
+		"Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, " +
+		"Signature=" +
+		"5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if auth := req.Header.Get("Authorization"); auth != exp {
+		t.Fatalf("unexpected authorization %s", auth)
+	}
+}
+
+func TestSTSRegion(t *testing.T) {
+	for host, exp := range map[string]string{
+		"sts.amazonaws.com":           "us-east-1",
+		"sts.eu-west-1.amazonaws.com": "eu-west-1",
+		"127.0.0.1:8080":              "us-west-2",
+	} {
+		if r := stsRegion(host, "us-west-2"); r != exp {
+			t.Errorf("%s: unexpected region %s", host, r)
+		}
+	}
+}
@@ -8,14 +8,17 @@ import (
 	"net/http"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/akutz/gofig"
 	"github.com/akutz/goof"
+	"golang.org/x/net/context"
 
 	"github.com/emccode/rexray/core"
 	"github.com/emccode/rexray/core/errors"
+	"github.com/emccode/rexray/core/waiter"
 	"github.com/goamz/goamz/aws"
 	"github.com/goamz/goamz/ec2"
 )
@@ -26,6 +29,12 @@ const providerName = "ec2"
 type driver struct {
 	instanceDocument *instanceIdentityDocument
 	ec2Instance      *ec2.EC2
+	region           aws.Region
+	creds            *credentials
+	credsLock        sync.Mutex
+	metadataURL      string
+	stsEndpoint      string
+	sysfs            sysfs
 	r                *core.RexRay
 }
 
@@ -49,11 +58,15 @@ func eff(fields goof.Fields) map[string]interface{} {
 
 func init() {
 	core.RegisterDriver(providerName, newDriver)
-	gofig.Register(configRegistration())
+	core.RegisterConfigSchema(configRegistration())
 }
 
 func newDriver() core.Driver {
-	return &driver{}
+	return &driver{
+		metadataURL: defaultMetadataURL,
+		stsEndpoint: defaultSTSEndpoint,
+		sysfs:       sysfsDir("/sys"),
+	}
 }
 
 func (d *driver) Init(r *core.RexRay) error {
@@ -65,18 +78,16 @@ func (d *driver) Init(r *core.RexRay) error {
 		return goof.WithFields(ef(), "error getting instance id doc")
 	}
 
-	auth := aws.Auth{
-		AccessKey: d.r.Config.GetString("aws.accessKey"),
-		SecretKey: d.r.Config.GetString("aws.secretKey"),
-	}
 	region := d.r.Config.GetString("aws.region")
 	if region == "" {
 		region = d.instanceDocument.Region
 	}
-	d.ec2Instance = ec2.New(
-		auth,
-		aws.Regions[region],
-	)
+	d.region = aws.Regions[region]
+
+	if d.creds, err = d.getCredentials(); err != nil {
+		return err
+	}
+	d.ec2Instance = ec2.New(d.creds.auth(), d.region)
 
 	log.WithField("provider", providerName).Info("storage driver initialized")
 
@@ -104,19 +115,38 @@ func (d *driver) Name() string {
 	return providerName
 }
 
-func (d *driver) GetVolumeMapping() ([]*core.BlockDevice, error) {
+// HealthCheck verifies that the driver's credentials are valid by describing
+// the local instance.
+func (d *driver) HealthCheck(ctx context.Context) error {
+	if _, err := d.getInstance(); err != nil {
+		return goof.WithFieldE(
+			"instanceId", d.instanceDocument.InstanceID,
+			"error describing instance", err)
+	}
+	return nil
+}
+
+func (d *driver) GetVolumeMapping(
+	ctx context.Context) ([]*core.BlockDevice, error) {
 	blockDevices, err := d.getBlockDevices(d.instanceDocument.InstanceID)
 	if err != nil {
 		return nil, err
 	}
 
+	nvme, err := nvmeDevices(d.sysfs)
+	if err != nil {
+		return nil, err
+	}
+
 	var BlockDevices []*core.BlockDevice
 	for _, blockDevice := range blockDevices {
+		deviceName := localDeviceName(
+			nvme, blockDevice.EBS.VolumeId, blockDevice.DeviceName)
 		sdBlockDevice := &core.BlockDevice{
 			ProviderName: providerName,
 			InstanceID:   d.instanceDocument.InstanceID,
 			Region:       d.instanceDocument.Region,
-			DeviceName:   blockDevice.DeviceName,
+			DeviceName:   deviceName,
 			VolumeID:     blockDevice.EBS.VolumeId,
 			Status:       blockDevice.EBS.Status,
 		}
@@ -180,7 +210,7 @@ func getTag(server ec2.Instance, key string) string {
 	return ""
 }
 
-func (d *driver) GetInstance() (*core.Instance, error) {
+func (d *driver) GetInstance(ctx context.Context) (*core.Instance, error) {
 
 	server, err := d.getInstance()
 	if err != nil {
@@ -200,7 +230,7 @@ func (d *driver) GetInstance() (*core.Instance, error) {
 
 func (d *driver) getInstance() (ec2.Instance, error) {
 
-	resp, err := d.ec2Instance.DescribeInstances(
+	resp, err := d.client().DescribeInstances(
 		[]string{
 			d.instanceDocument.InstanceID},
 		&ec2.Filter{})
@@ -212,16 +242,17 @@ func (d *driver) getInstance() (ec2.Instance, error) {
 }
 
 func (d *driver) CreateSnapshot(
+	ctx context.Context,
 	runAsync bool,
 	snapshotName, volumeID, description string) ([]*core.Snapshot, error) {
 
-	resp, err := d.ec2Instance.CreateSnapshot(volumeID, description)
+	resp, err := d.client().CreateSnapshot(volumeID, description)
 	if err != nil {
 		return nil, err
 	}
 
 	if snapshotName != "" {
-		_, err := d.ec2Instance.CreateTags(
+		_, err := d.client().CreateTags(
 			[]string{resp.Id}, []ec2.Tag{{"Name", snapshotName}})
 		if err != nil {
 			return nil, err
@@ -229,24 +260,25 @@ func (d *driver) CreateSnapshot(
 	}
 
 	if !runAsync {
-		log.Println("Waiting for snapshot to complete")
-		err = d.waitSnapshotComplete(resp.Snapshot.Id)
+		core.Logger(ctx).Println("Waiting for snapshot to complete")
+		err = d.waitSnapshotComplete(ctx, d.client(), resp.Snapshot.Id)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	snapshot, err := d.GetSnapshot("", resp.Snapshot.Id, "")
+	snapshot, err := d.GetSnapshot(ctx, "", resp.Snapshot.Id, "")
 	if err != nil {
 		return nil, err
 	}
 
-	log.Println("Created Snapshot: " + snapshot[0].SnapshotID)
+	core.Logger(ctx).Println("Created Snapshot: " + snapshot[0].SnapshotID)
 	return snapshot, nil
 
 }
 
 func (d *driver) getSnapshot(
+	api *ec2.EC2,
 	volumeID, snapshotID, snapshotName string) ([]ec2.Snapshot, error) {
 	filter := ec2.NewFilter()
 	if snapshotName != "" {
@@ -264,7 +296,7 @@ func (d *driver) getSnapshot(
 		filter.Add("snapshot-id", snapshotID)
 	}
 
-	resp, err := d.ec2Instance.Snapshots(snapshotList, filter)
+	resp, err := api.Snapshots(snapshotList, filter)
 	if err != nil {
 		return []ec2.Snapshot{}, err
 	}
@@ -273,13 +305,19 @@ func (d *driver) getSnapshot(
 }
 
 func (d *driver) GetSnapshot(
+	ctx context.Context,
 	volumeID, snapshotID, snapshotName string) ([]*core.Snapshot, error) {
 
-	snapshots, err := d.getSnapshot(volumeID, snapshotID, snapshotName)
+	snapshots, err := d.getSnapshot(
+		d.client(), volumeID, snapshotID, snapshotName)
 	if err != nil {
 		return nil, err
 	}
 
+	return newSnapshots(snapshots), nil
+}
+
+func newSnapshots(snapshots []ec2.Snapshot) []*core.Snapshot {
 	var snapshotsInt []*core.Snapshot
 	for _, snapshot := range snapshots {
 		name := getName(snapshot.Tags)
@@ -294,35 +332,35 @@ func (d *driver) GetSnapshot(
 		}
 		snapshotsInt = append(snapshotsInt, snapshotSD)
 	}
-
-	// log.Println("Got Snapshots: " + fmt.Sprintf("%+v", snapshotsInt))
-	return snapshotsInt, nil
+	return snapshotsInt
 }
 
-func (d *driver) RemoveSnapshot(snapshotID string) error {
-	_, err := d.ec2Instance.DeleteSnapshots([]string{snapshotID})
+func (d *driver) RemoveSnapshot(ctx context.Context, snapshotID string) error {
+	_, err := d.client().DeleteSnapshots([]string{snapshotID})
 	if err != nil {
 		return err
 	}
 
-	log.Println("Removed Snapshot: " + snapshotID)
+	core.Logger(ctx).Println("Removed Snapshot: " + snapshotID)
 	return nil
 }
 
-func (d *driver) GetDeviceNextAvailable() (string, error) {
+func (d *driver) GetDeviceNextAvailable(ctx context.Context) (string, error) {
 	letters := []string{
 		"a", "b", "c", "d", "e", "f", "g", "h",
 		"i", "j", "k", "l", "m", "n", "o", "p"}
 
 	blockDeviceNames := make(map[string]bool)
 
-	blockDeviceMapping, err := d.GetVolumeMapping()
+	// the device names with which the volumes were attached are reserved
+	// even if the volumes are exposed as NVMe devices
+	blockDeviceMapping, err := d.getBlockDevices(d.instanceDocument.InstanceID)
 	if err != nil {
 		return "", err
 	}
 
 	for _, blockDevice := range blockDeviceMapping {
-		re, _ := regexp.Compile(`^/dev/xvd([a-z])`)
+		re, _ := regexp.Compile(`^/dev/(?:xvd|sd)([a-z])`)
 		res := re.FindStringSubmatch(blockDevice.DeviceName)
 		if len(res) > 0 {
 			blockDeviceNames[res[1]] = true
@@ -345,7 +383,7 @@ func (d *driver) GetDeviceNextAvailable() (string, error) {
 	for _, letter := range letters {
 		if !blockDeviceNames[letter] {
 			nextDeviceName := "/dev/xvd" + letter
-			log.Println("Got next device name: " + nextDeviceName)
+			core.Logger(ctx).Println("Got next device name: " + nextDeviceName)
 			return nextDeviceName, nil
 		}
 	}
@@ -373,10 +411,11 @@ func getLocalDevices() (deviceNames []string, err error) {
 }
 
 func (d *driver) CreateVolume(
+	ctx context.Context,
 	runAsync bool, volumeName, volumeID, snapshotID, volumeType string,
 	IOPS, size int64, availabilityZone string) (*core.Volume, error) {
 
-	volumes, err := d.GetVolume("", volumeName)
+	volumes, err := d.GetVolume(ctx, "", volumeName)
 	if err != nil {
 		return nil, err
 	}
@@ -386,14 +425,14 @@ func (d *driver) CreateVolume(
 	}
 
 	resp, err := d.createVolume(
-		runAsync, volumeName, volumeID, snapshotID, volumeType,
+		ctx, runAsync, volumeName, volumeID, snapshotID, volumeType,
 		IOPS, size, availabilityZone)
 
 	if err != nil {
 		return nil, err
 	}
 
-	volumes, err = d.GetVolume(resp.VolumeId, "")
+	volumes, err = d.GetVolume(ctx, resp.VolumeId, "")
 	if err != nil {
 		return nil, err
 	}
@@ -404,6 +443,7 @@ func (d *driver) CreateVolume(
 }
 
 func (d *driver) createVolume(
+	ctx context.Context,
 	runAsync bool, volumeName, volumeID, snapshotID, volumeType string,
 	IOPS, size int64,
 	availabilityZone string) (*ec2.CreateVolumeResp, error) {
@@ -412,7 +452,21 @@ func (d *driver) createVolume(
 		return &ec2.CreateVolumeResp{}, errors.ErrRunAsyncFromVolume
 	}
 
-	var err error
+	// the IOPS of a volume that is cloned may be the baseline IOPS of a
+	// volume type that does not have provisioned IOPS, ex. gp2
+	if volumeID != "" && !supportsIOPS(volumeType) {
+		IOPS = 0
+	}
+
+	if err := validateVolume(volumeType, IOPS, size,
+		volumeID != "" || snapshotID != ""); err != nil {
+		return &ec2.CreateVolumeResp{}, err
+	}
+
+	encrypted, kmsKeyID, err := d.volumeEncryption(ctx)
+	if err != nil {
+		return &ec2.CreateVolumeResp{}, err
+	}
 
 	var server ec2.Instance
 	if server, err = d.getInstance(); err != nil {
@@ -421,7 +475,7 @@ func (d *driver) createVolume(
 
 	if volumeID != "" {
 		if snapshotID, err = d.createVolumeCreateSnapshot(
-			volumeID, snapshotID); err != nil {
+			ctx, volumeID, snapshotID); err != nil {
 			return &ec2.CreateVolumeResp{}, err
 		}
 	}
@@ -434,10 +488,12 @@ func (d *driver) createVolume(
 		AvailZone:  availabilityZone,
 		VolumeType: volumeType,
 		IOPS:       IOPS,
+		Encrypted:  encrypted,
 	}
 
 	var resp *ec2.CreateVolumeResp
-	if resp, err = d.createVolumeCreateVolume(options); err != nil {
+	if resp, err = d.createVolumeCreateVolume(
+		ctx, options, kmsKeyID); err != nil {
 		return &ec2.CreateVolumeResp{}, err
 	}
 
@@ -446,7 +502,7 @@ func (d *driver) createVolume(
 	}
 
 	if err = d.createVolumeWait(
-		runAsync, snapshotID, volumeID, resp); err != nil {
+		ctx, runAsync, snapshotID, volumeID, resp); err != nil {
 		return &ec2.CreateVolumeResp{}, err
 	}
 
@@ -454,13 +510,14 @@ func (d *driver) createVolume(
 }
 
 func (d *driver) createVolumeCreateSnapshot(
+	ctx context.Context,
 	volumeID string, snapshotID string) (string, error) {
 
 	var err error
 	var snapshots []*core.Snapshot
 
 	if snapshots, err = d.CreateSnapshot(
-		true, fmt.Sprintf("temp-%v", volumeID),
+		ctx, true, fmt.Sprintf("temp-%v", volumeID),
 		volumeID, "created for createVolume"); err != nil {
 		return "", err
 	}
@@ -476,18 +533,29 @@ func (d *driver) createVolumeEnsureAvailabilityZone(
 }
 
 func (d *driver) createVolumeCreateVolume(
-	options *ec2.CreateVolume) (resp *ec2.CreateVolumeResp, err error) {
-	for {
-		resp, err = d.ec2Instance.CreateVolume(options)
-		if err != nil {
-			if err.Error() ==
-				"Snapshot is in invalid state - pending (IncorrectState)" {
-				time.Sleep(1 * time.Second)
-				continue
+	ctx context.Context,
+	options *ec2.CreateVolume,
+	kmsKeyID string) (resp *ec2.CreateVolumeResp, err error) {
+	err = waiter.Until(ctx,
+		waiter.NewOptions(d.r.Config, waiter.OpCreateVolume),
+		func() (bool, error) {
+			var createErr error
+			if kmsKeyID != "" {
+				resp, createErr = d.createVolumeKMS(options, kmsKeyID)
+			} else {
+				resp, createErr = d.client().CreateVolume(options)
 			}
-			return nil, err
-		}
-		break
+			if createErr != nil {
+				if createErr.Error() ==
+					"Snapshot is in invalid state - pending (IncorrectState)" {
+					return false, nil
+				}
+				return false, createErr
+			}
+			return true, nil
+		})
+	if err != nil {
+		return nil, err
 	}
 	return
 }
@@ -497,25 +565,26 @@ func (d *driver) createVolumeCreateTags(
 	if volumeName == "" {
 		return
 	}
-	_, err = d.ec2Instance.CreateTags(
+	_, err = d.client().CreateTags(
 		[]string{resp.VolumeId}, []ec2.Tag{{"Name", volumeName}})
 
 	return
 }
 
 func (d *driver) createVolumeWait(
+	ctx context.Context,
 	runAsync bool, snapshotID, volumeID string,
 	resp *ec2.CreateVolumeResp) (err error) {
 	if runAsync {
 		return
 	}
-	log.Println("Waiting for volume creation to complete")
-	if err = d.waitVolumeComplete(resp.VolumeId); err != nil {
+	core.Logger(ctx).Println("Waiting for volume creation to complete")
+	if err = d.waitVolumeComplete(ctx, resp.VolumeId); err != nil {
 		return
 	}
 
 	if volumeID != "" {
-		if err = d.RemoveSnapshot(snapshotID); err != nil {
+		if err = d.RemoveSnapshot(ctx, snapshotID); err != nil {
 			return
 		}
 	}
@@ -536,7 +605,7 @@ func (d *driver) getVolume(
 		volumeList = append(volumeList, volumeID)
 	}
 
-	resp, err := d.ec2Instance.Volumes(volumeList, filter)
+	resp, err := d.client().Volumes(volumeList, filter)
 	if err != nil {
 		return []ec2.Volume{}, err
 	}
@@ -554,6 +623,7 @@ func getName(tags []ec2.Tag) string {
 }
 
 func (d *driver) GetVolume(
+	ctx context.Context,
 	volumeID, volumeName string) ([]*core.Volume, error) {
 
 	volumes, err := d.getVolume(volumeID, volumeName)
@@ -561,14 +631,26 @@ func (d *driver) GetVolume(
 		return []*core.Volume{}, err
 	}
 
+	var nvme map[string]string
+
 	var volumesSD []*core.Volume
 	for _, volume := range volumes {
 		var attachmentsSD []*core.VolumeAttachment
 		for _, attachment := range volume.Attachments {
+			deviceName := attachment.Device
+			if attachment.InstanceId == d.instanceDocument.InstanceID {
+				if nvme == nil {
+					if nvme, err = nvmeDevices(d.sysfs); err != nil {
+						return []*core.Volume{}, err
+					}
+				}
+				deviceName = localDeviceName(
+					nvme, attachment.VolumeId, deviceName)
+			}
 			attachmentSD := &core.VolumeAttachment{
 				VolumeID:   attachment.VolumeId,
 				InstanceID: attachment.InstanceId,
-				DeviceName: attachment.Device,
+				DeviceName: deviceName,
 				Status:     attachment.Status,
 			}
 			attachmentsSD = append(attachmentsSD, attachmentSD)
@@ -584,6 +666,7 @@ func (d *driver) GetVolume(
 			VolumeType:       volume.VolumeType,
 			IOPS:             volume.IOPS,
 			Size:             volume.Size,
+			Encrypted:        volume.Encrypted,
 			Attachments:      attachmentsSD,
 		}
 		volumesSD = append(volumesSD, volumeSD)
@@ -593,13 +676,14 @@ func (d *driver) GetVolume(
 }
 
 func (d *driver) GetVolumeAttach(
+	ctx context.Context,
 	volumeID, instanceID string) ([]*core.VolumeAttachment, error) {
 
 	if volumeID == "" {
 		return []*core.VolumeAttachment{}, errors.ErrMissingVolumeID
 	}
 
-	volumes, err := d.GetVolume(volumeID, "")
+	volumes, err := d.GetVolume(ctx, volumeID, "")
 	if err != nil {
 		return []*core.VolumeAttachment{}, err
 	}
@@ -618,103 +702,106 @@ func (d *driver) GetVolumeAttach(
 	return volumes[0].Attachments, nil
 }
 
-func (d *driver) waitSnapshotComplete(snapshotID string) error {
-	for {
-
-		snapshots, err := d.getSnapshot("", snapshotID, "")
-		if err != nil {
-			return err
-		}
-
-		snapshot := snapshots[0]
-		if snapshot.Status == "completed" {
-			break
-		}
-		time.Sleep(1 * time.Second)
-	}
-
-	return nil
+func (d *driver) waitSnapshotComplete(
+	ctx context.Context, api *ec2.EC2, snapshotID string) error {
+	return waiter.Until(ctx,
+		waiter.NewOptions(d.r.Config, waiter.OpCreateSnapshot),
+		func() (bool, error) {
+			snapshots, err := d.getSnapshot(api, "", snapshotID, "")
+			if err != nil {
+				return false, err
+			}
+			return snapshots[0].Status == "completed", nil
+		})
 }
 
-func (d *driver) waitVolumeComplete(volumeID string) error {
+func (d *driver) waitVolumeComplete(
+	ctx context.Context, volumeID string) error {
 	if volumeID == "" {
 		return errors.ErrMissingVolumeID
 	}
 
-	for {
-		volumes, err := d.getVolume(volumeID, "")
-		if err != nil {
-			return err
-		}
-
-		if volumes[0].Status == "available" {
-			break
-		}
-		time.Sleep(1 * time.Second)
-	}
-
-	return nil
+	return waiter.Until(ctx,
+		waiter.NewOptions(d.r.Config, waiter.OpCreateVolume),
+		func() (bool, error) {
+			volumes, err := d.getVolume(volumeID, "")
+			if err != nil {
+				return false, err
+			}
+			return volumes[0].Status == "available", nil
+		})
 }
 
-func (d *driver) waitVolumeAttach(volumeID, instanceID string) error {
+func (d *driver) waitVolumeAttach(
+	ctx context.Context, volumeID, instanceID string) error {
 	if volumeID == "" {
 		return errors.ErrMissingVolumeID
 	}
 
-	for {
-		volume, err := d.GetVolumeAttach(volumeID, instanceID)
-		if err != nil {
-			return err
-		}
-
-		if len(volume) == 0 {
-			break
-		}
+	return waiter.Until(ctx,
+		waiter.NewOptions(d.r.Config, waiter.OpAttach),
+		func() (bool, error) {
+			volume, err := d.GetVolumeAttach(ctx, volumeID, instanceID)
+			if err != nil {
+				return false, err
+			}
+			return len(volume) == 0 || volume[0].Status == "attached", nil
+		})
+}
 
-		if volume[0].Status == "attached" {
-			break
-		}
-		time.Sleep(1 * time.Second)
+// waitNVMeDevice waits for the NVMe device of a volume that was attached to
+// the instance to appear. The root volume of a Nitro instance is an NVMe
+// device, so the wait is skipped if the instance does not have any.
+func (d *driver) waitNVMeDevice(ctx context.Context, volumeID string) error {
+	nvme, err := nvmeDevices(d.sysfs)
+	if err != nil || len(nvme) == 0 {
+		return err
 	}
 
-	return nil
+	return waiter.Until(ctx,
+		waiter.NewOptions(d.r.Config, waiter.OpAttach),
+		func() (bool, error) {
+			nvme, err := nvmeDevices(d.sysfs)
+			if err != nil {
+				return false, err
+			}
+			_, ok := nvme[volumeID]
+			return ok, nil
+		})
 }
 
-func (d *driver) waitVolumeDetach(volumeID string) error {
+func (d *driver) waitVolumeDetach(ctx context.Context, volumeID string) error {
 	if volumeID == "" {
 		return errors.ErrMissingVolumeID
 	}
 
-	for {
-		volume, err := d.GetVolumeAttach(volumeID, "")
-		if err != nil {
-			return err
-		}
-
-		if len(volume) == 0 {
-			break
-		}
-		time.Sleep(1 * time.Second)
-	}
-
-	return nil
+	return waiter.Until(ctx,
+		waiter.NewOptions(d.r.Config, waiter.OpDetach),
+		func() (bool, error) {
+			volume, err := d.GetVolumeAttach(ctx, volumeID, "")
+			if err != nil {
+				return false, err
+			}
+			return len(volume) == 0, nil
+		})
 }
 
-func (d *driver) RemoveVolume(volumeID string) error {
+func (d *driver) RemoveVolume(ctx context.Context, volumeID string) error {
 	if volumeID == "" {
 		return errors.ErrMissingVolumeID
 	}
 
-	_, err := d.ec2Instance.DeleteVolume(volumeID)
+	_, err := d.client().DeleteVolume(volumeID)
 	if err != nil {
 		return err
 	}
 
-	log.Println("Deleted Volume: " + volumeID)
+	core.Logger(ctx).Println("Deleted Volume: " + volumeID)
 	return nil
 }
 
 func (d *driver) AttachVolume(
+	ctx context.Context,
 	runAsync bool,
 	volumeID, instanceID string, force bool) ([]*core.VolumeAttachment, error) {
 
@@ -722,18 +809,18 @@ func (d *driver) AttachVolume(
 		return nil, errors.ErrMissingVolumeID
 	}
 
-	nextDeviceName, err := d.GetDeviceNextAvailable()
+	nextDeviceName, err := d.GetDeviceNextAvailable(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	if force {
-		if err := d.DetachVolume(false, volumeID, "", true); err != nil {
+		if err := d.DetachVolume(ctx, false, volumeID, "", true); err != nil {
 			return nil, err
 		}
 	}
 
-	_, err = d.ec2Instance.AttachVolume(
+	_, err = d.client().AttachVolume(
 		volumeID, instanceID, nextDeviceName)
 
 	if err != nil {
@@ -741,24 +828,30 @@ func (d *driver) AttachVolume(
 	}
 
 	if !runAsync {
-		log.Println("Waiting for volume attachment to complete")
-		err = d.waitVolumeAttach(volumeID, instanceID)
+		core.Logger(ctx).Println("Waiting for volume attachment to complete")
+		err = d.waitVolumeAttach(ctx, volumeID, instanceID)
 		if err != nil {
 			return nil, err
 		}
+		if instanceID == d.instanceDocument.InstanceID {
+			if err = d.waitNVMeDevice(ctx, volumeID); err != nil {
+				return nil, err
+			}
+		}
 	}
 
-	volumeAttachment, err := d.GetVolumeAttach(volumeID, instanceID)
+	volumeAttachment, err := d.GetVolumeAttach(ctx, volumeID, instanceID)
 	if err != nil {
 		return nil, err
 	}
 
-	log.Println(fmt.Sprintf(
+	core.Logger(ctx).Println(fmt.Sprintf(
 		"Attached volume %s to instance %s", volumeID, instanceID))
 	return volumeAttachment, nil
 }
 
 func (d *driver) DetachVolume(
+	ctx context.Context,
 	runAsync bool,
 	volumeID, blank string, force bool) error {
 
@@ -775,24 +868,24 @@ func (d *driver) DetachVolume(
 		return nil
 	}
 
-	_, err = d.ec2Instance.DetachVolume(volumeID, force)
+	_, err = d.client().DetachVolume(volumeID, force)
 	if err != nil {
 		return err
 	}
 
 	if !runAsync {
-		log.Println("Waiting for volume detachment to complete")
-		err = d.waitVolumeDetach(volumeID)
+		core.Logger(ctx).Println("Waiting for volume detachment to complete")
+		err = d.waitVolumeDetach(ctx, volumeID)
 		if err != nil {
 			return err
 		}
 	}
 
-	log.Println("Detached volume", volumeID)
+	core.Logger(ctx).Println("Detached volume", volumeID)
 	return nil
 }
 
-func (d *driver) CopySnapshot(runAsync bool,
+func (d *driver) CopySnapshot(ctx context.Context, runAsync bool,
 	volumeID, snapshotID, snapshotName, destinationSnapshotName,
 	destinationRegion string) (*core.Snapshot, error) {
 
@@ -800,7 +893,8 @@ func (d *driver) CopySnapshot(runAsync bool,
 		return nil, goof.New("Missing volumeID, snapshotID, or snapshotName")
 	}
 
-	snapshots, err := d.getSnapshot(volumeID, snapshotID, snapshotName)
+	snapshots, err := d.getSnapshot(
+		d.client(), volumeID, snapshotID, snapshotName)
 	if err != nil {
 		return nil, err
 	}
@@ -813,34 +907,26 @@ func (d *driver) CopySnapshot(runAsync bool,
 
 	snapshotID = snapshots[0].Id
 
+	src := d.client()
+
 	options := &ec2.CopySnapshot{
-		SourceRegion:      d.ec2Instance.Region.Name,
+		SourceRegion:      src.Region.Name,
 		DestinationRegion: destinationRegion,
 		SourceSnapshotId:  snapshotID,
 		Description: fmt.Sprintf("[Copied %s from %s]",
-			snapshotID, d.ec2Instance.Region.Name),
+			snapshotID, src.Region.Name),
 	}
 	resp := &ec2.CopySnapshotResp{}
 
-	auth := aws.Auth{
-		AccessKey: d.r.Config.GetString("aws.accessKey"),
-		SecretKey: d.r.Config.GetString("aws.secretKey")}
-	destec2Instance := ec2.New(
-		auth,
-		aws.Regions[destinationRegion],
-	)
-
-	origec2Instance := d.ec2Instance
-	d.ec2Instance = destec2Instance
-	defer func() { d.ec2Instance = origec2Instance }()
+	dest := ec2.New(src.Auth, aws.Regions[destinationRegion])
 
-	resp, err = d.ec2Instance.CopySnapshot(options)
+	resp, err = dest.CopySnapshot(options)
 	if err != nil {
 		return nil, err
 	}
 
 	if destinationSnapshotName != "" {
-		_, err := d.ec2Instance.CreateTags(
+		_, err := dest.CreateTags(
 			[]string{resp.SnapshotId},
 			[]ec2.Tag{{"Name", destinationSnapshotName}})
 
@@ -850,25 +936,42 @@ func (d *driver) CopySnapshot(runAsync bool,
 	}
 
 	if !runAsync {
-		log.Println("Waiting for snapshot copy to complete")
-		err = d.waitSnapshotComplete(resp.SnapshotId)
+		core.Logger(ctx).Println("Waiting for snapshot copy to complete")
+		err = d.waitSnapshotComplete(ctx, dest, resp.SnapshotId)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	snapshot, err := d.GetSnapshot("", resp.SnapshotId, "")
+	snapshots, err = d.getSnapshot(dest, "", resp.SnapshotId, "")
 	if err != nil {
 		return nil, err
 	}
 
-	return snapshot[0], nil
+	return newSnapshots(snapshots)[0], nil
+}
+
+func (d *driver) CloneVolume(
+	ctx context.Context,
+	sourceVolumeID, newName string,
+	opts core.VolumeOpts) (*core.Volume, error) {
+	return core.CloneVolumeFromSnapshot(ctx, d, sourceVolumeID, newName, opts)
 }
 
-func configRegistration() *gofig.Registration {
-	r := gofig.NewRegistration("Amazon EC2")
+func configRegistration() *core.ConfigSchema {
+	r := core.NewConfigSchema("Amazon EC2", providerName)
 	r.Key(gofig.String, "", "", "", "aws.accessKey")
 	r.Key(gofig.String, "", "", "", "aws.secretKey")
 	r.Key(gofig.String, "", "", "", "aws.region")
+	r.Key(gofig.String, "", "", "", "aws.profile")
+	r.Key(gofig.String, "", "", "", "aws.credentialsFile")
+	r.Key(gofig.String, "", "", "", "aws.roleARN")
+	r.Key(gofig.String, "", "rexray", "", "aws.roleSessionName")
+	r.Key(gofig.String, "", "", "", "aws.externalID")
+	r.Key(gofig.String, "", "", "", "aws.stsEndpoint")
+	r.Key(gofig.Bool, "", false, "", "aws.encrypted")
+	r.Key(gofig.String, "", "", "", "aws.kmsKeyID")
+	r.Secret("aws.secretKey")
+	r.Secret("aws.externalID")
 	return r
 }
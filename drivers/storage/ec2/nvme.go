@@ -0,0 +1,93 @@
+package ec2
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ebsNVMeModel is the model of the NVMe controllers of the EBS volumes that
+// are attached to Nitro instances.
+const ebsNVMeModel = "Amazon Elastic Block Store"
+
+// sysfs reads the files of the sysfs filesystem.
+type sysfs interface {
+	ReadDir(path string) ([]os.FileInfo, error)
+	ReadFile(path string) ([]byte, error)
+}
+
+// sysfsDir is a sysfs filesystem mounted at a directory, ex. /sys.
+type sysfsDir string
+
+func (d sysfsDir) ReadDir(path string) ([]os.FileInfo, error) {
+	return ioutil.ReadDir(filepath.Join(string(d), path))
+}
+
+func (d sysfsDir) ReadFile(path string) ([]byte, error) {
+	return ioutil.ReadFile(filepath.Join(string(d), path))
+}
+
+var nvmeNamespaceRX = regexp.MustCompile(`^nvme\d+n\d+$`)
+
+// nvmeDevices returns the NVMe block devices of the EBS volumes that are
+// attached to the instance, by volume ID. The serial number of the NVMe
+// controller of an EBS volume is the volume's ID without the hyphen, ex.
+// vol0123456789abcdef0. The map is empty if the instance does not use NVMe.
+func nvmeDevices(fs sysfs) (map[string]string, error) {
+	devices := map[string]string{}
+
+	controllers, err := fs.ReadDir("class/nvme")
+	if err != nil {
+		if os.IsNotExist(err) {
+			return devices, nil
+		}
+		return nil, err
+	}
+
+	for _, c := range controllers {
+		cp := filepath.Join("class/nvme", c.Name())
+
+		model, err := fs.ReadFile(filepath.Join(cp, "model"))
+		if err != nil ||
+			strings.TrimSpace(string(model)) != ebsNVMeModel {
+			continue
+		}
+
+		serial, err := fs.ReadFile(filepath.Join(cp, "serial"))
+		if err != nil {
+			continue
+		}
+		volumeID := strings.TrimSpace(string(serial))
+		if !strings.HasPrefix(volumeID, "vol") {
+			continue
+		}
+		volumeID = "vol-" + strings.TrimPrefix(
+			strings.TrimPrefix(volumeID, "vol"), "-")
+
+		namespaces, err := fs.ReadDir(cp)
+		if err != nil {
+			return nil, err
+		}
+		for _, ns := range namespaces {
+			if nvmeNamespaceRX.MatchString(ns.Name()) {
+				devices[volumeID] = "/dev/" + ns.Name()
+				break
+			}
+		}
+	}
+
+	return devices, nil
+}
+
+// localDeviceName returns the name of the local device of a volume that is
+// attached to the instance with the device name; the volume's NVMe device if
+// the instance exposes the volume with NVMe, or else the device name.
+func localDeviceName(
+	nvme map[string]string, volumeID, deviceName string) string {
+	if d, ok := nvme[volumeID]; ok {
+		return d
+	}
+	return deviceName
+}
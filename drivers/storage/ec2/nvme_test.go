@@ -0,0 +1,77 @@
+package ec2
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newFakeSysfs returns a sysfs tree with the NVMe controllers, by name, and
+// their model and serial number.
+func newFakeSysfs(
+	t *testing.T, controllers map[string][2]string) (sysfsDir, func()) {
+
+	root, err := ioutil.TempDir("", "rexray-ec2-sysfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, c := range controllers {
+		cp := filepath.Join(root, "class/nvme", name)
+		if err := os.MkdirAll(filepath.Join(cp, name+"n1"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(
+			filepath.Join(cp, "model"), []byte(c[0]+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(
+			filepath.Join(cp, "serial"), []byte(c[1]+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return sysfsDir(root), func() { os.RemoveAll(root) }
+}
+
+func TestNVMeDevices(t *testing.T) {
+	fs, done := newFakeSysfs(t, map[string][2]string{
+		"nvme0": {ebsNVMeModel, "vol0123456789abcdef0"},
+		"nvme1": {ebsNVMeModel, "vol-0fedcba9876543210"},
+		"nvme2": {"Amazon EC2 NVMe Instance Storage", "AWS1234567890"},
+	})
+	defer done()
+
+	devices, err := nvmeDevices(fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(devices) != 2 ||
+		devices["vol-0123456789abcdef0"] != "/dev/nvme0n1" ||
+		devices["vol-0fedcba9876543210"] != "/dev/nvme1n1" {
+		t.Fatalf("unexpected devices %v", devices)
+	}
+
+	if d := localDeviceName(
+		devices, "vol-0123456789abcdef0", "/dev/xvdf"); d != "/dev/nvme0n1" {
+		t.Fatalf("unexpected device %s", d)
+	}
+	if d := localDeviceName(devices, "vol-1", "/dev/xvdg"); d != "/dev/xvdg" {
+		t.Fatalf("unexpected device %s", d)
+	}
+}
+
+func TestNVMeDevicesNotNitro(t *testing.T) {
+	fs, done := newFakeSysfs(t, nil)
+	defer done()
+
+	devices, err := nvmeDevices(fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 0 {
+		t.Fatalf("unexpected devices %v", devices)
+	}
+}
@@ -0,0 +1,210 @@
+package ec2
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/akutz/goof"
+)
+
+const defaultSTSEndpoint = "https://sts.amazonaws.com"
+
+// stsClient is the HTTP client of the AWS Security Token Service.
+var stsClient = &http.Client{Timeout: 30 * time.Second}
+
+type stsCredentials struct {
+	AccessKeyID     string    `xml:"AccessKeyId"`
+	SecretAccessKey string    `xml:"SecretAccessKey"`
+	SessionToken    string    `xml:"SessionToken"`
+	Expiration      time.Time `xml:"Expiration"`
+}
+
+type assumeRoleResponse struct {
+	Credentials stsCredentials `xml:"AssumeRoleResult>Credentials"`
+}
+
+type stsErrorResponse struct {
+	Code    string `xml:"Error>Code"`
+	Message string `xml:"Error>Message"`
+}
+
+// assumeRole returns the temporary credentials of the role, which are
+// requested from STS with the credentials creds. The session name is
+// aws.roleSessionName, and the external ID required by the role's trust
+// policy, if any, is aws.externalID.
+func (d *driver) assumeRole(
+	creds *credentials, roleARN string) (*credentials, error) {
+
+	sessionName := d.r.Config.GetString("aws.roleSessionName")
+	if sessionName == "" {
+		sessionName = "rexray"
+	}
+
+	endpoint := d.r.Config.GetString("aws.stsEndpoint")
+	if endpoint == "" {
+		endpoint = d.stsEndpoint
+	}
+
+	fields := eff(goof.Fields{
+		"roleARN":     roleARN,
+		"sessionName": sessionName,
+		"endpoint":    endpoint,
+		"source":      creds.source,
+	})
+
+	params := url.Values{
+		"Action":          {"AssumeRole"},
+		"Version":         {"2011-06-15"},
+		"RoleArn":         {roleARN},
+		"RoleSessionName": {sessionName},
+	}
+	if externalID := d.r.Config.GetString("aws.externalID"); externalID != "" {
+		params.Set("ExternalId", externalID)
+	}
+	body := params.Encode()
+
+	req, err := http.NewRequest("POST", endpoint, strings.NewReader(body))
+	if err != nil {
+		return nil, goof.WithFieldsE(fields, "invalid sts endpoint", err)
+	}
+	req.Header.Set(
+		"Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	signV4(req, []byte(body), creds,
+		stsRegion(req.URL.Host, d.region.Name), "sts", time.Now())
+
+	res, err := stsClient.Do(req)
+	if err != nil {
+		return nil, goof.WithFieldsE(fields, "error assuming role", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		fields["status"] = res.StatusCode
+		var errRes stsErrorResponse
+		if err := xml.NewDecoder(res.Body).Decode(&errRes); err == nil {
+			fields["code"] = errRes.Code
+			fields["message"] = errRes.Message
+		}
+		return nil, goof.WithFields(fields, "error assuming role")
+	}
+
+	var arRes assumeRoleResponse
+	if err := xml.NewDecoder(res.Body).Decode(&arRes); err != nil {
+		return nil, goof.WithFieldsE(
+			fields, "error decoding assumed role credentials", err)
+	}
+
+	c := arRes.Credentials
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return nil, goof.WithFields(fields, "no assumed role credentials")
+	}
+
+	return &credentials{
+		accessKey:  c.AccessKeyID,
+		secretKey:  c.SecretAccessKey,
+		token:      c.SessionToken,
+		expiration: c.Expiration,
+		source:     "role " + roleARN,
+	}, nil
+}
+
+// stsRegion returns the region of the STS endpoint. The global endpoint is
+// in us-east-1; regional endpoints, ex. sts.eu-west-1.amazonaws.com, are in
+// their region, and any other endpoint is assumed to be in the region of the
+// driver.
+func stsRegion(host, region string) string {
+	host = strings.Split(host, ":")[0]
+	if host == "sts.amazonaws.com" {
+		return "us-east-1"
+	}
+	parts := strings.Split(host, ".")
+	if len(parts) >= 4 && parts[0] == "sts" {
+		return parts[1]
+	}
+	if region == "" {
+		return "us-east-1"
+	}
+	return region
+}
+
+// signV4 signs the request with AWS Signature Version 4.
+func signV4(
+	req *http.Request, body []byte, creds *credentials,
+	region, service string, now time.Time) {
+
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.token != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.token)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	var names []string
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders string
+	for _, k := range names {
+		canonicalHeaders += k + ":" + headers[k] + "\n"
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		strings.Replace(req.URL.Query().Encode(), "+", "%20", -1),
+		canonicalHeaders,
+		signedHeaders,
+		sha256Hex(body),
+	}, "\n")
+
+	scope := strings.Join(
+		[]string{date, region, service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+creds.secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.accessKey, scope, signedHeaders, signature))
+}
+
+func sha256Hex(b []byte) string {
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,241 @@
+package ec2
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akutz/goof"
+	"golang.org/x/net/context"
+
+	"github.com/emccode/rexray/core"
+	"github.com/goamz/goamz/ec2"
+)
+
+// volumeTypeLimits are the limits of the size, in GB, and IOPS of an EBS
+// volume type.
+type volumeTypeLimits struct {
+	minSize int64
+	maxSize int64
+
+	// minIOPS is zero for the volume types that do not have provisioned IOPS.
+	minIOPS int64
+	maxIOPS int64
+
+	// maxIOPSPerGB is the maximum ratio of the provisioned IOPS to the size.
+	maxIOPSPerGB int64
+}
+
+// volumeTypes are the EBS volume types, by name.
+var volumeTypes = map[string]*volumeTypeLimits{
+	"standard": {minSize: 1, maxSize: 1024},
+	"gp2":      {minSize: 1, maxSize: 16384},
+	"io1": {
+		minSize:      4,
+		maxSize:      16384,
+		minIOPS:      100,
+		maxIOPS:      20000,
+		maxIOPSPerGB: 50,
+	},
+	"st1": {minSize: 500, maxSize: 16384},
+	"sc1": {minSize: 500, maxSize: 16384},
+}
+
+// validateVolume returns an error if the combination of the volume type, IOPS,
+// and size, in GB, is not valid for an EBS volume. A volume created from a
+// snapshot may omit the size, in which case the volume has the size of the
+// snapshot.
+func validateVolume(
+	volumeType string, IOPS, size int64, fromSnapshot bool) error {
+
+	fields := eff(goof.Fields{
+		"volumeType": volumeType,
+		"iops":       IOPS,
+		"size":       size,
+	})
+
+	if volumeType == "" {
+		volumeType = "standard"
+	}
+
+	limits, ok := volumeTypes[volumeType]
+	if !ok {
+		return goof.WithFields(fields,
+			"invalid volume type; must be standard, gp2, io1, st1, or sc1")
+	}
+
+	if size == 0 && !fromSnapshot {
+		return goof.WithFields(fields, "missing volume size")
+	}
+
+	if size != 0 && (size < limits.minSize || size > limits.maxSize) {
+		fields["minSize"] = limits.minSize
+		fields["maxSize"] = limits.maxSize
+		return goof.WithFields(fields, "invalid volume size for volume type")
+	}
+
+	if limits.minIOPS == 0 {
+		if IOPS != 0 {
+			return goof.WithFields(fields,
+				"volume type does not support provisioned iops")
+		}
+		return nil
+	}
+
+	if IOPS < limits.minIOPS || IOPS > limits.maxIOPS {
+		fields["minIOPS"] = limits.minIOPS
+		fields["maxIOPS"] = limits.maxIOPS
+		return goof.WithFields(fields, "invalid iops for volume type")
+	}
+
+	if size != 0 && IOPS > size*limits.maxIOPSPerGB {
+		fields["maxIOPSPerGB"] = limits.maxIOPSPerGB
+		return goof.WithFields(fields, "iops exceed the maximum for volume size")
+	}
+
+	return nil
+}
+
+// supportsIOPS returns a flag indicating whether or not the volume type has
+// provisioned IOPS.
+func supportsIOPS(volumeType string) bool {
+	limits, ok := volumeTypes[volumeType]
+	return ok && limits.minIOPS > 0
+}
+
+// volumeEncryption returns the encryption of a new volume; the volume's
+// encrypted and kmsKeyID options, or else the aws.encrypted and aws.kmsKeyID
+// configuration properties. Specifying a KMS key implies encryption, but the
+// encrypted option disables the configured default key.
+func (d *driver) volumeEncryption(
+	ctx context.Context) (encrypted bool, kmsKeyID string, err error) {
+
+	encrypted = d.r.Config.GetBool("aws.encrypted")
+	kmsKeyID = d.r.Config.GetString("aws.kmsKeyID")
+
+	v, optEncrypted := core.GetVolumeOpt(ctx, "encrypted")
+	if optEncrypted {
+		if encrypted, err = strconv.ParseBool(v); err != nil {
+			return false, "", goof.WithFieldsE(
+				eff(goof.Fields{"encrypted": v}), "invalid encrypted option", err)
+		}
+		if !encrypted {
+			kmsKeyID = ""
+		}
+	}
+
+	if v, ok := core.GetVolumeOpt(ctx, "kmsKeyID"); ok && v != "" {
+		if optEncrypted && !encrypted {
+			return false, "", goof.WithFields(
+				eff(goof.Fields{"kmsKeyID": v}),
+				"kms key specified for unencrypted volume")
+		}
+		kmsKeyID = v
+	}
+
+	if kmsKeyID != "" {
+		encrypted = true
+	}
+
+	return encrypted, kmsKeyID, nil
+}
+
+// ec2APIVersion is the version of the EC2 API of the requests the driver
+// sends itself rather than through the EC2 client.
+const ec2APIVersion = "2016-11-15"
+
+// ec2Client is the HTTP client of the requests the driver sends itself.
+var ec2Client = &http.Client{Timeout: 30 * time.Second}
+
+type ec2ErrorResponse struct {
+	Code    string `xml:"Errors>Error>Code"`
+	Message string `xml:"Errors>Error>Message"`
+}
+
+type createVolumeResponse struct {
+	RequestID string `xml:"requestId"`
+	VolumeID  string `xml:"volumeId"`
+}
+
+// createVolumeKMS creates a volume encrypted with the KMS key kmsKeyID. The
+// EC2 client cannot send a KMS key, so the request is signed and sent by the
+// driver. Errors have the same text as those of the EC2 client, ex.
+// "message (code)".
+func (d *driver) createVolumeKMS(
+	options *ec2.CreateVolume, kmsKeyID string) (*ec2.CreateVolumeResp, error) {
+
+	d.client()
+	d.credsLock.Lock()
+	creds := d.creds
+	d.credsLock.Unlock()
+
+	params := url.Values{
+		"Action":           {"CreateVolume"},
+		"Version":          {ec2APIVersion},
+		"AvailabilityZone": {options.AvailZone},
+		"Encrypted":        {"true"},
+		"KmsKeyId":         {kmsKeyID},
+	}
+	if options.Size > 0 {
+		params.Set("Size", strconv.FormatInt(options.Size, 10))
+	}
+	if options.SnapshotId != "" {
+		params.Set("SnapshotId", options.SnapshotId)
+	}
+	if options.VolumeType != "" {
+		params.Set("VolumeType", options.VolumeType)
+	}
+	if options.IOPS > 0 {
+		params.Set("Iops", strconv.FormatInt(options.IOPS, 10))
+	}
+	body := params.Encode()
+
+	fields := eff(goof.Fields{
+		"endpoint": d.region.EC2Endpoint,
+		"kmsKeyID": kmsKeyID,
+	})
+
+	req, err := http.NewRequest(
+		"POST", d.region.EC2Endpoint, strings.NewReader(body))
+	if err != nil {
+		return nil, goof.WithFieldsE(fields, "invalid ec2 endpoint", err)
+	}
+	req.Header.Set(
+		"Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	signV4(req, []byte(body), creds, d.region.Name, "ec2", time.Now())
+
+	res, err := ec2Client.Do(req)
+	if err != nil {
+		return nil, goof.WithFieldsE(fields, "error creating volume", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		var errRes ec2ErrorResponse
+		if err := xml.NewDecoder(res.Body).Decode(&errRes); err != nil ||
+			errRes.Code == "" {
+			fields["status"] = res.StatusCode
+			return nil, goof.WithFields(fields, "error creating volume")
+		}
+		return nil, goof.New(
+			fmt.Sprintf("%s (%s)", errRes.Message, errRes.Code))
+	}
+
+	var cvRes createVolumeResponse
+	if err := xml.NewDecoder(res.Body).Decode(&cvRes); err != nil {
+		return nil, goof.WithFieldsE(
+			fields, "error decoding created volume", err)
+	}
+	if cvRes.VolumeID == "" {
+		return nil, goof.WithFields(fields, "no created volume")
+	}
+
+	return &ec2.CreateVolumeResp{
+		RequestId: cvRes.RequestID,
+		VolumeId:  cvRes.VolumeID,
+	}, nil
+}
@@ -0,0 +1,167 @@
+package ec2
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/akutz/gofig"
+	"golang.org/x/net/context"
+
+	"github.com/emccode/rexray/core"
+	"github.com/goamz/goamz/aws"
+	"github.com/goamz/goamz/ec2"
+)
+
+func TestValidateVolume(t *testing.T) {
+	valid := []struct {
+		volumeType   string
+		IOPS, size   int64
+		fromSnapshot bool
+	}{
+		{"", 0, 100, false},
+		{"standard", 0, 1024, false},
+		{"gp2", 0, 16384, false},
+		{"io1", 100, 4, false},
+		{"io1", 20000, 400, false},
+		{"io1", 1000, 0, true},
+		{"st1", 0, 500, false},
+		{"sc1", 0, 0, true},
+	}
+	for _, v := range valid {
+		if err := validateVolume(
+			v.volumeType, v.IOPS, v.size, v.fromSnapshot); err != nil {
+			t.Errorf("%v: %v", v, err)
+		}
+	}
+
+	invalid := []struct {
+		volumeType   string
+		IOPS, size   int64
+		fromSnapshot bool
+	}{
+		{"gp3", 0, 100, false},
+		{"gp2", 0, 0, false},
+		{"standard", 0, 1025, false},
+		{"gp2", 0, 16385, false},
+		{"gp2", 100, 100, false},
+		{"io1", 0, 100, false},
+		{"io1", 100, 3, false},
+		{"io1", 20001, 1000, false},
+		{"io1", 1000, 10, false},
+		{"st1", 0, 499, false},
+		{"sc1", 100, 500, false},
+	}
+	for _, v := range invalid {
+		if err := validateVolume(
+			v.volumeType, v.IOPS, v.size, v.fromSnapshot); err == nil {
+			t.Errorf("%v: validated invalid volume", v)
+		}
+	}
+}
+
+func TestVolumeEncryption(t *testing.T) {
+	config := gofig.New()
+	d := &driver{r: core.New(config)}
+
+	tests := []struct {
+		encrypted, kmsKeyID string
+		opts                core.VolumeOpts
+		expEncrypted        bool
+		expKMSKeyID         string
+	}{
+		{"", "", nil, false, ""},
+		{"", "", core.VolumeOpts{"Encrypted": "true"}, true, ""},
+		{"", "", core.VolumeOpts{"kmskeyid": "k1"}, true, "k1"},
+		{"true", "", nil, true, ""},
+		{"true", "k1", nil, true, "k1"},
+		{"true", "k1", core.VolumeOpts{"kmsKeyID": "k2"}, true, "k2"},
+		{"true", "k1", core.VolumeOpts{"encrypted": "false"}, false, ""},
+	}
+	for _, v := range tests {
+		config.Set("aws.encrypted", v.encrypted == "true")
+		config.Set("aws.kmsKeyID", v.kmsKeyID)
+		ctx := core.WithVolumeOpts(context.Background(), v.opts)
+		encrypted, kmsKeyID, err := d.volumeEncryption(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if encrypted != v.expEncrypted || kmsKeyID != v.expKMSKeyID {
+			t.Errorf("%v: encrypted=%v kmsKeyID=%s",
+				v, encrypted, kmsKeyID)
+		}
+	}
+
+	ctx := core.WithVolumeOpts(context.Background(),
+		core.VolumeOpts{"encrypted": "false", "kmsKeyID": "k1"})
+	if _, _, err := d.volumeEncryption(ctx); err == nil {
+		t.Fatal("encrypted volume with kms key and encrypted=false")
+	}
+
+	ctx = core.WithVolumeOpts(context.Background(),
+		core.VolumeOpts{"encrypted": "maybe"})
+	if _, _, err := d.volumeEncryption(ctx); err == nil {
+		t.Fatal("parsed invalid encrypted option")
+	}
+}
+
+func TestCreateVolumeKMS(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, req *http.Request) {
+			if !strings.Contains(req.Header.Get("Authorization"),
+				"Credential=AKID/") ||
+				!strings.Contains(req.Header.Get("Authorization"),
+					"/us-east-1/ec2/aws4_request") {
+				t.Errorf("unexpected authorization %s",
+					req.Header.Get("Authorization"))
+			}
+			req.ParseForm()
+			if req.PostForm.Get("KmsKeyId") == "missing" {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, "<Response><Errors><Error>"+
+					"<Code>InvalidKMSKey.NotFound</Code>"+
+					"<Message>key not found</Message>"+
+					"</Error></Errors></Response>")
+				return
+			}
+			if req.PostForm.Get("Action") != "CreateVolume" ||
+				req.PostForm.Get("Encrypted") != "true" ||
+				req.PostForm.Get("KmsKeyId") != "k1" ||
+				req.PostForm.Get("Size") != "100" ||
+				req.PostForm.Get("AvailabilityZone") != "us-east-1a" ||
+				req.PostForm.Get("Iops") != "" {
+				t.Errorf("unexpected form %v", req.PostForm)
+			}
+			fmt.Fprint(w, "<CreateVolumeResponse><requestId>r1</requestId>"+
+				"<volumeId>vol-1</volumeId></CreateVolumeResponse>")
+		}))
+	defer srv.Close()
+
+	d := &driver{
+		r:      core.New(gofig.New()),
+		creds:  &credentials{accessKey: "AKID", secretKey: "secret"},
+		region: aws.Region{Name: "us-east-1", EC2Endpoint: srv.URL},
+	}
+
+	options := &ec2.CreateVolume{
+		AvailZone:  "us-east-1a",
+		Size:       100,
+		VolumeType: "gp2",
+		Encrypted:  true,
+	}
+	resp, err := d.createVolumeKMS(options, "k1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.VolumeId != "vol-1" || resp.RequestId != "r1" {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+
+	_, err = d.createVolumeKMS(options, "missing")
+	if err == nil ||
+		err.Error() != "key not found (InvalidKMSKey.NotFound)" {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
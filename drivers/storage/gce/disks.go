@@ -0,0 +1,177 @@
+package gce
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/akutz/goof"
+	"golang.org/x/net/context"
+	"google.golang.org/api/compute/v1"
+
+	"github.com/emccode/rexray/core"
+)
+
+// diskTypes are the persistent disk types, by volume type.
+var diskTypes = map[string]string{
+	"":            defaultVolumeType,
+	"standard":    "pd-standard",
+	"pd-standard": "pd-standard",
+	"ssd":         "pd-ssd",
+	"pd-ssd":      "pd-ssd",
+}
+
+// labelOptPrefix is the prefix of the volume options that are disk labels,
+// ex. label.team=db.
+const labelOptPrefix = "label."
+
+var (
+	labelInvalidRX = regexp.MustCompile(`[^a-z0-9_-]`)
+	labelKeyRX     = regexp.MustCompile(`^[a-z]`)
+)
+
+// getDiskType returns the persistent disk type of the volume type.
+func getDiskType(volumeType string) (string, error) {
+	diskType, ok := diskTypes[strings.ToLower(volumeType)]
+	if !ok {
+		return "", goof.WithFields(eff(goof.Fields{
+			"volumeType": volumeType,
+		}), "invalid volume type; must be pd-standard or pd-ssd")
+	}
+	return diskType, nil
+}
+
+// zoneRegion returns the region of the zone, ex. us-central1 for
+// us-central1-a.
+func zoneRegion(zone string) string {
+	if i := strings.LastIndex(zone, "-"); i > 0 {
+		return zone[:i]
+	}
+	return zone
+}
+
+// getReplicaZones returns the zones of the volume option replicaZones, ex.
+// us-central1-a,us-central1-b, which creates a regional persistent disk that
+// is replicated in the two zones, or nil if the option is not set.
+func getReplicaZones(ctx context.Context) ([]string, error) {
+	v, ok := core.GetVolumeOpt(ctx, "replicaZones")
+	if !ok || v == "" {
+		return nil, nil
+	}
+
+	var zones []string
+	for _, z := range strings.Split(v, ",") {
+		if z = strings.TrimSpace(z); z != "" {
+			zones = append(zones, z)
+		}
+	}
+
+	fields := eff(goof.Fields{"replicaZones": v})
+	if len(zones) != 2 {
+		return nil, goof.WithFields(
+			fields, "regional disks must have two replica zones")
+	}
+	if zones[0] == zones[1] {
+		return nil, goof.WithFields(fields, "replica zones must differ")
+	}
+	if zoneRegion(zones[0]) != zoneRegion(zones[1]) {
+		return nil, goof.WithFields(
+			fields, "replica zones must be in the same region")
+	}
+	return zones, nil
+}
+
+// getLabels returns the labels of a new disk: the label. volume options, and
+// the REX-Ray metadata of the disk, ex. the volume or snapshot from which the
+// disk is created.
+func (d *driver) getLabels(
+	ctx context.Context, volumeID, snapshotID string) map[string]string {
+
+	labels := map[string]string{"created-by": "rexray"}
+	if d.currentInstanceID != "" {
+		labels["rexray-instance"] = d.currentInstanceID
+	}
+	if volumeID != "" {
+		labels["rexray-source-volume"] = volumeID
+	}
+	if snapshotID != "" {
+		labels["rexray-source-snapshot"] = snapshotID
+	}
+
+	for k, v := range core.GetVolumeOpts(ctx) {
+		if len(k) > len(labelOptPrefix) &&
+			strings.EqualFold(k[:len(labelOptPrefix)], labelOptPrefix) {
+			labels[k[len(labelOptPrefix):]] = v
+		}
+	}
+
+	sanitized := map[string]string{}
+	for k, v := range labels {
+		if k = sanitizeLabel(k); !labelKeyRX.MatchString(k) {
+			k = "x" + k
+		}
+		sanitized[k] = sanitizeLabel(v)
+	}
+	return sanitized
+}
+
+// sanitizeLabel returns the label key or value with the characters that are
+// not allowed in labels replaced with underscores.
+func sanitizeLabel(s string) string {
+	s = labelInvalidRX.ReplaceAllString(strings.ToLower(s), "_")
+	if len(s) > 63 {
+		s = s[:63]
+	}
+	return s
+}
+
+// diskZones returns the zone of a zonal disk or the replica zones of a
+// regional disk.
+func diskZones(disk *compute.Disk) string {
+	if disk.Region == "" {
+		return getIndex(disk.Zone)
+	}
+	var zones []string
+	for _, z := range disk.ReplicaZones {
+		zones = append(zones, getIndex(z))
+	}
+	return strings.Join(zones, ",")
+}
+
+// listDisks returns the zonal disks of the driver's zone and the regional
+// disks of its region with the name, or all of the disks if the name is
+// empty.
+func (d *driver) listDisks(name string) ([]*compute.Disk, error) {
+	zonal := d.client.Disks.List(d.project, d.zone)
+	regional := d.client.RegionDisks.List(d.project, d.region)
+	if name != "" {
+		zonal.Filter(fmt.Sprintf("name eq '%s'", name))
+		regional.Filter(fmt.Sprintf("name eq '%s'", name))
+	}
+
+	zonalDisks, err := zonal.Do()
+	if err != nil {
+		return nil, err
+	}
+	regionalDisks, err := regional.Do()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(zonalDisks.Items, regionalDisks.Items...), nil
+}
+
+// getDisk returns the zonal or regional disk with the name, or nil if there
+// is no such disk.
+func (d *driver) getDisk(name string) (*compute.Disk, error) {
+	disks, err := d.listDisks(name)
+	if err != nil {
+		return nil, err
+	}
+	for _, disk := range disks {
+		if disk.Name == name {
+			return disk, nil
+		}
+	}
+	return nil, nil
+}
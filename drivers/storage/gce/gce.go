@@ -1,21 +1,19 @@
 package gce
 
 import (
-	"bytes"
 	"fmt"
 	"io/ioutil"
-	"net"
-	"net/http"
 	"strconv"
 	"strings"
-	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/akutz/gofig"
 	"github.com/akutz/goof"
 	"github.com/emccode/rexray/core"
 	"github.com/emccode/rexray/core/errors"
+	"github.com/emccode/rexray/core/waiter"
 	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/compute/v1"
 )
@@ -29,7 +27,9 @@ type driver struct {
 	client            *compute.Service
 	r                 *core.RexRay
 	zone              string
+	region            string
 	project           string
+	metadata          metadata
 }
 
 func ef() goof.Fields {
@@ -52,107 +52,125 @@ func eff(fields goof.Fields) map[string]interface{} {
 
 func init() {
 	core.RegisterDriver(providerName, newDriver)
-	gofig.Register(configRegistration())
+	core.RegisterConfigSchema(configRegistration())
 }
 
 func newDriver() core.Driver {
-	return &driver{}
+	return &driver{metadata: newMetadataServer()}
 }
 
 func (d *driver) Init(r *core.RexRay) error {
 	d.r = r
 
-	var err error
+	ctx := context.Background()
 
-	if d.zone, err = getCurrentZone(); err != nil {
-		return goof.WithError("error getting current zone", err)
-	}
-	if d.project, err = getCurrentProjectID(); err != nil {
-		return goof.WithError("error getting current project ID", err)
-	}
-	serviceAccountJSON, err := ioutil.ReadFile(d.r.Config.GetString("gce.keyfile"))
+	creds, err := d.getCredentials(ctx)
 	if err != nil {
-		log.WithField("provider", providerName).Fatalf("Could not read service account credentials file, %s => {%s}", d.r.Config.GetString("gce.keyfile"), err)
 		return err
 	}
 
-	config, err := google.JWTConfigFromJSON(serviceAccountJSON,
-		compute.ComputeScope,
-	)
-	if err != nil {
-		goof.WithFieldE("provider", providerName, "could not create JWT Config From JSON", err)
+	if err := d.initLocation(creds.ProjectID); err != nil {
 		return err
 	}
 
-	client, err := compute.New(config.Client(context.Background()))
+	client, err := compute.New(oauth2.NewClient(ctx, creds.TokenSource))
 	if err != nil {
-		log.WithField("provider", providerName).Fatalf("Could not create compute client => {%s}", err)
-		return err
+		return goof.WithFieldsE(ef(), "error creating compute client", err)
 	}
-
 	d.client = client
-	instanceID, err := getCurrentInstanceID()
-	if err != nil {
-		log.WithField("provider", providerName).Fatalf("Could not get current  instance => {%s}", err)
-		return err
-	}
-	d.currentInstanceID = instanceID
-	log.WithField("provider", providerName).Info("storage driver initialized")
+
+	log.WithFields(log.Fields{
+		"provider":   providerName,
+		"project":    d.project,
+		"zone":       d.zone,
+		"instanceID": d.currentInstanceID,
+	}).Info("storage driver initialized")
 	return nil
 }
 
-func getCurrentInstanceID() (string, error) {
-	return getMetadata("http://metadata.google.internal/computeMetadata/v1/instance/id")
-}
+// getCredentials returns the credentials of the service account key file
+// gce.keyfile, or else the Application Default Credentials: the key file
+// GOOGLE_APPLICATION_CREDENTIALS, the user credentials of the gcloud SDK, or
+// the service account of the instance.
+func (d *driver) getCredentials(
+	ctx context.Context) (*google.Credentials, error) {
 
-func getCurrentProjectID() (string, error) {
-	return getMetadata("http://metadata.google.internal/computeMetadata/v1/project/project-id")
-}
+	if keyFile := d.r.Config.GetString("gce.keyfile"); keyFile != "" {
+		fields := eff(goof.Fields{"keyfile": keyFile})
+		serviceAccountJSON, err := ioutil.ReadFile(keyFile)
+		if err != nil {
+			return nil, goof.WithFieldsE(
+				fields, "error reading service account key file", err)
+		}
+		creds, err := google.CredentialsFromJSON(
+			ctx, serviceAccountJSON, compute.ComputeScope)
+		if err != nil {
+			return nil, goof.WithFieldsE(
+				fields, "error reading service account key file", err)
+		}
+		return creds, nil
+	}
 
-func getCurrentZone() (zone string, err error) {
-	if zone, err = getMetadata("http://metadata.google.internal/computeMetadata/v1/instance/zone"); err != nil {
-		return "", err
+	creds, err := google.FindDefaultCredentials(ctx, compute.ComputeScope)
+	if err != nil {
+		return nil, goof.WithFieldsE(
+			ef(), "error finding application default credentials", err)
 	}
-	zone = getIndex(zone)
-	return
+	return creds, nil
 }
 
-func getMetadata(url string) (string, error) {
-	conn, err := net.DialTimeout("tcp", "metadata.google.internal:80", 50*time.Millisecond)
-	if err != nil {
-		return "", err
+// initLocation sets the project, zone, and instance of the driver to the
+// gce.project, gce.zone, and gce.instanceID properties. The project defaults
+// to the project of the credentials, and the metadata server is only queried
+// for the values that are still unknown. The instance is optional so that
+// volumes may be managed from outside of GCE.
+func (d *driver) initLocation(credsProjectID string) error {
+	var err error
+
+	if d.project = d.r.Config.GetString("gce.project"); d.project == "" {
+		d.project = credsProjectID
+	}
+	if d.project == "" {
+		if d.project, err = d.metadata.Get("project/project-id"); err != nil {
+			return goof.WithError("error getting current project ID", err)
+		}
 	}
-	defer conn.Close()
 
-	client := &http.Client{}
-	req, _ := http.NewRequest("GET", url, nil)
-	req.Header.Set("Metadata-Flavor", "Google")
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("Error: %v\n", err)
+	if d.zone = d.r.Config.GetString("gce.zone"); d.zone == "" {
+		if d.zone, err = d.metadata.Get("instance/zone"); err != nil {
+			return goof.WithError("error getting current zone", err)
+		}
+		d.zone = getIndex(d.zone)
 	}
+	d.region = zoneRegion(d.zone)
 
-	defer resp.Body.Close()
+	d.currentInstanceID = d.r.Config.GetString("gce.instanceID")
+	if d.currentInstanceID == "" {
+		if d.currentInstanceID, err = d.metadata.Get("instance/id"); err != nil {
+			log.WithFields(eff(goof.Fields{
+				"error": err,
+			})).Warn("error getting current instance ID")
+		}
+	}
 
-	buf := new(bytes.Buffer)
-	buf.ReadFrom(resp.Body)
-	s := buf.String()
-	return s, nil
+	return nil
 }
 
 func (d *driver) Name() string {
 	return providerName
 }
 
-func (d *driver) GetVolumeMapping() ([]*core.BlockDevice, error) {
-	log.WithField("provider", providerName).Debug("GetVolumeMapping")
+func (d *driver) GetVolumeMapping(
+	ctx context.Context) ([]*core.BlockDevice, error) {
+	core.Logger(ctx).WithField("provider", providerName).Debug(
+		"GetVolumeMapping")
 
 	diskMap := make(map[string]*compute.Disk)
-	disks, err := d.client.Disks.List(d.project, d.zone).Do()
+	disks, err := d.listDisks("")
 	if err != nil {
 		return []*core.BlockDevice{}, err
 	}
-	for _, disk := range disks.Items {
+	for _, disk := range disks {
 		diskMap[disk.SelfLink] = disk
 	}
 
@@ -163,15 +181,18 @@ func (d *driver) GetVolumeMapping() ([]*core.BlockDevice, error) {
 
 	var ret []*core.BlockDevice
 	for _, disk := range instance.Disks {
-		region := getIndex(diskMap[disk.Source].Zone)
+		sourceDisk, ok := diskMap[disk.Source]
+		if !ok {
+			continue
+		}
 		deviceName := fmt.Sprintf("/dev/disk/by-id/google-%s", disk.DeviceName)
 		ret = append(ret, &core.BlockDevice{
 			ProviderName: "gce",
 			InstanceID:   instance.Name,
 			VolumeID:     getIndex(disk.Source),
 			DeviceName:   deviceName,
-			Region:       region,
-			Status:       diskMap[disk.Source].Status,
+			Region:       diskZones(sourceDisk),
+			Status:       sourceDisk.Status,
 		})
 	}
 
@@ -185,11 +206,14 @@ func (d *driver) getInstance() (*compute.Instance, error) {
 	}
 
 	for _, instance := range instance.Items {
-		if strconv.FormatUint(instance.Id, 10) == d.currentInstanceID {
+		if strconv.FormatUint(instance.Id, 10) == d.currentInstanceID ||
+			instance.Name == d.currentInstanceID {
 			return instance, nil
 		}
 	}
-	return nil, goof.New("instance not found")
+	return nil, goof.WithFields(eff(goof.Fields{
+		"instanceID": d.currentInstanceID,
+	}), "instance not found")
 }
 
 func (d *driver) getInstances() (*compute.InstanceList, error) {
@@ -197,8 +221,8 @@ func (d *driver) getInstances() (*compute.InstanceList, error) {
 	return query.Do()
 }
 
-func (d *driver) GetInstance() (*core.Instance, error) {
-	log.WithField("provider", providerName).Debug("GetInstance")
+func (d *driver) GetInstance(ctx context.Context) (*core.Instance, error) {
+	core.Logger(ctx).WithField("provider", providerName).Debug("GetInstance")
 
 	instance, err := d.getInstance()
 	if err != nil {
@@ -214,27 +238,29 @@ func (d *driver) GetInstance() (*core.Instance, error) {
 }
 
 func (d *driver) CreateSnapshot(
+	ctx context.Context,
 	runAsync bool,
 	snapshotName, volumeID, description string) ([]*core.Snapshot, error) {
 
-	log.WithField("provider", providerName).Debug("CreateSnapshot")
+	core.Logger(ctx).WithField("provider", providerName).Debug("CreateSnapshot")
 
-	volumes, err := d.GetVolume(volumeID, "")
+	volumes, err := d.GetVolume(ctx, volumeID, "")
 
 	if len(volumes) == 0 {
 		return nil, goof.New("no volume returned by ID")
 	}
 
-	if err := d.createSnapshot(runAsync, snapshotName, volumes[0]); err != nil {
+	if err := d.createSnapshot(
+		ctx, runAsync, snapshotName, volumes[0]); err != nil {
 		return nil, err
 	}
 
-	snapshot, err := d.GetSnapshot("", snapshotName, "")
+	snapshot, err := d.GetSnapshot(ctx, "", snapshotName, "")
 	if err != nil {
 		return nil, err
 	}
 
-	log.WithFields(log.Fields{
+	core.Logger(ctx).WithFields(log.Fields{
 		"runAsync":     runAsync,
 		"snapshotName": snapshotName,
 		"volumeId":     volumeID,
@@ -244,20 +270,34 @@ func (d *driver) CreateSnapshot(
 
 }
 
-func (d *driver) createSnapshot(runAsync bool, snapshotName string, volume *core.Volume) error {
-	sourceDisk := fmt.Sprintf("https://www.googleapis.com/compute/v1/projects/%s/zones/%s/disks/%s",
-		d.project, d.zone, volume.Name)
+func (d *driver) createSnapshot(
+	ctx context.Context,
+	runAsync bool, snapshotName string, volume *core.Volume) error {
+	disk, err := d.getDisk(volume.Name)
+	if err != nil {
+		return err
+	}
+	if disk == nil {
+		return errors.ErrNoVolumesReturned
+	}
 	snapshot := &compute.Snapshot{
-		SourceDisk: sourceDisk,
+		SourceDisk: disk.SelfLink,
 		Name:       snapshotName,
 	}
-	operation, err := d.client.Disks.CreateSnapshot(d.project, d.zone, getIndex(sourceDisk), snapshot).Do()
+	var operation *compute.Operation
+	if disk.Region != "" {
+		operation, err = d.client.RegionDisks.CreateSnapshot(
+			d.project, getIndex(disk.Region), disk.Name, snapshot).Do()
+	} else {
+		operation, err = d.client.Disks.CreateSnapshot(
+			d.project, getIndex(disk.Zone), disk.Name, snapshot).Do()
+	}
 	if err != nil {
 		return goof.WithError("error creating snapshot", err)
 	}
 
 	if !runAsync {
-		err := d.waitUntilOperationIsFinished(operation)
+		err := d.waitUntilOperationIsFinished(ctx, operation)
 		if err != nil {
 			return err
 		}
@@ -266,19 +306,18 @@ func (d *driver) createSnapshot(runAsync bool, snapshotName string, volume *core
 }
 
 func (d *driver) GetSnapshot(
+	ctx context.Context,
 	volumeID, snapshotID, snapshotName string) ([]*core.Snapshot, error) {
 
-	log.WithField("provider", providerName).Debug("GetSnapshot")
+	core.Logger(ctx).WithField("provider", providerName).Debug("GetSnapshot")
 
-	var diskList *compute.DiskList
 	if volumeID != "" {
-		var err error
-		diskList, err = d.getVolume(volumeID, "")
+		disks, err := d.listDisks(volumeID)
 		if err != nil {
 			return nil, err
 		}
-		if len(diskList.Items) > 0 {
-			volumeID = strconv.FormatUint(diskList.Items[0].Id, 10)
+		if len(disks) > 0 {
+			volumeID = strconv.FormatUint(disks[0].Id, 10)
 		}
 	}
 
@@ -318,8 +357,9 @@ func (d *driver) getSnapshot(volumeID, snapshotID, snapshotName string) (*comput
 	return query.Do()
 }
 
-func (d *driver) RemoveSnapshot(snapshotID string) error {
-	log.WithField("provider", providerName).Debug("RemoveSnapshot :%s", snapshotID)
+func (d *driver) RemoveSnapshot(ctx context.Context, snapshotID string) error {
+	core.Logger(ctx).WithField("provider", providerName).Debugf(
+		"RemoveSnapshot :%s", snapshotID)
 	if _, err := d.client.Snapshots.Delete(d.project, snapshotID).Do(); err != nil {
 		return goof.WithError("problem removing snapshot", err)
 	}
@@ -345,40 +385,55 @@ func getLocalDevices() (deviceNames []string, err error) {
 	return deviceNames, nil
 }
 
-func (d *driver) GetDeviceNextAvailable() (string, error) {
+func (d *driver) GetDeviceNextAvailable(ctx context.Context) (string, error) {
 	return "", nil
 }
-func (d *driver) waitUntilOperationIsFinished(operation *compute.Operation) error {
+func (d *driver) waitUntilOperationIsFinished(
+	ctx context.Context, operation *compute.Operation) error {
 	opName := operation.Name
-OpLoop:
-	for {
-		time.Sleep(100 * time.Millisecond)
-		op, err := d.client.ZoneOperations.Get(d.project, d.zone, opName).Do()
-		if err != nil {
-			return err
-		}
+	return waiter.Until(ctx,
+		waiter.NewOptions(d.r.Config, waiter.OpJob),
+		func() (bool, error) {
+			var op *compute.Operation
+			var err error
+			if operation.Region != "" {
+				op, err = d.client.RegionOperations.Get(
+					d.project, getIndex(operation.Region), opName).Do()
+			} else {
+				zone := d.zone
+				if operation.Zone != "" {
+					zone = getIndex(operation.Zone)
+				}
+				op, err = d.client.ZoneOperations.Get(
+					d.project, zone, opName).Do()
+			}
+			if err != nil {
+				return false, err
+			}
 
-		switch op.Status {
-		case "PENDING", "RUNNING":
-			continue
-		case "DONE":
-			if op.Error != nil {
-				bytea, _ := op.Error.MarshalJSON()
-				return goof.New(string(bytea))
+			switch op.Status {
+			case "PENDING", "RUNNING":
+				return false, nil
+			case "DONE":
+				if op.Error != nil {
+					bytea, _ := op.Error.MarshalJSON()
+					return false, goof.New(string(bytea))
+				}
+				return true, nil
+			default:
+				return false, goof.WithFields(eff(goof.Fields{
+					"operation": opName,
+					"status":    op.Status,
+				}), "unknown operation status")
 			}
-			break OpLoop
-		default:
-			log.WithField("provider", providerName).Fatalf("Unknown status %q: %+v", op.Status, op)
-			return nil
-		}
-	}
-	return nil
+		})
 }
 
 func (d *driver) CreateVolume(
+	ctx context.Context,
 	runAsync bool, volumeName, volumeID, snapshotID, volumeType string,
 	IOPS, size int64, availabilityZone string) (*core.Volume, error) {
-	log.WithFields(log.Fields{
+	core.Logger(ctx).WithFields(log.Fields{
 		"provider":         providerName,
 		"volumeName":       volumeName,
 		"volumeID":         volumeID,
@@ -391,15 +446,21 @@ func (d *driver) CreateVolume(
 		availabilityZone = d.zone
 	}
 
-	if volumeType == "" {
-		volumeType = defaultVolumeType
+	diskType, err := getDiskType(volumeType)
+	if err != nil {
+		return nil, err
 	}
-	diskType := fmt.Sprintf("https://www.googleapis.com/compute/v1/projects/%s/zones/%s/diskTypes/%s",
-		d.project, d.zone, volumeType)
+
+	replicaZones, err := getReplicaZones(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := d.getLabels(ctx, volumeID, snapshotID)
 
 	var snapshots []*core.Snapshot
 	if volumeID != "" {
-		volume, err := d.GetVolume(volumeName, "")
+		volume, err := d.GetVolume(ctx, volumeName, "")
 		if err != nil {
 			return nil, err
 		}
@@ -409,7 +470,7 @@ func (d *driver) CreateVolume(
 		}
 
 		tmpSnapshotName := fmt.Sprintf("temp-%s", volumeID)
-		snapshots, err = d.CreateSnapshot(false, tmpSnapshotName, volumeID, "")
+		snapshots, err = d.CreateSnapshot(ctx, false, tmpSnapshotName, volumeID, "")
 		if err != nil {
 			return nil, err
 		}
@@ -421,38 +482,59 @@ func (d *driver) CreateVolume(
 
 	var snapshotURL string
 	if snapshotID != "" {
-		snapshotURL = fmt.Sprintf("https://www.googleapis.com/compute/v1/projects/%s/global/snapshots/%s",
-			d.project, snapshotID)
+		snapshotURL = fmt.Sprintf("%s/global/snapshots/%s",
+			d.projectURL(), snapshotID)
 	}
 
 	disk := &compute.Disk{
 		Name:           volumeName,
-		Zone:           availabilityZone,
-		Type:           diskType,
 		SizeGb:         size,
 		SourceSnapshot: snapshotURL,
+		Labels:         labels,
+	}
+
+	var createdVolume *compute.Operation
+	if len(replicaZones) > 0 {
+		region := zoneRegion(replicaZones[0])
+		disk.Region = region
+		disk.Type = fmt.Sprintf("%s/regions/%s/diskTypes/%s",
+			d.projectURL(), region, diskType)
+		for _, z := range replicaZones {
+			disk.ReplicaZones = append(disk.ReplicaZones,
+				fmt.Sprintf("%s/zones/%s", d.projectURL(), z))
+		}
+		createdVolume, err = d.client.RegionDisks.Insert(
+			d.project, region, disk).Do()
+	} else {
+		disk.Zone = availabilityZone
+		disk.Type = fmt.Sprintf("%s/zones/%s/diskTypes/%s",
+			d.projectURL(), availabilityZone, diskType)
+		createdVolume, err = d.client.Disks.Insert(
+			d.project, availabilityZone, disk).Do()
 	}
-	createdVolume, err := d.client.Disks.Insert(d.project, d.zone, disk).Do()
 	if err != nil {
 		return nil, err
 	}
 	if !runAsync && volumeID != "" {
-		err := d.waitUntilOperationIsFinished(createdVolume)
+		err := d.waitUntilOperationIsFinished(ctx, createdVolume)
 		if err != nil {
 			return nil, err
 		}
 	}
 
 	if volumeID != "" {
-		if err := d.RemoveSnapshot(snapshots[0].SnapshotID); err != nil {
+		if err := d.RemoveSnapshot(ctx, snapshots[0].SnapshotID); err != nil {
 			return nil, err
 		}
 	}
 
-	volume, err := d.GetVolume(volumeName, "")
+	volume, err := d.GetVolume(ctx, volumeName, "")
 	if err != nil {
 		return nil, err
 	}
+	if len(volume) == 0 {
+		return nil, errors.ErrNoVolumesReturned
+	}
 	return volume[0], nil
 }
 
@@ -473,21 +555,19 @@ func (d *driver) getVolumesAttachedToInstance(instances []*compute.Instance, vol
 	return attachments
 }
 
-func (d *driver) getVolume(volumeName, volumeID string) (*compute.DiskList, error) {
-	query := d.client.Disks.List(d.project, d.zone)
+func (d *driver) getVolume(volumeName, volumeID string) ([]*compute.Disk, error) {
 	if volumeID != "" {
-		query.Filter(fmt.Sprintf("name eq '%s'", volumeID))
-	} else if volumeName != "" {
-		query.Filter(fmt.Sprintf("name eq '%s'", volumeName))
+		return d.listDisks(volumeID)
 	}
-
-	return query.Do()
+	return d.listDisks(volumeName)
 }
 
 func (d *driver) GetVolume(
+	ctx context.Context,
 	volumeID, volumeName string) ([]*core.Volume, error) {
 
-	log.WithField("provider", providerName).Debugf("GetVolume :%s %s", volumeID, volumeName)
+	core.Logger(ctx).WithField("provider", providerName).Debugf(
+		"GetVolume :%s %s", volumeID, volumeName)
 	instanceList, err := d.getInstances()
 	if err != nil {
 		return nil, err
@@ -498,13 +578,13 @@ func (d *driver) GetVolume(
 		mapInstanceBySource[instance.SelfLink] = instance
 	}
 
-	diskList, err := d.getVolume(volumeName, volumeID)
+	disks, err := d.getVolume(volumeName, volumeID)
 	if err != nil {
 		return nil, err
 	}
 
 	var volumesSD []*core.Volume
-	for _, disk := range diskList.Items {
+	for _, disk := range disks {
 
 		var diskAttachments []*core.VolumeAttachment
 		for _, user := range disk.Users {
@@ -525,7 +605,7 @@ func (d *driver) GetVolume(
 		volumeSD := &core.Volume{
 			Name:             disk.Name,
 			VolumeID:         disk.Name,
-			AvailabilityZone: getIndex(disk.Zone),
+			AvailabilityZone: diskZones(disk),
 			Status:           disk.Status,
 			VolumeType:       getIndex(disk.Type),
 			IOPS:             0,
@@ -538,6 +618,11 @@ func (d *driver) GetVolume(
 	return volumesSD, nil
 }
 
+// projectURL returns the URL of the driver's project.
+func (d *driver) projectURL() string {
+	return "https://www.googleapis.com/compute/v1/projects/" + d.project
+}
+
 func getIndex(href string) string {
 	hrefFields := strings.Split(href, "/")
 	return hrefFields[len(hrefFields)-1]
@@ -554,8 +639,10 @@ func (d *driver) convertGCEAttachedDisk(instanceID string, disk *compute.Attache
 }
 
 func (d *driver) GetVolumeAttach(
+	ctx context.Context,
 	volumeID, instanceID string) ([]*core.VolumeAttachment, error) {
-	log.WithField("provider", providerName).Debugf("GetVolumeAttach :%s %s", volumeID, instanceID)
+	core.Logger(ctx).WithField("provider", providerName).Debugf(
+		"GetVolumeAttach :%s %s", volumeID, instanceID)
 	query := d.client.Instances.List(d.project, d.zone)
 	if instanceID != "" {
 		query.Filter(fmt.Sprintf("name eq '%s'", instanceID))
@@ -565,7 +652,7 @@ func (d *driver) GetVolumeAttach(
 		return nil, err
 	}
 
-	volumes, err := d.GetVolume("", "")
+	volumes, err := d.GetVolume(ctx, "", "")
 	if err != nil {
 		return nil, err
 	}
@@ -578,19 +665,36 @@ func (d *driver) GetVolumeAttach(
 	return d.getVolumesAttachedToInstance(instances.Items, volumeIDMapByName, volumeID), nil
 }
 
-func (d *driver) RemoveVolume(volumeID string) error {
-	log.WithField("provider", providerName).Debugf("RemoveVolume :%s", volumeID)
-	if _, err := d.client.Disks.Delete(d.project, d.zone, volumeID).Do(); err != nil {
+func (d *driver) RemoveVolume(ctx context.Context, volumeID string) error {
+	core.Logger(ctx).WithField("provider", providerName).Debugf(
+		"RemoveVolume :%s", volumeID)
+	disk, err := d.getDisk(volumeID)
+	if err != nil {
+		return goof.WithError("problem removing volume", err)
+	}
+	if disk == nil {
+		return errors.ErrNoVolumesReturned
+	}
+	if disk.Region != "" {
+		_, err = d.client.RegionDisks.Delete(
+			d.project, getIndex(disk.Region), volumeID).Do()
+	} else {
+		_, err = d.client.Disks.Delete(
+			d.project, getIndex(disk.Zone), volumeID).Do()
+	}
+	if err != nil {
 		return goof.WithError("problem removing volume", err)
 	}
 	return nil
 }
 
 func (d *driver) AttachVolume(
+	ctx context.Context,
 	runAsync bool,
 	volumeID, instanceID string, force bool) ([]*core.VolumeAttachment, error) {
 
-	log.WithField("provider", providerName).Debugf("AttachVolume %s %s", volumeID, instanceID)
+	core.Logger(ctx).WithField("provider", providerName).Debugf(
+		"AttachVolume %s %s", volumeID, instanceID)
 
 	if volumeID == "" {
 		return nil, errors.ErrMissingVolumeID
@@ -600,7 +704,7 @@ func (d *driver) AttachVolume(
 		return nil, goof.New("missing instance ID")
 	}
 
-	volumes, err := d.GetVolume(volumeID, "")
+	volumes, err := d.GetVolume(ctx, volumeID, "")
 	if err != nil {
 		return nil, err
 	}
@@ -612,32 +716,40 @@ func (d *driver) AttachVolume(
 	if len(volumes[0].Attachments) > 0 && !force {
 		return nil, goof.New("Volume already attached to another host")
 	} else if len(volumes[0].Attachments) > 0 && force {
-		if err := d.DetachVolume(false, volumeID, "", true); err != nil {
+		if err := d.DetachVolume(ctx, false, volumeID, "", true); err != nil {
 			return nil, err
 		}
 	}
 
-	if err := d.attachDisk(false, instanceID, volumes[0]); err != nil {
+	if err := d.attachDisk(ctx, false, instanceID, volumes[0]); err != nil {
 		return nil, err
 	}
 
-	return d.GetVolumeAttach(volumeID, instanceID)
+	return d.GetVolumeAttach(ctx, volumeID, instanceID)
 
 }
 
-func (d *driver) attachDisk(runAsync bool, instanceID string, volume *core.Volume) error {
+func (d *driver) attachDisk(
+	ctx context.Context,
+	runAsync bool, instanceID string, volume *core.Volume) error {
+	source, err := d.getDisk(volume.Name)
+	if err != nil {
+		return err
+	}
+	if source == nil {
+		return errors.ErrNoVolumesReturned
+	}
 	disk := &compute.AttachedDisk{
 		AutoDelete: false,
 		Boot:       false,
-		Source: fmt.Sprintf("https://www.googleapis.com/compute/v1/projects/%s/zones/%s/disks/%s",
-			d.project, d.zone, volume.Name),
+		Source:     source.SelfLink,
 	}
 	operation, err := d.client.Instances.AttachDisk(d.project, d.zone, instanceID, disk).Do()
 	if err != nil {
 		return err
 	}
 	if !runAsync {
-		err := d.waitUntilOperationIsFinished(operation)
+		err := d.waitUntilOperationIsFinished(ctx, operation)
 		if err != nil {
 			return err
 		}
@@ -646,6 +758,7 @@ func (d *driver) attachDisk(runAsync bool, instanceID string, volume *core.Volum
 }
 
 func (d *driver) DetachVolume(
+	ctx context.Context,
 	runAsync bool,
 	volumeID, instanceID string, force bool) error {
 
@@ -658,7 +771,7 @@ func (d *driver) DetachVolume(
 	if volumeID == "" {
 		return goof.WithFields(fields, "volumeId is required")
 	}
-	volumes, err := d.GetVolume(volumeID, "")
+	volumes, err := d.GetVolume(ctx, volumeID, "")
 	if err != nil {
 		return goof.WithFieldsE(fields, "error getting volume", err)
 	}
@@ -677,7 +790,7 @@ func (d *driver) DetachVolume(
 			return err
 		}
 		if !runAsync {
-			err := d.waitUntilOperationIsFinished(operation)
+			err := d.waitUntilOperationIsFinished(ctx, operation)
 			if err != nil {
 				return err
 			}
@@ -686,15 +799,25 @@ func (d *driver) DetachVolume(
 	return nil
 }
 
-func (d *driver) CopySnapshot(runAsync bool,
+func (d *driver) CopySnapshot(ctx context.Context, runAsync bool,
 	volumeID, snapshotID, snapshotName, destinationSnapshotName,
 	destinationRegion string) (*core.Snapshot, error) {
-	log.WithField("provider", providerName).Debug("CopySnapshot")
+	core.Logger(ctx).WithField("provider", providerName).Debug("CopySnapshot")
 	return nil, nil
 }
 
-func configRegistration() *gofig.Registration {
-	r := gofig.NewRegistration("Google GCE")
+func (d *driver) CloneVolume(
+	ctx context.Context,
+	sourceVolumeID, newName string,
+	opts core.VolumeOpts) (*core.Volume, error) {
+	return core.CloneVolumeFromSnapshot(ctx, d, sourceVolumeID, newName, opts)
+}
+
+func configRegistration() *core.ConfigSchema {
+	r := core.NewConfigSchema("Google GCE", providerName)
 	r.Key(gofig.String, "", "", "", "gce.keyfile")
+	r.Key(gofig.String, "", "", "", "gce.project")
+	r.Key(gofig.String, "", "", "", "gce.zone")
+	r.Key(gofig.String, "", "", "", "gce.instanceID")
 	return r
 }
@@ -0,0 +1,60 @@
+package gce
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/akutz/goof"
+)
+
+const defaultMetadataURL = "http://metadata.google.internal/computeMetadata/v1/"
+
+// metadata is the GCE metadata server of the instance.
+type metadata interface {
+
+	// Get returns the value of the metadata at the path, ex. instance/id.
+	Get(path string) (string, error)
+}
+
+// metadataServer is the metadata server at the URL, which is only reachable
+// from GCE instances.
+type metadataServer struct {
+	url    string
+	client *http.Client
+}
+
+func newMetadataServer() *metadataServer {
+	return &metadataServer{
+		url:    defaultMetadataURL,
+		client: &http.Client{Timeout: 2 * time.Second},
+	}
+}
+
+func (m *metadataServer) Get(path string) (string, error) {
+	fields := eff(goof.Fields{"path": path})
+
+	req, err := http.NewRequest("GET", m.url+path, nil)
+	if err != nil {
+		return "", goof.WithFieldsE(fields, "invalid metadata path", err)
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	res, err := m.client.Do(req)
+	if err != nil {
+		return "", goof.WithFieldsE(fields, "error getting metadata", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		fields["status"] = res.StatusCode
+		return "", goof.WithFields(fields, "error getting metadata")
+	}
+
+	buf, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", goof.WithFieldsE(fields, "error reading metadata", err)
+	}
+	return strings.TrimSpace(string(buf)), nil
+}
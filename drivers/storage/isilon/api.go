@@ -0,0 +1,435 @@
+package isilon
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/akutz/goof"
+	isi "github.com/emccode/goisilon"
+)
+
+// api is the Isilon API used by the driver.
+type api interface {
+
+	// Path returns the absolute path of the volume's directory.
+	Path(volumeName string) string
+
+	GetVolumes() ([]isi.Volume, error)
+	GetVolume(volumeID, volumeName string) (isi.Volume, error)
+	CreateVolume(volumeName string) (isi.Volume, error)
+	DeleteVolume(volumeName string) error
+
+	// GetExports returns the NFS exports.
+	GetExports() ([]*isiExport, error)
+
+	// GetExport returns the NFS export of the path, or nil if the path is not
+	// exported.
+	GetExport(path string) (*isiExport, error)
+
+	// CreateExport creates the NFS export and sets its ID.
+	CreateExport(export *isiExport) error
+
+	// UpdateExport replaces the clients of the NFS export.
+	UpdateExport(export *isiExport) error
+
+	// RemoveExport removes the NFS export.
+	RemoveExport(export *isiExport) error
+
+	// GetSnapshots returns the SnapshotIQ snapshots.
+	GetSnapshots() ([]*isiSnapshot, error)
+
+	// GetSnapshot returns the snapshot with the ID or name, or nil if there
+	// is no such snapshot.
+	GetSnapshot(snapshotID string) (*isiSnapshot, error)
+
+	// CreateSnapshot creates a snapshot of the directory at the path.
+	CreateSnapshot(path, snapshotName string) (*isiSnapshot, error)
+
+	// RemoveSnapshot removes the snapshot with the ID or name.
+	RemoveSnapshot(snapshotID string) error
+
+	// CopySnapshot copies the directory captured by the snapshot to the path.
+	CopySnapshot(snapshot *isiSnapshot, path string) error
+
+	// GetQuotas returns the SmartQuotas directory quotas.
+	GetQuotas() ([]*isiQuota, error)
+
+	// GetQuota returns the directory quota of the path, or nil if the path
+	// does not have a quota.
+	GetQuota(path string) (*isiQuota, error)
+
+	// SetQuotaSize sets the hard threshold, in bytes, of the directory quota
+	// of the path, creating the quota if the path does not have one.
+	SetQuotaSize(path string, size int64) error
+
+	// ClearQuota removes the directory quota of the path, if any.
+	ClearQuota(path string) error
+}
+
+// isiSnapshot is a SnapshotIQ snapshot.
+type isiSnapshot struct {
+	ID      int64  `json:"id"`
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	Created int64  `json:"created"`
+	State   string `json:"state"`
+}
+
+// isiQuota is a SmartQuotas quota.
+type isiQuota struct {
+	ID         string `json:"id"`
+	Path       string `json:"path"`
+	Type       string `json:"type"`
+	Enforced   bool   `json:"enforced"`
+	Thresholds struct {
+		Hard int64 `json:"hard"`
+	} `json:"thresholds"`
+	Usage struct {
+		Logical int64 `json:"logical"`
+	} `json:"usage"`
+}
+
+// isiExport is an NFS export. The root clients are the clients whose root
+// user is not mapped to nobody.
+type isiExport struct {
+	ID              int64    `json:"id,omitempty"`
+	Paths           []string `json:"paths"`
+	Clients         []string `json:"clients"`
+	RootClients     []string `json:"root_clients"`
+	ReadOnlyClients []string `json:"read_only_clients"`
+}
+
+// client is the Isilon API. The volumes are managed with goisilon, and the
+// exports, snapshots, and quotas with the platform API.
+type client struct {
+	*isi.Client
+
+	endpoint string
+	userName string
+	password string
+	http     *http.Client
+}
+
+func newClient(
+	endpoint string,
+	insecure bool,
+	userName, password, volumePath string) (*client, error) {
+
+	c, err := isi.NewClientWithArgs(
+		endpoint, insecure, userName, password, volumePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &client{
+		Client:   c,
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		userName: userName,
+		password: password,
+		http: &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+			},
+		},
+	}, nil
+}
+
+type papiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type papiErrors struct {
+	Errors []*papiError `json:"errors"`
+}
+
+// do sends a request to the platform or namespace API and decodes the
+// response into out, if out is not nil.
+func (c *client) do(
+	method, path string,
+	headers map[string]string,
+	in, out interface{}) (int, error) {
+
+	var body io.Reader
+	if in != nil {
+		buf, err := json.Marshal(in)
+		if err != nil {
+			return 0, err
+		}
+		body = bytes.NewReader(buf)
+	}
+
+	req, err := http.NewRequest(method, c.endpoint+path, body)
+	if err != nil {
+		return 0, err
+	}
+	req.SetBasicAuth(c.userName, c.password)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		fields := goof.Fields{
+			"method": method,
+			"path":   path,
+			"status": res.StatusCode,
+		}
+		var errs papiErrors
+		if err := json.NewDecoder(res.Body).Decode(&errs); err == nil &&
+			len(errs.Errors) > 0 {
+			fields["code"] = errs.Errors[0].Code
+			return res.StatusCode, goof.WithFields(
+				fields, errs.Errors[0].Message)
+		}
+		return res.StatusCode, goof.WithFields(fields, "isilon api error")
+	}
+
+	if out == nil {
+		return res.StatusCode, nil
+	}
+	return res.StatusCode, json.NewDecoder(res.Body).Decode(out)
+}
+
+func (c *client) GetSnapshots() ([]*isiSnapshot, error) {
+	var snapshots []*isiSnapshot
+	q := ""
+	for {
+		var res struct {
+			Snapshots []*isiSnapshot `json:"snapshots"`
+			Resume    string         `json:"resume"`
+		}
+		if _, err := c.do(
+			"GET", "/platform/1/snapshot/snapshots"+q, nil, nil, &res); err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, res.Snapshots...)
+		if res.Resume == "" {
+			return snapshots, nil
+		}
+		q = "?resume=" + url.QueryEscape(res.Resume)
+	}
+}
+
+func (c *client) GetSnapshot(snapshotID string) (*isiSnapshot, error) {
+	var res struct {
+		Snapshots []*isiSnapshot `json:"snapshots"`
+	}
+	status, err := c.do("GET",
+		"/platform/1/snapshot/snapshots/"+url.QueryEscape(snapshotID),
+		nil, nil, &res)
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Snapshots) == 0 {
+		return nil, nil
+	}
+	return res.Snapshots[0], nil
+}
+
+func (c *client) CreateSnapshot(
+	path, snapshotName string) (*isiSnapshot, error) {
+
+	req := map[string]string{"path": path}
+	if snapshotName != "" {
+		req["name"] = snapshotName
+	}
+	var snapshot isiSnapshot
+	if _, err := c.do(
+		"POST", "/platform/1/snapshot/snapshots", nil, req, &snapshot); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+func (c *client) RemoveSnapshot(snapshotID string) error {
+	_, err := c.do("DELETE",
+		"/platform/1/snapshot/snapshots/"+url.QueryEscape(snapshotID),
+		nil, nil, nil)
+	return err
+}
+
+func (c *client) CopySnapshot(snapshot *isiSnapshot, path string) error {
+	// the directories captured by a snapshot are read from the snapshot's
+	// directory, ex. /ifs/.snapshot/snap1/volumes/vol1
+	src := fmt.Sprintf("/namespace/ifs/.snapshot/%s/%s",
+		snapshot.Name, strings.TrimPrefix(snapshot.Path, "/ifs/"))
+	_, err := c.do("PUT", "/namespace"+path,
+		map[string]string{"x-isi-ifs-copy-source": src}, nil, nil)
+	return err
+}
+
+func (c *client) getQuotas(q string) ([]*isiQuota, error) {
+	var quotas []*isiQuota
+	for {
+		var res struct {
+			Quotas []*isiQuota `json:"quotas"`
+			Resume string      `json:"resume"`
+		}
+		if _, err := c.do(
+			"GET", "/platform/1/quota/quotas?"+q, nil, nil, &res); err != nil {
+			return nil, err
+		}
+		quotas = append(quotas, res.Quotas...)
+		if res.Resume == "" {
+			return quotas, nil
+		}
+		q = "resume=" + url.QueryEscape(res.Resume)
+	}
+}
+
+func (c *client) GetQuotas() ([]*isiQuota, error) {
+	return c.getQuotas("type=directory")
+}
+
+func (c *client) GetQuota(path string) (*isiQuota, error) {
+	quotas, err := c.getQuotas(
+		"type=directory&path=" + url.QueryEscape(path))
+	if err != nil {
+		return nil, err
+	}
+	for _, q := range quotas {
+		if q.Path == path {
+			return q, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *client) SetQuotaSize(path string, size int64) error {
+	q, err := c.GetQuota(path)
+	if err != nil {
+		return err
+	}
+
+	thresholds := map[string]int64{"hard": size}
+
+	if q != nil {
+		_, err := c.do("PUT", "/platform/1/quota/quotas/"+q.ID, nil,
+			map[string]interface{}{"thresholds": thresholds}, nil)
+		return err
+	}
+
+	_, err = c.do("POST", "/platform/1/quota/quotas", nil,
+		map[string]interface{}{
+			"path":                        path,
+			"type":                        "directory",
+			"enforced":                    true,
+			"include_snapshots":           false,
+			"thresholds_include_overhead": false,
+			"thresholds":                  thresholds,
+		}, nil)
+	return err
+}
+
+func (c *client) ClearQuota(path string) error {
+	q, err := c.GetQuota(path)
+	if err != nil || q == nil {
+		return err
+	}
+	_, err = c.do("DELETE", "/platform/1/quota/quotas/"+q.ID, nil, nil, nil)
+	return err
+}
+
+// snapshotID returns the ID of the snapshot as a string.
+func (s *isiSnapshot) snapshotID() string {
+	return strconv.FormatInt(s.ID, 10)
+}
+
+func (c *client) getExports(q string) ([]*isiExport, error) {
+	var exports []*isiExport
+	for {
+		var res struct {
+			Exports []*isiExport `json:"exports"`
+			Resume  string       `json:"resume"`
+		}
+		if _, err := c.do("GET", "/platform/1/protocols/nfs/exports"+q,
+			nil, nil, &res); err != nil {
+			return nil, err
+		}
+		exports = append(exports, res.Exports...)
+		if res.Resume == "" {
+			return exports, nil
+		}
+		q = "?resume=" + url.QueryEscape(res.Resume)
+	}
+}
+
+func (c *client) GetExports() ([]*isiExport, error) {
+	return c.getExports("")
+}
+
+func (c *client) GetExport(path string) (*isiExport, error) {
+	exports, err := c.getExports("?path=" + url.QueryEscape(path))
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range exports {
+		for _, p := range e.Paths {
+			if p == path {
+				return e, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (c *client) CreateExport(export *isiExport) error {
+	var res struct {
+		ID int64 `json:"id"`
+	}
+	if _, err := c.do("POST", "/platform/1/protocols/nfs/exports",
+		nil, export, &res); err != nil {
+		return err
+	}
+	export.ID = res.ID
+	return nil
+}
+
+func (c *client) UpdateExport(export *isiExport) error {
+	_, err := c.do("PUT", exportPath(export), nil,
+		map[string][]string{
+			"clients":           emptyIfNil(export.Clients),
+			"root_clients":      emptyIfNil(export.RootClients),
+			"read_only_clients": emptyIfNil(export.ReadOnlyClients),
+		}, nil)
+	return err
+}
+
+func (c *client) RemoveExport(export *isiExport) error {
+	_, err := c.do("DELETE", exportPath(export), nil, nil, nil)
+	return err
+}
+
+func exportPath(export *isiExport) string {
+	return "/platform/1/protocols/nfs/exports/" +
+		strconv.FormatInt(export.ID, 10)
+}
+
+// emptyIfNil returns an empty list for a nil list so that the list is cleared
+// rather than omitted when encoded.
+func emptyIfNil(v []string) []string {
+	if v == nil {
+		return []string{}
+	}
+	return v
+}
@@ -3,29 +3,39 @@ package isilon
 import (
 	"fmt"
 	"io/ioutil"
+	"net"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	//	"reflect"
 
 	//	"sort"
-	//	"strconv"
+	"strconv"
 	"strings"
+	"time"
 
 	isi "github.com/emccode/goisilon"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/akutz/gofig"
 	"github.com/akutz/goof"
+	"github.com/akutz/gotil"
+	"golang.org/x/net/context"
 
 	"github.com/emccode/rexray/core"
 	"github.com/emccode/rexray/core/errors"
 )
 
-const providerName = "Isilon"
+const (
+	providerName = "Isilon"
+
+	bytesPerGB = 1024 * 1024 * 1024
+)
 
 // The Isilon storage driver.
 type driver struct {
-	client *isi.Client
+	client api
 	r      *core.RexRay
 }
 
@@ -49,7 +59,7 @@ func eff(fields goof.Fields) map[string]interface{} {
 
 func init() {
 	core.RegisterDriver(providerName, newDriver)
-	gofig.Register(configRegistration())
+	core.RegisterConfigSchema(configRegistration())
 }
 
 func newDriver() core.Driver {
@@ -76,17 +86,17 @@ func (d *driver) Init(r *core.RexRay) error {
 		return goof.WithFields(fields, "device not detected")
 	}
 
-	var err error
-
-	if d.client, err = isi.NewClientWithArgs(
+	c, err := newClient(
 		d.endpoint(),
 		d.insecure(),
 		d.userName(),
 		d.password(),
-		d.volumePath()); err != nil {
+		d.volumePath())
+	if err != nil {
 		return goof.WithFieldsE(fields,
 			"error creating isilon client", err)
 	}
+	d.client = c
 
 	log.WithField("provider", providerName).Info("storage driver initialized")
 
@@ -118,39 +128,83 @@ func (d *driver) Name() string {
 	return providerName
 }
 
-func (d *driver) GetInstance() (*core.Instance, error) {
-	return &core.Instance{}, nil
+// GetInstance returns the local host. The instance ID is the address of the
+// host that is added to the clients of the NFS exports of the volumes that
+// are attached to the host.
+func (d *driver) GetInstance(ctx context.Context) (*core.Instance, error) {
+	clientAddress, err := d.clientAddress()
+	if err != nil {
+		return nil, err
+	}
 
-	//	instance := &core.Instance{
-	//		ProviderName: providerName,
-	//		InstanceID:   strconv.Itoa(initiator.Index),
-	//		Region:       "",
-	//		Name:         initiator.Name,
-	//	}
+	hostname, _ := os.Hostname()
 
-	// log.Println("Got Instance: " + fmt.Sprintf("%+v", instance))
-	//	return instance, nil
+	return &core.Instance{
+		ProviderName: providerName,
+		InstanceID:   clientAddress,
+		Region:       "",
+		Name:         hostname,
+	}, nil
+}
+
+// clientAddress returns the configured client address, or else the address
+// of the interface with which the host reaches the NFS host, or else the
+// host's name.
+func (d *driver) clientAddress() (string, error) {
+	if a := d.r.Config.GetString("isilon.clientAddress"); a != "" {
+		return a, nil
+	}
+
+	host := d.nfsHost()
+	if host == "" {
+		if u, err := url.Parse(d.endpoint()); err == nil {
+			host = u.Host
+		}
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	// dialing UDP does not send any packets
+	if conn, err := net.Dial("udp", net.JoinHostPort(host, "2049")); err == nil {
+		defer conn.Close()
+		return conn.LocalAddr().(*net.UDPAddr).IP.String(), nil
+	}
+
+	return os.Hostname()
 }
 
 func (d *driver) nfsMountPath(mountPath string) string {
 	return fmt.Sprintf("%s:%s", d.nfsHost(), mountPath)
 }
 
-func (d *driver) GetVolumeMapping() ([]*core.BlockDevice, error) {
-	exports, err := d.client.GetVolumeExports()
+// GetVolumeMapping returns the volumes that are exported to the local host.
+func (d *driver) GetVolumeMapping(
+	ctx context.Context) ([]*core.BlockDevice, error) {
+
+	clientAddress, err := d.clientAddress()
+	if err != nil {
+		return nil, err
+	}
+
+	exports, err := d.exports()
 	if err != nil {
 		return nil, err
 	}
 
 	var BlockDevices []*core.BlockDevice
-	for _, export := range exports {
+	for volumeName, export := range exports {
+		if !gotil.StringInSlice(clientAddress, exportClients(export)) {
+			continue
+		}
+		exportPath := d.client.Path(volumeName)
 		device := &core.BlockDevice{
 			ProviderName: providerName,
-			InstanceID:   "",
+			InstanceID:   clientAddress,
 			Region:       "",
-			DeviceName:   d.nfsMountPath(export.ExportPath),
-			VolumeID:     export.Volume.Name,
-			NetworkName:  export.ExportPath,
+			DeviceName:   d.nfsMountPath(exportPath),
+			VolumeID:     volumeName,
+			NetworkName:  exportPath,
 			Status:       "",
 		}
 		BlockDevices = append(BlockDevices, device)
@@ -159,6 +213,51 @@ func (d *driver) GetVolumeMapping() ([]*core.BlockDevice, error) {
 	return BlockDevices, nil
 }
 
+// exports returns the NFS exports of the volumes by volume name.
+func (d *driver) exports() (map[string]*isiExport, error) {
+	exports, err := d.client.GetExports()
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]*isiExport{}
+	for _, e := range exports {
+		for _, p := range e.Paths {
+			if volumeName := path.Base(p); d.client.Path(volumeName) == p {
+				m[volumeName] = e
+			}
+		}
+	}
+	return m, nil
+}
+
+// exportClients returns the clients to which the export grants read-write
+// access.
+func exportClients(e *isiExport) []string {
+	var clients []string
+	clients = append(clients, e.Clients...)
+	return append(clients, e.RootClients...)
+}
+
+// volumeAttachments returns an attachment of the volume for each client to
+// which the volume's export grants read-write access.
+func (d *driver) volumeAttachments(
+	volumeName string, e *isiExport) []*core.VolumeAttachment {
+
+	if e == nil {
+		return nil
+	}
+	var attachments []*core.VolumeAttachment
+	for _, c := range exportClients(e) {
+		attachments = append(attachments, &core.VolumeAttachment{
+			VolumeID:   volumeName,
+			InstanceID: c,
+			DeviceName: d.nfsMountPath(d.client.Path(volumeName)),
+			Status:     "",
+		})
+	}
+	return attachments
+}
+
 func (d *driver) getVolume(volumeID, volumeName string) ([]isi.Volume, error) {
 	var volumes []isi.Volume
 	if volumeID != "" || volumeName != "" {
@@ -180,7 +279,9 @@ func (d *driver) getVolume(volumeID, volumeName string) ([]isi.Volume, error) {
 	return volumes, nil
 }
 
-func (d *driver) GetVolume(volumeID, volumeName string) ([]*core.Volume, error) {
+func (d *driver) GetVolume(
+	ctx context.Context,
+	volumeID, volumeName string) ([]*core.Volume, error) {
 	volumes, err := d.getVolume(volumeID, volumeName)
 	if err != nil {
 		return nil, err
@@ -190,36 +291,26 @@ func (d *driver) GetVolume(volumeID, volumeName string) ([]*core.Volume, error)
 		return nil, nil
 	}
 
-	localVolumeMappings, err := d.GetVolumeMapping()
+	exports, err := d.exports()
 	if err != nil {
 		return nil, err
 	}
 
-	blockDeviceMap := make(map[string]*core.BlockDevice)
-	for _, volume := range localVolumeMappings {
-		blockDeviceMap[volume.VolumeID] = volume
+	quotas, err := d.quotas()
+	if err != nil {
+		return nil, err
 	}
 
 	var volumesSD []*core.Volume
 	for _, volume := range volumes {
-		var attachmentsSD []*core.VolumeAttachment
-		if _, exists := blockDeviceMap[volume.Name]; exists {
-			attachmentSD := &core.VolumeAttachment{
-				VolumeID: volume.Name,
-				//				InstanceID: strconv.Itoa(d.initiator.Index),
-				DeviceName: blockDeviceMap[volume.Name].DeviceName,
-				Status:     "",
-			}
-			attachmentsSD = append(attachmentsSD, attachmentSD)
-		}
-
 		volumeSD := &core.Volume{
 			Name:             volume.Name,
 			VolumeID:         volume.Name,
-			Size:             "", //strconv.Itoa(volSize / 1024 / 1024),
+			Size:             quotaSize(quotas[d.client.Path(volume.Name)]),
 			AvailabilityZone: "",
 			NetworkName:      d.client.Path(volume.Name), //volume.NaaName,
-			Attachments:      attachmentsSD,
+			Attachments: d.volumeAttachments(
+				volume.Name, exports[volume.Name]),
 		}
 		volumesSD = append(volumesSD, volumeSD)
 	}
@@ -228,31 +319,150 @@ func (d *driver) GetVolume(volumeID, volumeName string) ([]*core.Volume, error)
 }
 
 func (d *driver) CreateVolume(
+	ctx context.Context,
 	notUsed bool,
 	volumeName, volumeID, snapshotID, NUvolumeType string,
 	NUIOPS, size int64, NUavailabilityZone string) (*core.Volume, error) {
-	log.Println("Start CreateVolume() (", volumeName, ") (", volumeID, ")")
+	core.Logger(ctx).Println(
+		"Start CreateVolume() (", volumeName, ") (", volumeID, ")")
+
+	fields := eff(map[string]interface{}{
+		"volumeName": volumeName,
+		"snapshotID": snapshotID,
+		"size":       size,
+	})
+
+	if volumeName == "" {
+		return nil, goof.WithFields(fields, "volume name required")
+	}
+
+	volumePath := d.client.Path(volumeName)
+
+	if snapshotID != "" {
+		snapshot, err := d.client.GetSnapshot(snapshotID)
+		if err != nil {
+			return nil, goof.WithFieldsE(fields, "error getting snapshot", err)
+		}
+		if snapshot == nil {
+			return nil, goof.WithFields(fields, "snapshot not found")
+		}
 
-	newIsiVolume, _ := d.client.CreateVolume(volumeName)
-	volumes, _ := d.GetVolume(newIsiVolume.Name, newIsiVolume.Name)
+		// a volume restored from a snapshot without a size has the size of
+		// the volume from which the snapshot was taken
+		if size == 0 {
+			q, err := d.client.GetQuota(snapshot.Path)
+			if err != nil {
+				return nil, goof.WithFieldsE(
+					fields, "error getting snapshot volume quota", err)
+			}
+			if q != nil {
+				size = q.Thresholds.Hard / bytesPerGB
+			}
+		}
+
+		if err := d.client.CopySnapshot(snapshot, volumePath); err != nil {
+			return nil, goof.WithFieldsE(
+				fields, "error copying snapshot", err)
+		}
+	} else if _, err := d.client.CreateVolume(volumeName); err != nil {
+		return nil, goof.WithFieldsE(fields, "error creating volume", err)
+	}
+
+	if size > 0 {
+		if err := d.client.SetQuotaSize(
+			volumePath, size*bytesPerGB); err != nil {
+			if rerr := d.client.DeleteVolume(volumeName); rerr != nil {
+				log.WithFields(eff(goof.Fields{
+					"volumeName": volumeName,
+					"error":      rerr,
+				})).Warn("error removing volume after quota error")
+			}
+			return nil, goof.WithFieldsE(
+				fields, "error setting volume quota", err)
+		}
+	}
+
+	volumes, err := d.GetVolume(ctx, volumeName, volumeName)
+	if err != nil {
+		return nil, err
+	}
+	if len(volumes) == 0 {
+		return nil, errors.ErrNoVolumesReturned
+	}
 
 	return volumes[0], nil
 }
 
-func (d *driver) RemoveVolume(volumeID string) error {
+func (d *driver) RemoveVolume(ctx context.Context, volumeID string) error {
 	err := d.client.DeleteVolume(volumeID)
 	if err != nil {
 		return err
 	}
 
-	log.Println("Deleted Volume: " + volumeID)
+	// the quota is cleared once the volume is deleted so that a volume that
+	// cannot be deleted keeps its limit
+	if err := d.client.ClearQuota(d.client.Path(volumeID)); err != nil {
+		core.Logger(ctx).WithFields(eff(goof.Fields{
+			"volumeID": volumeID,
+			"error":    err,
+		})).Warn("error removing volume quota")
+	}
+
+	core.Logger(ctx).Println("Deleted Volume: " + volumeID)
 	return nil
 }
 
-//GetSnapshot returns snapshots from a volume or a specific snapshot
+// GetSnapshot returns snapshots from a volume or a specific snapshot
 func (d *driver) GetSnapshot(
+	ctx context.Context,
 	volumeID, snapshotID, snapshotName string) ([]*core.Snapshot, error) {
-	return nil, nil
+
+	var snapshots []*isiSnapshot
+	if snapshotID != "" {
+		snapshot, err := d.client.GetSnapshot(snapshotID)
+		if err != nil {
+			return nil, err
+		}
+		if snapshot != nil {
+			snapshots = append(snapshots, snapshot)
+		}
+	} else {
+		var err error
+		if snapshots, err = d.client.GetSnapshots(); err != nil {
+			return nil, err
+		}
+	}
+
+	quotas, err := d.quotas()
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshotsSD []*core.Snapshot
+	for _, snapshot := range snapshots {
+		// only the snapshots of volumes are returned
+		snapshotVolumeID := path.Base(snapshot.Path)
+		if d.client.Path(snapshotVolumeID) != snapshot.Path {
+			continue
+		}
+		if volumeID != "" && snapshotVolumeID != volumeID {
+			continue
+		}
+		if snapshotName != "" && snapshot.Name != snapshotName {
+			continue
+		}
+		snapshotsSD = append(snapshotsSD, &core.Snapshot{
+			Name:       snapshot.Name,
+			VolumeID:   snapshotVolumeID,
+			SnapshotID: snapshot.snapshotID(),
+			VolumeSize: quotaSize(quotas[snapshot.Path]),
+			StartTime: time.Unix(
+				snapshot.Created, 0).UTC().Format(time.RFC3339),
+			Status: snapshot.State,
+		})
+	}
+
+	return snapshotsSD, nil
 }
 
 func getIndex(href string) string {
@@ -261,39 +471,91 @@ func getIndex(href string) string {
 }
 
 func (d *driver) CreateSnapshot(
+	ctx context.Context,
 	notUsed bool,
 	snapshotName, volumeID, description string) ([]*core.Snapshot, error) {
-	return nil, nil
+
+	if volumeID == "" {
+		return nil, errors.ErrMissingVolumeID
+	}
+
+	snapshot, err := d.client.CreateSnapshot(
+		d.client.Path(volumeID), snapshotName)
+	if err != nil {
+		return nil, goof.WithFieldsE(eff(goof.Fields{
+			"volumeID":     volumeID,
+			"snapshotName": snapshotName,
+		}), "error creating snapshot", err)
+	}
+
+	core.Logger(ctx).Println("Created Snapshot: " + snapshot.snapshotID())
+	return d.GetSnapshot(ctx, "", snapshot.snapshotID(), "")
 }
 
-func (d *driver) RemoveSnapshot(snapshotID string) error {
+func (d *driver) RemoveSnapshot(ctx context.Context, snapshotID string) error {
+	if snapshotID == "" {
+		return goof.WithFields(ef(), "missing snapshot ID")
+	}
+
+	if err := d.client.RemoveSnapshot(snapshotID); err != nil {
+		return goof.WithFieldsE(eff(goof.Fields{"snapshotID": snapshotID}),
+			"error removing snapshot", err)
+	}
+
+	core.Logger(ctx).Println("Deleted Snapshot: " + snapshotID)
 	return nil
 }
 
-func (d *driver) GetVolumeAttach(volumeID, instanceID string) ([]*core.VolumeAttachment, error) {
+// quotas returns the directory quotas by path.
+func (d *driver) quotas() (map[string]*isiQuota, error) {
+	quotas, err := d.client.GetQuotas()
+	if err != nil {
+		return nil, err
+	}
+	m := map[string]*isiQuota{}
+	for _, q := range quotas {
+		m[q.Path] = q
+	}
+	return m, nil
+}
+
+// quotaSize returns the size, in GB, of a volume with the quota, which is the
+// quota's hard threshold rounded up to the next GB.
+func quotaSize(q *isiQuota) string {
+	if q == nil || q.Thresholds.Hard == 0 {
+		return ""
+	}
+	return strconv.FormatInt((q.Thresholds.Hard+bytesPerGB-1)/bytesPerGB, 10)
+}
+
+func (d *driver) GetVolumeAttach(
+	ctx context.Context,
+	volumeID, instanceID string) ([]*core.VolumeAttachment, error) {
 	if volumeID == "" {
 		return []*core.VolumeAttachment{}, errors.ErrMissingVolumeID
 	}
-	volume, err := d.GetVolume(volumeID, "")
+	volume, err := d.GetVolume(ctx, volumeID, "")
 	if err != nil {
 		return []*core.VolumeAttachment{}, err
 	}
 
+	if len(volume) == 0 {
+		return []*core.VolumeAttachment{}, errors.ErrNoVolumesReturned
+	}
+
 	if instanceID != "" {
-		var attached bool
 		for _, volumeAttachment := range volume[0].Attachments {
 			if volumeAttachment.InstanceID == instanceID {
-				return volume[0].Attachments, nil
+				return []*core.VolumeAttachment{volumeAttachment}, nil
 			}
 		}
-		if !attached {
-			return []*core.VolumeAttachment{}, nil
-		}
+		return []*core.VolumeAttachment{}, nil
 	}
 	return volume[0].Attachments, nil
 }
 
 func (d *driver) AttachVolume(
+	ctx context.Context,
 	notused bool,
 	volumeID, instanceID string, force bool) ([]*core.VolumeAttachment, error) {
 
@@ -301,7 +563,7 @@ func (d *driver) AttachVolume(
 		return nil, errors.ErrMissingVolumeID
 	}
 
-	volumes, err := d.GetVolume(volumeID, "")
+	volumes, err := d.GetVolume(ctx, volumeID, "")
 	if err != nil {
 		return nil, err
 	}
@@ -310,11 +572,17 @@ func (d *driver) AttachVolume(
 		return nil, errors.ErrNoVolumesReturned
 	}
 
-	if err := d.client.ExportVolume(volumeID); err != nil {
+	if instanceID == "" {
+		if instanceID, err = d.clientAddress(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := d.exportVolume(volumeID, instanceID, force); err != nil {
 		return nil, goof.WithError("problem exporting volume", err)
 	}
 
-	volumeAttachment, err := d.GetVolumeAttach(volumeID, instanceID)
+	volumeAttachment, err := d.GetVolumeAttach(ctx, volumeID, instanceID)
 	if err != nil {
 		return nil, err
 	}
@@ -323,12 +591,14 @@ func (d *driver) AttachVolume(
 
 }
 
-func (d *driver) DetachVolume(notUsed bool, volumeID string, blank string, force bool) error {
+func (d *driver) DetachVolume(
+	ctx context.Context,
+	notUsed bool, volumeID string, instanceID string, force bool) error {
 	if volumeID == "" {
 		return errors.ErrMissingVolumeID
 	}
 
-	volumes, err := d.GetVolume(volumeID, "")
+	volumes, err := d.GetVolume(ctx, volumeID, "")
 	if err != nil {
 		return err
 	}
@@ -337,22 +607,141 @@ func (d *driver) DetachVolume(notUsed bool, volumeID string, blank string, force
 		return errors.ErrNoVolumesReturned
 	}
 
-	if err := d.client.UnexportVolume(volumeID); err != nil {
+	if instanceID == "" {
+		if instanceID, err = d.clientAddress(); err != nil {
+			return err
+		}
+	}
+
+	if err := d.unexportVolume(volumeID, instanceID, force); err != nil {
 		return goof.WithError("problem unexporting volume", err)
 	}
 
-	log.Println("Detached volume", volumeID)
+	core.Logger(ctx).Println("Detached volume", volumeID)
 	return nil
 }
 
+// isOpenExport returns a flag indicating whether or not the export has no
+// clients, and is therefore open to all clients. Volumes exported by earlier
+// versions of the driver have such exports.
+func isOpenExport(e *isiExport) bool {
+	return len(e.Clients) == 0 &&
+		len(e.RootClients) == 0 &&
+		len(e.ReadOnlyClients) == 0
+}
+
+// exportVolume adds the client to the clients of the volume's NFS export,
+// creating the export if the volume is not exported. The export's other
+// clients are removed if force is true. An export that is open to all clients
+// is only restricted to the client if force is true, as restricting it would
+// revoke the access of the other hosts that use the volume.
+func (d *driver) exportVolume(volumeName, client string, force bool) error {
+	volumePath := d.client.Path(volumeName)
+
+	e, err := d.client.GetExport(volumePath)
+	if err != nil {
+		return err
+	}
+
+	if e != nil && isOpenExport(e) {
+		fields := eff(goof.Fields{
+			"volumeName": volumeName,
+			"client":     client,
+		})
+		if !force {
+			return goof.WithFields(fields,
+				"volume is exported to all clients; "+
+					"attach it with force to restrict its export")
+		}
+		log.WithFields(fields).Warn(
+			"restricting export of volume that was exported to all clients")
+	}
+
+	create := e == nil
+	if create {
+		e = &isiExport{Paths: []string{volumePath}}
+	} else if gotil.StringInSlice(client, exportClients(e)) && !force {
+		return nil
+	}
+
+	if force {
+		e.Clients = nil
+		e.RootClients = nil
+	}
+
+	if d.rootSquash() {
+		e.Clients = append(e.Clients, client)
+	} else {
+		e.RootClients = append(e.RootClients, client)
+	}
+
+	for _, c := range d.readOnlyClients() {
+		if !gotil.StringInSlice(c, e.ReadOnlyClients) {
+			e.ReadOnlyClients = append(e.ReadOnlyClients, c)
+		}
+	}
+
+	if create {
+		return d.client.CreateExport(e)
+	}
+	return d.client.UpdateExport(e)
+}
+
+// unexportVolume removes the client from the clients of the volume's NFS
+// export, removing the export once it has no read-write clients. All of the
+// clients are removed if force is true. An export that is open to all clients
+// is only removed if force is true.
+func (d *driver) unexportVolume(volumeName, client string, force bool) error {
+	e, err := d.client.GetExport(d.client.Path(volumeName))
+	if err != nil || e == nil {
+		return err
+	}
+
+	if isOpenExport(e) && !force {
+		log.WithFields(eff(goof.Fields{
+			"volumeName": volumeName,
+			"client":     client,
+		})).Warn("not removing export of volume exported to all clients")
+		return nil
+	}
+
+	if !force {
+		e.Clients = removeClient(e.Clients, client)
+		e.RootClients = removeClient(e.RootClients, client)
+	}
+
+	if force || len(exportClients(e)) == 0 {
+		return d.client.RemoveExport(e)
+	}
+	return d.client.UpdateExport(e)
+}
+
+func removeClient(clients []string, client string) []string {
+	var res []string
+	for _, c := range clients {
+		if c != client {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
 func (d *driver) CopySnapshot(
+	ctx context.Context,
 	runAsync bool,
 	volumeID, snapshotID, snapshotName,
 	destinationSnapshotName, destinationRegion string) (*core.Snapshot, error) {
 	return nil, errors.ErrNotImplemented
 }
 
-func (d *driver) GetDeviceNextAvailable() (string, error) {
+func (d *driver) CloneVolume(
+	ctx context.Context,
+	sourceVolumeID, newName string,
+	opts core.VolumeOpts) (*core.Volume, error) {
+	return core.CloneVolumeFromSnapshot(ctx, d, sourceVolumeID, newName, opts)
+}
+
+func (d *driver) GetDeviceNextAvailable(ctx context.Context) (string, error) {
 	return "", errors.ErrNotImplemented
 }
 
@@ -380,13 +769,28 @@ func (d *driver) nfsHost() string {
 	return d.r.Config.GetString("isilon.nfsHost")
 }
 
-func configRegistration() *gofig.Registration {
-	r := gofig.NewRegistration("Isilon")
+func (d *driver) rootSquash() bool {
+	return d.r.Config.GetBool("isilon.rootSquash")
+}
+
+func (d *driver) readOnlyClients() []string {
+	return d.r.Config.GetStringSlice("isilon.readOnlyClients")
+}
+
+func configRegistration() *core.ConfigSchema {
+	r := core.NewConfigSchema("Isilon", providerName)
 	r.Key(gofig.String, "", "", "", "isilon.endpoint")
 	r.Key(gofig.Bool, "", false, "", "isilon.insecure")
 	r.Key(gofig.String, "", "", "", "isilon.userName")
 	r.Key(gofig.String, "", "", "", "isilon.password")
 	r.Key(gofig.String, "", "", "", "isilon.volumePath")
 	r.Key(gofig.String, "", "", "", "isilon.nfsHost")
+	r.Key(gofig.String, "", "", "", "isilon.clientAddress")
+	r.Key(gofig.Bool, "", true, "", "isilon.rootSquash")
+	r.Key(gofig.String, "", "", "", "isilon.readOnlyClients")
+	r.Require("isilon.endpoint")
+	r.Require("isilon.userName")
+	r.Require("isilon.volumePath")
+	r.Secret("isilon.password")
 	return r
 }
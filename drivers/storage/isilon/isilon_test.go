@@ -0,0 +1,452 @@
+package isilon
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/akutz/gofig"
+	"golang.org/x/net/context"
+
+	"github.com/emccode/rexray/core"
+)
+
+// papiStub is a stub of the NFS export, snapshot, quota, and namespace APIs
+// of an Isilon cluster.
+type papiStub struct {
+	sync.Mutex
+	exports   map[string]*isiExport
+	snapshots map[string]*isiSnapshot
+	quotas    map[string]*isiQuota
+	copies    map[string]string
+	nextID    int64
+}
+
+func newPAPIStub() *papiStub {
+	return &papiStub{
+		exports:   map[string]*isiExport{},
+		snapshots: map[string]*isiSnapshot{},
+		quotas:    map[string]*isiQuota{},
+		copies:    map[string]string{},
+	}
+}
+
+func (s *papiStub) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	if u, p, ok := req.BasicAuth(); !ok || u != "admin" || p != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	const (
+		exportsPath   = "/platform/1/protocols/nfs/exports"
+		snapshotsPath = "/platform/1/snapshot/snapshots"
+		quotasPath    = "/platform/1/quota/quotas"
+	)
+
+	notFound := func() {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(&papiErrors{Errors: []*papiError{
+			&papiError{Code: "AEC_NOT_FOUND", Message: "not found"}}})
+	}
+
+	p := req.URL.Path
+	switch {
+	case req.Method == "GET" && p == exportsPath:
+		var res struct {
+			Exports []*isiExport `json:"exports"`
+		}
+		for _, v := range s.exports {
+			if ep := req.URL.Query().Get("path"); ep == "" || ep == v.Paths[0] {
+				res.Exports = append(res.Exports, v)
+			}
+		}
+		json.NewEncoder(w).Encode(&res)
+
+	case req.Method == "POST" && p == exportsPath:
+		var v isiExport
+		json.NewDecoder(req.Body).Decode(&v)
+		s.nextID++
+		v.ID = s.nextID
+		s.exports[strconv.FormatInt(v.ID, 10)] = &v
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]int64{"id": v.ID})
+
+	case strings.HasPrefix(p, exportsPath+"/"):
+		v, ok := s.exports[strings.TrimPrefix(p, exportsPath+"/")]
+		if !ok {
+			notFound()
+			return
+		}
+		if req.Method == "DELETE" {
+			delete(s.exports, strconv.FormatInt(v.ID, 10))
+		} else {
+			json.NewDecoder(req.Body).Decode(v)
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	case req.Method == "GET" && p == snapshotsPath:
+		var res struct {
+			Snapshots []*isiSnapshot `json:"snapshots"`
+		}
+		for _, v := range s.snapshots {
+			res.Snapshots = append(res.Snapshots, v)
+		}
+		json.NewEncoder(w).Encode(&res)
+
+	case req.Method == "POST" && p == snapshotsPath:
+		var in map[string]string
+		json.NewDecoder(req.Body).Decode(&in)
+		s.nextID++
+		v := &isiSnapshot{
+			ID:      s.nextID,
+			Name:    in["name"],
+			Path:    in["path"],
+			Created: 1460000000,
+			State:   "active",
+		}
+		s.snapshots[v.snapshotID()] = v
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(v)
+
+	case strings.HasPrefix(p, snapshotsPath+"/"):
+		v, ok := s.snapshots[strings.TrimPrefix(p, snapshotsPath+"/")]
+		if !ok {
+			notFound()
+			return
+		}
+		if req.Method == "DELETE" {
+			delete(s.snapshots, v.snapshotID())
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string][]*isiSnapshot{
+			"snapshots": []*isiSnapshot{v}})
+
+	case req.Method == "GET" && p == quotasPath:
+		var res struct {
+			Quotas []*isiQuota `json:"quotas"`
+		}
+		for _, v := range s.quotas {
+			if qp := req.URL.Query().Get("path"); qp == "" || qp == v.Path {
+				res.Quotas = append(res.Quotas, v)
+			}
+		}
+		json.NewEncoder(w).Encode(&res)
+
+	case req.Method == "POST" && p == quotasPath:
+		var v isiQuota
+		json.NewDecoder(req.Body).Decode(&v)
+		s.nextID++
+		v.ID = strconv.FormatInt(s.nextID, 10)
+		s.quotas[v.ID] = &v
+		w.WriteHeader(http.StatusCreated)
+
+	case strings.HasPrefix(p, quotasPath+"/"):
+		v, ok := s.quotas[strings.TrimPrefix(p, quotasPath+"/")]
+		if !ok {
+			notFound()
+			return
+		}
+		if req.Method == "DELETE" {
+			delete(s.quotas, v.ID)
+		} else {
+			json.NewDecoder(req.Body).Decode(v)
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	case req.Method == "PUT" && strings.HasPrefix(p, "/namespace/"):
+		s.copies[strings.TrimPrefix(p, "/namespace")] =
+			req.Header.Get("x-isi-ifs-copy-source")
+
+	default:
+		notFound()
+	}
+}
+
+// testAPI is the Isilon API with the stubbed platform API and a volume path.
+type testAPI struct {
+	*client
+}
+
+func (a *testAPI) Path(volumeName string) string {
+	return "/ifs/volumes/" + volumeName
+}
+
+func newTestDriver() (*driver, *papiStub, func()) {
+	stub := newPAPIStub()
+	srv := httptest.NewServer(stub)
+	config := gofig.New()
+	config.Set("isilon.nfsHost", "nfs")
+	config.Set("isilon.clientAddress", "10.0.0.1")
+	config.Set("isilon.rootSquash", true)
+	d := &driver{
+		r: core.New(config),
+		client: &testAPI{&client{
+			endpoint: srv.URL,
+			userName: "admin",
+			password: "secret",
+			http:     http.DefaultClient,
+		}},
+	}
+	return d, stub, srv.Close
+}
+
+func TestSnapshots(t *testing.T) {
+	d, stub, done := newTestDriver()
+	defer done()
+	ctx := context.Background()
+
+	snapshots, err := d.CreateSnapshot(ctx, false, "snap1", "vol1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 1 ||
+		snapshots[0].Name != "snap1" ||
+		snapshots[0].VolumeID != "vol1" ||
+		snapshots[0].Status != "active" {
+		t.Fatalf("unexpected snapshots %+v", snapshots)
+	}
+	snapshotID := snapshots[0].SnapshotID
+
+	if _, err := d.CreateSnapshot(ctx, false, "snap2", "vol2", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// snapshots of directories that are not volumes are not returned
+	if _, err := d.client.CreateSnapshot("/ifs/home", "home"); err != nil {
+		t.Fatal(err)
+	}
+
+	if snapshots, err = d.GetSnapshot(ctx, "", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 2 {
+		t.Fatalf("expected 2 snapshots, got %d", len(snapshots))
+	}
+
+	if snapshots, err = d.GetSnapshot(ctx, "vol1", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 1 || snapshots[0].SnapshotID != snapshotID {
+		t.Fatalf("unexpected snapshots %+v", snapshots)
+	}
+
+	if err := d.RemoveSnapshot(ctx, snapshotID); err != nil {
+		t.Fatal(err)
+	}
+	if snapshots, err = d.GetSnapshot(ctx, "", snapshotID, ""); err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 0 {
+		t.Fatalf("snapshot not removed %+v", snapshots)
+	}
+	if err := d.RemoveSnapshot(ctx, snapshotID); err == nil {
+		t.Fatal("removed a missing snapshot")
+	}
+
+	snapshot, err := d.client.GetSnapshot("2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.client.CopySnapshot(
+		snapshot, d.client.Path("vol3")); err != nil {
+		t.Fatal(err)
+	}
+	if src := stub.copies["/ifs/volumes/vol3"]; src !=
+		"/namespace/ifs/.snapshot/snap2/volumes/vol2" {
+		t.Fatalf("unexpected copy source %s", src)
+	}
+}
+
+func TestQuotas(t *testing.T) {
+	d, _, done := newTestDriver()
+	defer done()
+
+	p := d.client.Path("vol1")
+	if err := d.client.SetQuotaSize(p, 8*bytesPerGB); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.client.SetQuotaSize(p, 16*bytesPerGB); err != nil {
+		t.Fatal(err)
+	}
+
+	quotas, err := d.quotas()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quotas) != 1 {
+		t.Fatalf("expected 1 quota, got %d", len(quotas))
+	}
+	if hard := quotas[p].Thresholds.Hard; hard != 16*bytesPerGB {
+		t.Fatalf("unexpected hard threshold %d", hard)
+	}
+
+	if err := d.client.ClearQuota(p); err != nil {
+		t.Fatal(err)
+	}
+	if q, err := d.client.GetQuota(p); err != nil || q != nil {
+		t.Fatalf("quota not cleared %v %v", q, err)
+	}
+	if err := d.client.ClearQuota(p); err != nil {
+		t.Fatal(err)
+	}
+
+	q := &isiQuota{}
+	q.Thresholds.Hard = 16 * bytesPerGB
+	if size := quotaSize(q); size != "16" {
+		t.Fatalf("unexpected size %s", size)
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	d, _, done := newTestDriver()
+	defer done()
+
+	d.client.(*testAPI).password = "wrong"
+	if _, err := d.client.GetSnapshots(); err == nil {
+		t.Fatal("unauthorized request succeeded")
+	}
+}
+
+func TestExports(t *testing.T) {
+	d, stub, done := newTestDriver()
+	defer done()
+	d.r.Config.Set("isilon.readOnlyClients", []string{"backup"})
+
+	if err := d.exportVolume("vol1", "10.0.0.1", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.exportVolume("vol1", "10.0.0.1", false); err != nil {
+		t.Fatal(err)
+	}
+	d.r.Config.Set("isilon.rootSquash", false)
+	if err := d.exportVolume("vol1", "10.0.0.2", false); err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := d.client.GetExport(d.client.Path("vol1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stub.exports) != 1 ||
+		len(e.Clients) != 1 || e.Clients[0] != "10.0.0.1" ||
+		len(e.RootClients) != 1 || e.RootClients[0] != "10.0.0.2" ||
+		len(e.ReadOnlyClients) != 1 || e.ReadOnlyClients[0] != "backup" {
+		t.Fatalf("unexpected export %+v", e)
+	}
+
+	exports, err := d.exports()
+	if err != nil {
+		t.Fatal(err)
+	}
+	attachments := d.volumeAttachments("vol1", exports["vol1"])
+	if len(attachments) != 2 ||
+		attachments[0].InstanceID != "10.0.0.1" ||
+		attachments[0].DeviceName != "nfs:/ifs/volumes/vol1" ||
+		attachments[1].InstanceID != "10.0.0.2" {
+		t.Fatalf("unexpected attachments %+v", attachments)
+	}
+
+	bds, err := d.GetVolumeMapping(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bds) != 1 || bds[0].VolumeID != "vol1" {
+		t.Fatalf("unexpected volume mapping %+v", bds)
+	}
+
+	if err := d.unexportVolume("vol1", "10.0.0.1", false); err != nil {
+		t.Fatal(err)
+	}
+	if e, err = d.client.GetExport(d.client.Path("vol1")); err != nil {
+		t.Fatal(err)
+	}
+	if len(e.Clients) != 0 || len(e.RootClients) != 1 {
+		t.Fatalf("unexpected export %+v", e)
+	}
+
+	if err := d.unexportVolume("vol1", "10.0.0.2", false); err != nil {
+		t.Fatal(err)
+	}
+	if len(stub.exports) != 0 {
+		t.Fatal("export not removed")
+	}
+	if err := d.unexportVolume("vol1", "10.0.0.2", false); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExportsForce(t *testing.T) {
+	d, stub, done := newTestDriver()
+	defer done()
+
+	if err := d.exportVolume("vol1", "10.0.0.1", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.exportVolume("vol1", "10.0.0.2", true); err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := d.client.GetExport(d.client.Path("vol1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(e.Clients) != 1 || e.Clients[0] != "10.0.0.2" {
+		t.Fatalf("unexpected export %+v", e)
+	}
+
+	if err := d.exportVolume("vol1", "10.0.0.1", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.unexportVolume("vol1", "", true); err != nil {
+		t.Fatal(err)
+	}
+	if len(stub.exports) != 0 {
+		t.Fatal("export not removed")
+	}
+}
+
+func TestExportsOpen(t *testing.T) {
+	d, stub, done := newTestDriver()
+	defer done()
+
+	// an export without clients, as created by earlier versions of the
+	// driver, is open to all clients
+	p := d.client.Path("vol1")
+	if err := d.client.CreateExport(
+		&isiExport{Paths: []string{p}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.exportVolume("vol1", "10.0.0.1", false); err == nil {
+		t.Fatal("restricted an export that is open to all clients")
+	}
+	if err := d.unexportVolume("vol1", "10.0.0.1", false); err != nil {
+		t.Fatal(err)
+	}
+	e, err := d.client.GetExport(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e == nil || !isOpenExport(e) {
+		t.Fatalf("unexpected export %+v", e)
+	}
+
+	if err := d.exportVolume("vol1", "10.0.0.1", true); err != nil {
+		t.Fatal(err)
+	}
+	if e, err = d.client.GetExport(p); err != nil {
+		t.Fatal(err)
+	}
+	if len(stub.exports) != 1 ||
+		len(e.Clients) != 1 || e.Clients[0] != "10.0.0.1" {
+		t.Fatalf("unexpected export %+v", e)
+	}
+}
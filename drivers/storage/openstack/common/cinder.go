@@ -0,0 +1,501 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/akutz/goof"
+
+	"github.com/rackspace/gophercloud"
+)
+
+const (
+	// maxMicroversion is the highest Cinder v3 microversion the driver
+	// requests.
+	maxMicroversion = "3.50"
+
+	// attachmentsMicroversion is the Cinder v3 microversion of the
+	// attachments API.
+	attachmentsMicroversion = "3.27"
+
+	// multiattachMicroversion is the Cinder v3 microversion from which
+	// multi-attach volumes are created from volume types with the extra spec
+	// multiattach="<is> True".
+	multiattachMicroversion = "3.50"
+
+	// novaMultiattachMicroversion is the Nova microversion that attaches
+	// multi-attach volumes to more than one server.
+	novaMultiattachMicroversion = "2.60"
+)
+
+// blockStorageAPIs are the versions of the Cinder API and their service
+// catalog types, in the order in which they are discovered.
+var blockStorageAPIs = []struct {
+	version     string
+	serviceType string
+}{
+	{"v3", "volumev3"},
+	{"v2", "volumev2"},
+	{"v1", "volume"},
+}
+
+// cinder is a client of the Cinder block storage API v1, v2, or v3.
+type cinder struct {
+	client       *gophercloud.ServiceClient
+	version      string
+	microversion string
+}
+
+type cinderVolume struct {
+	ID               string                   `json:"id"`
+	Name             string                   `json:"name"`
+	DisplayName      string                   `json:"display_name"`
+	Status           string                   `json:"status"`
+	Size             int                      `json:"size"`
+	AvailabilityZone string                   `json:"availability_zone"`
+	VolumeType       string                   `json:"volume_type"`
+	SnapshotID       string                   `json:"snapshot_id"`
+	Encrypted        bool                     `json:"encrypted"`
+	Multiattach      bool                     `json:"multiattach"`
+	Attachments      []cinderVolumeAttachment `json:"attachments"`
+}
+
+type cinderVolumeAttachment struct {
+	AttachmentID string `json:"attachment_id"`
+	ServerID     string `json:"server_id"`
+	HostName     string `json:"host_name"`
+	VolumeID     string `json:"volume_id"`
+	Device       string `json:"device"`
+}
+
+// cinderAttachment is an attachment of the attachments API.
+type cinderAttachment struct {
+	ID         string `json:"id"`
+	Status     string `json:"status"`
+	Instance   string `json:"instance"`
+	VolumeID   string `json:"volume_id"`
+	AttachMode string `json:"attach_mode"`
+}
+
+type cinderSnapshot struct {
+	ID                 string `json:"id"`
+	Name               string `json:"name"`
+	DisplayName        string `json:"display_name"`
+	Description        string `json:"description"`
+	DisplayDescription string `json:"display_description"`
+	VolumeID           string `json:"volume_id"`
+	Status             string `json:"status"`
+	Size               int    `json:"size"`
+	CreatedAt          string `json:"created_at"`
+}
+
+type cinderVolumeType struct {
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	ExtraSpecs map[string]string `json:"extra_specs"`
+}
+
+type cinderLink struct {
+	Href string `json:"href"`
+	Rel  string `json:"rel"`
+}
+
+type cinderVolumeCreateOpts struct {
+	Name             string
+	Size             int
+	SnapshotID       string
+	VolumeType       string
+	AvailabilityZone string
+	Multiattach      bool
+}
+
+// newCinder returns a client of the Cinder API version, or the newest
+// version in the service catalog if the version is empty. The endpointOpts
+// returns the options of the endpoint of a service type.
+func newCinder(
+	provider *gophercloud.ProviderClient,
+	endpointOpts func(serviceType string) gophercloud.EndpointOpts,
+	version string) (*cinder, error) {
+
+	fields := goof.Fields{"version": version}
+
+	for _, api := range blockStorageAPIs {
+		if version != "" && !strings.EqualFold(version, api.version) {
+			continue
+		}
+
+		eo := endpointOpts(api.serviceType)
+		eo.Type = api.serviceType
+		eo.ApplyDefaults(api.serviceType)
+		fields["region"] = eo.Region
+		endpoint, err := provider.EndpointLocator(eo)
+		if err == gophercloud.ErrEndpointNotFound && version == "" {
+			continue
+		}
+		if err != nil {
+			fields["serviceType"] = api.serviceType
+			return nil, goof.WithFieldsE(
+				fields, "error getting block storage endpoint", err)
+		}
+
+		c := &cinder{
+			client: &gophercloud.ServiceClient{
+				ProviderClient: provider,
+				Endpoint:       gophercloud.NormalizeURL(endpoint),
+			},
+			version: api.version,
+		}
+		if c.version == "v3" {
+			c.microversion = c.getMicroversion()
+		}
+		return c, nil
+	}
+
+	if version != "" {
+		return nil, goof.WithFields(
+			fields, "invalid block storage API version; must be v1, v2, or v3")
+	}
+	return nil, goof.WithFields(fields, "no block storage endpoint found")
+}
+
+// getMicroversion returns the highest microversion of the v3 endpoint, up
+// to maxMicroversion, or 3.0 if the endpoint does not report it.
+func (c *cinder) getMicroversion() string {
+	endpoint := c.client.Endpoint
+	if i := strings.Index(endpoint, "/v3/"); i >= 0 {
+		endpoint = endpoint[:i+len("/v3/")]
+	}
+
+	var res struct {
+		Version  *cinderVersion  `json:"version"`
+		Versions []cinderVersion `json:"versions"`
+	}
+	if err := c.do(
+		"GET", endpoint, nil, &res, 200, 300); err != nil {
+		log.WithFields(log.Fields{
+			"endpoint": endpoint,
+			"error":    err,
+		}).Warn("error getting block storage microversion")
+		return "3.0"
+	}
+	if res.Version != nil {
+		res.Versions = append(res.Versions, *res.Version)
+	}
+
+	for _, v := range res.Versions {
+		if !strings.HasPrefix(v.ID, "v3") || v.Version == "" {
+			continue
+		}
+		if microversionAtLeast(v.Version, maxMicroversion) {
+			return maxMicroversion
+		}
+		return v.Version
+	}
+	return "3.0"
+}
+
+type cinderVersion struct {
+	ID      string `json:"id"`
+	Version string `json:"version"`
+}
+
+// microversionAtLeast returns a flag indicating whether or not the
+// microversion v, ex. 3.27, is greater than or equal to min.
+func microversionAtLeast(v, min string) bool {
+	parse := func(s string) (int, int) {
+		parts := strings.SplitN(s, ".", 2)
+		major, _ := strconv.Atoi(parts[0])
+		minor := 0
+		if len(parts) == 2 {
+			minor, _ = strconv.Atoi(parts[1])
+		}
+		return major, minor
+	}
+	vMajor, vMinor := parse(v)
+	minMajor, minMinor := parse(min)
+	return vMajor > minMajor || (vMajor == minMajor && vMinor >= minMinor)
+}
+
+// supports returns a flag indicating whether or not the client's API
+// supports the v3 microversion.
+func (c *cinder) supports(microversion string) bool {
+	return c.version == "v3" && microversionAtLeast(c.microversion, microversion)
+}
+
+// do issues the request and decodes the JSON response into res if it is
+// not nil.
+func (c *cinder) do(
+	method, url string, body, res interface{}, okCodes ...int) error {
+
+	opts := gophercloud.RequestOpts{JSONBody: body, OkCodes: okCodes}
+	if c.microversion != "" {
+		opts.MoreHeaders = map[string]string{
+			"OpenStack-API-Version": "volume " + c.microversion,
+		}
+	}
+
+	resp, err := c.client.Request(method, url, opts)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if res == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(res)
+}
+
+// nameKey returns the key of the name or description of the API version,
+// ex. display_name in v1.
+func (c *cinder) nameKey(key string) string {
+	if c.version == "v1" {
+		return "display_" + key
+	}
+	return key
+}
+
+func (c *cinder) listVolumes() ([]cinderVolume, error) {
+	var volumes []cinderVolume
+	for u := c.client.ServiceURL("volumes", "detail"); u != ""; {
+		var res struct {
+			Volumes []cinderVolume `json:"volumes"`
+			Links   []cinderLink   `json:"volumes_links"`
+		}
+		if err := c.do("GET", u, nil, &res, 200); err != nil {
+			return nil, err
+		}
+		for i := range res.Volumes {
+			volumes = append(volumes, c.normalizeVolume(res.Volumes[i]))
+		}
+		u = nextLink(res.Links)
+	}
+	return volumes, nil
+}
+
+func (c *cinder) getVolume(volumeID string) (*cinderVolume, error) {
+	var res struct {
+		Volume cinderVolume `json:"volume"`
+	}
+	if err := c.do(
+		"GET", c.client.ServiceURL("volumes", volumeID), nil, &res,
+		200); err != nil {
+		return nil, err
+	}
+	volume := c.normalizeVolume(res.Volume)
+	return &volume, nil
+}
+
+func (c *cinder) createVolume(
+	opts *cinderVolumeCreateOpts) (*cinderVolume, error) {
+
+	volume := map[string]interface{}{
+		c.nameKey("name"): opts.Name,
+		"size":            opts.Size,
+	}
+	if opts.SnapshotID != "" {
+		volume["snapshot_id"] = opts.SnapshotID
+	}
+	if opts.VolumeType != "" {
+		volume["volume_type"] = opts.VolumeType
+	}
+	if opts.AvailabilityZone != "" {
+		volume["availability_zone"] = opts.AvailabilityZone
+	}
+	if opts.Multiattach {
+		if c.version == "v1" {
+			return nil, goof.New(
+				"multi-attach volumes require block storage API v2 or v3")
+		}
+		volume["multiattach"] = true
+	}
+
+	var res struct {
+		Volume cinderVolume `json:"volume"`
+	}
+	if err := c.do(
+		"POST", c.client.ServiceURL("volumes"),
+		map[string]interface{}{"volume": volume}, &res,
+		200, 202); err != nil {
+		return nil, err
+	}
+	v := c.normalizeVolume(res.Volume)
+	return &v, nil
+}
+
+func (c *cinder) deleteVolume(volumeID string) error {
+	return c.do(
+		"DELETE", c.client.ServiceURL("volumes", volumeID), nil, nil, 202)
+}
+
+// forceDetach detaches the attachment of the volume, or all of the volume's
+// attachments if the attachment ID is empty, without the involvement of the
+// server.
+func (c *cinder) forceDetach(volumeID, attachmentID string) error {
+	forceDetach := map[string]interface{}{}
+	if attachmentID != "" && c.version != "v1" {
+		forceDetach["attachment_id"] = attachmentID
+	}
+	return c.do(
+		"POST", c.client.ServiceURL("volumes", volumeID, "action"),
+		map[string]interface{}{"os-force_detach": forceDetach}, nil,
+		202)
+}
+
+// listAttachments returns the attachments of the volume from the
+// attachments API, which includes the attachments that are in progress.
+func (c *cinder) listAttachments(
+	volumeID string) ([]cinderAttachment, error) {
+
+	var res struct {
+		Attachments []cinderAttachment `json:"attachments"`
+	}
+	if err := c.do(
+		"GET",
+		c.client.ServiceURL("attachments", "detail")+"?"+url.Values{
+			"volume_id": {volumeID},
+		}.Encode(),
+		nil, &res, 200); err != nil {
+		return nil, err
+	}
+	return res.Attachments, nil
+}
+
+func (c *cinder) listVolumeTypes() ([]cinderVolumeType, error) {
+	var res struct {
+		VolumeTypes []cinderVolumeType `json:"volume_types"`
+	}
+	if err := c.do(
+		"GET", c.client.ServiceURL("types"), nil, &res, 200); err != nil {
+		return nil, err
+	}
+	return res.VolumeTypes, nil
+}
+
+func (c *cinder) listSnapshots(
+	volumeID, snapshotName string) ([]cinderSnapshot, error) {
+
+	query := url.Values{}
+	if volumeID != "" {
+		query.Set("volume_id", volumeID)
+	}
+	if snapshotName != "" {
+		query.Set(c.nameKey("name"), snapshotName)
+	}
+
+	u := c.client.ServiceURL("snapshots", "detail")
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var snapshots []cinderSnapshot
+	for u != "" {
+		var res struct {
+			Snapshots []cinderSnapshot `json:"snapshots"`
+			Links     []cinderLink     `json:"snapshots_links"`
+		}
+		if err := c.do("GET", u, nil, &res, 200); err != nil {
+			return nil, err
+		}
+		for _, s := range res.Snapshots {
+			s = c.normalizeSnapshot(s)
+			if (volumeID == "" || s.VolumeID == volumeID) &&
+				(snapshotName == "" || s.Name == snapshotName) {
+				snapshots = append(snapshots, s)
+			}
+		}
+		u = nextLink(res.Links)
+	}
+	return snapshots, nil
+}
+
+func (c *cinder) getSnapshot(snapshotID string) (*cinderSnapshot, error) {
+	var res struct {
+		Snapshot cinderSnapshot `json:"snapshot"`
+	}
+	if err := c.do(
+		"GET", c.client.ServiceURL("snapshots", snapshotID), nil, &res,
+		200); err != nil {
+		return nil, err
+	}
+	snapshot := c.normalizeSnapshot(res.Snapshot)
+	return &snapshot, nil
+}
+
+func (c *cinder) createSnapshot(
+	snapshotName, volumeID, description string) (*cinderSnapshot, error) {
+
+	var res struct {
+		Snapshot cinderSnapshot `json:"snapshot"`
+	}
+	if err := c.do(
+		"POST", c.client.ServiceURL("snapshots"),
+		map[string]interface{}{
+			"snapshot": map[string]interface{}{
+				c.nameKey("name"):        snapshotName,
+				c.nameKey("description"): description,
+				"volume_id":              volumeID,
+				"force":                  true,
+			},
+		}, &res, 200, 202); err != nil {
+		return nil, err
+	}
+	snapshot := c.normalizeSnapshot(res.Snapshot)
+	return &snapshot, nil
+}
+
+func (c *cinder) deleteSnapshot(snapshotID string) error {
+	return c.do(
+		"DELETE", c.client.ServiceURL("snapshots", snapshotID), nil, nil, 202)
+}
+
+// normalizeVolume sets the name of a v1 volume.
+func (c *cinder) normalizeVolume(v cinderVolume) cinderVolume {
+	if v.Name == "" {
+		v.Name = v.DisplayName
+	}
+	return v
+}
+
+// normalizeSnapshot sets the name and description of a v1 snapshot.
+func (c *cinder) normalizeSnapshot(s cinderSnapshot) cinderSnapshot {
+	if s.Name == "" {
+		s.Name = s.DisplayName
+	}
+	if s.Description == "" {
+		s.Description = s.DisplayDescription
+	}
+	return s
+}
+
+// multiattach returns a flag indicating whether or not the volume type
+// creates multi-attach volumes, and whether or not its extra specs are
+// visible.
+func (t *cinderVolumeType) multiattach() (bool, bool) {
+	if t.ExtraSpecs == nil {
+		return false, false
+	}
+	return strings.EqualFold(
+		strings.Join(strings.Fields(t.ExtraSpecs["multiattach"]), " "),
+		"<is> True"), true
+}
+
+func nextLink(links []cinderLink) string {
+	for _, l := range links {
+		if l.Rel == "next" {
+			return l.Href
+		}
+	}
+	return ""
+}
+
+func (c *cinder) String() string {
+	if c.microversion != "" {
+		return fmt.Sprintf("%s (%s)", c.version, c.microversion)
+	}
+	return c.version
+}
@@ -0,0 +1,262 @@
+// Package common is the storage driver core shared by the OpenStack-based
+// providers, ex. OpenStack and Rackspace, which manages Cinder volumes and
+// their Nova attachments.
+package common
+
+import (
+	"os/exec"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/akutz/gofig"
+	"github.com/akutz/goof"
+
+	"github.com/emccode/rexray/core"
+
+	"github.com/rackspace/gophercloud"
+	"github.com/rackspace/gophercloud/openstack"
+)
+
+// Provider is an OpenStack-based provider, which supplies the instance
+// metadata, authentication, and endpoints of the provider to the Driver.
+type Provider interface {
+
+	// Name returns the name of the provider, ex. Openstack. Its lower-case
+	// form is the prefix of the provider's configuration keys.
+	Name() string
+
+	// MinSize returns the minimum size of a volume, in GB.
+	MinSize() int64
+
+	// DevicePrefix returns the prefix of the names of the block devices of
+	// attached volumes, ex. vd.
+	DevicePrefix() string
+
+	// InstanceID returns the ID of the instance.
+	InstanceID(config gofig.Config) (string, error)
+
+	// Region returns the region of the instance. It is used when the
+	// regionName is not configured.
+	Region(config gofig.Config) (string, error)
+
+	// AvailabilityZone returns the availability zone of the instance, or an
+	// empty string if the provider has none. It is used when the
+	// availabilityZoneName is not configured.
+	AvailabilityZone(config gofig.Config) (string, error)
+
+	// AuthenticatedClient returns a client authenticated with the options.
+	AuthenticatedClient(
+		opts gophercloud.AuthOptions) (*gophercloud.ProviderClient, error)
+
+	// EndpointOpts returns the options of the endpoint of the service type,
+	// ex. compute or volumev2, in the region.
+	EndpointOpts(serviceType, region string) gophercloud.EndpointOpts
+}
+
+// Driver is the storage driver of an OpenStack-based provider.
+type Driver struct {
+	p                Provider
+	provider         *gophercloud.ProviderClient
+	client           *gophercloud.ServiceClient
+	cinder           *cinder
+	region           string
+	availabilityZone string
+	instanceID       string
+	r                *core.RexRay
+}
+
+// NewDriver returns a new storage driver of the provider.
+func NewDriver(p Provider) *Driver {
+	return &Driver{p: p}
+}
+
+func (d *Driver) ef() goof.Fields {
+	return goof.Fields{
+		"provider": d.p.Name(),
+	}
+}
+
+func (d *Driver) eff(fields goof.Fields) map[string]interface{} {
+	errFields := map[string]interface{}{
+		"provider": d.p.Name(),
+	}
+	if fields != nil {
+		for k, v := range fields {
+			errFields[k] = v
+		}
+	}
+	return errFields
+}
+
+// Init initializes the driver.
+func (d *Driver) Init(r *core.RexRay) error {
+	d.r = r
+	fields := d.ef()
+	var err error
+
+	if d.instanceID, err = d.p.InstanceID(d.r.Config); err != nil {
+		return err
+	}
+
+	fields["instanceId"] = d.instanceID
+
+	if d.regionName() == "" {
+		if d.region, err = d.p.Region(d.r.Config); err != nil {
+			return err
+		}
+	} else {
+		d.region = d.regionName()
+	}
+	fields["region"] = d.region
+
+	if d.availabilityZoneName() == "" {
+		if d.availabilityZone, err = d.p.AvailabilityZone(
+			d.r.Config); err != nil {
+			return err
+		}
+	} else {
+		d.availabilityZone = d.availabilityZoneName()
+	}
+	fields["availabilityZone"] = d.availabilityZone
+
+	authOpts := d.getAuthOptions()
+
+	fields["identityEndpoint"] = d.authURL()
+	fields["userId"] = d.userID()
+	fields["userName"] = d.userName()
+	if d.password() == "" {
+		fields["password"] = ""
+	} else {
+		fields["password"] = "******"
+	}
+	fields["tenantId"] = d.tenantID()
+	fields["tenantName"] = d.tenantName()
+	fields["domainId"] = d.domainID()
+	fields["domainName"] = d.domainName()
+
+	if d.provider, err = d.p.AuthenticatedClient(authOpts); err != nil {
+		return goof.WithFieldsE(fields,
+			"error getting authenticated client", err)
+	}
+
+	if d.client, err = openstack.NewComputeV2(
+		d.provider, d.endpointOpts("compute")); err != nil {
+		return goof.WithFieldsE(fields, "error getting newComputeV2", err)
+	}
+
+	if d.cinder, err = newCinder(
+		d.provider, d.endpointOpts, d.blockStorageAPIVersion()); err != nil {
+		return goof.WithFieldsE(fields,
+			"error getting block storage client", err)
+	}
+
+	log.WithFields(log.Fields{
+		"provider":        d.p.Name(),
+		"blockStorageAPI": d.cinder.String(),
+	}).Info("storage driver initialized")
+
+	return nil
+}
+
+// Name returns the name of the provider.
+func (d *Driver) Name() string {
+	return d.p.Name()
+}
+
+// NewCmd returns a command whose environment is the environment of the
+// configuration.
+func NewCmd(c gofig.Config, name string, args ...string) *exec.Cmd {
+	cmd := exec.Command(name, args...)
+	cmd.Env = c.EnvVars()
+	return cmd
+}
+
+func (d *Driver) getAuthOptions() gophercloud.AuthOptions {
+	return gophercloud.AuthOptions{
+		IdentityEndpoint: d.authURL(),
+		UserID:           d.userID(),
+		Username:         d.userName(),
+		Password:         d.password(),
+		TenantID:         d.tenantID(),
+		TenantName:       d.tenantName(),
+		DomainID:         d.domainID(),
+		DomainName:       d.domainName(),
+		AllowReauth:      true,
+	}
+}
+
+func (d *Driver) endpointOpts(serviceType string) gophercloud.EndpointOpts {
+	return d.p.EndpointOpts(serviceType, d.region)
+}
+
+func (d *Driver) key(name string) string {
+	return strings.ToLower(d.p.Name()) + "." + name
+}
+
+func (d *Driver) authURL() string {
+	return d.r.Config.GetString(d.key("authURL"))
+}
+
+func (d *Driver) userID() string {
+	return d.r.Config.GetString(d.key("userID"))
+}
+
+func (d *Driver) userName() string {
+	return d.r.Config.GetString(d.key("userName"))
+}
+
+func (d *Driver) password() string {
+	return d.r.Config.GetString(d.key("password"))
+}
+
+func (d *Driver) tenantID() string {
+	return d.r.Config.GetString(d.key("tenantID"))
+}
+
+func (d *Driver) tenantName() string {
+	return d.r.Config.GetString(d.key("tenantName"))
+}
+
+func (d *Driver) domainID() string {
+	return d.r.Config.GetString(d.key("domainID"))
+}
+
+func (d *Driver) domainName() string {
+	return d.r.Config.GetString(d.key("domainName"))
+}
+
+func (d *Driver) regionName() string {
+	return d.r.Config.GetString(d.key("regionName"))
+}
+
+func (d *Driver) availabilityZoneName() string {
+	return d.r.Config.GetString(d.key("availabilityZoneName"))
+}
+
+func (d *Driver) blockStorageAPIVersion() string {
+	return d.r.Config.GetString(d.key("blockStorageAPIVersion"))
+}
+
+// ConfigRegistration returns the configuration schema of the keys shared by
+// the OpenStack-based providers, ex. openstack.authURL for the provider
+// Openstack.
+func ConfigRegistration(name string) *core.ConfigSchema {
+	p := strings.ToLower(name) + "."
+	r := core.NewConfigSchema(name, name)
+	r.Key(gofig.String, "", "", "", p+"authURL")
+	r.Key(gofig.String, "", "", "", p+"userID")
+	r.Key(gofig.String, "", "", "", p+"userName")
+	r.Key(gofig.String, "", "", "", p+"password")
+	r.Key(gofig.String, "", "", "", p+"tenantID")
+	r.Key(gofig.String, "", "", "", p+"tenantName")
+	r.Key(gofig.String, "", "", "", p+"domainID")
+	r.Key(gofig.String, "", "", "", p+"domainName")
+	r.Key(gofig.String, "", "", "", p+"regionName")
+	r.Key(gofig.String, "", "", "", p+"availabilityZoneName")
+	r.Key(gofig.String, "", "", "", p+"blockStorageAPIVersion")
+	r.Exclude(p+"userID", p+"userName")
+	r.Exclude(p+"tenantID", p+"tenantName")
+	r.Exclude(p+"domainID", p+"domainName")
+	r.Secret(p + "password")
+	return r
+}
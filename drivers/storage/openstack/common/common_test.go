@@ -0,0 +1,844 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/akutz/gofig"
+	"golang.org/x/net/context"
+
+	"github.com/rackspace/gophercloud"
+	"github.com/rackspace/gophercloud/openstack"
+
+	"github.com/emccode/rexray/core"
+)
+
+// fakeOpenStack is a fake of Keystone v2.0, Nova, and the Cinder API
+// versions of the tenant t1 with the servers vm1 and vm2.
+type fakeOpenStack struct {
+	sync.Mutex
+	t   *testing.T
+	srv *httptest.Server
+
+	// the Cinder API versions in the service catalog and the highest v3
+	// microversion
+	volumeAPIs      []string
+	maxMicroversion string
+
+	volumes     map[string]*fakeVolume
+	snapshots   map[string]*cinderSnapshot
+	volumeTypes []cinderVolumeType
+	nextID      int
+}
+
+type fakeVolume struct {
+	cinderVolume
+
+	// the attachments that are in progress, which only the attachments API
+	// returns
+	pending []cinderAttachment
+}
+
+func newFakeOpenStack(t *testing.T, volumeAPIs ...string) *fakeOpenStack {
+	f := &fakeOpenStack{
+		t:               t,
+		volumeAPIs:      volumeAPIs,
+		maxMicroversion: "3.59",
+		volumes:         map[string]*fakeVolume{},
+		snapshots:       map[string]*cinderSnapshot{},
+		volumeTypes: []cinderVolumeType{
+			{ID: "t-1", Name: "lvm", ExtraSpecs: map[string]string{}},
+			{ID: "t-2", Name: "shared", ExtraSpecs: map[string]string{
+				"multiattach": "<is>  True",
+			}},
+		},
+	}
+	f.srv = httptest.NewServer(f)
+	return f
+}
+
+func (f *fakeOpenStack) newID(prefix string) string {
+	f.nextID++
+	return fmt.Sprintf("%s%d", prefix, f.nextID)
+}
+
+func (f *fakeOpenStack) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	switch {
+	case req.URL.Path == "/v2.0/tokens":
+		f.serveTokens(w)
+	case len(parts) == 2 && parts[0] == "volume" && parts[1] == "v3":
+		writeJSON(w, 200, map[string]interface{}{
+			"versions": []map[string]string{{
+				"id":          "v3.0",
+				"status":      "CURRENT",
+				"version":     f.maxMicroversion,
+				"min_version": "3.0",
+			}},
+		})
+	case len(parts) > 3 && parts[0] == "volume" && parts[2] == "t1":
+		f.serveCinder(w, req, parts[1], parts[3:])
+	case len(parts) > 3 && parts[0] == "compute" && parts[2] == "t1":
+		f.serveNova(w, req, parts[3:])
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeOpenStack) serveTokens(w http.ResponseWriter) {
+	endpoint := func(url string) []map[string]string {
+		return []map[string]string{{
+			"region":      "RegionOne",
+			"publicURL":   url,
+			"internalURL": url,
+			"adminURL":    url,
+		}}
+	}
+
+	catalog := []map[string]interface{}{{
+		"name":      "nova",
+		"type":      "compute",
+		"endpoints": endpoint(f.srv.URL + "/compute/v2.1/t1"),
+	}}
+	for _, v := range f.volumeAPIs {
+		serviceType := "volume" + v
+		if v == "v1" {
+			serviceType = "volume"
+		}
+		catalog = append(catalog, map[string]interface{}{
+			"name":      "cinder" + v,
+			"type":      serviceType,
+			"endpoints": endpoint(f.srv.URL + "/volume/" + v + "/t1"),
+		})
+	}
+
+	writeJSON(w, 200, map[string]interface{}{
+		"access": map[string]interface{}{
+			"token": map[string]interface{}{
+				"id":      "token1",
+				"expires": "2030-01-01T00:00:00Z",
+				"tenant":  map[string]string{"id": "t1", "name": "t1"},
+			},
+			"serviceCatalog": catalog,
+			"user":           map[string]string{"id": "u1", "name": "u1"},
+		},
+	})
+}
+
+func (f *fakeOpenStack) serveCinder(
+	w http.ResponseWriter, req *http.Request, version string, parts []string) {
+
+	microversion := ""
+	if h := req.Header.Get("OpenStack-API-Version"); h != "" {
+		microversion = strings.TrimPrefix(h, "volume ")
+	}
+	if (version == "v3") != (microversion != "") {
+		f.t.Errorf("unexpected microversion %q of %s", microversion, version)
+	}
+
+	nameKey := "name"
+	if version == "v1" {
+		nameKey = "display_name"
+	}
+
+	var body map[string]map[string]interface{}
+	if req.Method == "POST" {
+		json.NewDecoder(req.Body).Decode(&body)
+	}
+
+	switch {
+	case req.Method == "GET" && parts[0] == "types":
+		writeJSON(w, 200, map[string]interface{}{"volume_types": f.volumeTypes})
+
+	case req.Method == "GET" && len(parts) == 2 && parts[0] == "volumes" &&
+		parts[1] == "detail":
+		volumes := []interface{}{}
+		for _, v := range f.volumes {
+			volumes = append(volumes, f.volumeJSON(v, nameKey))
+		}
+		writeJSON(w, 200, map[string]interface{}{"volumes": volumes})
+
+	case req.Method == "GET" && len(parts) == 2 && parts[0] == "volumes":
+		v, ok := f.volumes[parts[1]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writeJSON(w, 200, map[string]interface{}{
+			"volume": f.volumeJSON(v, nameKey),
+		})
+
+	case req.Method == "POST" && len(parts) == 1 && parts[0] == "volumes":
+		opts := body["volume"]
+		v := &fakeVolume{cinderVolume: cinderVolume{
+			ID:               f.newID("vol-"),
+			Name:             opts[nameKey].(string),
+			Status:           "available",
+			Size:             int(opts["size"].(float64)),
+			AvailabilityZone: "nova",
+		}}
+		if az, ok := opts["availability_zone"].(string); ok {
+			v.AvailabilityZone = az
+		}
+		if t, ok := opts["volume_type"].(string); ok {
+			v.VolumeType = t
+		}
+		if microversionAtLeast(microversion, "3.50") {
+			for _, t := range f.volumeTypes {
+				if t.Name == v.VolumeType {
+					v.Multiattach, _ = t.multiattach()
+				}
+			}
+		} else {
+			v.Multiattach, _ = opts["multiattach"].(bool)
+		}
+		f.volumes[v.ID] = v
+		writeJSON(w, 202, map[string]interface{}{
+			"volume": f.volumeJSON(v, nameKey),
+		})
+
+	case req.Method == "DELETE" && len(parts) == 2 && parts[0] == "volumes":
+		delete(f.volumes, parts[1])
+		w.WriteHeader(http.StatusAccepted)
+
+	case req.Method == "POST" && len(parts) == 3 && parts[2] == "action":
+		v, ok := f.volumes[parts[1]]
+		forceDetach, isForceDetach := body["os-force_detach"]
+		if !ok || !isForceDetach {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		attachmentID, _ := forceDetach["attachment_id"].(string)
+		var attachments []cinderVolumeAttachment
+		for _, a := range v.Attachments {
+			if attachmentID != "" && a.AttachmentID != attachmentID {
+				attachments = append(attachments, a)
+			}
+		}
+		f.setAttachments(v, attachments)
+		w.WriteHeader(http.StatusAccepted)
+
+	case req.Method == "GET" && len(parts) == 2 && parts[0] == "attachments":
+		if !microversionAtLeast(microversion, "3.27") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		attachments := []cinderAttachment{}
+		if v, ok := f.volumes[req.URL.Query().Get("volume_id")]; ok {
+			for _, a := range v.Attachments {
+				attachments = append(attachments, cinderAttachment{
+					ID:         a.AttachmentID,
+					Status:     "attached",
+					Instance:   a.ServerID,
+					VolumeID:   v.ID,
+					AttachMode: "rw",
+				})
+			}
+			attachments = append(attachments, v.pending...)
+		}
+		writeJSON(w, 200, map[string]interface{}{"attachments": attachments})
+
+	case req.Method == "POST" && len(parts) == 1 && parts[0] == "snapshots":
+		opts := body["snapshot"]
+		volumeID := opts["volume_id"].(string)
+		s := &cinderSnapshot{
+			ID:       f.newID("snap-"),
+			Name:     opts[nameKey].(string),
+			VolumeID: volumeID,
+			Status:   "available",
+			Size:     f.volumes[volumeID].Size,
+		}
+		f.snapshots[s.ID] = s
+		writeJSON(w, 202, map[string]interface{}{
+			"snapshot": snapshotJSON(s, nameKey),
+		})
+
+	case req.Method == "GET" && len(parts) == 2 && parts[0] == "snapshots" &&
+		parts[1] == "detail":
+		snapshots := []interface{}{}
+		for _, s := range f.snapshots {
+			snapshots = append(snapshots, snapshotJSON(s, nameKey))
+		}
+		writeJSON(w, 200, map[string]interface{}{"snapshots": snapshots})
+
+	case req.Method == "GET" && len(parts) == 2 && parts[0] == "snapshots":
+		s, ok := f.snapshots[parts[1]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writeJSON(w, 200, map[string]interface{}{
+			"snapshot": snapshotJSON(s, nameKey),
+		})
+
+	case req.Method == "DELETE" && len(parts) == 2 && parts[0] == "snapshots":
+		delete(f.snapshots, parts[1])
+		w.WriteHeader(http.StatusAccepted)
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeOpenStack) serveNova(
+	w http.ResponseWriter, req *http.Request, parts []string) {
+
+	if len(parts) < 2 || parts[0] != "servers" ||
+		(parts[1] != "vm1" && parts[1] != "vm2") {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	serverID := parts[1]
+
+	switch {
+	case req.Method == "GET" && len(parts) == 2:
+		writeJSON(w, 200, map[string]interface{}{
+			"server": map[string]string{"id": serverID, "name": serverID},
+		})
+
+	case req.Method == "GET" && len(parts) == 3:
+		attachments := []map[string]string{}
+		for _, v := range f.volumes {
+			for _, a := range v.Attachments {
+				if a.ServerID == serverID {
+					attachments = append(attachments, map[string]string{
+						"id":       v.ID,
+						"device":   a.Device,
+						"serverId": serverID,
+						"volumeId": v.ID,
+					})
+				}
+			}
+		}
+		writeJSON(w, 200, map[string]interface{}{
+			"volumeAttachments": attachments,
+		})
+
+	case req.Method == "POST" && len(parts) == 3:
+		var body struct {
+			VolumeAttachment struct {
+				VolumeID string `json:"volumeId"`
+				Device   string `json:"device"`
+			} `json:"volumeAttachment"`
+		}
+		json.NewDecoder(req.Body).Decode(&body)
+		v, ok := f.volumes[body.VolumeAttachment.VolumeID]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		microversion := req.Header.Get("X-OpenStack-Nova-API-Version")
+		if (len(v.Attachments) > 0 && !v.Multiattach) ||
+			(v.Multiattach && microversion != "2.60") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		a := cinderVolumeAttachment{
+			AttachmentID: f.newID("att-"),
+			ServerID:     serverID,
+			VolumeID:     v.ID,
+			Device:       body.VolumeAttachment.Device,
+		}
+		f.setAttachments(v, append(v.Attachments, a))
+		writeJSON(w, 200, map[string]interface{}{
+			"volumeAttachment": map[string]string{
+				"id":       v.ID,
+				"device":   a.Device,
+				"serverId": serverID,
+				"volumeId": v.ID,
+			},
+		})
+
+	case req.Method == "DELETE" && len(parts) == 4:
+		v, ok := f.volumes[parts[3]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var attachments []cinderVolumeAttachment
+		for _, a := range v.Attachments {
+			if a.ServerID != serverID {
+				attachments = append(attachments, a)
+			}
+		}
+		f.setAttachments(v, attachments)
+		w.WriteHeader(http.StatusAccepted)
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeOpenStack) setAttachments(
+	v *fakeVolume, attachments []cinderVolumeAttachment) {
+
+	v.Attachments = attachments
+	if len(attachments) == 0 {
+		v.Status = "available"
+	} else {
+		v.Status = "in-use"
+	}
+}
+
+func (f *fakeOpenStack) volumeJSON(
+	v *fakeVolume, nameKey string) map[string]interface{} {
+
+	attachments := []cinderVolumeAttachment{}
+	attachments = append(attachments, v.Attachments...)
+	return map[string]interface{}{
+		"id":                v.ID,
+		nameKey:             v.Name,
+		"status":            v.Status,
+		"size":              v.Size,
+		"availability_zone": v.AvailabilityZone,
+		"volume_type":       v.VolumeType,
+		"multiattach":       v.Multiattach,
+		"attachments":       attachments,
+	}
+}
+
+func snapshotJSON(s *cinderSnapshot, nameKey string) map[string]interface{} {
+	return map[string]interface{}{
+		"id":        s.ID,
+		nameKey:     s.Name,
+		"volume_id": s.VolumeID,
+		"status":    s.Status,
+		"size":      s.Size,
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func newTestProvider(
+	t *testing.T, f *fakeOpenStack) *gophercloud.ProviderClient {
+
+	provider, err := openstack.AuthenticatedClient(gophercloud.AuthOptions{
+		IdentityEndpoint: f.srv.URL + "/v2.0/",
+		Username:         "u1",
+		Password:         "p1",
+		TenantID:         "t1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider
+}
+
+// fakeProvider is a provider of the fake OpenStack whose instance is vm1 in
+// RegionOne.
+type fakeProvider struct {
+	minSize      int64
+	devicePrefix string
+}
+
+func (p *fakeProvider) Name() string         { return "Fake" }
+func (p *fakeProvider) MinSize() int64       { return p.minSize }
+func (p *fakeProvider) DevicePrefix() string { return p.devicePrefix }
+
+func (p *fakeProvider) InstanceID(config gofig.Config) (string, error) {
+	return "vm1", nil
+}
+
+func (p *fakeProvider) Region(config gofig.Config) (string, error) {
+	return "RegionOne", nil
+}
+
+func (p *fakeProvider) AvailabilityZone(config gofig.Config) (string, error) {
+	return "", nil
+}
+
+func (p *fakeProvider) AuthenticatedClient(
+	opts gophercloud.AuthOptions) (*gophercloud.ProviderClient, error) {
+	return openstack.AuthenticatedClient(opts)
+}
+
+func (p *fakeProvider) EndpointOpts(
+	serviceType, region string) gophercloud.EndpointOpts {
+	return gophercloud.EndpointOpts{Region: region}
+}
+
+func regionOpts(region string) func(string) gophercloud.EndpointOpts {
+	return func(string) gophercloud.EndpointOpts {
+		return gophercloud.EndpointOpts{Region: region}
+	}
+}
+
+func newTestDriver(
+	t *testing.T, f *fakeOpenStack, version string) *Driver {
+
+	provider := newTestProvider(t, f)
+	client, err := openstack.NewComputeV2(
+		provider, gophercloud.EndpointOpts{Region: "RegionOne"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := newCinder(provider, regionOpts("RegionOne"), version)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &Driver{
+		p:                &fakeProvider{1, "vd"},
+		r:                core.New(gofig.New()),
+		provider:         provider,
+		client:           client,
+		cinder:           c,
+		region:           "RegionOne",
+		availabilityZone: "nova",
+		instanceID:       "vm1",
+	}
+}
+
+func newTestConfig(f *fakeOpenStack) gofig.Config {
+	config := gofig.New()
+	config.Set("fake.authURL", f.srv.URL+"/v2.0/")
+	config.Set("fake.userName", "u1")
+	config.Set("fake.password", "p1")
+	config.Set("fake.tenantID", "t1")
+	return config
+}
+
+func TestInit(t *testing.T) {
+	f := newFakeOpenStack(t, "v2")
+	defer f.srv.Close()
+
+	config := newTestConfig(f)
+	config.Set("fake.availabilityZoneName", "az1")
+	d := NewDriver(&fakeProvider{75, "xvd"})
+	if err := d.Init(core.New(config)); err != nil {
+		t.Fatal(err)
+	}
+	if d.Name() != "Fake" || d.instanceID != "vm1" ||
+		d.region != "RegionOne" || d.availabilityZone != "az1" ||
+		d.cinder.String() != "v2" {
+		t.Fatalf("unexpected driver %+v", d)
+	}
+
+	v, err := d.CreateVolume(
+		context.Background(), false, "v1", "", "", "", 0, 10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Size != "75" || v.AvailabilityZone != "az1" {
+		t.Fatalf("unexpected volume %+v", v)
+	}
+
+	attachments, err := d.AttachVolume(
+		context.Background(), false, v.VolumeID, "vm1", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attachments) != 1 ||
+		!strings.HasPrefix(attachments[0].DeviceName, "/dev/xvd") {
+		t.Fatalf("unexpected attachments %+v", attachments)
+	}
+
+	config = newTestConfig(f)
+	config.Set("fake.regionName", "RegionTwo")
+	if err := NewDriver(&fakeProvider{75, "xvd"}).Init(core.New(config)); err == nil {
+		t.Fatal("initialized a driver of a missing region")
+	}
+}
+
+func TestBlockStorageAPIDiscovery(t *testing.T) {
+	f := newFakeOpenStack(t, "v1", "v2", "v3")
+	defer f.srv.Close()
+	provider := newTestProvider(t, f)
+
+	for version, exp := range map[string]string{
+		"":   "v3 (3.50)",
+		"v3": "v3 (3.50)",
+		"V2": "v2",
+		"v1": "v1",
+	} {
+		c, err := newCinder(provider, regionOpts("RegionOne"), version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.String() != exp {
+			t.Errorf("%s: unexpected block storage API %s", version, c)
+		}
+	}
+
+	f.maxMicroversion = "3.27"
+	c, err := newCinder(provider, regionOpts("RegionOne"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.microversion != "3.27" || !c.supports(attachmentsMicroversion) ||
+		c.supports(multiattachMicroversion) {
+		t.Errorf("unexpected microversion %s", c.microversion)
+	}
+
+	if _, err := newCinder(provider, regionOpts("RegionOne"), "v4"); err == nil {
+		t.Error("got a client of an invalid version")
+	}
+	if _, err := newCinder(provider, regionOpts("RegionTwo"), ""); err == nil {
+		t.Error("got a client of a missing region")
+	}
+
+	f.volumeAPIs = []string{"v1", "v2"}
+	provider = newTestProvider(t, f)
+	if c, err = newCinder(provider, regionOpts("RegionOne"), ""); err != nil {
+		t.Fatal(err)
+	}
+	if c.version != "v2" {
+		t.Errorf("unexpected version %s", c.version)
+	}
+	if _, err := newCinder(provider, regionOpts("RegionOne"), "v3"); err == nil {
+		t.Error("got a client of a missing version")
+	}
+}
+
+func TestMicroversionAtLeast(t *testing.T) {
+	for _, tc := range []struct {
+		v, min string
+		exp    bool
+	}{
+		{"3.50", "3.27", true},
+		{"3.27", "3.27", true},
+		{"3.9", "3.27", false},
+		{"3.0", "3.50", false},
+		{"4.0", "3.50", true},
+	} {
+		if microversionAtLeast(tc.v, tc.min) != tc.exp {
+			t.Errorf("%s >= %s is not %v", tc.v, tc.min, tc.exp)
+		}
+	}
+}
+
+func TestVolumeTypes(t *testing.T) {
+	f := newFakeOpenStack(t, "v2")
+	defer f.srv.Close()
+	d := newTestDriver(t, f, "")
+
+	v, err := d.CreateVolume(
+		context.Background(), false, "v1", "", "", "t-1", 0, 10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.VolumeType != "lvm" || v.Name != "v1" || v.Size != "10" ||
+		v.AvailabilityZone != "nova" {
+		t.Fatalf("unexpected volume %+v", v)
+	}
+
+	if _, err = d.CreateVolume(
+		context.Background(), false, "v2", "", "", "ssd", 0, 10, ""); err == nil {
+		t.Fatal("created a volume with an invalid type")
+	}
+}
+
+func TestSnapshots(t *testing.T) {
+	for _, version := range []string{"v1", "v2", "v3"} {
+		f := newFakeOpenStack(t, version)
+		d := newTestDriver(t, f, "")
+
+		v, err := d.CreateVolume(
+			context.Background(), false, "v1", "", "", "", 0, 10, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		snapshots, err := d.CreateSnapshot(
+			context.Background(), false, "s1", v.VolumeID, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(snapshots) != 1 || snapshots[0].Name != "s1" ||
+			snapshots[0].VolumeID != v.VolumeID {
+			t.Fatalf("%s: unexpected snapshots %+v", version, snapshots)
+		}
+
+		if snapshots, err = d.GetSnapshot(
+			context.Background(), "", "", "s1"); err != nil {
+			t.Fatal(err)
+		}
+		if len(snapshots) != 1 {
+			t.Fatalf("%s: unexpected snapshots %+v", version, snapshots)
+		}
+
+		clone, err := d.CloneVolume(
+			context.Background(), v.VolumeID, "v2", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if clone.Name != "v2" || clone.Size != "10" {
+			t.Fatalf("%s: unexpected clone %+v", version, clone)
+		}
+
+		if err := d.RemoveSnapshot(
+			context.Background(), snapshots[0].SnapshotID); err != nil {
+			t.Fatal(err)
+		}
+		f.srv.Close()
+	}
+}
+
+func TestMultiAttach(t *testing.T) {
+	f := newFakeOpenStack(t, "v3")
+	defer f.srv.Close()
+	d := newTestDriver(t, f, "")
+
+	ctx := core.WithVolumeOpts(context.Background(),
+		core.VolumeOpts{"multiattach": "true"})
+	if _, err := d.CreateVolume(
+		ctx, false, "v1", "", "", "", 0, 10, ""); err == nil {
+		t.Fatal("created a multi-attach volume without a volume type")
+	}
+	if _, err := d.CreateVolume(
+		ctx, false, "v1", "", "", "lvm", 0, 10, ""); err == nil {
+		t.Fatal("created a multi-attach volume of a single-attach type")
+	}
+
+	v, err := d.CreateVolume(ctx, false, "v1", "", "", "shared", 0, 10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.MultiAttach {
+		t.Fatalf("volume is not multi-attach %+v", v)
+	}
+
+	for _, instanceID := range []string{"vm1", "vm2"} {
+		if _, err := d.AttachVolume(
+			context.Background(), false, v.VolumeID, instanceID,
+			false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// an external host reserving the volume
+	f.volumes[v.VolumeID].pending = []cinderAttachment{{
+		ID:       "att-host",
+		Status:   "reserved",
+		VolumeID: v.VolumeID,
+	}}
+
+	attachments, err := d.GetVolumeAttach(
+		context.Background(), v.VolumeID, "vm2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attachments) != 3 || attachments[0].InstanceID != "vm2" ||
+		attachments[0].Status != "attached" ||
+		attachments[0].DeviceName == "" ||
+		attachments[2].Status != "reserved" {
+		t.Fatalf("unexpected attachments %+v", attachments)
+	}
+
+	if err := d.DetachVolume(
+		context.Background(), false, v.VolumeID, "vm1", false); err != nil {
+		t.Fatal(err)
+	}
+	f.volumes[v.VolumeID].pending = nil
+	if attachments, err = d.GetVolumeAttach(
+		context.Background(), v.VolumeID, ""); err != nil {
+		t.Fatal(err)
+	}
+	if len(attachments) != 1 || attachments[0].InstanceID != "vm2" {
+		t.Fatalf("unexpected attachments %+v", attachments)
+	}
+	if attachments, err = d.GetVolumeAttach(
+		context.Background(), v.VolumeID, "vm1"); err != nil {
+		t.Fatal(err)
+	}
+	if len(attachments) != 0 {
+		t.Fatalf("unexpected attachments %+v", attachments)
+	}
+
+	// a forced attach does not detach the volume from the other servers
+	if _, err := d.AttachVolume(
+		context.Background(), false, v.VolumeID, "vm1", true); err != nil {
+		t.Fatal(err)
+	}
+	if attachments, err = d.GetVolumeAttach(
+		context.Background(), v.VolumeID, ""); err != nil {
+		t.Fatal(err)
+	}
+	if len(attachments) != 2 {
+		t.Fatalf("unexpected attachments %+v", attachments)
+	}
+	if attachments, err = d.GetVolumeAttach(
+		context.Background(), v.VolumeID, "vm1"); err != nil {
+		t.Fatal(err)
+	}
+	if len(attachments) != 2 || attachments[0].InstanceID != "vm1" {
+		t.Fatalf("unexpected attachments %+v", attachments)
+	}
+}
+
+func TestMultiAttachV2(t *testing.T) {
+	f := newFakeOpenStack(t, "v2")
+	defer f.srv.Close()
+	d := newTestDriver(t, f, "")
+
+	ctx := core.WithVolumeOpts(context.Background(),
+		core.VolumeOpts{"multiattach": "true"})
+	v, err := d.CreateVolume(ctx, false, "v1", "", "", "", 0, 10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.MultiAttach {
+		t.Fatalf("volume is not multi-attach %+v", v)
+	}
+
+	for _, instanceID := range []string{"vm1", "vm2"} {
+		if _, err := d.AttachVolume(
+			context.Background(), false, v.VolumeID, instanceID,
+			false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	attachments, err := d.GetVolumeAttach(
+		context.Background(), v.VolumeID, "vm2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attachments) != 2 || attachments[0].InstanceID != "vm2" {
+		t.Fatalf("unexpected attachments %+v", attachments)
+	}
+
+	if err := d.DetachVolume(
+		context.Background(), false, v.VolumeID, "vm2", true); err != nil {
+		t.Fatal(err)
+	}
+	vols, err := d.GetVolume(context.Background(), v.VolumeID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vols[0].Attachments) != 1 ||
+		vols[0].Attachments[0].InstanceID != "vm1" {
+		t.Fatalf("unexpected attachments %+v", vols[0].Attachments)
+	}
+
+	ctx = core.WithVolumeOpts(context.Background(),
+		core.VolumeOpts{"multiattach": "maybe"})
+	if _, err := d.CreateVolume(
+		ctx, false, "v2", "", "", "", 0, 10, ""); err == nil {
+		t.Fatal("created a volume with an invalid multiattach option")
+	}
+}
+
+func TestMultiAttachV1(t *testing.T) {
+	f := newFakeOpenStack(t, "v1")
+	defer f.srv.Close()
+	d := newTestDriver(t, f, "")
+
+	ctx := core.WithVolumeOpts(context.Background(),
+		core.VolumeOpts{"multiattach": "true"})
+	if _, err := d.CreateVolume(
+		ctx, false, "v1", "", "", "", 0, 10, ""); err == nil {
+		t.Fatal("created a multi-attach volume with the v1 API")
+	}
+}
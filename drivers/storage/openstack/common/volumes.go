@@ -0,0 +1,987 @@
+package common
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/akutz/goof"
+	"golang.org/x/net/context"
+
+	"github.com/emccode/rexray/core"
+	"github.com/emccode/rexray/core/errors"
+	"github.com/emccode/rexray/core/waiter"
+
+	"github.com/rackspace/gophercloud"
+	"github.com/rackspace/gophercloud/openstack/compute/v2/extensions/volumeattach"
+	"github.com/rackspace/gophercloud/openstack/compute/v2/servers"
+)
+
+func (d *Driver) getInstance() (*servers.Server, error) {
+	server, err := servers.Get(d.client, d.instanceID).Extract()
+	if err != nil {
+		return nil,
+			goof.WithFieldsE(d.ef(), "error getting server instance", err)
+	}
+
+	return server, nil
+}
+
+func (d *Driver) GetInstance(ctx context.Context) (*core.Instance, error) {
+	server, err := d.getInstance()
+	if err != nil {
+		return nil,
+			goof.WithFieldsE(d.ef(), "error getting driver instance", err)
+	}
+
+	instance := &core.Instance{
+		ProviderName: d.p.Name(),
+		InstanceID:   d.instanceID,
+		Region:       d.region,
+		Name:         server.Name,
+	}
+
+	return instance, nil
+}
+
+func (d *Driver) GetVolumeMapping(
+	ctx context.Context) ([]*core.BlockDevice, error) {
+	blockDevices, err := d.getBlockDevices(d.instanceID)
+	if err != nil {
+		return nil,
+			goof.WithFieldsE(d.eff(goof.Fields{
+				"instanceId": d.instanceID,
+			}), "error getting block devices", err)
+	}
+
+	var BlockDevices []*core.BlockDevice
+	for _, blockDevice := range blockDevices {
+		sdBlockDevice := &core.BlockDevice{
+			ProviderName: d.p.Name(),
+			InstanceID:   d.instanceID,
+			VolumeID:     blockDevice.VolumeID,
+			DeviceName:   blockDevice.Device,
+			Region:       d.region,
+			Status:       "",
+		}
+		BlockDevices = append(BlockDevices, sdBlockDevice)
+	}
+
+	return BlockDevices, nil
+
+}
+
+func (d *Driver) getBlockDevices(
+	instanceID string) ([]volumeattach.VolumeAttachment, error) {
+
+	fields := d.eff(goof.Fields{"instanceId": instanceID})
+
+	allPages, err := volumeattach.List(d.client, instanceID).AllPages()
+	if err != nil {
+		return []volumeattach.VolumeAttachment{},
+			goof.WithFieldsE(fields, "error listing volume attachments", err)
+	}
+
+	volumeAttachments, err := volumeattach.ExtractVolumeAttachments(allPages)
+	if err != nil {
+		return []volumeattach.VolumeAttachment{},
+			goof.WithFieldsE(fields, "error extracting volume attachments", err)
+	}
+
+	return volumeAttachments, nil
+
+}
+
+func (d *Driver) getVolume(
+	volumeID, volumeName string) (volumesRet []cinderVolume, err error) {
+
+	if volumeID != "" {
+		volume, err := d.cinder.getVolume(volumeID)
+		if err != nil {
+			return []cinderVolume{},
+				goof.WithFieldsE(d.eff(goof.Fields{
+					"volumeId":   volumeID,
+					"volumeName": volumeName}),
+					"error getting volumes", err)
+		}
+		volumesRet = append(volumesRet, *volume)
+	} else {
+		volumesRet, err = d.cinder.listVolumes()
+		if err != nil {
+			return []cinderVolume{},
+				goof.WithFieldsE(d.eff(goof.Fields{
+					"volumeId":   volumeID,
+					"volumeName": volumeName}),
+					"error listing volumes", err)
+		}
+
+		var volumesRetFiltered []cinderVolume
+		if volumeName != "" {
+			var found bool
+			for _, volume := range volumesRet {
+				if volume.Name == volumeName {
+					volumesRetFiltered = append(volumesRetFiltered, volume)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return []cinderVolume{}, nil
+			}
+			volumesRet = volumesRetFiltered
+		}
+	}
+
+	return volumesRet, nil
+}
+
+func (d *Driver) GetVolume(
+	ctx context.Context,
+	volumeID, volumeName string) ([]*core.Volume, error) {
+
+	volumesRet, err := d.getVolume(volumeID, volumeName)
+	if err != nil {
+		return []*core.Volume{},
+			goof.WithFieldsE(d.eff(goof.Fields{
+				"volumeId":   volumeID,
+				"volumeName": volumeName}),
+				"error getting volume", err)
+	}
+
+	var volumesSD []*core.Volume
+	for _, volume := range volumesRet {
+		var attachmentsSD []*core.VolumeAttachment
+		for _, attachment := range volume.Attachments {
+			attachmentsSD = append(
+				attachmentsSD, newVolumeAttachment(attachment))
+		}
+
+		volumeSD := &core.Volume{
+			Name:             volume.Name,
+			VolumeID:         volume.ID,
+			AvailabilityZone: volume.AvailabilityZone,
+			Status:           volume.Status,
+			VolumeType:       volume.VolumeType,
+			IOPS:             0,
+			Size:             strconv.Itoa(volume.Size),
+			Encrypted:        volume.Encrypted,
+			MultiAttach:      volume.Multiattach,
+			Attachments:      attachmentsSD,
+		}
+		volumesSD = append(volumesSD, volumeSD)
+	}
+
+	return volumesSD, nil
+}
+
+func newVolumeAttachment(
+	attachment cinderVolumeAttachment) *core.VolumeAttachment {
+
+	instanceID := attachment.ServerID
+	if instanceID == "" {
+		instanceID = attachment.HostName
+	}
+	return &core.VolumeAttachment{
+		VolumeID:   attachment.VolumeID,
+		InstanceID: instanceID,
+		DeviceName: attachment.Device,
+		Status:     "",
+	}
+}
+
+func (d *Driver) GetVolumeAttach(
+	ctx context.Context,
+	volumeID, instanceID string) ([]*core.VolumeAttachment, error) {
+
+	fields := d.eff(map[string]interface{}{
+		"volumeId":   volumeID,
+		"instanceId": instanceID,
+	})
+
+	if volumeID == "" {
+		return []*core.VolumeAttachment{},
+			goof.WithFields(fields, "volumeId is required")
+	}
+	volume, err := d.getVolume(volumeID, "")
+	if err != nil {
+		return []*core.VolumeAttachment{},
+			goof.WithFieldsE(fields, "error getting volume attach", err)
+	}
+	if len(volume) == 0 {
+		return []*core.VolumeAttachment{},
+			goof.WithFieldsE(fields, "", errors.ErrNoVolumesReturned)
+	}
+
+	attachments, err := d.getVolumeAttachments(&volume[0])
+	if err != nil {
+		return []*core.VolumeAttachment{},
+			goof.WithFieldsE(fields, "error getting volume attach", err)
+	}
+
+	if instanceID == "" {
+		return attachments, nil
+	}
+
+	// a multi-attach volume is attached to more than one instance, so the
+	// attachments of the instance come first
+	var instanceAttachments, otherAttachments []*core.VolumeAttachment
+	for _, attachment := range attachments {
+		if attachment.InstanceID == instanceID {
+			instanceAttachments = append(instanceAttachments, attachment)
+		} else {
+			otherAttachments = append(otherAttachments, attachment)
+		}
+	}
+	if len(instanceAttachments) == 0 {
+		return []*core.VolumeAttachment{}, nil
+	}
+	return append(instanceAttachments, otherAttachments...), nil
+}
+
+// getVolumeAttachments returns all of the attachments of the volume. The
+// attachments API also returns the attachments that are in progress and
+// their status, and the volume's attachments provide their devices.
+func (d *Driver) getVolumeAttachments(
+	volume *cinderVolume) ([]*core.VolumeAttachment, error) {
+
+	var attachments []*core.VolumeAttachment
+	if !d.cinder.supports(attachmentsMicroversion) {
+		for _, attachment := range volume.Attachments {
+			attachments = append(attachments, newVolumeAttachment(attachment))
+		}
+		return attachments, nil
+	}
+
+	cinderAttachments, err := d.cinder.listAttachments(volume.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, attachment := range cinderAttachments {
+		attachmentSD := &core.VolumeAttachment{
+			VolumeID:   volume.ID,
+			InstanceID: attachment.Instance,
+			Status:     attachment.Status,
+		}
+		for _, va := range volume.Attachments {
+			if va.AttachmentID == attachment.ID {
+				attachmentSD.DeviceName = va.Device
+				if attachmentSD.InstanceID == "" {
+					attachmentSD.InstanceID = newVolumeAttachment(va).InstanceID
+				}
+			}
+		}
+		attachments = append(attachments, attachmentSD)
+	}
+	return attachments, nil
+}
+
+func (d *Driver) getSnapshot(
+	volumeID,
+	snapshotID,
+	snapshotName string) (allSnapshots []cinderSnapshot, err error) {
+
+	fields := d.eff(map[string]interface{}{
+		"volumeId":     volumeID,
+		"snapshotId":   snapshotID,
+		"snapshotName": snapshotName,
+	})
+
+	if snapshotID != "" {
+		snapshot, err := d.cinder.getSnapshot(snapshotID)
+		if err != nil {
+			return []cinderSnapshot{},
+				goof.WithFieldsE(fields, "error getting snapshot", err)
+		}
+
+		allSnapshots = append(allSnapshots, *snapshot)
+	} else {
+		allSnapshots, err = d.cinder.listSnapshots(volumeID, snapshotName)
+		if err != nil {
+			return []cinderSnapshot{},
+				goof.WithFieldsE(fields, "error listing snapshot", err)
+		}
+	}
+
+	return allSnapshots, nil
+}
+
+func (d *Driver) GetSnapshot(
+	ctx context.Context,
+	volumeID, snapshotID, snapshotName string) ([]*core.Snapshot, error) {
+
+	snapshots, err := d.getSnapshot(volumeID, snapshotID, snapshotName)
+	if err != nil {
+		return nil,
+			goof.WithFieldsE(d.eff(goof.Fields{
+				"volumeId":     volumeID,
+				"snapshotId":   snapshotID,
+				"snapshotName": snapshotName}),
+				"error getting snapshot", err)
+	}
+
+	var snapshotsInt []*core.Snapshot
+	for _, snapshot := range snapshots {
+		snapshotSD := &core.Snapshot{
+			Name:        snapshot.Name,
+			VolumeID:    snapshot.VolumeID,
+			SnapshotID:  snapshot.ID,
+			VolumeSize:  strconv.Itoa(snapshot.Size),
+			StartTime:   snapshot.CreatedAt,
+			Description: snapshot.Description,
+			Status:      snapshot.Status,
+		}
+		snapshotsInt = append(snapshotsInt, snapshotSD)
+	}
+
+	return snapshotsInt, nil
+}
+
+func (d *Driver) CreateSnapshot(
+	ctx context.Context,
+	runAsync bool,
+	snapshotName, volumeID, description string) ([]*core.Snapshot, error) {
+
+	fields := d.eff(map[string]interface{}{
+		"runAsync":     runAsync,
+		"snapshotName": snapshotName,
+		"volumeId":     volumeID,
+		"description":  description,
+	})
+
+	resp, err := d.cinder.createSnapshot(snapshotName, volumeID, description)
+	if err != nil {
+		return nil,
+			goof.WithFieldsE(fields, "error creating snapshot", err)
+	}
+
+	if !runAsync {
+		core.Logger(ctx).Debug("waiting for snapshot creation to complete")
+		err = d.waitSnapshotStatus(ctx, resp.ID, "available")
+		if err != nil {
+			return nil,
+				goof.WithFieldsE(fields,
+					"error waiting for snapshot creation to complete", err)
+		}
+	}
+
+	snapshot, err := d.GetSnapshot(ctx, "", resp.ID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	core.Logger(ctx).WithFields(log.Fields{
+		"runAsync":     runAsync,
+		"snapshotName": snapshotName,
+		"volumeId":     volumeID,
+		"description":  description}).Debug("created snapshot")
+
+	return snapshot, nil
+
+}
+
+func (d *Driver) RemoveSnapshot(ctx context.Context, snapshotID string) error {
+	if err := d.cinder.deleteSnapshot(snapshotID); err != nil {
+		return goof.WithFieldE(
+			"snapshotId", snapshotID, "error removing snapshot", err)
+	}
+
+	core.Logger(ctx).WithField("snapshotId", snapshotID).Debug(
+		"removed snapshot")
+
+	return nil
+}
+
+func (d *Driver) CreateVolume(
+	ctx context.Context,
+	runAsync bool,
+	volumeName string,
+	volumeID string,
+	snapshotID string,
+	volumeType string,
+	IOPS int64,
+	size int64,
+	availabilityZone string) (*core.Volume, error) {
+
+	fields := map[string]interface{}{
+		"provider":         d.p.Name(),
+		"runAsync":         runAsync,
+		"volumeName":       volumeName,
+		"volumeId":         volumeID,
+		"snapshotId":       snapshotID,
+		"volumeType":       volumeType,
+		"iops":             IOPS,
+		"size":             size,
+		"availabilityZone": availabilityZone,
+	}
+
+	if volumeID != "" && runAsync {
+		return nil, errors.ErrRunAsyncFromVolume
+	}
+
+	d.createVolumeEnsureAvailabilityZone(&availabilityZone)
+
+	multiattach, err := getMultiattach(ctx)
+	if err != nil {
+		return nil, goof.WithFieldsE(fields, "invalid multiattach option", err)
+	}
+	if volumeType, err = d.getVolumeType(volumeType, multiattach); err != nil {
+		return nil, err
+	}
+
+	if err = d.createVolumeHandleSnapshotID(
+		ctx, &size, snapshotID, fields); err != nil {
+		return nil, err
+	}
+
+	var volume []*core.Volume
+	if volume, err = d.createVolumeHandleVolumeID(
+		ctx,
+		&availabilityZone, &snapshotID, &volumeID, &size, fields); err != nil {
+		return nil, err
+	}
+
+	d.createVolumeEnsureSize(&size)
+
+	options := &cinderVolumeCreateOpts{
+		Name:             volumeName,
+		Size:             int(size),
+		SnapshotID:       snapshotID,
+		VolumeType:       volumeType,
+		AvailabilityZone: availabilityZone,
+		Multiattach:      multiattach,
+	}
+	resp, err := d.cinder.createVolume(options)
+	if err != nil {
+		return nil,
+			goof.WithFieldsE(fields, "error creating volume", err)
+	}
+
+	if !runAsync {
+		core.Logger(ctx).Debug("waiting for volume creation to complete")
+		err = d.waitVolumeStatus(ctx, resp.ID, "available")
+		if err != nil {
+			return nil,
+				goof.WithFields(fields,
+					"error waiting for volume creation to complete")
+		}
+
+		if volumeID != "" {
+			err := d.RemoveSnapshot(ctx, snapshotID)
+			if err != nil {
+				return nil,
+					goof.WithFields(fields,
+						"error removing snapshot")
+			}
+		}
+	}
+
+	fields["volumeId"] = resp.ID
+	fields["volumeName"] = ""
+
+	volume, err = d.GetVolume(ctx, resp.ID, "")
+	if err != nil {
+		return nil, goof.WithFields(fields,
+			"error removing snapshot")
+	}
+
+	core.Logger(ctx).WithFields(fields).Debug("created volume")
+	return volume[0], nil
+}
+
+// getMultiattach returns the multiattach volume option, which creates a
+// volume that may be attached to more than one instance.
+func getMultiattach(ctx context.Context) (bool, error) {
+	v, ok := core.GetVolumeOpt(ctx, "multiattach")
+	if !ok || v == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(v)
+}
+
+// getVolumeType returns the name of the volume type with the name or ID, or
+// an error if there is no such volume type. Multi-attach volumes of the
+// Cinder v3 microversion 3.50 or later require a volume type with the extra
+// spec multiattach="<is> True".
+func (d *Driver) getVolumeType(
+	volumeType string, multiattach bool) (string, error) {
+
+	requireMultiattach := multiattach &&
+		d.cinder.supports(multiattachMicroversion)
+
+	fields := d.eff(goof.Fields{
+		"volumeType":  volumeType,
+		"multiattach": multiattach,
+	})
+
+	if volumeType == "" {
+		if requireMultiattach {
+			return "", goof.WithFields(fields,
+				"multi-attach volumes require a volume type with "+
+					"multiattach=\"<is> True\"")
+		}
+		return "", nil
+	}
+
+	volumeTypes, err := d.cinder.listVolumeTypes()
+	if err != nil {
+		return "", goof.WithFieldsE(fields, "error listing volume types", err)
+	}
+
+	var names []string
+	for _, t := range volumeTypes {
+		if t.Name != volumeType && t.ID != volumeType {
+			names = append(names, t.Name)
+			continue
+		}
+		if ok, visible := t.multiattach(); requireMultiattach && visible && !ok {
+			return "", goof.WithFields(fields,
+				"volume type does not support multi-attach volumes")
+		}
+		return t.Name, nil
+	}
+
+	fields["volumeTypes"] = names
+	return "", goof.WithFields(fields, "invalid volume type")
+}
+
+func (d *Driver) createVolumeEnsureAvailabilityZone(availabilityZone *string) {
+	if *availabilityZone == "" {
+		*availabilityZone = d.availabilityZone
+	}
+}
+
+func (d *Driver) createVolumeEnsureSize(size *int64) {
+	if minSize := d.p.MinSize(); *size != 0 && *size < minSize {
+		*size = minSize
+	}
+}
+
+func (d *Driver) createVolumeHandleSnapshotID(
+	ctx context.Context,
+	size *int64, snapshotID string, fields map[string]interface{}) error {
+	if snapshotID == "" {
+		return nil
+	}
+	snapshots, err := d.GetSnapshot(ctx, "", snapshotID, "")
+	if err != nil {
+		return goof.WithFieldsE(fields, "error getting snapshot", err)
+	}
+
+	if len(snapshots) == 0 {
+		return goof.WithFields(fields, "snapshot array is empty")
+	}
+
+	volSize := snapshots[0].VolumeSize
+	sizeInt, err := strconv.Atoi(volSize)
+	if err != nil {
+		f := goof.Fields{
+			"volumeSize": volSize,
+		}
+		for k, v := range fields {
+			f[k] = v
+		}
+		return goof.WithFieldsE(f, "error casting volume size", err)
+	}
+	*size = int64(sizeInt)
+	return nil
+}
+
+func (d *Driver) createVolumeHandleVolumeID(
+	ctx context.Context,
+	availabilityZone, snapshotID, volumeID *string,
+	size *int64,
+	fields map[string]interface{}) ([]*core.Volume, error) {
+
+	if *volumeID == "" {
+		return nil, nil
+	}
+
+	var err error
+	var volume []*core.Volume
+
+	if volume, err = d.GetVolume(ctx, *volumeID, ""); err != nil {
+		return nil, goof.WithFieldsE(fields, "error getting volumes", err)
+	}
+
+	if len(volume) == 0 {
+		return nil, goof.WithFieldsE(fields, "", errors.ErrNoVolumesReturned)
+	}
+
+	volSize := volume[0].Size
+	sizeInt, err := strconv.Atoi(volSize)
+	if err != nil {
+		f := goof.Fields{
+			"volumeSize": volSize,
+		}
+		for k, v := range fields {
+			f[k] = v
+		}
+		return nil,
+			goof.WithFieldsE(f, "error casting volume size", err)
+	}
+	*size = int64(sizeInt)
+
+	*volumeID = volume[0].VolumeID
+	snapshot, err := d.CreateSnapshot(
+		ctx, false, fmt.Sprintf("temp-%s", *volumeID), *volumeID, "")
+	if err != nil {
+		return nil,
+			goof.WithFields(fields, "error creating snapshot")
+	}
+
+	*snapshotID = snapshot[0].SnapshotID
+
+	if *availabilityZone == "" {
+		*availabilityZone = volume[0].AvailabilityZone
+	}
+
+	return volume, nil
+}
+
+func (d *Driver) RemoveVolume(ctx context.Context, volumeID string) error {
+	fields := d.eff(map[string]interface{}{
+		"volumeId": volumeID,
+	})
+	if volumeID == "" {
+		return goof.WithFields(fields, "volumeId is required")
+	}
+	if err := d.cinder.deleteVolume(volumeID); err != nil {
+		return goof.WithFieldsE(fields, "error removing volume", err)
+	}
+
+	core.Logger(ctx).WithFields(fields).Debug("removed volume")
+	return nil
+}
+
+func (d *Driver) GetDeviceNextAvailable(ctx context.Context) (string, error) {
+	letters := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l", "m", "n", "o", "p"}
+	blockDeviceNames := make(map[string]bool)
+
+	blockDeviceMapping, err := d.GetVolumeMapping(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	prefix := d.p.DevicePrefix()
+	for _, blockDevice := range blockDeviceMapping {
+		re, _ := regexp.Compile(`^/dev/` + prefix + `([a-z])`)
+		res := re.FindStringSubmatch(blockDevice.DeviceName)
+		if len(res) > 0 {
+			blockDeviceNames[res[1]] = true
+		}
+	}
+
+	localDevices, err := d.getLocalDevices()
+	if err != nil {
+		return "", err
+	}
+
+	for _, localDevice := range localDevices {
+		re, _ := regexp.Compile(`^` + prefix + `([a-z])`)
+		res := re.FindStringSubmatch(localDevice)
+		if len(res) > 0 {
+			blockDeviceNames[res[1]] = true
+		}
+	}
+
+	for _, letter := range letters {
+		if !blockDeviceNames[letter] {
+			nextDeviceName := "/dev/" + prefix + letter
+			return nextDeviceName, nil
+		}
+	}
+	return "", goof.New("No available device")
+}
+
+func (d *Driver) getLocalDevices() (deviceNames []string, err error) {
+	file := "/proc/partitions"
+	contentBytes, err := ioutil.ReadFile(file)
+	if err != nil {
+		return []string{},
+			goof.WithFieldsE(
+				d.eff(goof.Fields{"file": file}), "error reading file", err)
+	}
+
+	content := string(contentBytes)
+
+	lines := strings.Split(content, "\n")
+	if len(lines) < 2 {
+		return deviceNames, nil
+	}
+	for _, line := range lines[2:] {
+		fields := strings.Fields(line)
+		if len(fields) == 4 {
+			deviceNames = append(deviceNames, fields[3])
+		}
+	}
+
+	return deviceNames, nil
+}
+
+func (d *Driver) AttachVolume(
+	ctx context.Context,
+	runAsync bool, volumeID, instanceID string, force bool) ([]*core.VolumeAttachment, error) {
+
+	fields := d.eff(map[string]interface{}{
+		"runAsync":   runAsync,
+		"volumeId":   volumeID,
+		"instanceId": instanceID,
+	})
+
+	nextDeviceName, err := d.GetDeviceNextAvailable(ctx)
+	if err != nil {
+		return nil, goof.WithFieldsE(
+			fields, "error getting next available device", err)
+	}
+
+	volume, err := d.getVolume(volumeID, "")
+	if err != nil {
+		return nil, goof.WithFieldsE(fields, "error getting volume", err)
+	}
+	if len(volume) == 0 {
+		return nil, goof.WithFieldsE(fields, "", errors.ErrNoVolumesReturned)
+	}
+
+	// a multi-attach volume's attachments to other servers do not block the
+	// attachment, and they are the other members of the cluster that shares
+	// the volume, so only the instance's own, possibly stale, attachment is
+	// force detached
+	if force {
+		detachFrom := ""
+		if volume[0].Multiattach {
+			detachFrom = instanceID
+		}
+		if err := d.DetachVolume(
+			ctx, false, volumeID, detachFrom, true); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = d.attachVolume(
+		instanceID, volumeID, nextDeviceName,
+		volume[0].Multiattach); err != nil {
+		return nil, goof.WithFieldsE(
+			fields, "error attaching volume", err)
+	}
+
+	if !runAsync {
+		core.Logger(ctx).WithFields(fields).Debug(
+			"waiting for volume to attach")
+		err = d.waitVolumeAttach(ctx, volumeID, instanceID)
+		if err != nil {
+			return nil, goof.WithFieldsE(
+				fields, "error waiting for volume to attach", err)
+		}
+	}
+
+	volumeAttachment, err := d.GetVolumeAttach(ctx, volumeID, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	core.Logger(ctx).WithFields(fields).Debug("volume attached")
+	return volumeAttachment, nil
+}
+
+// attachVolume attaches the volume to the server. Attaching a multi-attach
+// volume to more than one server requires the Nova microversion 2.60.
+func (d *Driver) attachVolume(
+	instanceID, volumeID, device string, multiattach bool) error {
+
+	opts := gophercloud.RequestOpts{
+		JSONBody: map[string]interface{}{
+			"volumeAttachment": map[string]interface{}{
+				"volumeId": volumeID,
+				"device":   device,
+			},
+		},
+		OkCodes: []int{200},
+	}
+	if multiattach {
+		opts.MoreHeaders = map[string]string{
+			"X-OpenStack-Nova-API-Version": novaMultiattachMicroversion,
+		}
+	}
+
+	resp, err := d.client.Request(
+		"POST",
+		d.client.ServiceURL("servers", instanceID, "os-volume_attachments"),
+		opts)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// DetachVolume detaches the volume from the instance, or from all of the
+// instances to which it is attached if the instance ID is empty.
+func (d *Driver) DetachVolume(
+	ctx context.Context,
+	runAsync bool, volumeID, instanceID string, force bool) error {
+
+	fields := d.eff(map[string]interface{}{
+		"runAsync":   runAsync,
+		"volumeId":   volumeID,
+		"instanceId": instanceID,
+	})
+
+	if volumeID == "" {
+		return goof.WithFields(fields, "volumeId is required")
+	}
+	volume, err := d.getVolume(volumeID, "")
+	if err != nil {
+		return goof.WithFieldsE(fields, "error getting volume", err)
+	}
+
+	if len(volume) == 0 {
+		return goof.WithFields(fields, "no volumes returned")
+	}
+
+	var attachments []cinderVolumeAttachment
+	for _, attachment := range volume[0].Attachments {
+		if instanceID == "" || attachment.ServerID == instanceID {
+			attachments = append(attachments, attachment)
+		}
+	}
+
+	if len(attachments) == 0 {
+		return nil
+	}
+
+	for _, attachment := range attachments {
+		fields["instanceId"] = attachment.ServerID
+		if force {
+			if err := d.cinder.forceDetach(
+				volumeID, attachment.AttachmentID); err != nil {
+				return goof.WithFieldsE(
+					fields, "error forcing detach volume", err)
+			}
+			continue
+		}
+
+		if attachment.ServerID == "" {
+			fields["hostName"] = attachment.HostName
+			return goof.WithFields(fields,
+				"volume attached to a host may only be force detached")
+		}
+		if resp := volumeattach.Delete(
+			d.client, attachment.ServerID, volumeID); resp.Err != nil {
+			return goof.WithFieldsE(fields, "error detaching volume", resp.Err)
+		}
+	}
+
+	if !runAsync {
+		core.Logger(ctx).WithFields(fields).Debug(
+			"waiting for volume to detach")
+		err = d.waitVolumeDetach(ctx, volumeID, instanceID)
+		if err != nil {
+			return goof.WithFieldsE(
+				fields, "error waiting for volume to detach", err)
+		}
+	}
+
+	core.Logger(ctx).WithFields(fields).Debug("volume detached")
+	return nil
+}
+
+// isAttached returns a flag indicating whether or not the volume is
+// attached to the instance, or to any instance if the instance ID is empty.
+func isAttached(volume *cinderVolume, instanceID string) bool {
+	for _, attachment := range volume.Attachments {
+		if instanceID == "" || attachment.ServerID == instanceID {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Driver) waitVolumeAttach(
+	ctx context.Context, volumeID, instanceID string) error {
+
+	fields := d.eff(map[string]interface{}{
+		"volumeId":   volumeID,
+		"instanceId": instanceID,
+	})
+
+	if volumeID == "" {
+		return goof.WithFields(fields, "volumeId is required")
+	}
+
+	return waiter.Until(ctx,
+		waiter.NewOptions(d.r.Config, waiter.OpAttach),
+		func() (bool, error) {
+			volume, err := d.cinder.getVolume(volumeID)
+			if err != nil {
+				return false, goof.WithFieldsE(fields, "error getting volume", err)
+			}
+			return volume.Status == "in-use" &&
+				isAttached(volume, instanceID), nil
+		})
+}
+
+func (d *Driver) waitVolumeDetach(
+	ctx context.Context, volumeID, instanceID string) error {
+
+	fields := d.eff(map[string]interface{}{
+		"volumeId":   volumeID,
+		"instanceId": instanceID,
+	})
+
+	if volumeID == "" {
+		return goof.WithFields(fields, "volumeId is required")
+	}
+
+	return waiter.Until(ctx,
+		waiter.NewOptions(d.r.Config, waiter.OpDetach),
+		func() (bool, error) {
+			volume, err := d.cinder.getVolume(volumeID)
+			if err != nil {
+				return false, goof.WithFieldsE(fields, "error getting volume", err)
+			}
+			return !isAttached(volume, instanceID), nil
+		})
+}
+
+func (d *Driver) waitSnapshotStatus(
+	ctx context.Context, snapshotID, status string) error {
+	return waiter.Until(ctx,
+		waiter.NewOptions(d.r.Config, waiter.OpCreateSnapshot),
+		func() (bool, error) {
+			snapshot, err := d.cinder.getSnapshot(snapshotID)
+			if err != nil {
+				return false, err
+			}
+			return snapshot.Status == status, nil
+		})
+}
+
+func (d *Driver) waitVolumeStatus(
+	ctx context.Context, volumeID, status string) error {
+	return waiter.Until(ctx,
+		waiter.NewOptions(d.r.Config, waiter.OpCreateVolume),
+		func() (bool, error) {
+			volume, err := d.cinder.getVolume(volumeID)
+			if err != nil {
+				return false, err
+			}
+			return volume.Status == status, nil
+		})
+}
+
+func (d *Driver) CopySnapshot(
+	ctx context.Context,
+	runAsync bool, volumeID, snapshotID, snapshotName, destinationSnapshotName,
+	destinationRegion string) (*core.Snapshot, error) {
+	return nil, goof.New("This driver does not implement CopySnapshot")
+}
+
+func (d *Driver) CloneVolume(
+	ctx context.Context,
+	sourceVolumeID, newName string,
+	opts core.VolumeOpts) (*core.Volume, error) {
+	return core.CloneVolumeFromSnapshot(ctx, d, sourceVolumeID, newName, opts)
+}
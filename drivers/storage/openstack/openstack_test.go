@@ -0,0 +1,18 @@
+package openstack
+
+import "testing"
+
+func TestParseInstanceID(t *testing.T) {
+	out := `# dmidecode 2.12
+System Information
+	Manufacturer: OpenStack Foundation
+	Product Name: OpenStack Nova
+	UUID: 3F2504E0-4F89-11D3-9A0C-0305E82C3301
+`
+	if id := parseInstanceID(out); id != "3f2504e0-4f89-11d3-9a0c-0305e82c3301" {
+		t.Fatalf("unexpected instance id %q", id)
+	}
+	if id := parseInstanceID("System Information\n"); id != "" {
+		t.Fatalf("unexpected instance id %q", id)
+	}
+}
@@ -0,0 +1,27 @@
+package rackspace
+
+import "testing"
+
+func TestParseInstanceID(t *testing.T) {
+	id, err := parseInstanceID("instance-9a1f0c5e-2b4d-4e6f-8a1b-3c5d7e9f1a2b\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "9a1f0c5e-2b4d-4e6f-8a1b-3c5d7e9f1a2b" {
+		t.Fatalf("unexpected instance id %q", id)
+	}
+	if _, err := parseInstanceID("vm1\n"); err == nil {
+		t.Fatal("parsed an invalid instance name")
+	}
+}
+
+func TestEndpointOpts(t *testing.T) {
+	p := &provider{}
+	if eo := p.EndpointOpts("volumev2", "DFW"); eo.Name != "cloudBlockStorage" ||
+		eo.Region != "DFW" {
+		t.Fatalf("unexpected endpoint opts %+v", eo)
+	}
+	if eo := p.EndpointOpts("compute", "DFW"); eo.Name != "cloudServersOpenStack" {
+		t.Fatalf("unexpected endpoint opts %+v", eo)
+	}
+}
@@ -0,0 +1,92 @@
+package scaleio
+
+import (
+	"github.com/emccode/goscaleio"
+	types "github.com/emccode/goscaleio/types/v1"
+)
+
+// api is the part of the ScaleIO gateway and of the local SDC with which the
+// driver creates, maps, and removes volumes.
+type api interface {
+
+	// CreateVolume creates a volume in the storage pool.
+	CreateVolume(
+		storagePool *types.StoragePool,
+		param *types.VolumeParam) (*types.VolumeResp, error)
+
+	// GetVolume returns the volumes of the storage pool with the ID or name,
+	// or all of its volumes if both are empty.
+	GetVolume(
+		storagePool *types.StoragePool,
+		volumeID, volumeName string,
+		getSnapshots bool) ([]*types.Volume, error)
+
+	// RemoveVolume removes the volume.
+	RemoveVolume(volume *types.Volume, removeMode string) error
+
+	// MapVolumeSdc maps the volume to an SDC.
+	MapVolumeSdc(volume *types.Volume, param *types.MapVolumeSdcParam) error
+
+	// UnmapVolumeSdc unmaps the volume from an SDC.
+	UnmapVolumeSdc(volume *types.Volume, param *types.UnmapVolumeSdcParam) error
+
+	// SetMappedSdcLimits sets the limits of the volume's mapping to an SDC.
+	SetMappedSdcLimits(
+		volume *types.Volume, param *types.SetMappedSdcLimitsParam) error
+
+	// GetLocalVolumeMap returns the volumes mapped to the local SDC.
+	GetLocalVolumeMap() ([]*goscaleio.SdcMappedVolume, error)
+}
+
+// gatewayAPI is the api of a ScaleIO gateway client.
+type gatewayAPI struct {
+	client *goscaleio.Client
+}
+
+func (a *gatewayAPI) CreateVolume(
+	storagePool *types.StoragePool,
+	param *types.VolumeParam) (*types.VolumeResp, error) {
+
+	sp := goscaleio.NewStoragePool(a.client)
+	sp.StoragePool = storagePool
+	return sp.CreateVolume(param)
+}
+
+func (a *gatewayAPI) GetVolume(
+	storagePool *types.StoragePool,
+	volumeID, volumeName string,
+	getSnapshots bool) ([]*types.Volume, error) {
+
+	sp := goscaleio.NewStoragePool(a.client)
+	sp.StoragePool = storagePool
+	return sp.GetVolume("", volumeID, "", volumeName, getSnapshots)
+}
+
+func (a *gatewayAPI) volume(volume *types.Volume) *goscaleio.Volume {
+	v := goscaleio.NewVolume(a.client)
+	v.Volume = volume
+	return v
+}
+
+func (a *gatewayAPI) RemoveVolume(volume *types.Volume, removeMode string) error {
+	return a.volume(volume).RemoveVolume(removeMode)
+}
+
+func (a *gatewayAPI) MapVolumeSdc(
+	volume *types.Volume, param *types.MapVolumeSdcParam) error {
+	return a.volume(volume).MapVolumeSdc(param)
+}
+
+func (a *gatewayAPI) UnmapVolumeSdc(
+	volume *types.Volume, param *types.UnmapVolumeSdcParam) error {
+	return a.volume(volume).UnmapVolumeSdc(param)
+}
+
+func (a *gatewayAPI) SetMappedSdcLimits(
+	volume *types.Volume, param *types.SetMappedSdcLimitsParam) error {
+	return a.volume(volume).SetMappedSdcLimits(param)
+}
+
+func (a *gatewayAPI) GetLocalVolumeMap() ([]*goscaleio.SdcMappedVolume, error) {
+	return goscaleio.GetLocalVolumeMap()
+}
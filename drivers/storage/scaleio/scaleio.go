@@ -3,16 +3,18 @@ package scaleio
 import (
 	"strconv"
 	"strings"
-	"time"
+	"sync"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/akutz/gofig"
 	"github.com/akutz/goof"
+	"golang.org/x/net/context"
 
 	"github.com/emccode/goscaleio"
 	types "github.com/emccode/goscaleio/types/v1"
 
 	"github.com/emccode/rexray/core"
+	"github.com/emccode/rexray/core/waiter"
 )
 
 const providerName = "ScaleIO"
@@ -24,7 +26,12 @@ type driver struct {
 	protectionDomain *goscaleio.ProtectionDomain
 	storagePool      *goscaleio.StoragePool
 	sdc              *goscaleio.Sdc
+	api              api
 	r                *core.RexRay
+
+	// storagePools are the known storage pools, by ID
+	storagePools  map[string]*types.StoragePool
+	storagePoolsL sync.Mutex
 }
 
 func ef() goof.Fields {
@@ -47,11 +54,13 @@ func eff(fields goof.Fields) map[string]interface{} {
 
 func init() {
 	core.RegisterDriver(providerName, newDriver)
-	gofig.Register(configRegistration())
+	core.RegisterConfigSchema(configRegistration())
 }
 
 func newDriver() core.Driver {
-	return &driver{}
+	return &driver{
+		storagePools: map[string]*types.StoragePool{},
+	}
 }
 
 func (d *driver) Init(r *core.RexRay) error {
@@ -71,6 +80,7 @@ func (d *driver) Init(r *core.RexRay) error {
 		d.useCerts()); err != nil {
 		return goof.WithFieldsE(fields, "error constructing new client", err)
 	}
+	d.api = &gatewayAPI{d.client}
 
 	if _, err := d.client.Authenticate(
 		&goscaleio.ConfigConnect{
@@ -114,6 +124,7 @@ func (d *driver) Init(r *core.RexRay) error {
 	}
 	d.storagePool = goscaleio.NewStoragePool(d.client)
 	d.storagePool.StoragePool = sp
+	d.cacheStoragePools(sp)
 
 	var sdcGUID string
 	if sdcGUID, err = goscaleio.GetSdcLocalGUID(); err != nil {
@@ -136,11 +147,34 @@ func (d *driver) Name() string {
 	return providerName
 }
 
+// HealthCheck verifies that the driver is able to authenticate with the
+// ScaleIO gateway. It authenticates a client of its own so that the probe
+// never replaces the token of the client that serves the driver's calls.
+func (d *driver) HealthCheck(ctx context.Context) error {
+	client, err := goscaleio.NewClientWithArgs(
+		d.endpoint(),
+		d.insecure(),
+		d.useCerts())
+	if err != nil {
+		return goof.WithFieldE(
+			"endpoint", d.endpoint(), "error constructing new client", err)
+	}
+	if _, err := client.Authenticate(
+		&goscaleio.ConfigConnect{
+			d.endpoint(),
+			d.userName(),
+			d.password()}); err != nil {
+		return goof.WithFieldE(
+			"endpoint", d.endpoint(), "error authenticating", err)
+	}
+	return nil
+}
+
 func (d *driver) getInstance() (*goscaleio.Sdc, error) {
 	return d.sdc, nil
 }
 
-func (d *driver) GetInstance() (*core.Instance, error) {
+func (d *driver) GetInstance(ctx context.Context) (*core.Instance, error) {
 
 	server, err := d.getInstance()
 	if err != nil {
@@ -154,7 +188,7 @@ func (d *driver) GetInstance() (*core.Instance, error) {
 		Name:         server.Sdc.Name,
 	}
 
-	log.WithFields(log.Fields{
+	core.Logger(ctx).WithFields(log.Fields{
 		"provider": providerName,
 		"instance": instance,
 	}).Debug("got instance")
@@ -162,7 +196,7 @@ func (d *driver) GetInstance() (*core.Instance, error) {
 }
 
 func (d *driver) getBlockDevices() ([]*goscaleio.SdcMappedVolume, error) {
-	volumeMaps, err := goscaleio.GetLocalVolumeMap()
+	volumeMaps, err := d.api.GetLocalVolumeMap()
 	if err != nil {
 		return []*goscaleio.SdcMappedVolume{},
 			goof.WithFieldsE(ef(), "error getting local volume map", err)
@@ -170,7 +204,8 @@ func (d *driver) getBlockDevices() ([]*goscaleio.SdcMappedVolume, error) {
 	return volumeMaps, nil
 }
 
-func (d *driver) GetVolumeMapping() ([]*core.BlockDevice, error) {
+func (d *driver) GetVolumeMapping(
+	ctx context.Context) ([]*core.BlockDevice, error) {
 	blockDevices, err := d.getBlockDevices()
 	if err != nil {
 		return nil,
@@ -190,7 +225,7 @@ func (d *driver) GetVolumeMapping() ([]*core.BlockDevice, error) {
 		BlockDevices = append(BlockDevices, sdBlockDevice)
 	}
 
-	log.WithFields(log.Fields{
+	core.Logger(ctx).WithFields(log.Fields{
 		"provider":     providerName,
 		"blockDevices": BlockDevices,
 	}).Debug("got block device mappings")
@@ -199,17 +234,38 @@ func (d *driver) GetVolumeMapping() ([]*core.BlockDevice, error) {
 
 func (d *driver) getVolume(
 	volumeID, volumeName string, getSnapshots bool) ([]*types.Volume, error) {
-	volumes, err := d.storagePool.GetVolume("", volumeID, "", volumeName, getSnapshots)
+	if volumeID != "" || volumeName != "" {
+		volumes, err := d.api.GetVolume(
+			d.storagePool.StoragePool, volumeID, volumeName, getSnapshots)
+		if err != nil {
+			return nil, err
+		}
+		return volumes, nil
+	}
+
+	// the volumes of all of the storage pools, as volumes may be created in
+	// any storage pool
+	storagePools, err := d.listStoragePools()
 	if err != nil {
 		return nil, err
 	}
+
+	var volumes []*types.Volume
+	for _, sp := range storagePools {
+		spVolumes, err := d.api.GetVolume(sp, "", "", getSnapshots)
+		if err != nil {
+			return nil, err
+		}
+		volumes = append(volumes, spVolumes...)
+	}
 	return volumes, nil
 }
 
 func (d *driver) GetVolume(
+	ctx context.Context,
 	volumeID, volumeName string) ([]*core.Volume, error) {
 
-	sdcMappedVolumes, err := goscaleio.GetLocalVolumeMap()
+	sdcMappedVolumes, err := d.api.GetLocalVolumeMap()
 	if err != nil {
 		return []*core.Volume{}, err
 	}
@@ -243,17 +299,32 @@ func (d *driver) GetVolume(
 			attachmentsSD = append(attachmentsSD, attachmentSD)
 		}
 
-		var IOPS int64
-		if len(volume.MappedSdcInfo) > 0 {
-			IOPS = int64(volume.MappedSdcInfo[0].LimitIops)
+		limits := getMappedLimits(volume, d.sdc.Sdc.ID)
+		if limits == nil {
+			limits = getMappedLimits(volume, "")
+		}
+		if limits == nil {
+			limits = &volumeLimits{}
 		}
+
+		// the volume type is the name of the storage pool, and the
+		// availability zone is its protection domain
+		volumeType := volume.StoragePoolID
+		availabilityZone := d.protectionDomain.ProtectionDomain.ID
+		if sp := d.getStoragePoolByID(volume.StoragePoolID); sp != nil {
+			volumeType = sp.Name
+			availabilityZone = sp.ProtectionDomainID
+		}
+
 		volumeSD := &core.Volume{
 			Name:             volume.Name,
 			VolumeID:         volume.ID,
-			AvailabilityZone: d.protectionDomain.ProtectionDomain.ID,
+			AvailabilityZone: availabilityZone,
 			Status:           "",
-			VolumeType:       volume.StoragePoolID,
-			IOPS:             IOPS,
+			VolumeType:       volumeType,
+			IOPS:             limits.iops,
+			BandwidthLimit:   limits.bandwidth,
+			ThinProvisioned:  volume.VolumeType == thinProvisioned,
 			Size:             strconv.Itoa(volume.SizeInKb / 1024 / 1024),
 			Attachments:      attachmentsSD,
 		}
@@ -264,6 +335,7 @@ func (d *driver) GetVolume(
 }
 
 func (d *driver) GetVolumeAttach(
+	ctx context.Context,
 	volumeID, instanceID string) ([]*core.VolumeAttachment, error) {
 
 	fields := eff(map[string]interface{}{
@@ -275,7 +347,7 @@ func (d *driver) GetVolumeAttach(
 		return []*core.VolumeAttachment{},
 			goof.WithFields(fields, "volumeId is required")
 	}
-	volume, err := d.GetVolume(volumeID, "")
+	volume, err := d.GetVolume(ctx, volumeID, "")
 	if err != nil {
 		return []*core.VolumeAttachment{},
 			goof.WithFieldsE(fields, "error getting volume", err)
@@ -296,6 +368,7 @@ func (d *driver) GetVolumeAttach(
 }
 
 func (d *driver) GetSnapshot(
+	ctx context.Context,
 	volumeID, snapshotID, snapshotName string) ([]*core.Snapshot, error) {
 
 	if snapshotID != "" {
@@ -323,7 +396,7 @@ func (d *driver) GetSnapshot(
 		}
 	}
 
-	log.WithFields(log.Fields{
+	core.Logger(ctx).WithFields(log.Fields{
 		"provider":  providerName,
 		"snapshots": snapshotsInt,
 	}).Debug("got snapshots")
@@ -331,6 +404,7 @@ func (d *driver) GetSnapshot(
 }
 
 func (d *driver) CreateSnapshot(
+	ctx context.Context,
 	notUsed bool,
 	snapshotName, volumeID, description string) ([]*core.Snapshot, error) {
 
@@ -356,11 +430,11 @@ func (d *driver) CreateSnapshot(
 	}
 
 	if snapshot, err = d.GetSnapshot(
-		"", snapshotVolumes.VolumeIDList[0], ""); err != nil {
+		ctx, "", snapshotVolumes.VolumeIDList[0], ""); err != nil {
 		return nil, err
 	}
 
-	log.WithFields(log.Fields{
+	core.Logger(ctx).WithFields(log.Fields{
 		"provider": providerName,
 		"snapshot": snapshot}).Debug("created snapshot")
 	return snapshot, nil
@@ -368,6 +442,7 @@ func (d *driver) CreateSnapshot(
 }
 
 func (d *driver) createVolume(
+	ctx context.Context,
 	notUsed bool,
 	volumeName, volumeID, snapshotID, volumeType string,
 	IOPS, size int64, availabilityZone string) (*types.VolumeResp, error) {
@@ -375,7 +450,7 @@ func (d *driver) createVolume(
 	snapshot := &core.Snapshot{}
 	if volumeID != "" {
 		snapshotInt, err := d.CreateSnapshot(
-			true, volumeName, volumeID, "created for createVolume")
+			ctx, true, volumeName, volumeID, "created for createVolume")
 		if err != nil {
 			return &types.VolumeResp{}, err
 		}
@@ -383,39 +458,67 @@ func (d *driver) createVolume(
 		return &types.VolumeResp{ID: snapshot.SnapshotID}, nil
 	}
 
+	limits, err := getVolumeLimits(ctx, IOPS)
+	if err != nil {
+		return &types.VolumeResp{}, err
+	}
+
+	storagePool, provisioning, err := d.getStoragePool(
+		ctx, volumeType, availabilityZone)
+	if err != nil {
+		return &types.VolumeResp{}, err
+	}
+
 	volumeParam := &types.VolumeParam{
 		Name:           volumeName,
 		VolumeSizeInKb: strconv.Itoa(int(size) * 1024 * 1024),
-		VolumeType:     volumeType,
+		VolumeType:     provisioning,
 	}
 
-	volumeResp, err := d.storagePool.CreateVolume(volumeParam)
+	volumeResp, err := d.api.CreateVolume(
+		storagePool.StoragePool, volumeParam)
 	if err != nil {
 		return &types.VolumeResp{}, err
 	}
 
+	// the volume exists whether or not its limits are saved, so a volume
+	// whose limits cannot be saved is attached with the configured limits
+	// rather than orphaned by an error
+	if limits.isSet() {
+		if err := d.saveVolumeLimits(volumeResp.ID, limits); err != nil {
+			core.Logger(ctx).WithFields(log.Fields{
+				"provider":       providerName,
+				"volumeId":       volumeResp.ID,
+				"iopsLimit":      limits.iops,
+				"bandwidthLimit": limits.bandwidth,
+				"error":          err,
+			}).Warn("error saving volume limits")
+		}
+	}
+
 	return volumeResp, nil
 }
 
 func (d *driver) CreateVolume(
+	ctx context.Context,
 	notUsed bool,
 	volumeName, volumeID, snapshotID, volumeType string,
 	IOPS, size int64, availabilityZone string) (*core.Volume, error) {
 
 	resp, err := d.createVolume(
-		notUsed, volumeName, volumeID, snapshotID,
+		ctx, notUsed, volumeName, volumeID, snapshotID,
 		volumeType, IOPS, size, availabilityZone)
 
 	if err != nil {
 		return nil, err
 	}
 
-	volumes, err := d.GetVolume(resp.ID, "")
+	volumes, err := d.GetVolume(ctx, resp.ID, "")
 	if err != nil {
 		return nil, err
 	}
 
-	log.WithFields(log.Fields{
+	core.Logger(ctx).WithFields(log.Fields{
 		"provider": providerName,
 		"volume":   volumes[0],
 	}).Debug("created volume")
@@ -423,7 +526,7 @@ func (d *driver) CreateVolume(
 
 }
 
-func (d *driver) RemoveVolume(volumeID string) error {
+func (d *driver) RemoveVolume(ctx context.Context, volumeID string) error {
 
 	fields := eff(map[string]interface{}{
 		"volumeId": volumeID,
@@ -440,19 +543,20 @@ func (d *driver) RemoveVolume(volumeID string) error {
 		return goof.WithFieldsE(fields, "error getting volume", err)
 	}
 
-	targetVolume := goscaleio.NewVolume(d.client)
-	targetVolume.Volume = volumes[0]
-
-	if err = targetVolume.RemoveVolume("ONLY_ME"); err != nil {
+	if err = d.api.RemoveVolume(volumes[0], "ONLY_ME"); err != nil {
 		return goof.WithFieldsE(fields, "error removing volume", err)
 	}
 
-	log.WithFields(fields).Debug("removed volume")
+	if err = d.removeVolumeLimits(volumeID); err != nil {
+		return goof.WithFieldsE(fields, "error removing volume limits", err)
+	}
+
+	core.Logger(ctx).WithFields(fields).Debug("removed volume")
 	return nil
 }
 
-func (d *driver) RemoveSnapshot(snapshotID string) error {
-	err := d.RemoveVolume(snapshotID)
+func (d *driver) RemoveSnapshot(ctx context.Context, snapshotID string) error {
+	err := d.RemoveVolume(ctx, snapshotID)
 	if err != nil {
 		return err
 	}
@@ -460,11 +564,12 @@ func (d *driver) RemoveSnapshot(snapshotID string) error {
 	return nil
 }
 
-func (d *driver) GetDeviceNextAvailable() (string, error) {
+func (d *driver) GetDeviceNextAvailable(ctx context.Context) (string, error) {
 	return "", nil
 }
 
 func (d *driver) AttachVolume(
+	ctx context.Context,
 	runAsync bool,
 	volumeID, instanceID string, force bool) ([]*core.VolumeAttachment, error) {
 
@@ -478,49 +583,60 @@ func (d *driver) AttachVolume(
 		return nil, goof.WithFields(fields, "volumeId is required")
 	}
 
+	volumes, err := d.getVolume(volumeID, "", false)
+	if err != nil {
+		return nil, goof.WithFieldsE(fields, "error getting volume", err)
+	}
+
+	if len(volumes) == 0 {
+		return nil, goof.WithFields(fields, "no volumes returned")
+	}
+
+	// the limits are read before the volume is detached from other SDCs so
+	// that they move with the volume
+	limits, err := d.getAttachLimits(volumes[0])
+	if err != nil {
+		return nil, err
+	}
+
 	if force {
-		if err := d.DetachVolume(false, volumeID, "", true); err != nil {
+		if err := d.DetachVolume(ctx, false, volumeID, "", true); err != nil {
 			return nil, err
 		}
 	}
 
 	mapVolumeSdcParam := &types.MapVolumeSdcParam{
-		SdcID: d.sdc.Sdc.ID,
+		SdcID:                 d.sdc.Sdc.ID,
 		AllowMultipleMappings: "false",
 		AllSdcs:               "",
 	}
 
-	volumes, err := d.getVolume(volumeID, "", false)
+	err = d.api.MapVolumeSdc(volumes[0], mapVolumeSdcParam)
 	if err != nil {
-		return nil, goof.WithFieldsE(fields, "error getting volume", err)
-	}
-
-	if len(volumes) == 0 {
-		return nil, goof.WithFields(fields, "no volumes returned")
+		return nil, goof.WithFieldsE(fields, "error mapping volume sdc", err)
 	}
 
-	targetVolume := goscaleio.NewVolume(d.client)
-	targetVolume.Volume = volumes[0]
-
-	err = targetVolume.MapVolumeSdc(mapVolumeSdcParam)
-	if err != nil {
-		return nil, goof.WithFieldsE(fields, "error mapping volume sdc", err)
+	if err = d.setMappedLimits(volumes[0], limits); err != nil {
+		fields["iopsLimit"] = limits.iops
+		fields["bandwidthLimit"] = limits.bandwidth
+		return nil, goof.WithFieldsE(
+			fields, "error setting volume sdc limits", err)
 	}
 
-	_, err = waitMount(volumes[0].ID)
+	_, err = d.waitMount(ctx, volumes[0].ID)
 	if err != nil {
 		fields["volumeId"] = volumes[0].ID
 		return nil, goof.WithFieldsE(
 			fields, "error waiting on volume to mount", err)
 	}
 
-	volumeAttachment, err := d.GetVolumeAttach(volumeID, instanceID)
+	volumeAttachment, err := d.GetVolumeAttach(ctx, volumeID, instanceID)
 	if err != nil {
 		return nil, goof.WithFieldsE(
 			fields, "error getting volume attachments", err)
 	}
 
-	log.WithFields(log.Fields{
+	core.Logger(ctx).WithFields(log.Fields{
 		"provider":   providerName,
 		"volumeId":   volumeID,
 		"instanceId": instanceID,
@@ -529,6 +645,7 @@ func (d *driver) AttachVolume(
 }
 
 func (d *driver) DetachVolume(
+	ctx context.Context,
 	runAsync bool, volumeID string, blank string, force bool) error {
 
 	fields := eff(map[string]interface{}{
@@ -550,9 +667,6 @@ func (d *driver) DetachVolume(
 		return goof.WithFields(fields, "no volumes returned")
 	}
 
-	targetVolume := goscaleio.NewVolume(d.client)
-	targetVolume.Volume = volumes[0]
-
 	unmapVolumeSdcParam := &types.UnmapVolumeSdcParam{
 		SdcID:                "",
 		IgnoreScsiInitiators: "true",
@@ -565,15 +679,16 @@ func (d *driver) DetachVolume(
 		unmapVolumeSdcParam.SdcID = d.sdc.Sdc.ID
 	}
 
-	_ = targetVolume.UnmapVolumeSdc(unmapVolumeSdcParam)
+	_ = d.api.UnmapVolumeSdc(volumes[0], unmapVolumeSdcParam)
 
-	log.WithFields(log.Fields{
+	core.Logger(ctx).WithFields(log.Fields{
 		"provider": providerName,
 		"volumeId": volumeID}).Debug("detached volume")
 	return nil
 }
 
 func (d *driver) CopySnapshot(
+	ctx context.Context,
 	runAsync bool,
 	volumeID, snapshotID,
 	snapshotName, destinationSnapshotName,
@@ -581,61 +696,86 @@ func (d *driver) CopySnapshot(
 	return nil, goof.New("This driver does not implement CopySnapshot")
 }
 
-func waitMount(volumeID string) (*goscaleio.SdcMappedVolume, error) {
+func (d *driver) CloneVolume(
+	ctx context.Context,
+	sourceVolumeID, newName string,
+	opts core.VolumeOpts) (*core.Volume, error) {
+
+	fields := eff(map[string]interface{}{
+		"sourceVolumeId": sourceVolumeID,
+		"newName":        newName,
+	})
+
+	if sourceVolumeID == "" {
+		return nil, goof.WithFields(fields, "sourceVolumeId is required")
+	}
+
+	// a ScaleIO snapshot is a fully-fledged, writable volume, so the clone is
+	// simply a snapshot of the source volume with the new name
+	snapshots, err := d.CreateSnapshot(
+		ctx, false, newName, sourceVolumeID, "created for CloneVolume")
+	if err != nil {
+		return nil, goof.WithFieldsE(fields, "error cloning volume", err)
+	}
+
+	if len(snapshots) == 0 {
+		return nil, goof.WithFields(fields, "no clone returned")
+	}
+
+	volumes, err := d.GetVolume(ctx, snapshots[0].SnapshotID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(volumes) == 0 {
+		return nil, goof.WithFields(fields, "failed to get cloned volume")
+	}
+
+	core.Logger(ctx).WithFields(log.Fields{
+		"provider": providerName,
+		"volume":   volumes[0],
+	}).Debug("cloned volume")
+	return volumes[0], nil
+}
+
+func (d *driver) waitMount(
+	ctx context.Context,
+	volumeID string) (*goscaleio.SdcMappedVolume, error) {
 
-	timeout := make(chan bool, 1)
-	go func() {
-		time.Sleep(10 * time.Second)
-		timeout <- true
-	}()
+	core.Logger(ctx).WithField("provider", providerName).Debug(
+		"waiting for volume mount")
 
-	successCh := make(chan *goscaleio.SdcMappedVolume, 1)
-	errorCh := make(chan error, 1)
-	go func(volumeID string) {
-		log.WithField("provider", providerName).Debug("waiting for volume mount")
-		for {
-			sdcMappedVolumes, err := goscaleio.GetLocalVolumeMap()
+	var sdcMappedVolume *goscaleio.SdcMappedVolume
+	err := waiter.Until(ctx,
+		waiter.NewOptions(d.r.Config, waiter.OpMount),
+		func() (bool, error) {
+			sdcMappedVolumes, err := d.api.GetLocalVolumeMap()
 			if err != nil {
-				errorCh <- goof.WithFieldE(
+				return false, goof.WithFieldE(
 					"provider", providerName,
 					"problem getting local volume mappings", err)
-				return
 			}
 
-			sdcMappedVolume := &goscaleio.SdcMappedVolume{}
-			var foundVolume bool
-			for _, sdcMappedVolume = range sdcMappedVolumes {
-				if sdcMappedVolume.VolumeID ==
-					volumeID && sdcMappedVolume.SdcDevice != "" {
-					foundVolume = true
-					break
+			for _, smv := range sdcMappedVolumes {
+				if smv.VolumeID == volumeID && smv.SdcDevice != "" {
+					sdcMappedVolume = smv
+					return true, nil
 				}
 			}
 
-			if foundVolume {
-				successCh <- sdcMappedVolume
-				return
-			}
-			time.Sleep(100 * time.Millisecond)
-		}
+			return false, nil
+		})
 
-	}(volumeID)
-
-	select {
-	case sdcMappedVolume := <-successCh:
-		log.WithFields(log.Fields{
-			"provider": providerName,
-			"volumeId": sdcMappedVolume.VolumeID,
-			"volume":   sdcMappedVolume.SdcDevice,
-		}).Debug("got sdcMappedVolume")
-		return sdcMappedVolume, nil
-	case err := <-errorCh:
+	if err != nil {
 		return &goscaleio.SdcMappedVolume{}, err
-	case <-timeout:
-		return &goscaleio.SdcMappedVolume{}, goof.WithFields(
-			ef(), "timed out waiting for mount")
 	}
 
+	core.Logger(ctx).WithFields(log.Fields{
+		"provider": providerName,
+		"volumeId": sdcMappedVolume.VolumeID,
+		"volume":   sdcMappedVolume.SdcDevice,
+	}).Debug("got sdcMappedVolume")
+	return sdcMappedVolume, nil
 }
 
 func (d *driver) endpoint() string {
@@ -686,8 +826,20 @@ func (d *driver) storagePoolName() string {
 	return d.r.Config.GetString("scaleio.storagePoolName")
 }
 
-func configRegistration() *gofig.Registration {
-	r := gofig.NewRegistration("ScaleIO")
+func (d *driver) provisioning() string {
+	return d.r.Config.GetString("scaleio.provisioning")
+}
+
+func (d *driver) iopsLimit() int {
+	return d.r.Config.GetInt("scaleio.iopsLimit")
+}
+
+func (d *driver) bandwidthLimit() int {
+	return d.r.Config.GetInt("scaleio.bandwidthLimit")
+}
+
+func configRegistration() *core.ConfigSchema {
+	r := core.NewConfigSchema("ScaleIO", providerName)
 	r.Key(gofig.String, "", "", "", "scaleio.endpoint")
 	r.Key(gofig.Bool, "", false, "", "scaleio.insecure")
 	r.Key(gofig.Bool, "", false, "", "scaleio.useCerts")
@@ -700,5 +852,16 @@ func configRegistration() *gofig.Registration {
 	r.Key(gofig.String, "", "", "", "scaleio.protectionDomainName")
 	r.Key(gofig.String, "", "", "", "scaleio.storagePoolID")
 	r.Key(gofig.String, "", "", "", "scaleio.storagePoolName")
+	r.Key(gofig.String, "", "", "", "scaleio.provisioning")
+	r.Key(gofig.Int, "", 0, "", "scaleio.iopsLimit")
+	r.Key(gofig.Int, "", 0, "", "scaleio.bandwidthLimit")
+	r.Require("scaleio.endpoint")
+	r.Require("scaleio.systemID", "scaleio.systemName")
+	r.Require("scaleio.protectionDomainID", "scaleio.protectionDomainName")
+	r.Require("scaleio.storagePoolID", "scaleio.storagePoolName")
+	r.Exclude("scaleio.systemID", "scaleio.systemName")
+	r.Exclude("scaleio.protectionDomainID", "scaleio.protectionDomainName")
+	r.Exclude("scaleio.storagePoolID", "scaleio.storagePoolName")
+	r.Secret("scaleio.password")
 	return r
 }
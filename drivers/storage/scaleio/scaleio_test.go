@@ -0,0 +1,259 @@
+package scaleio
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/akutz/gofig"
+	"golang.org/x/net/context"
+
+	"github.com/emccode/goscaleio"
+	types "github.com/emccode/goscaleio/types/v1"
+
+	"github.com/emccode/rexray/core"
+	"github.com/emccode/rexray/util"
+)
+
+func TestGetProvisioning(t *testing.T) {
+	for provisioning, exp := range map[string]string{
+		"":                 "",
+		"thin":             thinProvisioned,
+		"Thick":            thickProvisioned,
+		"ThinProvisioned":  thinProvisioned,
+		"thickprovisioned": thickProvisioned,
+	} {
+		p, err := getProvisioning(provisioning)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p != exp {
+			t.Errorf("%s: unexpected provisioning %s", provisioning, p)
+		}
+	}
+	if _, err := getProvisioning("pool1"); err == nil {
+		t.Error("got the provisioning of a storage pool")
+	}
+	if isProvisioning("pool1") || isProvisioning("") || !isProvisioning("thin") {
+		t.Error("unexpected provisioning")
+	}
+}
+
+func TestGetVolumeLimits(t *testing.T) {
+	ctx := core.WithVolumeOpts(context.Background(), core.VolumeOpts{
+		"iopsLimit":      "500",
+		"bandwidthLimit": "10240",
+	})
+	limits, err := getVolumeLimits(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limits.iops != 500 || limits.bandwidth != 10240 {
+		t.Fatalf("unexpected limits %+v", limits)
+	}
+
+	if limits, err = getVolumeLimits(ctx, 100); err != nil {
+		t.Fatal(err)
+	}
+	if limits.iops != 100 {
+		t.Fatalf("unexpected limits %+v", limits)
+	}
+
+	if limits, err = getVolumeLimits(context.Background(), 0); err != nil {
+		t.Fatal(err)
+	}
+	if limits.isSet() {
+		t.Fatalf("unexpected limits %+v", limits)
+	}
+
+	ctx = core.WithVolumeOpts(context.Background(), core.VolumeOpts{
+		"bandwidthLimit": "-1",
+	})
+	if _, err = getVolumeLimits(ctx, 0); err == nil {
+		t.Fatal("got a negative limit")
+	}
+}
+
+func TestGetMappedLimits(t *testing.T) {
+	volume := &types.Volume{
+		MappedSdcInfo: []*types.MappedSdcInfo{
+			{SdcID: "sdc1", LimitIops: 100},
+			{SdcID: "sdc2", LimitIops: 200, LimitBwInMbps: 10},
+		},
+	}
+	if l := getMappedLimits(volume, "sdc2"); l.iops != 200 ||
+		l.bandwidth != 10240 {
+		t.Fatalf("unexpected limits %+v", l)
+	}
+	if l := getMappedLimits(volume, ""); l.iops != 100 {
+		t.Fatalf("unexpected limits %+v", l)
+	}
+	if l := getMappedLimits(volume, "sdc3"); l != nil {
+		t.Fatalf("unexpected limits %+v", l)
+	}
+}
+
+// fakeAPI is an api whose volumes and mappings are kept in memory.
+type fakeAPI struct {
+	sync.Mutex
+	volumes map[string]*types.Volume
+	mapped  []*goscaleio.SdcMappedVolume
+	limits  []*types.SetMappedSdcLimitsParam
+}
+
+func newFakeAPI() *fakeAPI {
+	return &fakeAPI{volumes: map[string]*types.Volume{}}
+}
+
+func (a *fakeAPI) CreateVolume(
+	storagePool *types.StoragePool,
+	param *types.VolumeParam) (*types.VolumeResp, error) {
+	a.Lock()
+	defer a.Unlock()
+	size, _ := strconv.Atoi(param.VolumeSizeInKb)
+	v := &types.Volume{
+		ID:            fmt.Sprintf("vol%d", len(a.volumes)+1),
+		Name:          param.Name,
+		SizeInKb:      size,
+		StoragePoolID: storagePool.ID,
+		VolumeType:    param.VolumeType,
+	}
+	a.volumes[v.ID] = v
+	return &types.VolumeResp{ID: v.ID}, nil
+}
+
+func (a *fakeAPI) GetVolume(
+	storagePool *types.StoragePool,
+	volumeID, volumeName string,
+	getSnapshots bool) ([]*types.Volume, error) {
+	a.Lock()
+	defer a.Unlock()
+	var volumes []*types.Volume
+	for _, v := range a.volumes {
+		if (volumeID == "" || v.ID == volumeID) &&
+			(volumeName == "" || v.Name == volumeName) {
+			volumes = append(volumes, v)
+		}
+	}
+	return volumes, nil
+}
+
+func (a *fakeAPI) RemoveVolume(volume *types.Volume, removeMode string) error {
+	a.Lock()
+	defer a.Unlock()
+	delete(a.volumes, volume.ID)
+	return nil
+}
+
+func (a *fakeAPI) MapVolumeSdc(
+	volume *types.Volume, param *types.MapVolumeSdcParam) error {
+	a.Lock()
+	defer a.Unlock()
+	v := a.volumes[volume.ID]
+	v.MappedSdcInfo = append(v.MappedSdcInfo,
+		&types.MappedSdcInfo{SdcID: param.SdcID})
+	a.mapped = append(a.mapped, &goscaleio.SdcMappedVolume{
+		VolumeID:  volume.ID,
+		SdcDevice: "/dev/scini" + volume.ID,
+	})
+	return nil
+}
+
+func (a *fakeAPI) UnmapVolumeSdc(
+	volume *types.Volume, param *types.UnmapVolumeSdcParam) error {
+	a.Lock()
+	defer a.Unlock()
+	a.volumes[volume.ID].MappedSdcInfo = nil
+	a.mapped = nil
+	return nil
+}
+
+func (a *fakeAPI) SetMappedSdcLimits(
+	volume *types.Volume, param *types.SetMappedSdcLimitsParam) error {
+	a.Lock()
+	defer a.Unlock()
+	a.limits = append(a.limits, param)
+	iops, _ := strconv.Atoi(param.IopsLimit)
+	bandwidth, _ := strconv.Atoi(param.BandwidthLimitInKbps)
+	for _, info := range a.volumes[volume.ID].MappedSdcInfo {
+		if info.SdcID == param.SdcID {
+			info.LimitIops = iops
+			info.LimitBwInMbps = bandwidth / 1024
+		}
+	}
+	return nil
+}
+
+func (a *fakeAPI) GetLocalVolumeMap() ([]*goscaleio.SdcMappedVolume, error) {
+	a.Lock()
+	defer a.Unlock()
+	return append([]*goscaleio.SdcMappedVolume{}, a.mapped...), nil
+}
+
+// newTestDriver returns a driver of the fake api, as if it was initialized
+// by a new process.
+func newTestDriver(a api) *driver {
+	sp := &types.StoragePool{ID: "sp1", Name: "pool1", ProtectionDomainID: "pd1"}
+	d := newDriver().(*driver)
+	d.r = core.New(gofig.New())
+	d.api = a
+	d.sdc = &goscaleio.Sdc{Sdc: &types.Sdc{ID: "sdc1"}}
+	d.protectionDomain = &goscaleio.ProtectionDomain{
+		ProtectionDomain: &types.ProtectionDomain{ID: "pd1"}}
+	d.storagePool = &goscaleio.StoragePool{StoragePool: sp}
+	d.cacheStoragePools(sp)
+	return d
+}
+
+func TestCreateAndAttachVolumeLimits(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rexray-scaleio")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	oldPrefix := util.GetPrefix()
+	util.Prefix(dir)
+	defer util.Prefix(oldPrefix)
+
+	a := newFakeAPI()
+	ctx := core.WithVolumeOpts(context.Background(), core.VolumeOpts{
+		"iopsLimit":      "500",
+		"bandwidthLimit": "10240",
+	})
+	volume, err := newTestDriver(a).CreateVolume(
+		ctx, false, "v1", "", "", "", 0, 8, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the limits are applied by another process after a restart
+	d := newTestDriver(a)
+	if _, err := d.AttachVolume(
+		context.Background(), false, volume.VolumeID, "", false); err != nil {
+		t.Fatal(err)
+	}
+	if len(a.limits) != 1 || a.limits[0].SdcID != "sdc1" ||
+		a.limits[0].IopsLimit != "500" ||
+		a.limits[0].BandwidthLimitInKbps != "10240" {
+		t.Fatalf("unexpected limits %+v", a.limits)
+	}
+
+	volumes, err := d.GetVolume(context.Background(), volume.VolumeID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if volumes[0].IOPS != 500 || volumes[0].BandwidthLimit != 10240 ||
+		len(volumes[0].Attachments) != 1 {
+		t.Fatalf("unexpected volume %+v", volumes[0])
+	}
+
+	if err := d.RemoveVolume(context.Background(), volume.VolumeID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(limitsFilePath(volume.VolumeID)); !os.IsNotExist(err) {
+		t.Fatalf("limits of removed volume not removed: %v", err)
+	}
+}
@@ -0,0 +1,365 @@
+package scaleio
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/akutz/goof"
+	"golang.org/x/net/context"
+
+	"github.com/emccode/goscaleio"
+	types "github.com/emccode/goscaleio/types/v1"
+
+	"github.com/emccode/rexray/core"
+	"github.com/emccode/rexray/util"
+)
+
+// The ScaleIO volume types, which are the provisioning of the volumes.
+const (
+	thinProvisioned  = "ThinProvisioned"
+	thickProvisioned = "ThickProvisioned"
+)
+
+// volumeLimits are the SDC-level limits of a mapped volume. A zero limit is
+// unlimited.
+type volumeLimits struct {
+
+	// iops is the IOPS limit.
+	iops int64
+
+	// bandwidth is the bandwidth limit, in KB/s.
+	bandwidth int64
+}
+
+func (l *volumeLimits) isSet() bool {
+	return l != nil && (l.iops > 0 || l.bandwidth > 0)
+}
+
+// getProvisioning returns the ScaleIO volume type of the provisioning, ex.
+// ThinProvisioned for thin, or an empty string for the default provisioning
+// of the storage pool.
+func getProvisioning(provisioning string) (string, error) {
+	switch strings.ToLower(provisioning) {
+	case "":
+		return "", nil
+	case "thin", strings.ToLower(thinProvisioned):
+		return thinProvisioned, nil
+	case "thick", strings.ToLower(thickProvisioned):
+		return thickProvisioned, nil
+	}
+	return "", goof.WithFields(eff(goof.Fields{
+		"provisioning": provisioning,
+	}), "invalid provisioning; must be thin or thick")
+}
+
+// isProvisioning returns a flag indicating whether or not the volume type is
+// a provisioning rather than a storage pool.
+func isProvisioning(volumeType string) bool {
+	p, err := getProvisioning(volumeType)
+	return err == nil && p != ""
+}
+
+// getLimit returns the limit of the volume option, or zero if the option is
+// not set.
+func getLimit(ctx context.Context, name string) (int64, error) {
+	v, ok := core.GetVolumeOpt(ctx, name)
+	if !ok || v == "" {
+		return 0, nil
+	}
+	limit, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || limit < 0 {
+		return 0, goof.WithFields(eff(goof.Fields{
+			name: v,
+		}), "invalid limit; must be a non-negative integer")
+	}
+	return limit, nil
+}
+
+// getVolumeLimits returns the limits of a new volume: the IOPS, or the
+// iopsLimit volume option, and the bandwidthLimit volume option, in KB/s.
+func getVolumeLimits(ctx context.Context, IOPS int64) (*volumeLimits, error) {
+	limits := &volumeLimits{iops: IOPS}
+
+	var err error
+	if limits.iops == 0 {
+		if limits.iops, err = getLimit(ctx, "iopsLimit"); err != nil {
+			return nil, err
+		}
+	}
+	if limits.bandwidth, err = getLimit(ctx, "bandwidthLimit"); err != nil {
+		return nil, err
+	}
+	return limits, nil
+}
+
+// getMappedLimits returns the limits of the volume's mapping to the SDC, or
+// of its first mapping if the SDC ID is empty, or nil if there is no such
+// mapping.
+func getMappedLimits(volume *types.Volume, sdcID string) *volumeLimits {
+	for _, info := range volume.MappedSdcInfo {
+		if sdcID == "" || info.SdcID == sdcID {
+			return &volumeLimits{
+				iops:      int64(info.LimitIops),
+				bandwidth: int64(info.LimitBwInMbps) * 1024,
+			}
+		}
+	}
+	return nil
+}
+
+// getStoragePool returns the storage pool of a new volume and its
+// provisioning. The volume options protectionDomainID, protectionDomainName,
+// storagePoolID, and storagePoolName select the storage pool, which is
+// otherwise the storage pool of the volume type, or the configured storage
+// pool. The availability zone is the protection domain. The provisioning
+// volume option, a volume type of thin or thick, or the configured
+// provisioning select the provisioning.
+func (d *driver) getStoragePool(
+	ctx context.Context,
+	volumeType, availabilityZone string) (*goscaleio.StoragePool, string, error) {
+
+	provisioning, _ := core.GetVolumeOpt(ctx, "provisioning")
+	if provisioning == "" && isProvisioning(volumeType) {
+		provisioning = volumeType
+	}
+	if provisioning == "" {
+		provisioning = d.provisioning()
+	}
+	provisioning, err := getProvisioning(provisioning)
+	if err != nil {
+		return nil, "", err
+	}
+
+	pdID, _ := core.GetVolumeOpt(ctx, "protectionDomainID")
+	pdName, _ := core.GetVolumeOpt(ctx, "protectionDomainName")
+	if pdID == "" && pdName == "" {
+		pdID, pdName = availabilityZone, availabilityZone
+	}
+
+	spID, _ := core.GetVolumeOpt(ctx, "storagePoolID")
+	spName, _ := core.GetVolumeOpt(ctx, "storagePoolName")
+	if spID == "" && spName == "" && !isProvisioning(volumeType) {
+		spID, spName = volumeType, volumeType
+	}
+
+	fields := eff(goof.Fields{
+		"protectionDomainId":   pdID,
+		"protectionDomainName": pdName,
+		"storagePoolId":        spID,
+		"storagePoolName":      spName,
+	})
+
+	if pdID == "" && pdName == "" && spID == "" && spName == "" {
+		return d.storagePool, provisioning, nil
+	}
+
+	pd := d.protectionDomain
+	if pdID != "" || pdName != "" {
+		pds, err := d.system.GetProtectionDomain("")
+		if err != nil {
+			return nil, "", goof.WithFieldsE(
+				fields, "error getting protection domains", err)
+		}
+		pd = nil
+		var names []string
+		for _, p := range pds {
+			if (pdID != "" && p.ID == pdID) ||
+				(pdName != "" && p.Name == pdName) {
+				pd = goscaleio.NewProtectionDomain(d.client)
+				pd.ProtectionDomain = p
+				break
+			}
+			names = append(names, p.Name)
+		}
+		if pd == nil {
+			fields["protectionDomains"] = names
+			return nil, "", goof.WithFields(fields, "invalid protection domain")
+		}
+	}
+
+	if spID == "" && spName == "" {
+		if pd.ProtectionDomain.ID == d.protectionDomain.ProtectionDomain.ID {
+			return d.storagePool, provisioning, nil
+		}
+		return nil, "", goof.WithFields(fields,
+			"a storage pool of the protection domain is required")
+	}
+
+	sps, err := pd.GetStoragePool("")
+	if err != nil {
+		return nil, "", goof.WithFieldsE(
+			fields, "error getting storage pools", err)
+	}
+	var names []string
+	for _, p := range sps {
+		if (spID != "" && p.ID == spID) || (spName != "" && p.Name == spName) {
+			sp := goscaleio.NewStoragePool(d.client)
+			sp.StoragePool = p
+			d.cacheStoragePools(p)
+			return sp, provisioning, nil
+		}
+		names = append(names, p.Name)
+	}
+	fields["storagePools"] = names
+	return nil, "", goof.WithFields(fields, "invalid storage pool")
+}
+
+// listStoragePools returns the storage pools of all of the protection
+// domains of the system.
+func (d *driver) listStoragePools() ([]*types.StoragePool, error) {
+	pds, err := d.system.GetProtectionDomain("")
+	if err != nil {
+		return nil, goof.WithFieldsE(
+			ef(), "error getting protection domains", err)
+	}
+
+	var storagePools []*types.StoragePool
+	for _, p := range pds {
+		pd := goscaleio.NewProtectionDomain(d.client)
+		pd.ProtectionDomain = p
+		sps, err := pd.GetStoragePool("")
+		if err != nil {
+			return nil, goof.WithFieldsE(eff(goof.Fields{
+				"protectionDomainId": p.ID,
+			}), "error getting storage pools", err)
+		}
+		storagePools = append(storagePools, sps...)
+	}
+
+	d.cacheStoragePools(storagePools...)
+	return storagePools, nil
+}
+
+func (d *driver) cacheStoragePools(storagePools ...*types.StoragePool) {
+	d.storagePoolsL.Lock()
+	defer d.storagePoolsL.Unlock()
+	for _, sp := range storagePools {
+		d.storagePools[sp.ID] = sp
+	}
+}
+
+// getStoragePoolByID returns the storage pool with the ID, or nil if there
+// is no such storage pool.
+func (d *driver) getStoragePoolByID(id string) *types.StoragePool {
+	d.storagePoolsL.Lock()
+	sp, ok := d.storagePools[id]
+	d.storagePoolsL.Unlock()
+	if ok {
+		return sp
+	}
+
+	if _, err := d.listStoragePools(); err != nil {
+		return nil
+	}
+
+	d.storagePoolsL.Lock()
+	defer d.storagePoolsL.Unlock()
+	return d.storagePools[id]
+}
+
+// limitsDirPath returns the path to the directory in which the limits of the
+// volumes created on the host are saved, as the limits belong to a volume's
+// mappings and are only applied when it is attached.
+func limitsDirPath() string {
+	return util.LibFilePath("scaleio/limits")
+}
+
+func limitsFilePath(volumeID string) string {
+	return filepath.Join(limitsDirPath(), volumeID+".json")
+}
+
+// savedLimits is the saved form of a volume's limits.
+type savedLimits struct {
+	IOPS      int64 `json:"iops"`
+	Bandwidth int64 `json:"bandwidth"`
+}
+
+// saveVolumeLimits saves the limits of a new volume, which are applied when
+// the volume is attached.
+func (d *driver) saveVolumeLimits(volumeID string, limits *volumeLimits) error {
+	buf, err := json.Marshal(&savedLimits{limits.iops, limits.bandwidth})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(limitsDirPath(), 0755); err != nil {
+		return err
+	}
+
+	// write to a temporary file and rename it so that readers in other
+	// processes never observe partially written limits
+	tmp := limitsFilePath(volumeID) + ".tmp"
+	if err := ioutil.WriteFile(tmp, buf, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, limitsFilePath(volumeID))
+}
+
+// loadVolumeLimits returns the saved limits of the volume, or nil if the
+// volume has none.
+func (d *driver) loadVolumeLimits(volumeID string) (*volumeLimits, error) {
+	buf, err := ioutil.ReadFile(limitsFilePath(volumeID))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var saved savedLimits
+	if err := json.Unmarshal(buf, &saved); err != nil {
+		return nil, err
+	}
+	return &volumeLimits{iops: saved.IOPS, bandwidth: saved.Bandwidth}, nil
+}
+
+// removeVolumeLimits removes the saved limits of the volume.
+func (d *driver) removeVolumeLimits(volumeID string) error {
+	err := os.Remove(limitsFilePath(volumeID))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// getAttachLimits returns the limits of the volume's mapping to the SDC:
+// the saved limits of the volume, or the limits of its mapping to another
+// SDC, or the configured limits.
+func (d *driver) getAttachLimits(volume *types.Volume) (*volumeLimits, error) {
+	limits, err := d.loadVolumeLimits(volume.ID)
+	if err != nil {
+		return nil, goof.WithFieldsE(eff(goof.Fields{
+			"volumeId": volume.ID,
+		}), "error loading volume limits", err)
+	}
+	if limits != nil {
+		return limits, nil
+	}
+
+	if limits = getMappedLimits(volume, ""); limits.isSet() {
+		return limits, nil
+	}
+
+	return &volumeLimits{
+		iops:      int64(d.iopsLimit()),
+		bandwidth: int64(d.bandwidthLimit()),
+	}, nil
+}
+
+// setMappedLimits sets the limits of the volume's mapping to the SDC.
+func (d *driver) setMappedLimits(
+	volume *types.Volume, limits *volumeLimits) error {
+
+	if !limits.isSet() {
+		return nil
+	}
+	return d.api.SetMappedSdcLimits(volume, &types.SetMappedSdcLimitsParam{
+		SdcID:                d.sdc.Sdc.ID,
+		IopsLimit:            strconv.FormatInt(limits.iops, 10),
+		BandwidthLimitInKbps: strconv.FormatInt(limits.bandwidth, 10),
+	})
+}
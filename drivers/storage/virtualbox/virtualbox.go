@@ -14,6 +14,7 @@ import (
 	"github.com/akutz/gofig"
 	"github.com/akutz/goof"
 	vbox "github.com/appropriate/go-virtualboxclient/virtualboxclient"
+	"golang.org/x/net/context"
 
 	"github.com/emccode/rexray/core"
 	"github.com/emccode/rexray/core/errors"
@@ -48,7 +49,7 @@ func eff(fields goof.Fields) map[string]interface{} {
 
 func init() {
 	core.RegisterDriver(providerName, newDriver)
-	gofig.Register(configRegistration())
+	core.RegisterConfigSchema(configRegistration())
 }
 
 func newDriver() core.Driver {
@@ -104,7 +105,7 @@ func (d *driver) checkSession() error {
 	_, err := d.virtualbox.FindMachine(d.machine.ID)
 	if err != nil {
 		log.Debug("logging in again")
-		d.login()
+		return d.login()
 	}
 	return nil
 }
@@ -120,7 +121,19 @@ func (d *driver) Name() string {
 	return providerName
 }
 
-func (d *driver) GetInstance() (*core.Instance, error) {
+// HealthCheck verifies that the driver has a valid session with the
+// VirtualBox web service, logging in again if the session has expired.
+func (d *driver) HealthCheck(ctx context.Context) error {
+	d.m.Lock()
+	defer d.m.Unlock()
+	if err := d.checkSession(); err != nil {
+		return goof.WithFieldE(
+			"endpoint", d.endpoint(), "error checking session", err)
+	}
+	return nil
+}
+
+func (d *driver) GetInstance(ctx context.Context) (*core.Instance, error) {
 
 	instance := &core.Instance{
 		ProviderName: providerName,
@@ -166,7 +179,8 @@ func (d *driver) getLocalDeviceByID() (map[string]string, error) {
 	return mapDiskByID, nil
 }
 
-func (d *driver) GetVolumeMapping() ([]*core.BlockDevice, error) {
+func (d *driver) GetVolumeMapping(
+	ctx context.Context) ([]*core.BlockDevice, error) {
 	d.m.Lock()
 	defer d.m.Unlock()
 	d.checkSession()
@@ -224,7 +238,9 @@ func (d *driver) GetVolumeMapping() ([]*core.BlockDevice, error) {
 
 }
 
-func (d *driver) GetVolume(volumeID, volumeName string) ([]*core.Volume, error) {
+func (d *driver) GetVolume(
+	ctx context.Context,
+	volumeID, volumeName string) ([]*core.Volume, error) {
 	d.m.Lock()
 	d.checkSession()
 
@@ -238,7 +254,7 @@ func (d *driver) GetVolume(volumeID, volumeName string) ([]*core.Volume, error)
 		return nil, nil
 	}
 
-	volumeMapping, err := d.GetVolumeMapping()
+	volumeMapping, err := d.GetVolumeMapping(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -279,6 +295,7 @@ func (d *driver) GetVolume(volumeID, volumeName string) ([]*core.Volume, error)
 }
 
 func (d *driver) CreateVolume(
+	ctx context.Context,
 	notUsed bool,
 	volumeName, volumeID, snapshotID, NUvolumeType string,
 	NUIOPS, size int64, NUavailabilityZone string) (*core.Volume, error) {
@@ -292,7 +309,7 @@ func (d *driver) CreateVolume(
 
 	size = size * 1024 * 1024 * 1024
 
-	volumes, err := d.GetVolume("", volumeName)
+	volumes, err := d.GetVolume(ctx, "", volumeName)
 	if err != nil {
 		return nil, err
 	}
@@ -306,7 +323,7 @@ func (d *driver) CreateVolume(
 		return nil, goof.WithFieldsE(fields, "error creating new volume", err)
 	}
 
-	volumes, err = d.GetVolume(volume.ID, "")
+	volumes, err = d.GetVolume(ctx, volume.ID, "")
 	if err != nil {
 		return nil, err
 	}
@@ -318,7 +335,16 @@ func (d *driver) CreateVolume(
 	return volumes[0], nil
 }
 
-func (d *driver) RemoveVolume(volumeID string) error {
+// CloneVolume is not implemented; the VirtualBox client cannot clone media,
+// and the driver has no snapshots to clone from.
+func (d *driver) CloneVolume(
+	ctx context.Context,
+	sourceVolumeID, newName string,
+	opts core.VolumeOpts) (*core.Volume, error) {
+	return nil, errors.ErrNotImplemented
+}
+
+func (d *driver) RemoveVolume(ctx context.Context, volumeID string) error {
 	d.m.Lock()
 	defer d.m.Unlock()
 	d.checkSession()
@@ -337,25 +363,29 @@ func (d *driver) RemoveVolume(volumeID string) error {
 
 //GetSnapshot returns snapshots from a volume or a specific snapshot
 func (d *driver) GetSnapshot(
+	ctx context.Context,
 	volumeID, snapshotID, snapshotName string) ([]*core.Snapshot, error) {
 	return nil, errors.ErrNotImplemented
 }
 
 func (d *driver) CreateSnapshot(
+	ctx context.Context,
 	notUsed bool,
 	snapshotName, volumeID, description string) ([]*core.Snapshot, error) {
 	return nil, errors.ErrNotImplemented
 }
 
-func (d *driver) RemoveSnapshot(snapshotID string) error {
+func (d *driver) RemoveSnapshot(ctx context.Context, snapshotID string) error {
 	return errors.ErrNotImplemented
 }
 
-func (d *driver) GetVolumeAttach(volumeID, instanceID string) ([]*core.VolumeAttachment, error) {
+func (d *driver) GetVolumeAttach(
+	ctx context.Context,
+	volumeID, instanceID string) ([]*core.VolumeAttachment, error) {
 	if volumeID == "" {
 		return []*core.VolumeAttachment{}, errors.ErrMissingVolumeID
 	}
-	volume, err := d.GetVolume(volumeID, "")
+	volume, err := d.GetVolume(ctx, volumeID, "")
 	if err != nil {
 		return []*core.VolumeAttachment{}, err
 	}
@@ -374,7 +404,7 @@ func (d *driver) GetVolumeAttach(volumeID, instanceID string) ([]*core.VolumeAtt
 	return volume[0].Attachments, nil
 }
 
-func (d *driver) rescanScsiHosts() {
+func (d *driver) rescanScsiHosts(ctx context.Context) {
 	hosts := "/sys/class/scsi_host/"
 	if dirs, err := ioutil.ReadDir(hosts); err == nil {
 		for _, f := range dirs {
@@ -383,10 +413,14 @@ func (d *driver) rescanScsiHosts() {
 			ioutil.WriteFile(name, data, 0666)
 		}
 	}
-	time.Sleep(1 * time.Second)
+	select {
+	case <-ctx.Done():
+	case <-time.After(1 * time.Second):
+	}
 }
 
 func (d *driver) AttachVolume(
+	ctx context.Context,
 	runAsync bool,
 	volumeID, instanceID string, force bool) ([]*core.VolumeAttachment, error) {
 
@@ -394,7 +428,7 @@ func (d *driver) AttachVolume(
 		return nil, errors.ErrMissingVolumeID
 	}
 
-	volumes, err := d.GetVolume(volumeID, "")
+	volumes, err := d.GetVolume(ctx, volumeID, "")
 	if err != nil {
 		return nil, err
 	}
@@ -406,7 +440,7 @@ func (d *driver) AttachVolume(
 	if len(volumes[0].Attachments) > 0 && !force {
 		return nil, goof.New("volume already attached to a host")
 	} else if len(volumes[0].Attachments) > 0 && force {
-		if err := d.DetachVolume(false, volumeID, "", true); err != nil {
+		if err := d.DetachVolume(ctx, false, volumeID, "", true); err != nil {
 			return nil, err
 		}
 	}
@@ -415,9 +449,9 @@ func (d *driver) AttachVolume(
 		return nil, goof.WithFieldE("volumeID", volumeID, "error attaching volume", err)
 	}
 
-	d.rescanScsiHosts()
+	d.rescanScsiHosts(ctx)
 
-	volumeAttachment, err := d.GetVolumeAttach(volumeID, instanceID)
+	volumeAttachment, err := d.GetVolumeAttach(ctx, volumeID, instanceID)
 	if err != nil {
 		return nil, err
 	}
@@ -426,12 +460,14 @@ func (d *driver) AttachVolume(
 
 }
 
-func (d *driver) DetachVolume(notUsed bool, volumeID string, blank string, notused bool) error {
+func (d *driver) DetachVolume(
+	ctx context.Context,
+	notUsed bool, volumeID string, blank string, notused bool) error {
 	if volumeID == "" {
 		return errors.ErrMissingVolumeID
 	}
 
-	volumes, err := d.GetVolume(volumeID, "")
+	volumes, err := d.GetVolume(ctx, volumeID, "")
 	if err != nil {
 		return err
 	}
@@ -444,18 +480,19 @@ func (d *driver) DetachVolume(notUsed bool, volumeID string, blank string, notus
 		return goof.WithFieldE("volumeID", volumeID, "error detaching volume", err)
 	}
 
-	log.Println("Detached volume", volumeID)
+	core.Logger(ctx).Println("Detached volume", volumeID)
 	return nil
 }
 
 func (d *driver) CopySnapshot(
+	ctx context.Context,
 	runAsync bool,
 	volumeID, snapshotID, snapshotName,
 	destinationSnapshotName, destinationRegion string) (*core.Snapshot, error) {
 	return nil, errors.ErrNotImplemented
 }
 
-func (d *driver) GetDeviceNextAvailable() (string, error) {
+func (d *driver) GetDeviceNextAvailable(ctx context.Context) (string, error) {
 	return "", errors.ErrNotImplemented
 }
 
@@ -674,8 +711,8 @@ func (d *driver) controllerName() string {
 	return cn
 }
 
-func configRegistration() *gofig.Registration {
-	r := gofig.NewRegistration("virtualbox")
+func configRegistration() *core.ConfigSchema {
+	r := core.NewConfigSchema("virtualbox", providerName)
 	r.Key(gofig.String, "", "", "", "virtualbox.endpoint")
 	r.Key(gofig.String, "", "", "", "virtualbox.volumePath")
 	r.Key(gofig.String, "", "", "", "virtualbox.localMachineNameOrId")
@@ -683,5 +720,8 @@ func configRegistration() *gofig.Registration {
 	r.Key(gofig.String, "", "", "", "virtualbox.password")
 	r.Key(gofig.Bool, "", false, "", "virtualbox.tls")
 	r.Key(gofig.String, "", "", "", "virtualbox.controllerName")
+	r.Require("virtualbox.endpoint")
+	r.Require("virtualbox.volumePath")
+	r.Secret("virtualbox.password")
 	return r
 }
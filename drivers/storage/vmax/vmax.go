@@ -13,13 +13,14 @@ import (
 	"github.com/akutz/gofig"
 	"github.com/akutz/goof"
 	govmax "github.com/emccode/govmax/api/v1"
+	"golang.org/x/net/context"
 
 	"github.com/emccode/rexray/core"
 	"github.com/emccode/rexray/core/errors"
+	"github.com/emccode/rexray/core/waiter"
 )
 
 const providerName = "VMAX"
-const jobTimeout = 30
 
 // The XtremIO storage driver.
 type driver struct {
@@ -57,7 +58,7 @@ func eff(fields goof.Fields) map[string]interface{} {
 
 func init() {
 	core.RegisterDriver(providerName, newDriver)
-	gofig.Register(configRegistration())
+	core.RegisterConfigSchema(configRegistration())
 }
 
 func newDriver() core.Driver {
@@ -123,7 +124,24 @@ func (d *driver) Name() string {
 	return providerName
 }
 
-func (d *driver) GetInstance() (*core.Instance, error) {
+// HealthCheck verifies that the driver is able to log in to the SMI-S
+// provider.
+func (d *driver) HealthCheck(ctx context.Context) error {
+	if _, err := govmax.New(
+		d.smisHost(),
+		d.smisPort(),
+		d.insecure(),
+		d.userName(),
+		d.password()); err != nil {
+		return goof.WithFieldsE(map[string]interface{}{
+			"smisHost": d.smisHost(),
+			"smisPort": d.smisPort(),
+		}, "error logging in", err)
+	}
+	return nil
+}
+
+func (d *driver) GetInstance(ctx context.Context) (*core.Instance, error) {
 	instance := &core.Instance{
 		ProviderName: providerName,
 		InstanceID:   d.vmh.Vm.Reference().Value,
@@ -133,9 +151,10 @@ func (d *driver) GetInstance() (*core.Instance, error) {
 	return instance, nil
 }
 
-func (d *driver) GetVolumeMapping() ([]*core.BlockDevice, error) {
+func (d *driver) GetVolumeMapping(
+	ctx context.Context) ([]*core.BlockDevice, error) {
 
-	volumes, err := d.GetVolume("", "")
+	volumes, err := d.GetVolume(ctx, "", "")
 	if err != nil {
 		return nil, err
 	}
@@ -177,7 +196,9 @@ func (d *driver) unprefixVolumeName(volName string) string {
 	return strings.TrimPrefix(volName, d.volPrefix)
 }
 
-func (d *driver) GetVolume(volumeID, volumeName string) ([]*core.Volume, error) {
+func (d *driver) GetVolume(
+	ctx context.Context,
+	volumeID, volumeName string) ([]*core.Volume, error) {
 
 	localDeviceMap, err := d.getLocalWWNDeviceByID()
 	if err != nil {
@@ -230,68 +251,39 @@ func getID(id string) string {
 	return fields[len(fields)-1]
 }
 
-func (d *driver) waitJob(instanceID string) (*govmax.GetJobStatusResp, error) {
+func (d *driver) waitJob(
+	ctx context.Context,
+	instanceID string) (*govmax.GetJobStatusResp, error) {
 
-	timeout := make(chan bool, 1)
-	go func() {
-		time.Sleep(jobTimeout * time.Second)
-		timeout <- true
-	}()
+	core.Logger(ctx).Println("waiting for job to complete")
 
-	successCh := make(chan *govmax.GetJobStatusResp, 1)
-	errorCh := make(chan struct {
-		err           error
-		jobStatusResp *govmax.GetJobStatusResp
-	}, 1)
-	go func(instanceID string) {
-		log.Println("waiting for job to complete")
-		for {
-			jobStatusResp, jobStatus, err := d.client.GetJobStatus(instanceID)
+	var jobStatusResp *govmax.GetJobStatusResp
+	err := waiter.Until(ctx,
+		waiter.NewOptions(d.r.Config, waiter.OpJob),
+		func() (bool, error) {
+			var jobStatus string
+			var err error
+			jobStatusResp, jobStatus, err = d.client.GetJobStatus(instanceID)
 			if err != nil {
-				errorCh <- struct {
-					err           error
-					jobStatusResp *govmax.GetJobStatusResp
-				}{
-					goof.WithError(
-						"error getting job status", err),
-					nil,
-				}
+				return false, goof.WithError("error getting job status", err)
 			}
 
-			switch {
-			case jobStatus == "TERMINATED" || jobStatus == "KILLED" ||
-				jobStatus == "EXCEPTION":
-				errorCh <- struct {
-					err           error
-					jobStatusResp *govmax.GetJobStatusResp
-				}{
-					goof.Newf(
-						"problem with job: %s", jobStatus),
-					jobStatusResp,
-				}
-				return
-			case jobStatus == "COMPLETED":
-				successCh <- jobStatusResp
-				return
+			switch jobStatus {
+			case "TERMINATED", "KILLED", "EXCEPTION":
+				return false, goof.Newf("problem with job: %s", jobStatus)
+			case "COMPLETED":
+				return true, nil
 			}
 
-			time.Sleep(100 * time.Millisecond)
-		}
-	}(instanceID)
-
-	select {
-	case jobStatusResp := <-successCh:
-		return jobStatusResp, nil
-	case jobStatusRespErr := <-errorCh:
-		return jobStatusRespErr.jobStatusResp, jobStatusRespErr.err
-	case <-timeout:
-		return nil, goof.New("timed out waiting for job")
-	}
+			return false, nil
+		})
 
+	return jobStatusResp, err
 }
 
-func (d *driver) volumeExists(volumeName string) (bool, error) {
-	volumes, err := d.GetVolume("", volumeName)
+func (d *driver) volumeExists(
+	ctx context.Context, volumeName string) (bool, error) {
+	volumes, err := d.GetVolume(ctx, "", volumeName)
 	if err != nil {
 		return false, err
 	}
@@ -303,11 +295,12 @@ func (d *driver) volumeExists(volumeName string) (bool, error) {
 }
 
 func (d *driver) CreateVolume(
+	ctx context.Context,
 	runAsync bool,
 	volumeName, volumeID, snapshotID, NUvolumeType string,
 	NUIOPS, size int64, NUavailabilityZone string) (*core.Volume, error) {
 
-	exists, err := d.volumeExists(volumeName)
+	exists, err := d.volumeExists(ctx, volumeName)
 	if err != nil && !exists {
 		return nil, err
 	} else if exists {
@@ -333,7 +326,7 @@ func (d *driver) CreateVolume(
 	}
 
 	if !runAsync {
-		jobStatusResp, err := d.waitJob(queuedJob.Entries[0].Content.I_Parameters.I_Job.E0_InstanceID)
+		jobStatusResp, err := d.waitJob(ctx, queuedJob.Entries[0].Content.I_Parameters.I_Job.E0_InstanceID)
 		if err != nil {
 			return nil, err
 		}
@@ -347,7 +340,7 @@ func (d *driver) CreateVolume(
 			return nil, goof.New("new volumeID not found")
 		}
 
-		volume, err := d.GetVolume(fields[1], "")
+		volume, err := d.GetVolume(ctx, fields[1], "")
 		if err != nil {
 			return nil, err
 		}
@@ -361,7 +354,7 @@ func (d *driver) CreateVolume(
 	return nil, nil
 }
 
-func (d *driver) RemoveVolume(volumeID string) error {
+func (d *driver) RemoveVolume(ctx context.Context, volumeID string) error {
 	fields := eff(map[string]interface{}{
 		"volumeID": volumeID,
 	})
@@ -387,35 +380,39 @@ func (d *driver) RemoveVolume(volumeID string) error {
 		return goof.New("no jobs returned")
 	}
 
-	_, err = d.waitJob(queuedJob.Entries[0].Content.I_Parameters.I_Job.E0_InstanceID)
+	_, err = d.waitJob(ctx, queuedJob.Entries[0].Content.I_Parameters.I_Job.E0_InstanceID)
 	if err != nil {
 		return err
 	}
 
-	log.Println("Deleted Volume: " + volumeID)
+	core.Logger(ctx).Println("Deleted Volume: " + volumeID)
 	return nil
 }
 
 func (d *driver) GetSnapshot(
+	ctx context.Context,
 	volumeID, snapshotID, snapshotName string) ([]*core.Snapshot, error) {
 	return nil, goof.New("not implemented in driver")
 }
 
 func (d *driver) CreateSnapshot(
+	ctx context.Context,
 	notUsed bool,
 	snapshotName, volumeID, description string) ([]*core.Snapshot, error) {
 	return nil, goof.New("not implemented in driver")
 }
 
-func (d *driver) RemoveSnapshot(snapshotID string) error {
+func (d *driver) RemoveSnapshot(ctx context.Context, snapshotID string) error {
 	return goof.New("not implemented in driver")
 }
 
-func (d *driver) GetVolumeAttach(volumeID, instanceID string) ([]*core.VolumeAttachment, error) {
+func (d *driver) GetVolumeAttach(
+	ctx context.Context,
+	volumeID, instanceID string) ([]*core.VolumeAttachment, error) {
 	if volumeID == "" {
 		return nil, errors.ErrMissingVolumeID
 	}
-	volume, err := d.GetVolume(volumeID, "")
+	volume, err := d.GetVolume(ctx, volumeID, "")
 	if err != nil {
 		return nil, err
 	}
@@ -434,7 +431,7 @@ func (d *driver) GetVolumeAttach(volumeID, instanceID string) ([]*core.VolumeAtt
 	return volume[0].Attachments, nil
 }
 
-func (d *driver) rescanScsiHosts() {
+func (d *driver) rescanScsiHosts(ctx context.Context) {
 	hosts := "/sys/class/scsi_host/"
 	if dirs, err := ioutil.ReadDir(hosts); err == nil {
 		for _, f := range dirs {
@@ -443,7 +440,10 @@ func (d *driver) rescanScsiHosts() {
 			ioutil.WriteFile(name, data, 0666)
 		}
 	}
-	time.Sleep(1 * time.Second)
+	select {
+	case <-ctx.Done():
+	case <-time.After(1 * time.Second):
+	}
 }
 
 func (d *driver) multipath() bool {
@@ -485,7 +485,8 @@ func (d *driver) getLocalWWNDeviceByID() (map[string]string, error) {
 	return mapDiskByID, nil
 }
 
-func (d *driver) attachVolumeToSG(runAsync bool, volumeID string) error {
+func (d *driver) attachVolumeToSG(
+	ctx context.Context, runAsync bool, volumeID string) error {
 	PostVol2SGRequest := &govmax.PostVolumesToSGReq{
 		PostVolumesToSGRequestContent: &govmax.PostVolumesToSGReqContent{
 			AtType: "http://schemas.emc.com/ecom/edaa/root/emc/Symm_ControllerconfigurationService",
@@ -517,7 +518,7 @@ func (d *driver) attachVolumeToSG(runAsync bool, volumeID string) error {
 		}
 
 		jobResp, err := d.waitJob(
-			queuedJob.Entries[0].Content.I_Parameters.I_Job.E0_InstanceID)
+			ctx, queuedJob.Entries[0].Content.I_Parameters.I_Job.E0_InstanceID)
 		if err != nil {
 			if len(jobResp.Entries) > 0 {
 				if !strings.Contains(jobResp.Entries[0].Content.I_ErrorDescription,
@@ -533,10 +534,11 @@ func (d *driver) attachVolumeToSG(runAsync bool, volumeID string) error {
 }
 
 func (d *driver) AttachVolume(
+	ctx context.Context,
 	runAsync bool,
 	volumeID, instanceID string, force bool) ([]*core.VolumeAttachment, error) {
 
-	volumes, err := d.GetVolume(volumeID, "")
+	volumes, err := d.GetVolume(ctx, volumeID, "")
 	if err != nil {
 		return nil, err
 	}
@@ -545,7 +547,7 @@ func (d *driver) AttachVolume(
 		return nil, goof.New("volume not found")
 	}
 
-	if err := d.attachVolumeToSG(runAsync, volumeID); err != nil {
+	if err := d.attachVolumeToSG(ctx, runAsync, volumeID); err != nil {
 		return nil, goof.WithError("error adding volume to storage group", err)
 	}
 
@@ -575,7 +577,7 @@ func (d *driver) AttachVolume(
 		go d.vmh.RescanAllHba(host)
 	}
 
-	d.rescanScsiHosts()
+	d.rescanScsiHosts(ctx)
 
 	localDeviceMap, err := d.getLocalWWNDeviceByID()
 	if err != nil {
@@ -588,9 +590,10 @@ func (d *driver) AttachVolume(
 		return nil, goof.New("local device not found for volume")
 	}
 
-	log.WithFields(log.Fields{"deviceName": deviceName}).Println("discovered device")
+	core.Logger(ctx).WithFields(log.Fields{"deviceName": deviceName}).Println(
+		"discovered device")
 
-	volumeAttachment, err := d.GetVolumeAttach(volumeID, instanceID)
+	volumeAttachment, err := d.GetVolumeAttach(ctx, volumeID, instanceID)
 	if err != nil {
 		return nil, err
 	}
@@ -620,7 +623,8 @@ func (d *driver) deleteScsiDevice(deviceID string) error {
 
 }
 
-func (d *driver) detachVolumeFromSG(runAsync bool, volumeID string) error {
+func (d *driver) detachVolumeFromSG(
+	ctx context.Context, runAsync bool, volumeID string) error {
 	RemVol2SGRequest := &govmax.PostVolumesToSGReq{
 		PostVolumesToSGRequestContent: &govmax.PostVolumesToSGReqContent{
 			AtType: "http://schemas.emc.com/ecom/edaa/root/emc/Symm_ControllerconfigurationService",
@@ -650,7 +654,7 @@ func (d *driver) detachVolumeFromSG(runAsync bool, volumeID string) error {
 		}
 
 		jobResp, err := d.waitJob(
-			queuedJob.Entries[0].Content.I_Parameters.I_Job.E0_InstanceID)
+			ctx, queuedJob.Entries[0].Content.I_Parameters.I_Job.E0_InstanceID)
 		if err != nil {
 			if len(jobResp.Entries) > 0 {
 				if !strings.Contains(jobResp.Entries[0].Content.I_ErrorDescription,
@@ -665,9 +669,11 @@ func (d *driver) detachVolumeFromSG(runAsync bool, volumeID string) error {
 	return nil
 }
 
-func (d *driver) DetachVolume(runAsync bool, volumeID string, blank string, notused bool) error {
+func (d *driver) DetachVolume(
+	ctx context.Context,
+	runAsync bool, volumeID string, blank string, notused bool) error {
 
-	volumes, err := d.GetVolume(volumeID, "")
+	volumes, err := d.GetVolume(ctx, volumeID, "")
 	if err != nil {
 		return err
 	}
@@ -684,22 +690,30 @@ func (d *driver) DetachVolume(runAsync bool, volumeID string, blank string, notu
 		return goof.WithError("error removing RDM from vm", err)
 	}
 
-	if err := d.detachVolumeFromSG(runAsync, volumeID); err != nil {
+	if err := d.detachVolumeFromSG(ctx, runAsync, volumeID); err != nil {
 		return goof.WithError("error detaching volume from storage group", err)
 	}
 
-	log.Println("Detached volume", volumeID)
+	core.Logger(ctx).Println("Detached volume", volumeID)
 	return nil
 }
 
 func (d *driver) CopySnapshot(
+	ctx context.Context,
 	runAsync bool,
 	volumeID, snapshotID, snapshotName,
 	destinationSnapshotName, destinationRegion string) (*core.Snapshot, error) {
 	return nil, errors.ErrNotImplemented
 }
 
-func (d *driver) GetDeviceNextAvailable() (string, error) {
+func (d *driver) CloneVolume(
+	ctx context.Context,
+	sourceVolumeID, newName string,
+	opts core.VolumeOpts) (*core.Volume, error) {
+	return core.CloneVolumeFromSnapshot(ctx, d, sourceVolumeID, newName, opts)
+}
+
+func (d *driver) GetDeviceNextAvailable(ctx context.Context) (string, error) {
 	return "", errors.ErrNotImplemented
 }
 
@@ -751,8 +765,8 @@ func (d *driver) storageGroup() string {
 	return d.r.Config.GetString("vmax.storageGroup")
 }
 
-func configRegistration() *gofig.Registration {
-	r := gofig.NewRegistration("GOVMAX")
+func configRegistration() *core.ConfigSchema {
+	r := core.NewConfigSchema("GOVMAX", providerName)
 	r.Key(gofig.String, "", "", "", "vmax.smishost")
 	r.Key(gofig.String, "", "", "", "vmax.smisport")
 	r.Key(gofig.Bool, "", false, "", "vmax.insecure")
@@ -765,6 +779,9 @@ func configRegistration() *gofig.Registration {
 	r.Key(gofig.String, "", "", "", "vmax.vmh.userName")
 	r.Key(gofig.String, "", "", "", "vmax.vmh.password")
 	r.Key(gofig.String, "", "", "", "vmax.vmh.host")
-
+	r.Require("vmax.smishost")
+	r.Require("vmax.smisport")
+	r.Require("vmax.sid")
+	r.Secret("vmax.password", "vmax.vmh.password")
 	return r
 }
@@ -11,15 +11,17 @@ import (
 	"sort"
 	"strconv"
 	"strings"
-	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/akutz/gofig"
 	"github.com/akutz/goof"
 	xtio "github.com/emccode/goxtremio"
+	"golang.org/x/net/context"
 
 	"github.com/emccode/rexray/core"
 	"github.com/emccode/rexray/core/errors"
+	"github.com/emccode/rexray/core/waiter"
+	"github.com/emccode/rexray/util"
 )
 
 const providerName = "XtremIO"
@@ -56,7 +58,7 @@ func eff(fields goof.Fields) map[string]interface{} {
 
 func init() {
 	core.RegisterDriver(providerName, newDriver)
-	gofig.Register(configRegistration())
+	core.RegisterConfigSchema(configRegistration())
 }
 
 func newDriver() core.Driver {
@@ -157,6 +159,16 @@ func (d *driver) Name() string {
 	return providerName
 }
 
+// HealthCheck verifies that the driver is able to log in to the XtremIO
+// management server by listing the array's initiators.
+func (d *driver) HealthCheck(ctx context.Context) error {
+	if _, err := d.client.GetInitiators(); err != nil {
+		return goof.WithFieldE(
+			"endpoint", d.endpoint(), "error logging in", err)
+	}
+	return nil
+}
+
 func (d *driver) getVolumesSig() (string, error) {
 	volumes, err := d.client.GetVolumes()
 	if err != nil {
@@ -349,7 +361,7 @@ func (d *driver) getLocalDeviceByID() (map[string]string, error) {
 	return mapDiskByID, nil
 }
 
-func (d *driver) GetInstance() (*core.Instance, error) {
+func (d *driver) GetInstance(ctx context.Context) (*core.Instance, error) {
 
 	initiator, err := d.getInitiator()
 	if err != nil {
@@ -367,7 +379,8 @@ func (d *driver) GetInstance() (*core.Instance, error) {
 	return instance, nil
 }
 
-func (d *driver) GetVolumeMapping() ([]*core.BlockDevice, error) {
+func (d *driver) GetVolumeMapping(
+	ctx context.Context) ([]*core.BlockDevice, error) {
 
 	mapDiskByID, err := d.getLocalDeviceByID()
 	if err != nil {
@@ -436,7 +449,9 @@ func (d *driver) getVolume(volumeID, volumeName string) ([]xtio.Volume, error) {
 	return volumes, nil
 }
 
-func (d *driver) GetVolume(volumeID, volumeName string) ([]*core.Volume, error) {
+func (d *driver) GetVolume(
+	ctx context.Context,
+	volumeID, volumeName string) ([]*core.Volume, error) {
 
 	volumes, err := d.getVolume(volumeID, volumeName)
 	if err != nil && err.Error() == "obj_not_found" {
@@ -506,6 +521,7 @@ func (d *driver) GetVolume(volumeID, volumeName string) ([]*core.Volume, error)
 }
 
 func (d *driver) CreateVolume(
+	ctx context.Context,
 	notUsed bool,
 	volumeName, volumeID, snapshotID, NUvolumeType string,
 	NUIOPS, size int64, NUavailabilityZone string) (*core.Volume, error) {
@@ -529,40 +545,84 @@ func (d *driver) CreateVolume(
 		}
 
 		index := getIndex(res.Links[0].Href)
-		volumes, err = d.GetVolume(index, "")
+		volumes, err = d.GetVolume(ctx, index, "")
 		if err != nil {
 			return nil, err
 		}
 	} else {
 		if snapshotID != "" {
-			snapshots, err := d.GetSnapshot("", snapshotID, "")
+			snapshots, err := d.GetSnapshot(ctx, "", snapshotID, "")
 			if err != nil {
 				return nil, err
 			}
 			volumeID = snapshots[0].VolumeID
 		}
-		snapshot, err := d.CreateSnapshot(false, volumeName, volumeID, "")
-		if err != nil {
-			return nil, err
-		}
+		return d.CloneVolume(ctx, volumeID, volumeName, nil)
+	}
 
-		snapshotID := snapshot[0].SnapshotID
-		snapshots, err := d.getSnapshot(snapshotID, "")
-		if err != nil {
-			return nil, err
-		}
+	return volumes[0], nil
+}
 
-		volumes, err = d.GetVolume(
-			strconv.Itoa(int(snapshots[0].VolID[2].(float64))), "")
-		if err != nil {
-			return nil, err
-		}
+func (d *driver) CloneVolume(
+	ctx context.Context,
+	sourceVolumeID, newName string,
+	opts core.VolumeOpts) (*core.Volume, error) {
+
+	fields := eff(map[string]interface{}{
+		"sourceVolumeID": sourceVolumeID,
+		"newName":        newName,
+	})
+
+	if sourceVolumeID == "" {
+		return nil, errors.ErrMissingVolumeID
+	}
+
+	// XtremIO snapshots are writable volumes in their own right, so a clone
+	// is a snapshot of the source volume with the new name
+	snapshot, err := d.CreateSnapshot(ctx, false, newName, sourceVolumeID, "")
+	if err != nil {
+		return nil, goof.WithFieldsE(fields, "error cloning volume", err)
+	}
+
+	if len(snapshot) == 0 {
+		return nil, goof.WithFields(fields, "no clone snapshot returned")
+	}
+
+	snapshotID := snapshot[0].SnapshotID
+	fields["snapshotID"] = snapshotID
+
+	snapshots, err := d.getSnapshot(snapshotID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(snapshots) == 0 {
+		return nil, goof.WithFields(fields, "failed to get clone snapshot")
+	}
+
+	// the index of the snapshot's volume is the third element of its vol-id
+	if len(snapshots[0].VolID) < 3 {
+		return nil, goof.WithFields(fields, "clone snapshot has no volume")
+	}
+	volIndex, ok := snapshots[0].VolID[2].(float64)
+	if !ok {
+		fields["volID"] = snapshots[0].VolID
+		return nil, goof.WithFields(fields, "invalid clone snapshot volume")
+	}
+
+	volumes, err := d.GetVolume(ctx, strconv.Itoa(int(volIndex)), "")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(volumes) == 0 {
+		return nil, goof.WithFields(fields, "failed to get cloned volume")
 	}
 
 	return volumes[0], nil
 }
 
-func (d *driver) RemoveVolume(volumeID string) error {
+func (d *driver) RemoveVolume(ctx context.Context, volumeID string) error {
 	fields := eff(map[string]interface{}{
 		"volumeID": volumeID,
 	})
@@ -572,7 +632,7 @@ func (d *driver) RemoveVolume(volumeID string) error {
 		return goof.WithFieldsE(fields, "error deleting volume", err)
 	}
 
-	log.Println("Deleted Volume: " + volumeID)
+	core.Logger(ctx).Println("Deleted Volume: " + volumeID)
 	return nil
 }
 
@@ -601,6 +661,7 @@ func (d *driver) getSnapshot(snapshotID, snapshotName string) ([]xtio.Snapshot,
 
 //GetSnapshot returns snapshots from a volume or a specific snapshot
 func (d *driver) GetSnapshot(
+	ctx context.Context,
 	volumeID, snapshotID, snapshotName string) ([]*core.Snapshot, error) {
 
 	var snapshotsInt []*core.Snapshot
@@ -675,6 +736,7 @@ func getIndex(href string) string {
 }
 
 func (d *driver) CreateSnapshot(
+	ctx context.Context,
 	notUsed bool,
 	snapshotName, volumeID, description string) ([]*core.Snapshot, error) {
 
@@ -695,7 +757,7 @@ func (d *driver) CreateSnapshot(
 	}
 
 	index := getIndex(postSnapshotsResp.Links[0].Href)
-	snapshot, err := d.GetSnapshot("", index, "")
+	snapshot, err := d.GetSnapshot(ctx, "", index, "")
 	if err != nil {
 		return nil, err
 	}
@@ -703,7 +765,7 @@ func (d *driver) CreateSnapshot(
 	return snapshot, nil
 }
 
-func (d *driver) RemoveSnapshot(snapshotID string) error {
+func (d *driver) RemoveSnapshot(ctx context.Context, snapshotID string) error {
 	err := d.client.DeleteSnapshot(snapshotID, "")
 	if err != nil {
 		return goof.WithFieldE("snapshotID", snapshotID, "error deleting snapshot", err)
@@ -712,11 +774,13 @@ func (d *driver) RemoveSnapshot(snapshotID string) error {
 	return nil
 }
 
-func (d *driver) GetVolumeAttach(volumeID, instanceID string) ([]*core.VolumeAttachment, error) {
+func (d *driver) GetVolumeAttach(
+	ctx context.Context,
+	volumeID, instanceID string) ([]*core.VolumeAttachment, error) {
 	if volumeID == "" {
 		return []*core.VolumeAttachment{}, errors.ErrMissingVolumeID
 	}
-	volume, err := d.GetVolume(volumeID, "")
+	volume, err := d.GetVolume(ctx, volumeID, "")
 	if err != nil {
 		return []*core.VolumeAttachment{}, err
 	}
@@ -735,9 +799,10 @@ func (d *driver) GetVolumeAttach(volumeID, instanceID string) ([]*core.VolumeAtt
 	return volume[0].Attachments, nil
 }
 
-func (d *driver) waitAttach(volumeID string) (*core.BlockDevice, error) {
+func (d *driver) waitAttach(
+	ctx context.Context, volumeID string) (*core.BlockDevice, error) {
 
-	volumes, err := d.GetVolume(volumeID, "")
+	volumes, err := d.GetVolume(ctx, volumeID, "")
 	if err != nil {
 		return nil, err
 	}
@@ -746,54 +811,45 @@ func (d *driver) waitAttach(volumeID string) (*core.BlockDevice, error) {
 		return nil, goof.New("no volumes returned")
 	}
 
-	timeout := make(chan bool, 1)
-	go func() {
-		time.Sleep(10 * time.Second)
-		timeout <- true
-	}()
+	core.Logger(ctx).Println("XtremIO: waiting for volume attach")
 
-	successCh := make(chan *core.BlockDevice, 1)
-	errorCh := make(chan error, 1)
-	go func(volumeID string) {
-		log.Println("XtremIO: waiting for volume attach")
-		for {
+	var blockDevice *core.BlockDevice
+	err = waiter.Until(ctx,
+		waiter.NewOptions(d.r.Config, waiter.OpMount),
+		func() (bool, error) {
 			if d.multipath() {
-				_, _ = exec.Command("/sbin/multipath", "-f",
-					fmt.Sprintf("3%s", volumes[0].NetworkName)).Output()
-				_, _ = exec.Command("/sbin/multipath").Output()
+				_, _ = util.CommandOutput(ctx, exec.Command("/sbin/multipath",
+					"-f", fmt.Sprintf("3%s", volumes[0].NetworkName)))
+				_, _ = util.CommandOutput(ctx, exec.Command("/sbin/multipath"))
 			}
 
-			blockDevices, err := d.GetVolumeMapping()
+			blockDevices, err := d.GetVolumeMapping(ctx)
 			if err != nil {
-				errorCh <- goof.Newf(
+				return false, goof.Newf(
 					"problem getting local block devices: %s", err)
-				return
 			}
 
-			for _, blockDevice := range blockDevices {
-				if blockDevice.VolumeID == volumeID {
-					successCh <- blockDevice
-					return
+			for _, bd := range blockDevices {
+				if bd.VolumeID == volumeID {
+					blockDevice = bd
+					return true, nil
 				}
 			}
-			time.Sleep(100 * time.Millisecond)
-		}
-	}(volumeID)
 
-	select {
-	case blockDevice := <-successCh:
-		log.Println(fmt.Sprintf("XtremIO: got attachedVolume %s at %s",
-			blockDevice.VolumeID, blockDevice.DeviceName))
-		return blockDevice, nil
-	case err := <-errorCh:
+			return false, nil
+		})
+
+	if err != nil {
 		return nil, err
-	case <-timeout:
-		return nil, goof.New("timed out waiting for mount")
 	}
 
+	core.Logger(ctx).Println(fmt.Sprintf("XtremIO: got attachedVolume %s at %s",
+		blockDevice.VolumeID, blockDevice.DeviceName))
+	return blockDevice, nil
 }
 
 func (d *driver) AttachVolume(
+	ctx context.Context,
 	runAsync bool,
 	volumeID, instanceID string, force bool) ([]*core.VolumeAttachment, error) {
 
@@ -801,7 +857,7 @@ func (d *driver) AttachVolume(
 		return nil, errors.ErrMissingVolumeID
 	}
 
-	volumes, err := d.GetVolume(volumeID, "")
+	volumes, err := d.GetVolume(ctx, volumeID, "")
 	if err != nil {
 		return nil, err
 	}
@@ -813,7 +869,7 @@ func (d *driver) AttachVolume(
 	if len(volumes[0].Attachments) > 0 && !force {
 		return nil, goof.New("Volume already attached to another host")
 	} else if len(volumes[0].Attachments) > 0 && force {
-		if err := d.DetachVolume(false, volumeID, "", true); err != nil {
+		if err := d.DetachVolume(ctx, false, volumeID, "", true); err != nil {
 			return nil, err
 		}
 	}
@@ -849,15 +905,15 @@ func (d *driver) AttachVolume(
 	}
 
 	if !runAsync {
-		_, err := d.waitAttach(volumeID)
+		_, err := d.waitAttach(ctx, volumeID)
 		if err != nil {
 			return nil, err
 		}
 	} else {
-		_, _ = d.GetVolumeMapping()
+		_, _ = d.GetVolumeMapping(ctx)
 	}
 
-	volumeAttachment, err := d.GetVolumeAttach(volumeID, instanceID)
+	volumeAttachment, err := d.GetVolumeAttach(ctx, volumeID, instanceID)
 	if err != nil {
 		return nil, err
 	}
@@ -895,12 +951,14 @@ func (d *driver) getLunMaps(initiatorName, volumeID string) (xtio.Refs, error) {
 	return refs, nil
 }
 
-func (d *driver) DetachVolume(notUsed bool, volumeID string, blank string, notused bool) error {
+func (d *driver) DetachVolume(
+	ctx context.Context,
+	notUsed bool, volumeID string, blank string, notused bool) error {
 	if volumeID == "" {
 		return errors.ErrMissingVolumeID
 	}
 
-	volumes, err := d.GetVolume(volumeID, "")
+	volumes, err := d.GetVolume(ctx, volumeID, "")
 	if err != nil {
 		return err
 	}
@@ -910,8 +968,8 @@ func (d *driver) DetachVolume(notUsed bool, volumeID string, blank string, notus
 	}
 
 	if d.multipath() {
-		_, _ = exec.Command("/sbin/multipath", "-f",
-			fmt.Sprintf("3%s", volumes[0].NetworkName)).Output()
+		_, _ = util.CommandOutput(ctx, exec.Command("/sbin/multipath",
+			"-f", fmt.Sprintf("3%s", volumes[0].NetworkName)))
 	}
 
 	if err := d.updateInitiatorsSig(); err != nil {
@@ -945,18 +1003,19 @@ func (d *driver) DetachVolume(notUsed bool, volumeID string, blank string, notus
 		}
 	}
 
-	log.Println("Detached volume", volumeID)
+	core.Logger(ctx).Println("Detached volume", volumeID)
 	return nil
 }
 
 func (d *driver) CopySnapshot(
+	ctx context.Context,
 	runAsync bool,
 	volumeID, snapshotID, snapshotName,
 	destinationSnapshotName, destinationRegion string) (*core.Snapshot, error) {
 	return nil, errors.ErrNotImplemented
 }
 
-func (d *driver) GetDeviceNextAvailable() (string, error) {
+func (d *driver) GetDeviceNextAvailable(ctx context.Context) (string, error) {
 	return "", errors.ErrNotImplemented
 }
 
@@ -988,8 +1047,8 @@ func (d *driver) remoteManagement() bool {
 	return d.r.Config.GetBool("xtremio.remoteManagement")
 }
 
-func configRegistration() *gofig.Registration {
-	r := gofig.NewRegistration("XtremIO")
+func configRegistration() *core.ConfigSchema {
+	r := core.NewConfigSchema("XtremIO", providerName)
 	r.Key(gofig.String, "", "", "", "xtremio.endpoint")
 	r.Key(gofig.Bool, "", false, "", "xtremio.insecure")
 	r.Key(gofig.String, "", "", "", "xtremio.userName")
@@ -997,5 +1056,8 @@ func configRegistration() *gofig.Registration {
 	r.Key(gofig.Bool, "", false, "", "xtremio.deviceMapper")
 	r.Key(gofig.Bool, "", false, "", "xtremio.multipath")
 	r.Key(gofig.Bool, "", false, "", "xtremio.remoteManagement")
+	r.Require("xtremio.endpoint")
+	r.Require("xtremio.userName")
+	r.Secret("xtremio.password")
 	return r
 }
@@ -9,6 +9,7 @@ import (
 	log "github.com/Sirupsen/logrus"
 	"github.com/akutz/gofig"
 	"github.com/akutz/goof"
+	"golang.org/x/net/context"
 
 	"github.com/emccode/rexray/core"
 	"github.com/emccode/rexray/util"
@@ -29,8 +30,8 @@ var (
 
 func init() {
 	core.RegisterDriver(providerName, newDriver)
-	gofig.Register(configRegistration())
-	mountDirectoryPath = util.LibFilePath("volumes")
+	core.RegisterConfigSchema(configRegistration())
+	mountDirectoryPath = util.VolumesDirPath()
 	os.MkdirAll(mountDirectoryPath, 0755)
 }
 
@@ -58,8 +59,11 @@ func (d *driver) Name() string {
 }
 
 // Mount will perform the steps to get an existing Volume with or without a fileystem mounted to a guest
-func (d *driver) Mount(volumeName, volumeID string, overwriteFs bool, newFsType string, preempt bool) (string, error) {
-	log.WithFields(log.Fields{
+func (d *driver) Mount(
+	ctx context.Context,
+	volumeName, volumeID string,
+	overwriteFs bool, newFsType string, preempt bool) (string, error) {
+	core.Logger(ctx).WithFields(log.Fields{
 		"volumeName":  volumeName,
 		"volumeID":    volumeID,
 		"overwriteFs": overwriteFs,
@@ -72,7 +76,7 @@ func (d *driver) Mount(volumeName, volumeID string, overwriteFs bool, newFsType
 	var instance *core.Instance
 
 	if vols, volAttachments, instance, err = d.prefixToMountUnmount(
-		volumeName, volumeID); err != nil {
+		ctx, volumeName, volumeID); err != nil {
 		return "", err
 	}
 
@@ -82,11 +86,11 @@ func (d *driver) Mount(volumeName, volumeID string, overwriteFs bool, newFsType
 			return "", err
 		}
 
-		log.Debug("performing precautionary unmount")
-		_ = d.r.OS.Unmount(mp)
+		core.Logger(ctx).Debug("performing precautionary unmount")
+		_ = d.r.OS.Unmount(ctx, mp)
 
 		volAttachments, err = d.r.Storage.AttachVolume(
-			false, vols[0].VolumeID, instance.InstanceID, preempt)
+			ctx, false, vols[0].VolumeID, instance.InstanceID, preempt)
 		if err != nil {
 			return "", err
 		}
@@ -100,7 +104,7 @@ func (d *driver) Mount(volumeName, volumeID string, overwriteFs bool, newFsType
 		return "", goof.New("no device name returned")
 	}
 
-	mounts, err := d.r.OS.GetMounts(volAttachments[0].DeviceName, "")
+	mounts, err := d.r.OS.GetMounts(ctx, volAttachments[0].DeviceName, "")
 	if err != nil {
 		return "", err
 	}
@@ -117,7 +121,7 @@ func (d *driver) Mount(volumeName, volumeID string, overwriteFs bool, newFsType
 	}
 
 	if err := d.r.OS.Format(
-		volAttachments[0].DeviceName, newFsType, overwriteFs); err != nil {
+		ctx, volAttachments[0].DeviceName, newFsType, overwriteFs); err != nil {
 		return "", err
 	}
 
@@ -131,7 +135,7 @@ func (d *driver) Mount(volumeName, volumeID string, overwriteFs bool, newFsType
 	}
 
 	if err := d.r.OS.Mount(
-		volAttachments[0].DeviceName, mountPath, "", ""); err != nil {
+		ctx, volAttachments[0].DeviceName, mountPath, "", ""); err != nil {
 		return "", err
 	}
 
@@ -139,9 +143,10 @@ func (d *driver) Mount(volumeName, volumeID string, overwriteFs bool, newFsType
 }
 
 // Unmount will perform the steps to unmount and existing volume and detach
-func (d *driver) Unmount(volumeName, volumeID string) error {
+func (d *driver) Unmount(
+	ctx context.Context, volumeName, volumeID string) error {
 
-	log.WithFields(log.Fields{
+	core.Logger(ctx).WithFields(log.Fields{
 		"volumeName": volumeName,
 		"volumeID":   volumeID,
 		"driverName": d.Name()}).Info("unmounting volume")
@@ -151,7 +156,7 @@ func (d *driver) Unmount(volumeName, volumeID string) error {
 	var volAttachments []*core.VolumeAttachment
 
 	if vols, volAttachments, _, err = d.prefixToMountUnmount(
-		volumeName, volumeID); err != nil {
+		ctx, volumeName, volumeID); err != nil {
 		return err
 	}
 
@@ -159,27 +164,27 @@ func (d *driver) Unmount(volumeName, volumeID string) error {
 		return nil
 	}
 
-	mounts, err := d.r.OS.GetMounts(volAttachments[0].DeviceName, "")
+	mounts, err := d.r.OS.GetMounts(ctx, volAttachments[0].DeviceName, "")
 	if err != nil {
 		return err
 	}
 
 	if len(mounts) > 0 {
-		err := d.r.OS.Unmount(mounts[0].Mountpoint)
+		err := d.r.OS.Unmount(ctx, mounts[0].Mountpoint)
 		if err != nil {
 			return err
 		}
 	}
 
-	err = d.r.Storage.DetachVolume(false, vols[0].VolumeID, "", false)
+	err = d.r.Storage.DetachVolume(ctx, false, vols[0].VolumeID, "", false)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (d *driver) getInstance() (*core.Instance, error) {
-	instances, err := d.r.Storage.GetInstances()
+func (d *driver) getInstance(ctx context.Context) (*core.Instance, error) {
+	instances, err := d.r.Storage.GetInstances(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -196,6 +201,7 @@ func (d *driver) getInstance() (*core.Instance, error) {
 }
 
 func (d *driver) prefixToMountUnmount(
+	ctx context.Context,
 	volumeName,
 	volumeID string) ([]*core.Volume, []*core.VolumeAttachment, *core.Instance, error) {
 	if volumeName == "" && volumeID == "" {
@@ -204,12 +210,12 @@ func (d *driver) prefixToMountUnmount(
 
 	var instance *core.Instance
 	var err error
-	if instance, err = d.getInstance(); err != nil {
+	if instance, err = d.getInstance(ctx); err != nil {
 		return nil, nil, nil, err
 	}
 
 	var vols []*core.Volume
-	if vols, err = d.r.Storage.GetVolume(volumeID, volumeName); err != nil {
+	if vols, err = d.r.Storage.GetVolume(ctx, volumeID, volumeName); err != nil {
 		return nil, nil, nil, err
 	}
 
@@ -222,7 +228,7 @@ func (d *driver) prefixToMountUnmount(
 
 	var volAttachments []*core.VolumeAttachment
 	if volAttachments, err = d.r.Storage.GetVolumeAttach(
-		vols[0].VolumeID, instance.InstanceID); err != nil {
+		ctx, vols[0].VolumeID, instance.InstanceID); err != nil {
 		return nil, nil, nil, err
 	}
 
@@ -230,8 +236,9 @@ func (d *driver) prefixToMountUnmount(
 }
 
 // Path returns the mounted path of the volume
-func (d *driver) Path(volumeName, volumeID string) (string, error) {
-	log.WithFields(log.Fields{
+func (d *driver) Path(
+	ctx context.Context, volumeName, volumeID string) (string, error) {
+	core.Logger(ctx).WithFields(log.Fields{
 		"volumeName": volumeName,
 		"volumeID":   volumeID,
 		"driverName": d.Name()}).Info("getting path to volume")
@@ -239,7 +246,7 @@ func (d *driver) Path(volumeName, volumeID string) (string, error) {
 		return "", goof.New("Missing volume name or ID")
 	}
 
-	instances, err := d.r.Storage.GetInstances()
+	instances, err := d.r.Storage.GetInstances(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -251,7 +258,7 @@ func (d *driver) Path(volumeName, volumeID string) (string, error) {
 		return "", goof.New("Too many instances returned, limit the storagedrivers")
 	}
 
-	volumes, err := d.r.Storage.GetVolume(volumeID, volumeName)
+	volumes, err := d.r.Storage.GetVolume(ctx, volumeID, volumeName)
 	if err != nil {
 		return "", err
 	}
@@ -263,7 +270,7 @@ func (d *driver) Path(volumeName, volumeID string) (string, error) {
 		return "", goof.New("Multiple volumes returned by name")
 	}
 
-	volumeAttachment, err := d.r.Storage.GetVolumeAttach(volumes[0].VolumeID, instances[0].InstanceID)
+	volumeAttachment, err := d.r.Storage.GetVolumeAttach(ctx, volumes[0].VolumeID, instances[0].InstanceID)
 	if err != nil {
 		return "", err
 	}
@@ -272,7 +279,7 @@ func (d *driver) Path(volumeName, volumeID string) (string, error) {
 		return "", nil
 	}
 
-	mounts, err := d.r.OS.GetMounts(volumeAttachment[0].DeviceName, "")
+	mounts, err := d.r.OS.GetMounts(ctx, volumeAttachment[0].DeviceName, "")
 	if err != nil {
 		return "", err
 	}
@@ -285,8 +292,10 @@ func (d *driver) Path(volumeName, volumeID string) (string, error) {
 }
 
 // Create will create a remote volume
-func (d *driver) Create(volumeName string, volumeOpts core.VolumeOpts) error {
-	log.WithFields(log.Fields{
+func (d *driver) Create(
+	ctx context.Context,
+	volumeName string, volumeOpts core.VolumeOpts) error {
+	core.Logger(ctx).WithFields(log.Fields{
 		"volumeName": volumeName,
 		"volumeOpts": volumeOpts,
 		"driverName": d.Name()}).Info("creating volume")
@@ -297,7 +306,7 @@ func (d *driver) Create(volumeName string, volumeOpts core.VolumeOpts) error {
 
 	var err error
 
-	if err = d.createGetInstance(); err != nil {
+	if err = d.createGetInstance(ctx); err != nil {
 		return err
 	}
 
@@ -309,7 +318,7 @@ func (d *driver) Create(volumeName string, volumeOpts core.VolumeOpts) error {
 	var overwriteFs bool
 	var volumes []*core.Volume
 
-	volumes, overwriteFs, err = d.createGetVolumes(volumeName, volumeOpts)
+	volumes, overwriteFs, err = d.createGetVolumes(ctx, volumeName, volumeOpts)
 	if err != nil {
 		return err
 	}
@@ -321,7 +330,7 @@ func (d *driver) Create(volumeName string, volumeOpts core.VolumeOpts) error {
 	var volFrom *core.Volume
 	var volumeID string
 	if volFrom, err = d.createInitVolume(
-		volumeName, volumeOpts); err != nil {
+		ctx, volumeName, volumeOpts); err != nil {
 		return err
 	} else if volFrom != nil {
 		volumeID = volFrom.VolumeID
@@ -329,7 +338,7 @@ func (d *driver) Create(volumeName string, volumeOpts core.VolumeOpts) error {
 
 	var snapFrom *core.Snapshot
 	var snapshotID string
-	if snapFrom, err = d.createGetSnapshot(volumeOpts); err != nil {
+	if snapFrom, err = d.createGetSnapshot(ctx, volumeOpts); err != nil {
 		return err
 	} else if snapFrom != nil {
 		snapshotID = snapFrom.SnapshotID
@@ -340,24 +349,39 @@ func (d *driver) Create(volumeName string, volumeOpts core.VolumeOpts) error {
 	size := createInitSize(volumeOpts, volFrom, snapFrom)
 	availabilityZone := createInitAvailabilityZone(volumeOpts)
 
-	if len(volumes) == 0 {
+	if len(volumes) == 0 && volFrom != nil {
+		// not every storage driver creates a volume from a volume ID, so
+		// a volume created from another one is cloned
+		cloneOpts := core.VolumeOpts{}
+		for k, v := range volumeOpts {
+			cloneOpts[k] = v
+		}
+		cloneOpts["volumetype"] = volumeType
+		cloneOpts["iops"] = strconv.FormatInt(IOPS, 10)
+		cloneOpts["size"] = strconv.FormatInt(size, 10)
+		cloneOpts["availabilityzone"] = availabilityZone
+		if _, err = d.r.Storage.CloneVolume(
+			ctx, volumeID, volumeName, cloneOpts); err != nil {
+			return err
+		}
+	} else if len(volumes) == 0 {
 		if _, err = d.r.Storage.CreateVolume(
-			false, volumeName, volumeID, snapshotID,
+			ctx, false, volumeName, "", snapshotID,
 			volumeType, IOPS, size, availabilityZone); err != nil {
 			return err
 		}
 	}
 
 	if newFsType != "" || overwriteFs {
-		_, err = d.Mount(volumeName, "", overwriteFs, newFsType, false)
+		_, err = d.Mount(ctx, volumeName, "", overwriteFs, newFsType, false)
 		if err != nil {
-			log.WithFields(log.Fields{
+			core.Logger(ctx).WithFields(log.Fields{
 				"volumeName":  volumeName,
 				"overwriteFs": overwriteFs,
 				"newFsType":   newFsType,
 				"driverName":  d.Name()}).Error("Failed to create or mount file system")
 		}
-		err = d.Unmount(volumeName, "")
+		err = d.Unmount(ctx, volumeName, "")
 		if err != nil {
 			return err
 		}
@@ -367,6 +391,7 @@ func (d *driver) Create(volumeName string, volumeOpts core.VolumeOpts) error {
 }
 
 func (d *driver) createInitVolume(
+	ctx context.Context,
 	volumeName string,
 	volumeOpts core.VolumeOpts) (*core.Volume, error) {
 
@@ -382,7 +407,7 @@ func (d *driver) createInitVolume(
 
 	var err error
 	var volumes []*core.Volume
-	if volumes, err = d.r.Storage.GetVolume(optVolumeID, optVolumeName); err != nil {
+	if volumes, err = d.r.Storage.GetVolume(ctx, optVolumeID, optVolumeName); err != nil {
 		return nil, err
 	}
 
@@ -399,6 +424,7 @@ func (d *driver) createInitVolume(
 }
 
 func (d *driver) createGetSnapshot(
+	ctx context.Context,
 	volumeOpts core.VolumeOpts) (*core.Snapshot, error) {
 
 	var optSnapshotName string
@@ -415,7 +441,7 @@ func (d *driver) createGetSnapshot(
 	var snapshots []*core.Snapshot
 
 	if snapshots, err = d.r.Storage.GetSnapshot(
-		"", optSnapshotID, optSnapshotName); err != nil {
+		ctx, "", optSnapshotID, optSnapshotName); err != nil {
 		return nil, err
 	}
 
@@ -431,11 +457,11 @@ func (d *driver) createGetSnapshot(
 	return snapshots[0], nil
 }
 
-func (d *driver) createGetInstance() error {
+func (d *driver) createGetInstance(ctx context.Context) error {
 	var err error
 	var instances []*core.Instance
 
-	if instances, err = d.r.Storage.GetInstances(); err != nil {
+	if instances, err = d.r.Storage.GetInstances(ctx); err != nil {
 		return err
 	}
 
@@ -451,12 +477,13 @@ func (d *driver) createGetInstance() error {
 }
 
 func (d *driver) createGetVolumes(
+	ctx context.Context,
 	volumeName string,
 	volumeOpts core.VolumeOpts) ([]*core.Volume, bool, error) {
 	var err error
 	var volumes []*core.Volume
 
-	if volumes, err = d.r.Storage.GetVolume("", volumeName); err != nil {
+	if volumes, err = d.r.Storage.GetVolume(ctx, "", volumeName); err != nil {
 		return nil, false, err
 	}
 
@@ -556,8 +583,8 @@ func createInitString(optKey, envVar string,
 }
 
 // Remove will remove a remote volume
-func (d *driver) Remove(volumeName string) error {
-	log.WithFields(log.Fields{
+func (d *driver) Remove(ctx context.Context, volumeName string) error {
+	core.Logger(ctx).WithFields(log.Fields{
 		"volumeName": volumeName,
 		"driverName": d.Name()}).Info("removing volume")
 
@@ -565,7 +592,7 @@ func (d *driver) Remove(volumeName string) error {
 		return goof.New("Missing volume name")
 	}
 
-	instances, err := d.r.Storage.GetInstances()
+	instances, err := d.r.Storage.GetInstances(ctx)
 	if err != nil {
 		return err
 	}
@@ -577,7 +604,7 @@ func (d *driver) Remove(volumeName string) error {
 		return goof.New("Too many instances returned, limit the storagedrivers")
 	}
 
-	volumes, err := d.r.Storage.GetVolume("", volumeName)
+	volumes, err := d.r.Storage.GetVolume(ctx, "", volumeName)
 	if err != nil {
 		return err
 	}
@@ -589,12 +616,12 @@ func (d *driver) Remove(volumeName string) error {
 		return goof.New("Multiple volumes returned by name")
 	}
 
-	err = d.Unmount("", volumes[0].VolumeID)
+	err = d.Unmount(ctx, "", volumes[0].VolumeID)
 	if err != nil {
 		return err
 	}
 
-	err = d.r.Storage.RemoveVolume(volumes[0].VolumeID)
+	err = d.r.Storage.RemoveVolume(ctx, volumes[0].VolumeID)
 	if err != nil {
 		return err
 	}
@@ -603,13 +630,15 @@ func (d *driver) Remove(volumeName string) error {
 }
 
 // Attach will attach a volume to an instance
-func (d *driver) Attach(volumeName, instanceID string, force bool) (string, error) {
-	log.WithFields(log.Fields{
+func (d *driver) Attach(
+	ctx context.Context,
+	volumeName, instanceID string, force bool) (string, error) {
+	core.Logger(ctx).WithFields(log.Fields{
 		"volumeName": volumeName,
 		"instanceID": instanceID,
 		"driverName": d.Name()}).Info("attaching volume")
 
-	volumes, err := d.r.Storage.GetVolume("", volumeName)
+	volumes, err := d.r.Storage.GetVolume(ctx, "", volumeName)
 	if err != nil {
 		return "", err
 	}
@@ -621,12 +650,12 @@ func (d *driver) Attach(volumeName, instanceID string, force bool) (string, erro
 		return "", goof.New("Multiple volumes returned by name")
 	}
 
-	_, err = d.r.Storage.AttachVolume(true, volumes[0].VolumeID, instanceID, force)
+	_, err = d.r.Storage.AttachVolume(ctx, true, volumes[0].VolumeID, instanceID, force)
 	if err != nil {
 		return "", err
 	}
 
-	volumes, err = d.r.Storage.GetVolume("", volumeName)
+	volumes, err = d.r.Storage.GetVolume(ctx, "", volumeName)
 	if err != nil {
 		return "", err
 	}
@@ -635,28 +664,30 @@ func (d *driver) Attach(volumeName, instanceID string, force bool) (string, erro
 }
 
 // Remove will remove a remote volume
-func (d *driver) Detach(volumeName, instanceID string, force bool) error {
-	log.WithFields(log.Fields{
+func (d *driver) Detach(
+	ctx context.Context, volumeName, instanceID string, force bool) error {
+	core.Logger(ctx).WithFields(log.Fields{
 		"volumeName": volumeName,
 		"instanceID": instanceID,
 		"driverName": d.Name()}).Info("detaching volume")
 
-	volume, err := d.r.Storage.GetVolume("", volumeName)
+	volume, err := d.r.Storage.GetVolume(ctx, "", volumeName)
 	if err != nil {
 		return err
 	}
 
-	return d.r.Storage.DetachVolume(true, volume[0].VolumeID, instanceID, force)
+	return d.r.Storage.DetachVolume(ctx, true, volume[0].VolumeID, instanceID, force)
 }
 
 // NetworkName will return relevant information about how a volume can be discovered on an OS
-func (d *driver) NetworkName(volumeName, instanceID string) (string, error) {
-	log.WithFields(log.Fields{
+func (d *driver) NetworkName(
+	ctx context.Context, volumeName, instanceID string) (string, error) {
+	core.Logger(ctx).WithFields(log.Fields{
 		"volumeName": volumeName,
 		"instanceID": instanceID,
 		"driverName": d.Name()}).Info("returning network name")
 
-	volumes, err := d.r.Storage.GetVolume("", volumeName)
+	volumes, err := d.r.Storage.GetVolume(ctx, "", volumeName)
 	if err != nil {
 		return "", err
 	}
@@ -669,7 +700,7 @@ func (d *driver) NetworkName(volumeName, instanceID string) (string, error) {
 	}
 
 	volumeAttachment, err := d.r.Storage.GetVolumeAttach(
-		volumes[0].VolumeID, instanceID)
+		ctx, volumes[0].VolumeID, instanceID)
 	if err != nil {
 		return "", err
 	}
@@ -678,7 +709,7 @@ func (d *driver) NetworkName(volumeName, instanceID string) (string, error) {
 		return "", goof.New("Volume not attached")
 	}
 
-	volumes, err = d.r.Storage.GetVolume("", volumeName)
+	volumes, err = d.r.Storage.GetVolume(ctx, "", volumeName)
 	if err != nil {
 		return "", err
 	}
@@ -694,8 +725,8 @@ func (d *driver) volumeRootPath() string {
 	return d.r.Config.GetString("linux.volume.rootPath")
 }
 
-func configRegistration() *gofig.Registration {
-	r := gofig.NewRegistration("Docker")
+func configRegistration() *core.ConfigSchema {
+	r := core.NewConfigSchema("Docker", providerName)
 	r.Key(gofig.String, "", "", "", "docker.volumeType")
 	r.Key(gofig.Int, "", 0, "", "docker.iops")
 	r.Key(gofig.Int, "", 0, "", "docker.size")
@@ -3,15 +3,17 @@ package volume
 import (
 	// loads the volume drivers
 	"github.com/akutz/gofig"
+
+	"github.com/emccode/rexray/core"
 	_ "github.com/emccode/rexray/drivers/volume/docker"
 )
 
 func init() {
-	gofig.Register(configRegistration())
+	core.RegisterConfigSchema(configRegistration())
 }
 
-func configRegistration() *gofig.Registration {
-	r := gofig.NewRegistration("Volume")
+func configRegistration() *core.ConfigSchema {
+	r := core.NewConfigSchema("Volume")
 	r.Key(gofig.Bool, "", false, "", "rexray.volume.mount.preempt", "preempt")
 	return r
 }
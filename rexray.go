@@ -37,7 +37,10 @@
 //
 //     r.InitDrivers()
 //
-//     volumes, err := r.Storage.GetVolumeMapping()
+//     ctx, cancel := core.NewContext(context.Background(), r.Config, "")
+//     defer cancel()
+//
+//     volumes, err := r.Storage.GetVolumeMapping(ctx)
 //
 package rexray
 
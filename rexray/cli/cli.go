@@ -5,12 +5,14 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	log "github.com/Sirupsen/logrus"
 	glog "github.com/akutz/golf/logrus"
 	"github.com/akutz/gotil"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+	"golang.org/x/net/context"
 	"gopkg.in/yaml.v1"
 
 	"github.com/emccode/rexray/core"
@@ -31,6 +33,9 @@ type CLI struct {
 	r *core.RexRay
 	c *cobra.Command
 
+	ctx    context.Context
+	cancel context.CancelFunc
+
 	serviceCmd               *cobra.Command
 	moduleCmd                *cobra.Command
 	versionCmd               *cobra.Command
@@ -48,6 +53,7 @@ type CLI struct {
 	serviceStartCmd          *cobra.Command
 	serviceRestartCmd        *cobra.Command
 	serviceStopCmd           *cobra.Command
+	serviceReloadCmd         *cobra.Command
 	serviceStatusCmd         *cobra.Command
 	serviceInitSysCmd        *cobra.Command
 	adapterCmd               *cobra.Command
@@ -59,6 +65,7 @@ type CLI struct {
 	snapshotCreateCmd        *cobra.Command
 	snapshotRemoveCmd        *cobra.Command
 	volumeCreateCmd          *cobra.Command
+	volumeCloneCmd           *cobra.Command
 	volumeRemoveCmd          *cobra.Command
 	volumeAttachCmd          *cobra.Command
 	volumeDetachCmd          *cobra.Command
@@ -70,12 +77,24 @@ type CLI struct {
 	volumeMountCmd           *cobra.Command
 	volumeUnmountCmd         *cobra.Command
 	volumePathCmd            *cobra.Command
+	operationCmd             *cobra.Command
+	operationGetCmd          *cobra.Command
+	operationWaitCmd         *cobra.Command
+	auditCmd                 *cobra.Command
+	auditTailCmd             *cobra.Command
+	auditQueryCmd            *cobra.Command
+	doctorCmd                *cobra.Command
+	configCmd                *cobra.Command
+	configValidateCmd        *cobra.Command
 
 	outputFormat            string
+	outputColumns           []string
+	outputSort              string
 	client                  string
 	fg                      bool
 	force                   bool
 	cfgFile                 string
+	storageDriver           string
 	snapshotID              string
 	volumeID                string
 	runAsync                bool
@@ -87,6 +106,7 @@ type CLI struct {
 	volumeName              string
 	snapshotName            string
 	availabilityZone        string
+	volumeOpts              []string
 	destinationSnapshotName string
 	destinationRegion       string
 	deviceName              string
@@ -100,6 +120,19 @@ type CLI struct {
 	moduleInstanceAddress   string
 	moduleInstanceStart     bool
 	moduleConfig            []string
+	timeout                 time.Duration
+	auditLines              int
+	auditFollow             bool
+	auditSince              string
+	auditUntil              string
+	auditOps                []string
+	auditVolume             string
+	auditCaller             string
+	auditFailed             bool
+	fix                     bool
+	status                  string
+	attachedTo              string
+	nameGlob                string
 }
 
 const (
@@ -153,6 +186,10 @@ func NewWithArgs(a ...string) *CLI {
 	c.initDeviceCmdsAndFlags()
 	c.initVolumeCmdsAndFlags()
 	c.initSnapshotCmdsAndFlags()
+	c.initOperationCmdsAndFlags()
+	c.initAuditCmdsAndFlags()
+	c.initDoctorCmdsAndFlags()
+	c.initConfigCmdsAndFlags()
 
 	c.initServiceCmdsAndFlags()
 	c.initModuleCmdsAndFlags()
@@ -194,6 +231,11 @@ func (c *CLI) Execute() {
 }
 
 func (c *CLI) execute() {
+	defer func() {
+		if c.cancel != nil {
+			c.cancel()
+		}
+	}()
 	defer func() {
 		r := recover()
 		if r != nil {
@@ -237,6 +279,20 @@ func (c *CLI) addOutputFormatFlag(fs *pflag.FlagSet) {
 		&c.outputFormat, "format", "f", "yml", "The output format (yml, json)")
 }
 
+// addListOutputFlags adds the flags that select the output format, columns,
+// and order of the commands that print lists with printOutput.
+func (c *CLI) addListOutputFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(
+		&c.outputFormat, "format", "f", "yml",
+		"The output format (yml, json, table, or a Go template such as "+
+			"'{{.Name}}')")
+	fs.StringSliceVar(&c.outputColumns, "columns", nil,
+		"The columns printed by the table format, ex. Name,Status")
+	fs.StringVar(&c.outputSort, "sort", "",
+		"The column by which the output is sorted; prefix with - to "+
+			"sort in descending order")
+}
+
 func (c *CLI) updateLogLevel() {
 	switch c.logLevel() {
 	case "panic":
@@ -267,6 +323,12 @@ func (c *CLI) preRun(cmd *cobra.Command, args []string) {
 
 	c.updateLogLevel()
 
+	c.ctx, c.cancel = core.NewContext(context.Background(), c.r.Config, "")
+	c.ctx = core.WithCaller(c.ctx, cliCaller())
+	if c.storageDriver != "" {
+		c.ctx = core.WithStorageDriverName(c.ctx, c.storageDriver)
+	}
+
 	if isHelpFlag(cmd) {
 		cmd.Help()
 		panic(&helpFlagPanic{})
@@ -338,6 +400,10 @@ func (c *CLI) checkCmdPermRequirements(cmd *cobra.Command) error {
 		return checkOpPerms("restarted")
 	}
 
+	if cmd == c.serviceReloadCmd {
+		return checkOpPerms("reloaded")
+	}
+
 	return nil
 }
 
@@ -378,6 +444,14 @@ func (c *CLI) isInitDriverManagersCmd(cmd *cobra.Command) bool {
 		cmd != c.adapterGetTypesCmd &&
 		cmd != c.versionCmd &&
 		cmd != c.envCmd &&
+		cmd != c.operationCmd &&
+		cmd != c.operationGetCmd &&
+		cmd != c.operationWaitCmd &&
+		cmd != c.auditCmd &&
+		cmd != c.auditTailCmd &&
+		cmd != c.auditQueryCmd &&
+		cmd != c.configCmd &&
+		cmd != c.configValidateCmd &&
 		c.isServiceCmd(cmd) &&
 		c.isModuleCmd(cmd)
 }
@@ -389,6 +463,7 @@ func (c *CLI) isServiceCmd(cmd *cobra.Command) bool {
 		cmd != c.uninstallCmd &&
 		cmd != c.serviceStatusCmd &&
 		cmd != c.serviceStopCmd &&
+		cmd != c.serviceReloadCmd &&
 		!(cmd == c.serviceStartCmd && (c.client != "" || c.fg || c.force))
 }
 
@@ -399,6 +474,11 @@ func (c *CLI) isModuleCmd(cmd *cobra.Command) bool {
 		cmd != c.moduleInstancesListCmd
 }
 
+// logger returns a log entry that includes the ID of the command's request.
+func (c *CLI) logger() *log.Entry {
+	return core.Logger(c.ctx)
+}
+
 func (c *CLI) logLevel() string {
 	return c.r.Config.GetString("rexray.logLevel")
 }
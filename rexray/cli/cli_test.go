@@ -43,6 +43,10 @@ func TestVolumeGetJSON(t *testing.T) {
 	a(t, "volume", "get", "-f", "json")
 }
 
+func TestVolumeClone(t *testing.T) {
+	a(t, "volume", "clone", "--volumeid=test", "--volumename=clone")
+}
+
 func TestAdapterGet(t *testing.T) {
 	a(t, "adapter", "get")
 }
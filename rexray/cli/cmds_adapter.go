@@ -3,7 +3,6 @@ package cli
 import (
 	"fmt"
 
-	log "github.com/Sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
@@ -43,23 +42,18 @@ func (c *CLI) initAdapterCmds() {
 		Short: "List the configured adapter instances",
 		Run: func(cmd *cobra.Command, args []string) {
 
-			allInstances, err := c.r.Storage.GetInstances()
+			allInstances, err := c.r.Storage.GetInstances(c.ctx)
 			if err != nil {
 				panic(err)
 			}
 
-			if len(allInstances) > 0 {
-				out, err := c.marshalOutput(&allInstances)
-				if err != nil {
-					log.Fatal(err)
-				}
-				fmt.Println(out)
-			}
+			c.printOutput(allInstances,
+				"ProviderName", "InstanceID", "Name", "Region")
 		},
 	}
 	c.adapterCmd.AddCommand(c.adapterGetInstancesCmd)
 }
 
 func (c *CLI) initAdapterFlags() {
-	c.addOutputFormatFlag(c.adapterGetInstancesCmd.Flags())
+	c.addListOutputFlags(c.adapterGetInstancesCmd.Flags())
 }
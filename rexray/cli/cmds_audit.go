@@ -0,0 +1,201 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"os/user"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/akutz/goof"
+	"github.com/spf13/cobra"
+
+	"github.com/emccode/rexray/core"
+	"github.com/emccode/rexray/core/audit"
+)
+
+func (c *CLI) initAuditCmdsAndFlags() {
+	c.initAuditCmds()
+	c.initAuditFlags()
+}
+
+func (c *CLI) initAuditCmds() {
+
+	c.auditCmd = &cobra.Command{
+		Use:   "audit",
+		Short: "The audit log of mutating storage operations",
+		Run: func(cmd *cobra.Command, args []string) {
+			if isHelpFlags(cmd) {
+				cmd.Usage()
+			} else {
+				c.auditTailCmd.Run(c.auditTailCmd, args)
+			}
+		},
+	}
+	c.c.AddCommand(c.auditCmd)
+
+	c.auditTailCmd = &cobra.Command{
+		Use:   "tail",
+		Short: "Print the most recent audit records",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			path := core.AuditLogPath(c.r.Config)
+			f, err := c.auditFilter()
+			if err != nil {
+				c.logger().Fatal(err)
+			}
+
+			records, err := audit.Tail(path, c.auditLines, f)
+			if err != nil {
+				c.logger().Fatal(err)
+			}
+			c.printAuditRecords(records)
+
+			if !c.auditFollow {
+				return
+			}
+
+			stop := make(chan struct{})
+			sigc := make(chan os.Signal, 1)
+			signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
+			go func() {
+				<-sigc
+				close(stop)
+			}()
+
+			if err := audit.Follow(path, f, time.Second, stop,
+				func(r *audit.Record) error {
+					c.printAuditRecords([]*audit.Record{r})
+					return nil
+				}); err != nil {
+				c.logger().Fatal(err)
+			}
+		},
+	}
+	c.auditCmd.AddCommand(c.auditTailCmd)
+
+	c.auditQueryCmd = &cobra.Command{
+		Use:   "query",
+		Short: "Print the audit records that match the provided filters",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			f, err := c.auditFilter()
+			if err != nil {
+				c.logger().Fatal(err)
+			}
+
+			var records []*audit.Record
+			if err := audit.Query(core.AuditLogPath(c.r.Config), f,
+				func(r *audit.Record) error {
+					records = append(records, r)
+					return nil
+				}); err != nil {
+				c.logger().Fatal(err)
+			}
+			c.printAuditRecords(records)
+		},
+	}
+	c.auditCmd.AddCommand(c.auditQueryCmd)
+}
+
+func (c *CLI) initAuditFlags() {
+	c.auditTailCmd.Flags().IntVarP(&c.auditLines, "lines", "n", 10,
+		"The number of records to print")
+	c.auditTailCmd.Flags().BoolVar(&c.auditFollow, "follow", false,
+		"Print records as they are written until interrupted")
+
+	for _, cmd := range []*cobra.Command{c.auditTailCmd, c.auditQueryCmd} {
+		fs := cmd.Flags()
+		fs.StringVar(&c.auditSince, "since", "",
+			"Only records at or after this time, ex. 2016-03-01T17:00:00Z "+
+				"or a duration such as 24h")
+		fs.StringVar(&c.auditUntil, "until", "",
+			"Only records before this time, ex. 2016-03-01T18:00:00Z "+
+				"or a duration such as 1h")
+		fs.StringSliceVar(&c.auditOps, "op", nil,
+			"Only records of these operations, ex. DetachVolume,RemoveVolume")
+		fs.StringVar(&c.auditVolume, "volume", "",
+			"Only records for this volume ID or name")
+		fs.StringVar(&c.auditCaller, "caller", "",
+			"Only records requested by this caller type, user, UID, or client")
+		fs.BoolVar(&c.auditFailed, "failed", false,
+			"Only records of failed operations")
+		c.addOutputFormatFlag(fs)
+	}
+}
+
+func (c *CLI) auditFilter() (*audit.Filter, error) {
+	f := &audit.Filter{
+		Ops:    c.auditOps,
+		Volume: c.auditVolume,
+		Caller: c.auditCaller,
+		Failed: c.auditFailed,
+	}
+
+	var err error
+	if f.Since, err = parseAuditTime(c.auditSince); err != nil {
+		return nil, err
+	}
+	if f.Until, err = parseAuditTime(c.auditUntil); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// parseAuditTime parses an RFC 3339 time or a duration, which is relative to
+// the current time.
+func parseAuditTime(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return time.Time{}, goof.WithField("time", v, "invalid time")
+	}
+	return time.Now().Add(-d), nil
+}
+
+// printAuditRecords prints the records as JSON lines, or as a YAML list
+// whose items may be appended to by further calls.
+func (c *CLI) printAuditRecords(records []*audit.Record) {
+	if len(records) == 0 {
+		return
+	}
+
+	if strings.ToUpper(c.outputFormat) == "JSON" {
+		for _, r := range records {
+			buf, err := marshalJSONOutput(r)
+			if err != nil {
+				c.logger().Fatal(err)
+			}
+			fmt.Println(string(buf))
+		}
+		return
+	}
+
+	buf, err := marshalYamlOutput(records)
+	if err != nil {
+		c.logger().Fatal(err)
+	}
+	fmt.Print(string(buf))
+}
+
+// cliCaller returns the identity of the user running the CLI.
+func cliCaller() *audit.Caller {
+	caller := &audit.Caller{
+		Type: audit.CallerCLI,
+		UID:  strconv.Itoa(os.Getuid()),
+	}
+	if u, err := user.Current(); err == nil {
+		caller.User = u.Username
+	} else {
+		caller.User = os.Getenv("USER")
+	}
+	return caller
+}
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func (c *CLI) initConfigCmdsAndFlags() {
+	c.initConfigCmds()
+	c.initConfigFlags()
+}
+
+func (c *CLI) initConfigCmds() {
+	c.configCmd = &cobra.Command{
+		Use:   "config",
+		Short: "The REX-Ray configuration",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Usage()
+		},
+	}
+	c.c.AddCommand(c.configCmd)
+
+	c.configValidateCmd = &cobra.Command{
+		Use:   "validate",
+		Short: "Validate the configuration and initialize the enabled drivers",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			v := c.r.Validate()
+
+			out, err := c.marshalOutput(v)
+			if err != nil {
+				c.logger().Fatal(err)
+			}
+			fmt.Println(out)
+
+			if !v.Valid() {
+				panic(1)
+			}
+		},
+	}
+	c.configCmd.AddCommand(c.configValidateCmd)
+}
+
+func (c *CLI) initConfigFlags() {
+	c.addOutputFormatFlag(c.configValidateCmd.Flags())
+}
@@ -1,9 +1,6 @@
 package cli
 
 import (
-	"fmt"
-
-	log "github.com/Sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
@@ -32,16 +29,12 @@ func (c *CLI) initDeviceCmds() {
 		Aliases: []string{"ls", "list"},
 		Run: func(cmd *cobra.Command, args []string) {
 
-			mounts, err := c.r.OS.GetMounts(c.deviceName, c.mountPoint)
+			mounts, err := c.r.OS.GetMounts(c.ctx, c.deviceName, c.mountPoint)
 			if err != nil {
-				log.Fatal(err)
+				c.logger().Fatal(err)
 			}
 
-			out, err := c.marshalOutput(&mounts)
-			if err != nil {
-				log.Fatal(err)
-			}
-			fmt.Println(out)
+			c.printOutput(mounts, "Source", "Mountpoint", "Fstype", "Opts")
 		},
 	}
 
@@ -51,14 +44,15 @@ func (c *CLI) initDeviceCmds() {
 		Run: func(cmd *cobra.Command, args []string) {
 
 			if c.deviceName == "" || c.mountPoint == "" {
-				log.Fatal("Missing --devicename and --mountpoint")
+				c.logger().Fatal("Missing --devicename and --mountpoint")
 			}
 
 			// mountOptions = fmt.Sprintf("val,%s", mountOptions)
 			err := c.r.OS.Mount(
-				c.deviceName, c.mountPoint, c.mountOptions, c.mountLabel)
+				c.ctx, c.deviceName, c.mountPoint,
+				c.mountOptions, c.mountLabel)
 			if err != nil {
-				log.Fatal(err)
+				c.logger().Fatal(err)
 			}
 
 		},
@@ -70,12 +64,12 @@ func (c *CLI) initDeviceCmds() {
 		Run: func(cmd *cobra.Command, args []string) {
 
 			if c.mountPoint == "" {
-				log.Fatal("Missing --mountpoint")
+				c.logger().Fatal("Missing --mountpoint")
 			}
 
-			err := c.r.OS.Unmount(c.mountPoint)
+			err := c.r.OS.Unmount(c.ctx, c.mountPoint)
 			if err != nil {
-				log.Fatal(err)
+				c.logger().Fatal(err)
 			}
 
 		},
@@ -87,16 +81,16 @@ func (c *CLI) initDeviceCmds() {
 		Run: func(cmd *cobra.Command, args []string) {
 
 			if c.deviceName == "" {
-				log.Fatal("Missing --devicename")
+				c.logger().Fatal("Missing --devicename")
 			}
 
 			if c.fsType == "" {
 				c.fsType = "ext4"
 			}
 
-			err := c.r.OS.Format(c.deviceName, c.fsType, c.overwriteFs)
+			err := c.r.OS.Format(c.ctx, c.deviceName, c.fsType, c.overwriteFs)
 			if err != nil {
-				log.Fatal(err)
+				c.logger().Fatal(err)
 			}
 		},
 	}
@@ -114,6 +108,6 @@ func (c *CLI) initDeviceFlags() {
 	c.deviceFormatCmd.Flags().StringVar(&c.fsType, "fstype", "", "fstype")
 	c.deviceFormatCmd.Flags().BoolVar(&c.overwriteFs, "overwritefs", false, "overwritefs")
 
-	c.addOutputFormatFlag(c.deviceCmd.Flags())
-	c.addOutputFormatFlag(c.deviceGetCmd.Flags())
+	c.addListOutputFlags(c.deviceCmd.Flags())
+	c.addListOutputFlags(c.deviceGetCmd.Flags())
 }
@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/emccode/rexray/core"
+)
+
+func (c *CLI) initDoctorCmdsAndFlags() {
+	c.initDoctorCmds()
+	c.initDoctorFlags()
+}
+
+func (c *CLI) initDoctorCmds() {
+	c.doctorCmd = &cobra.Command{
+		Use:   "doctor",
+		Short: "Find and optionally repair drift between the host and provider",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			report, err := c.r.Reconcile(c.ctx, &core.ReconcileOptions{
+				Fix: c.fix,
+			})
+			if err != nil {
+				c.logger().Fatalf("Error: %s", err)
+			}
+
+			out, err := c.marshalOutput(report)
+			if err != nil {
+				c.logger().Fatal(err)
+			}
+			fmt.Println(out)
+		},
+	}
+	c.c.AddCommand(c.doctorCmd)
+}
+
+func (c *CLI) initDoctorFlags() {
+	c.doctorCmd.Flags().BoolVar(&c.fix, "fix", false,
+		"Repair the drift that is found")
+	c.addOutputFormatFlag(c.doctorCmd.Flags())
+}
@@ -9,6 +9,8 @@ import (
 	log "github.com/Sirupsen/logrus"
 	"github.com/akutz/gotil"
 	"github.com/spf13/cobra"
+
+	"github.com/emccode/rexray/core"
 )
 
 func (c *CLI) initModuleCmdsAndFlags() {
@@ -120,12 +122,16 @@ func (c *CLI) initModuleCmds() {
 				panic(cfgJSONErr)
 			}
 
-			log.WithFields(log.Fields{
+			fields := log.Fields{
 				"url":     u,
 				"typeId":  modTypeIDStr,
 				"address": c.moduleInstanceAddress,
 				"start":   modInstStartStr,
-				"config":  cfgJSON}).Debug("post create module instance")
+			}
+			if rc, err := core.RedactConfig(c.r.Config); err == nil {
+				fields["config"], _ = rc.ToJSON()
+			}
+			log.WithFields(fields).Debug("post create module instance")
 
 			client := &http.Client{}
 			resp, respErr := client.PostForm(u,
@@ -0,0 +1,251 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/akutz/goof"
+	"github.com/akutz/gotil"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+
+	"github.com/emccode/rexray/core"
+	"github.com/emccode/rexray/daemon/module"
+)
+
+func (c *CLI) initOperationCmdsAndFlags() {
+	c.initOperationCmds()
+	c.initOperationFlags()
+}
+
+func (c *CLI) initOperationCmds() {
+
+	c.operationCmd = &cobra.Command{
+		Use:   "operation",
+		Short: "The asynchronous operation manager",
+		Run: func(cmd *cobra.Command, args []string) {
+			if isHelpFlags(cmd) {
+				cmd.Usage()
+			} else {
+				c.operationGetCmd.Run(c.operationGetCmd, args)
+			}
+		},
+	}
+	c.c.AddCommand(c.operationCmd)
+
+	c.operationGetCmd = &cobra.Command{
+		Use:     "get [id]",
+		Short:   "Get one or all operations",
+		Aliases: []string{"ls", "list"},
+		Run: func(cmd *cobra.Command, args []string) {
+
+			var v interface{}
+			if len(args) > 0 {
+				op, err := c.getOperation(args[0])
+				if err != nil {
+					c.logger().Fatal(err)
+				}
+				v = op
+			} else {
+				ops, err := c.getOperations()
+				if err != nil {
+					c.logger().Fatal(err)
+				}
+				if len(ops) == 0 {
+					return
+				}
+				v = ops
+			}
+
+			out, err := c.marshalOutput(v)
+			if err != nil {
+				c.logger().Fatal(err)
+			}
+			fmt.Println(out)
+		},
+	}
+	c.operationCmd.AddCommand(c.operationGetCmd)
+
+	c.operationWaitCmd = &cobra.Command{
+		Use:   "wait <id>",
+		Short: "Wait for an operation to complete",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			if len(args) == 0 {
+				c.logger().Fatalf("missing operation id")
+			}
+
+			op, err := c.waitOperation(args[0], c.timeout)
+			if err != nil {
+				c.logger().Fatal(err)
+			}
+
+			out, err := c.marshalOutput(op)
+			if err != nil {
+				c.logger().Fatal(err)
+			}
+			fmt.Println(out)
+
+			if op.State == core.OperationFailed {
+				panic(1)
+			}
+		},
+	}
+	c.operationCmd.AddCommand(c.operationWaitCmd)
+}
+
+func (c *CLI) initOperationFlags() {
+	c.operationWaitCmd.Flags().DurationVar(&c.timeout, "timeout", 0,
+		"The amount of time to wait before giving up, ex. 30s. "+
+			"A value of 0 waits indefinitely.")
+
+	c.addOutputFormatFlag(c.operationCmd.Flags())
+	c.addOutputFormatFlag(c.operationGetCmd.Flags())
+	c.addOutputFormatFlag(c.operationWaitCmd.Flags())
+}
+
+// startOperation submits an asynchronous operation to the REX-Ray service and
+// prints the operation without waiting for it to complete. If the service is
+// not available the operation is executed in the foreground instead since an
+// operation cannot outlive the process that runs it.
+func (c *CLI) startOperation(req *core.OperationRequest) {
+
+	op := &core.Operation{}
+	ok, err := c.doServiceRequest("POST", "/r/operations", req, op)
+	if err != nil {
+		c.logger().Fatal(err)
+	}
+
+	if !ok {
+		c.logger().Warn("service unavailable; running operation in the foreground")
+		if op, err = c.r.Operations.Start(c.ctx, req); err != nil {
+			c.logger().Fatal(err)
+		}
+		if op, err = c.r.Operations.Wait(
+			context.Background(), op.ID); err != nil {
+			c.logger().Fatal(err)
+		}
+	}
+
+	out, err := c.marshalOutput(op)
+	if err != nil {
+		c.logger().Fatal(err)
+	}
+	fmt.Println(out)
+}
+
+func (c *CLI) getOperation(id string) (*core.Operation, error) {
+	op := &core.Operation{}
+	ok, err := c.doServiceRequest("GET", "/r/operations/"+id, nil, op)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return c.r.Operations.Get(id)
+	}
+	return op, nil
+}
+
+func (c *CLI) getOperations() ([]*core.Operation, error) {
+	var ops []*core.Operation
+	ok, err := c.doServiceRequest("GET", "/r/operations", nil, &ops)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return c.r.Operations.List()
+	}
+	return ops, nil
+}
+
+func (c *CLI) waitOperation(
+	id string, timeout time.Duration) (*core.Operation, error) {
+
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+
+	for {
+		op, err := c.getOperation(id)
+		if err != nil {
+			return nil, err
+		}
+
+		if op.Done() {
+			return op, nil
+		}
+
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return nil, goof.WithFields(goof.Fields{
+				"id":      id,
+				"state":   op.State,
+				"timeout": timeout,
+			}, "timed out waiting for operation")
+		}
+
+		time.Sleep(time.Second)
+	}
+}
+
+// doServiceRequest sends a request to the REX-Ray service's admin API
+// and unmarshals the response into v. The returned flag is false if the
+// service could not be reached.
+func (c *CLI) doServiceRequest(
+	method, path string, body, v interface{}) (bool, error) {
+
+	_, addr, err := gotil.ParseAddress(c.host())
+	if err != nil {
+		return false, err
+	}
+
+	var reqBody bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reqBody).Encode(body); err != nil {
+			return false, err
+		}
+	}
+
+	u := fmt.Sprintf("http://%s%s", addr, path)
+	req, err := http.NewRequest(method, u, &reqBody)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(module.RequestIDHeader, core.RequestID(c.ctx))
+	req.Header.Set(module.CallerHeader, core.GetCaller(c.ctx).String())
+	if n := core.StorageDriverName(c.ctx); n != "" {
+		req.Header.Set(module.StorageDriverHeader, n)
+	}
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		c.logger().WithFields(log.Fields{
+			"url":   u,
+			"error": err,
+		}).Debug("error contacting service")
+		return false, nil
+	}
+
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return true, err
+	}
+
+	if resp.StatusCode >= 300 {
+		return true, goof.WithFields(goof.Fields{
+			"url":    u,
+			"status": resp.StatusCode,
+			"body":   string(respBody),
+		}, "service request failed")
+	}
+
+	return true, json.Unmarshal(respBody, v)
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/emccode/rexray/core"
 	"github.com/emccode/rexray/util"
 )
 
@@ -28,7 +29,7 @@ func (c *CLI) initOtherCmds() {
 		Use:   "env",
 		Short: "Print the REX-Ray environment",
 		Run: func(cmd *cobra.Command, args []string) {
-			evs := c.r.Config.EnvVars()
+			evs := core.RedactEnvVars(c.r.Config)
 			for _, ev := range evs {
 				fmt.Println(ev)
 			}
@@ -64,6 +65,9 @@ func (c *CLI) initOtherFlags() {
 		"The path to a custom REX-Ray configuration file")
 	c.c.PersistentFlags().BoolP(
 		"verbose", "v", false, "Print verbose help information")
+	c.c.PersistentFlags().StringVar(&c.storageDriver, "storageDriver", "",
+		"The storage driver, or instance of a storage driver such as "+
+			"scaleio/prod, used by the command")
 
 	// add the flag sets
 	for _, fs := range c.r.Config.FlagSets() {
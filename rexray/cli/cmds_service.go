@@ -33,7 +33,7 @@ func (c *CLI) initServiceCmds() {
 
 	c.serviceRestartCmd = &cobra.Command{
 		Use:     "restart",
-		Aliases: []string{"reload", "force-reload"},
+		Aliases: []string{"force-reload"},
 		Short:   "Restart the service",
 		Run: func(cmd *cobra.Command, args []string) {
 			c.restart()
@@ -42,6 +42,16 @@ func (c *CLI) initServiceCmds() {
 	c.c.AddCommand(c.serviceRestartCmd)
 	c.serviceCmd.AddCommand(c.serviceRestartCmd)
 
+	c.serviceReloadCmd = &cobra.Command{
+		Use:   "reload",
+		Short: "Reload the service's configuration",
+		Run: func(cmd *cobra.Command, args []string) {
+			reload()
+		},
+	}
+	c.c.AddCommand(c.serviceReloadCmd)
+	c.serviceCmd.AddCommand(c.serviceReloadCmd)
+
 	c.serviceStopCmd = &cobra.Command{
 		Use:   "stop",
 		Short: "Stop the service",
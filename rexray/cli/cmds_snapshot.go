@@ -3,8 +3,9 @@ package cli
 import (
 	"fmt"
 
-	log "github.com/Sirupsen/logrus"
 	"github.com/spf13/cobra"
+
+	"github.com/emccode/rexray/core"
 )
 
 func (c *CLI) initSnapshotCmdsAndFlags() {
@@ -34,18 +35,14 @@ func (c *CLI) initSnapshotCmds() {
 		Run: func(cmd *cobra.Command, args []string) {
 
 			allSnapshots, err := c.r.Storage.GetSnapshot(
-				c.volumeID, c.snapshotID, c.snapshotName)
+				c.ctx, c.volumeID, c.snapshotID, c.snapshotName)
 			if err != nil {
-				log.Fatal(err)
+				c.logger().Fatal(err)
 			}
 
-			if len(allSnapshots) > 0 {
-				out, err := c.marshalOutput(&allSnapshots)
-				if err != nil {
-					log.Fatal(err)
-				}
-				fmt.Println(out)
-			}
+			c.printOutput(allSnapshots,
+				"Name", "SnapshotID", "VolumeID", "VolumeSize", "Status",
+				"StartTime")
 		},
 	}
 	c.snapshotCmd.AddCommand(c.snapshotGetCmd)
@@ -57,18 +54,28 @@ func (c *CLI) initSnapshotCmds() {
 		Run: func(cmd *cobra.Command, args []string) {
 
 			if c.volumeID == "" {
-				log.Fatalf("missing --volumeid")
+				c.logger().Fatalf("missing --volumeid")
+			}
+
+			if c.runAsync {
+				c.startOperation(&core.OperationRequest{
+					Type:         core.OperationCreateSnapshot,
+					SnapshotName: c.snapshotName,
+					VolumeID:     c.volumeID,
+					Description:  c.description,
+				})
+				return
 			}
 
 			snapshot, err := c.r.Storage.CreateSnapshot(
-				c.runAsync, c.snapshotName, c.volumeID, c.description)
+				c.ctx, false, c.snapshotName, c.volumeID, c.description)
 			if err != nil {
-				log.Fatal(err)
+				c.logger().Fatal(err)
 			}
 
 			out, err := c.marshalOutput(&snapshot)
 			if err != nil {
-				log.Fatal(err)
+				c.logger().Fatal(err)
 			}
 			fmt.Println(out)
 
@@ -83,12 +90,12 @@ func (c *CLI) initSnapshotCmds() {
 		Run: func(cmd *cobra.Command, args []string) {
 
 			if c.snapshotID == "" {
-				log.Fatalf("missing --snapshotid")
+				c.logger().Fatalf("missing --snapshotid")
 			}
 
-			err := c.r.Storage.RemoveSnapshot(c.snapshotID)
+			err := c.r.Storage.RemoveSnapshot(c.ctx, c.snapshotID)
 			if err != nil {
-				log.Fatal(err)
+				c.logger().Fatal(err)
 			}
 
 		},
@@ -101,19 +108,31 @@ func (c *CLI) initSnapshotCmds() {
 		Run: func(cmd *cobra.Command, args []string) {
 
 			if c.snapshotID == "" && c.volumeID == "" && c.volumeName == "" {
-				log.Fatalf("missing --volumeid or --snapshotid or --volumename")
+				c.logger().Fatalf("missing --volumeid or --snapshotid or --volumename")
+			}
+
+			if c.runAsync {
+				c.startOperation(&core.OperationRequest{
+					Type:               core.OperationCopySnapshot,
+					VolumeID:           c.volumeID,
+					SnapshotID:         c.snapshotID,
+					SnapshotName:       c.snapshotName,
+					TargetSnapshotName: c.destinationSnapshotName,
+					TargetRegion:       c.destinationRegion,
+				})
+				return
 			}
 
 			snapshot, err := c.r.Storage.CopySnapshot(
-				c.runAsync, c.volumeID, c.snapshotID,
+				c.ctx, false, c.volumeID, c.snapshotID,
 				c.snapshotName, c.destinationSnapshotName, c.destinationRegion)
 			if err != nil {
-				log.Fatal(err)
+				c.logger().Fatal(err)
 			}
 
 			out, err := c.marshalOutput(&snapshot)
 			if err != nil {
-				log.Fatal(err)
+				c.logger().Fatal(err)
 			}
 			fmt.Println(out)
 		},
@@ -137,8 +156,8 @@ func (c *CLI) initSnapshotFlags() {
 	c.snapshotCopyCmd.Flags().StringVar(&c.destinationSnapshotName, "destinationsnapshotname", "", "destinationsnapshotname")
 	c.snapshotCopyCmd.Flags().StringVar(&c.destinationRegion, "destinationregion", "", "destinationregion")
 
-	c.addOutputFormatFlag(c.snapshotCmd.Flags())
-	c.addOutputFormatFlag(c.snapshotGetCmd.Flags())
+	c.addListOutputFlags(c.snapshotCmd.Flags())
+	c.addListOutputFlags(c.snapshotGetCmd.Flags())
 	c.addOutputFormatFlag(c.snapshotCopyCmd.Flags())
 	c.addOutputFormatFlag(c.snapshotCreateCmd.Flags())
 }
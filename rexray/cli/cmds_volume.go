@@ -2,9 +2,14 @@ package cli
 
 import (
 	"fmt"
+	"path"
+	"strconv"
+	"strings"
 
-	log "github.com/Sirupsen/logrus"
+	"github.com/akutz/goof"
 	"github.com/spf13/cobra"
+
+	"github.com/emccode/rexray/core"
 )
 
 func (c *CLI) initVolumeCmdsAndFlags() {
@@ -32,15 +37,15 @@ func (c *CLI) initVolumeCmds() {
 		Short: "Print the volume mapping(s)",
 		Run: func(cmd *cobra.Command, args []string) {
 
-			allBlockDevices, err := c.r.Storage.GetVolumeMapping()
+			allBlockDevices, err := c.r.Storage.GetVolumeMapping(c.ctx)
 			if err != nil {
-				log.Fatalf("Error: %s", err)
+				c.logger().Fatalf("Error: %s", err)
 			}
 
 			if len(allBlockDevices) > 0 {
 				out, err := c.marshalOutput(&allBlockDevices)
 				if err != nil {
-					log.Fatal(err)
+					c.logger().Fatal(err)
 				}
 				fmt.Println(out)
 			}
@@ -54,18 +59,20 @@ func (c *CLI) initVolumeCmds() {
 		Aliases: []string{"ls", "list"},
 		Run: func(cmd *cobra.Command, args []string) {
 
-			allVolumes, err := c.r.Storage.GetVolume(c.volumeID, c.volumeName)
+			allVolumes, err := c.r.Storage.GetVolume(
+				c.ctx, c.volumeID, c.volumeName)
 			if err != nil {
-				log.Fatal(err)
+				c.logger().Fatal(err)
 			}
 
-			if len(allVolumes) > 0 {
-				out, err := c.marshalOutput(&allVolumes)
-				if err != nil {
-					log.Fatal(err)
-				}
-				fmt.Println(out)
+			allVolumes, err = c.filterVolumes(allVolumes)
+			if err != nil {
+				c.logger().Fatal(err)
 			}
+
+			c.printOutput(allVolumes,
+				"Name", "VolumeID", "Size", "Status", "VolumeType",
+				"AvailabilityZone", "Attachments")
 		},
 	}
 	c.volumeCmd.AddCommand(c.volumeGetCmd)
@@ -77,19 +84,40 @@ func (c *CLI) initVolumeCmds() {
 		Run: func(cmd *cobra.Command, args []string) {
 
 			if c.size == 0 && c.snapshotID == "" && c.volumeID == "" {
-				log.Fatalf("missing --size")
+				c.logger().Fatalf("missing --size")
+			}
+
+			opts, err := c.parseVolumeOpts()
+			if err != nil {
+				c.logger().Fatal(err)
+			}
+
+			if c.runAsync {
+				c.startOperation(&core.OperationRequest{
+					Type:             core.OperationCreateVolume,
+					VolumeName:       c.volumeName,
+					VolumeID:         c.volumeID,
+					SnapshotID:       c.snapshotID,
+					VolumeType:       c.volumeType,
+					IOPS:             c.iops,
+					Size:             c.size,
+					AvailabilityZone: c.availabilityZone,
+					Opts:             opts,
+				})
+				return
 			}
 
 			volume, err := c.r.Storage.CreateVolume(
-				c.runAsync, c.volumeName, c.volumeID, c.snapshotID,
+				core.WithVolumeOpts(c.ctx, opts), false,
+				c.volumeName, c.volumeID, c.snapshotID,
 				c.volumeType, c.iops, c.size, c.availabilityZone)
 			if err != nil {
-				log.Fatal(err)
+				c.logger().Fatal(err)
 			}
 
 			out, err := c.marshalOutput(&volume)
 			if err != nil {
-				log.Fatal(err)
+				c.logger().Fatal(err)
 			}
 			fmt.Println(out)
 
@@ -97,6 +125,40 @@ func (c *CLI) initVolumeCmds() {
 	}
 	c.volumeCmd.AddCommand(c.volumeCreateCmd)
 
+	c.volumeCloneCmd = &cobra.Command{
+		Use:   "clone",
+		Short: "Clone a volume",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			if c.volumeID == "" {
+				c.logger().Fatalf("missing --volumeid")
+			}
+
+			if c.volumeName == "" {
+				c.logger().Fatalf("missing --volumename")
+			}
+
+			opts, err := c.volumeCloneOpts()
+			if err != nil {
+				c.logger().Fatal(err)
+			}
+
+			volume, err := c.r.Storage.CloneVolume(
+				c.ctx, c.volumeID, c.volumeName, opts)
+			if err != nil {
+				c.logger().Fatal(err)
+			}
+
+			out, err := c.marshalOutput(&volume)
+			if err != nil {
+				c.logger().Fatal(err)
+			}
+			fmt.Println(out)
+
+		},
+	}
+	c.volumeCmd.AddCommand(c.volumeCloneCmd)
+
 	c.volumeRemoveCmd = &cobra.Command{
 		Use:     "remove",
 		Short:   "Remove a volume",
@@ -104,12 +166,12 @@ func (c *CLI) initVolumeCmds() {
 		Run: func(cmd *cobra.Command, args []string) {
 
 			if c.volumeID == "" {
-				log.Fatalf("missing --volumeid")
+				c.logger().Fatalf("missing --volumeid")
 			}
 
-			err := c.r.Storage.RemoveVolume(c.volumeID)
+			err := c.r.Storage.RemoveVolume(c.ctx, c.volumeID)
 			if err != nil {
-				log.Fatal(err)
+				c.logger().Fatal(err)
 			}
 
 		},
@@ -122,18 +184,28 @@ func (c *CLI) initVolumeCmds() {
 		Run: func(cmd *cobra.Command, args []string) {
 
 			if c.volumeID == "" {
-				log.Fatalf("missing --volumeid")
+				c.logger().Fatalf("missing --volumeid")
+			}
+
+			if c.runAsync {
+				c.startOperation(&core.OperationRequest{
+					Type:       core.OperationAttachVolume,
+					VolumeID:   c.volumeID,
+					InstanceID: c.instanceID,
+					Force:      c.force,
+				})
+				return
 			}
 
 			volumeAttachment, err := c.r.Storage.AttachVolume(
-				c.runAsync, c.volumeID, c.instanceID, c.force)
+				c.ctx, false, c.volumeID, c.instanceID, c.force)
 			if err != nil {
-				log.Fatal(err)
+				c.logger().Fatal(err)
 			}
 
 			out, err := c.marshalOutput(&volumeAttachment)
 			if err != nil {
-				log.Fatal(err)
+				c.logger().Fatal(err)
 			}
 			fmt.Println(out)
 
@@ -147,13 +219,23 @@ func (c *CLI) initVolumeCmds() {
 		Run: func(cmd *cobra.Command, args []string) {
 
 			if c.volumeID == "" {
-				log.Fatalf("missing --volumeid")
+				c.logger().Fatalf("missing --volumeid")
+			}
+
+			if c.runAsync {
+				c.startOperation(&core.OperationRequest{
+					Type:       core.OperationDetachVolume,
+					VolumeID:   c.volumeID,
+					InstanceID: c.instanceID,
+					Force:      c.force,
+				})
+				return
 			}
 
 			err := c.r.Storage.DetachVolume(
-				c.runAsync, c.volumeID, c.instanceID, c.force)
+				c.ctx, false, c.volumeID, c.instanceID, c.force)
 			if err != nil {
-				log.Fatal(err)
+				c.logger().Fatal(err)
 			}
 
 		},
@@ -165,18 +247,19 @@ func (c *CLI) initVolumeCmds() {
 		Short: "Mount a volume",
 		Run: func(cmd *cobra.Command, args []string) {
 			if c.volumeName == "" && c.volumeID == "" {
-				log.Fatal("Missing --volumename or --volumeid")
+				c.logger().Fatal("Missing --volumename or --volumeid")
 			}
 
 			mountPath, err := c.r.Volume.Mount(
-				c.volumeName, c.volumeID, c.overwriteFs, c.fsType, false)
+				c.ctx, c.volumeName, c.volumeID,
+				c.overwriteFs, c.fsType, false)
 			if err != nil {
-				log.Fatal(err)
+				c.logger().Fatal(err)
 			}
 
 			out, err := c.marshalOutput(&mountPath)
 			if err != nil {
-				log.Fatal(err)
+				c.logger().Fatal(err)
 			}
 			fmt.Println(out)
 
@@ -190,12 +273,12 @@ func (c *CLI) initVolumeCmds() {
 		Run: func(cmd *cobra.Command, args []string) {
 
 			if c.volumeName == "" && c.volumeID == "" {
-				log.Fatal("Missing --volumename or --volumeid")
+				c.logger().Fatal("Missing --volumename or --volumeid")
 			}
 
-			err := c.r.Volume.Unmount(c.volumeName, c.volumeID)
+			err := c.r.Volume.Unmount(c.ctx, c.volumeName, c.volumeID)
 			if err != nil {
-				log.Fatal(err)
+				c.logger().Fatal(err)
 			}
 		},
 	}
@@ -207,18 +290,18 @@ func (c *CLI) initVolumeCmds() {
 		Run: func(cmd *cobra.Command, args []string) {
 
 			if c.volumeName == "" && c.volumeID == "" {
-				log.Fatal("Missing --volumename or --volumeid")
+				c.logger().Fatal("Missing --volumename or --volumeid")
 			}
 
-			mountPath, err := c.r.Volume.Path(c.volumeName, c.volumeID)
+			mountPath, err := c.r.Volume.Path(c.ctx, c.volumeName, c.volumeID)
 			if err != nil {
-				log.Fatal(err)
+				c.logger().Fatal(err)
 			}
 
 			if mountPath != "" {
 				out, err := c.marshalOutput(&mountPath)
 				if err != nil {
-					log.Fatal(err)
+					c.logger().Fatal(err)
 				}
 				fmt.Println(out)
 			}
@@ -230,6 +313,11 @@ func (c *CLI) initVolumeCmds() {
 func (c *CLI) initVolumeFlags() {
 	c.volumeGetCmd.Flags().StringVar(&c.volumeName, "volumename", "", "volumename")
 	c.volumeGetCmd.Flags().StringVar(&c.volumeID, "volumeid", "", "volumeid")
+	c.volumeGetCmd.Flags().StringVar(&c.status, "status", "", "Only volumes with the status")
+	c.volumeGetCmd.Flags().StringVar(&c.attachedTo, "attached-to", "", "Only volumes attached to the instance ID")
+	c.volumeGetCmd.Flags().StringVar(&c.volumeType, "type", "", "Only volumes of the volume type")
+	c.volumeGetCmd.Flags().StringVar(&c.availabilityZone, "az", "", "Only volumes in the availability zone")
+	c.volumeGetCmd.Flags().StringVar(&c.nameGlob, "name-glob", "", "Only volumes whose names match the pattern, ex. 'db-*'")
 	c.volumeCreateCmd.Flags().BoolVar(&c.runAsync, "runasync", false, "runasync")
 	c.volumeCreateCmd.Flags().StringVar(&c.volumeName, "volumename", "", "volumename")
 	c.volumeCreateCmd.Flags().StringVar(&c.volumeType, "volumetype", "", "volumetype")
@@ -238,6 +326,14 @@ func (c *CLI) initVolumeFlags() {
 	c.volumeCreateCmd.Flags().Int64Var(&c.iops, "iops", 0, "IOPS")
 	c.volumeCreateCmd.Flags().Int64Var(&c.size, "size", 0, "size")
 	c.volumeCreateCmd.Flags().StringVar(&c.availabilityZone, "availabilityzone", "", "availabilityzone")
+	c.volumeCreateCmd.Flags().StringSliceVarP(&c.volumeOpts, "options", "o", nil, "A comma-separated string of key=value pairs used by some storage drivers, ex. encrypted=true")
+	c.volumeCloneCmd.Flags().StringVar(&c.volumeID, "volumeid", "", "volumeid")
+	c.volumeCloneCmd.Flags().StringVar(&c.volumeName, "volumename", "", "volumename")
+	c.volumeCloneCmd.Flags().StringVar(&c.volumeType, "volumetype", "", "volumetype")
+	c.volumeCloneCmd.Flags().Int64Var(&c.iops, "iops", 0, "IOPS")
+	c.volumeCloneCmd.Flags().Int64Var(&c.size, "size", 0, "size")
+	c.volumeCloneCmd.Flags().StringVar(&c.availabilityZone, "availabilityzone", "", "availabilityzone")
+	c.volumeCloneCmd.Flags().StringSliceVarP(&c.volumeOpts, "options", "o", nil, "A comma-separated string of key=value pairs used by some storage drivers, ex. encrypted=true")
 	c.volumeRemoveCmd.Flags().StringVar(&c.volumeID, "volumeid", "", "volumeid")
 	c.volumeAttachCmd.Flags().BoolVar(&c.runAsync, "runasync", false, "runasync")
 	c.volumeAttachCmd.Flags().StringVar(&c.volumeID, "volumeid", "", "volumeid")
@@ -256,11 +352,88 @@ func (c *CLI) initVolumeFlags() {
 	c.volumePathCmd.Flags().StringVar(&c.volumeID, "volumeid", "", "volumeid")
 	c.volumePathCmd.Flags().StringVar(&c.volumeName, "volumename", "", "volumename")
 
-	c.addOutputFormatFlag(c.volumeCmd.Flags())
-	c.addOutputFormatFlag(c.volumeGetCmd.Flags())
+	c.addListOutputFlags(c.volumeCmd.Flags())
+	c.addListOutputFlags(c.volumeGetCmd.Flags())
 	c.addOutputFormatFlag(c.volumeCreateCmd.Flags())
+	c.addOutputFormatFlag(c.volumeCloneCmd.Flags())
 	c.addOutputFormatFlag(c.volumeAttachCmd.Flags())
+	c.addOutputFormatFlag(c.volumeDetachCmd.Flags())
 	c.addOutputFormatFlag(c.volumeMountCmd.Flags())
 	c.addOutputFormatFlag(c.volumePathCmd.Flags())
 	c.addOutputFormatFlag(c.volumeMapCmd.Flags())
 }
+
+// parseVolumeOpts returns the volume options of the --options flag.
+func (c *CLI) parseVolumeOpts() (core.VolumeOpts, error) {
+	opts := core.VolumeOpts{}
+	for _, o := range c.volumeOpts {
+		kv := strings.SplitN(o, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, goof.WithField("option", o, "invalid volume option")
+		}
+		opts[kv[0]] = kv[1]
+	}
+	return opts, nil
+}
+
+func (c *CLI) volumeCloneOpts() (core.VolumeOpts, error) {
+	opts, err := c.parseVolumeOpts()
+	if err != nil {
+		return nil, err
+	}
+	if c.volumeType != "" {
+		opts["volumetype"] = c.volumeType
+	}
+	if c.iops > 0 {
+		opts["iops"] = strconv.FormatInt(c.iops, 10)
+	}
+	if c.size > 0 {
+		opts["size"] = strconv.FormatInt(c.size, 10)
+	}
+	if c.availabilityZone != "" {
+		opts["availabilityzone"] = c.availabilityZone
+	}
+	return opts, nil
+}
+
+// filterVolumes returns the volumes that match the filters of the volume get
+// command.
+func (c *CLI) filterVolumes(volumes []*core.Volume) ([]*core.Volume, error) {
+	var filtered []*core.Volume
+	for _, v := range volumes {
+		if c.status != "" && !strings.EqualFold(v.Status, c.status) {
+			continue
+		}
+		if c.volumeType != "" && !strings.EqualFold(v.VolumeType, c.volumeType) {
+			continue
+		}
+		if c.availabilityZone != "" &&
+			!strings.EqualFold(v.AvailabilityZone, c.availabilityZone) {
+			continue
+		}
+		if c.nameGlob != "" {
+			ok, err := path.Match(c.nameGlob, v.Name)
+			if err != nil {
+				return nil, goof.WithFieldE(
+					"nameGlob", c.nameGlob, "invalid name pattern", err)
+			}
+			if !ok {
+				continue
+			}
+		}
+		if c.attachedTo != "" && !volumeAttachedTo(v, c.attachedTo) {
+			continue
+		}
+		filtered = append(filtered, v)
+	}
+	return filtered, nil
+}
+
+func volumeAttachedTo(v *core.Volume, instanceID string) bool {
+	for _, a := range v.Attachments {
+		if a.InstanceID == instanceID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,239 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"github.com/akutz/goof"
+
+	"github.com/emccode/rexray/core"
+)
+
+const (
+	outputFormatYAML  = "yml"
+	outputFormatJSON  = "json"
+	outputFormatTable = "table"
+)
+
+// cellFormatters format the values of the types that do not print well in a
+// table cell.
+var cellFormatters = map[reflect.Type]func(v reflect.Value) string{
+	reflect.TypeOf(&core.VolumeAttachment{}): func(v reflect.Value) string {
+		return v.Interface().(*core.VolumeAttachment).InstanceID
+	},
+}
+
+// printOutput sorts the items, a slice of structs or of pointers to structs,
+// by the --sort flag and prints them in the output format selected by the
+// --format flag; yml, json, a table of the columns selected by the --columns
+// flag, or a Go template executed for each item. The columns are the table's
+// default columns.
+func (c *CLI) printOutput(items interface{}, columns ...string) {
+	if len(c.outputColumns) > 0 {
+		columns = c.outputColumns
+	}
+	if err := writeOutput(
+		os.Stdout, items, c.outputFormat, columns, c.outputSort); err != nil {
+		c.logger().Fatal(err)
+	}
+}
+
+func writeOutput(
+	w io.Writer,
+	items interface{},
+	format string,
+	columns []string,
+	sortBy string) error {
+
+	iv := reflect.ValueOf(items)
+	for iv.Kind() == reflect.Ptr {
+		iv = iv.Elem()
+	}
+	if iv.Kind() != reflect.Slice {
+		return goof.New("output is not a list")
+	}
+
+	if sortBy != "" {
+		if err := sortItems(iv, sortBy); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case strings.EqualFold(format, outputFormatJSON):
+		if iv.Len() == 0 {
+			return nil
+		}
+		buf, err := marshalJSONOutput(iv.Interface())
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(buf))
+		return err
+	case strings.EqualFold(format, outputFormatTable):
+		return writeTable(w, iv, columns)
+	case strings.Contains(format, "{{"):
+		return writeTemplate(w, iv, format)
+	case format == "" ||
+		strings.EqualFold(format, outputFormatYAML) ||
+		strings.EqualFold(format, "yaml"):
+		if iv.Len() == 0 {
+			return nil
+		}
+		buf, err := marshalYamlOutput(iv.Interface())
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(buf))
+		return err
+	}
+
+	return goof.WithField("format", format, "invalid output format")
+}
+
+func writeTable(w io.Writer, items reflect.Value, columns []string) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+
+	var header []string
+	for _, col := range columns {
+		header = append(header, strings.ToUpper(col))
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+
+	for i := 0; i < items.Len(); i++ {
+		var cells []string
+		for _, col := range columns {
+			fv, err := field(items.Index(i), col)
+			if err != nil {
+				return err
+			}
+			cells = append(cells, cell(fv))
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+func writeTemplate(w io.Writer, items reflect.Value, format string) error {
+	t, err := template.New("format").Parse(format)
+	if err != nil {
+		return goof.WithFieldE("format", format, "invalid output template", err)
+	}
+	for i := 0; i < items.Len(); i++ {
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, items.Index(i).Interface()); err != nil {
+			return err
+		}
+		buf.WriteString("\n")
+		if _, err := buf.WriteTo(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortItems sorts the items by the field named by sortBy, or in descending
+// order if the name is prefixed with a -. Fields whose values are numbers are
+// sorted numerically.
+func sortItems(items reflect.Value, sortBy string) error {
+	desc := strings.HasPrefix(sortBy, "-")
+	name := strings.TrimPrefix(sortBy, "-")
+
+	keys := make([]string, items.Len())
+	for i := range keys {
+		fv, err := field(items.Index(i), name)
+		if err != nil {
+			return err
+		}
+		keys[i] = cell(fv)
+	}
+
+	s := &itemSorter{items: items, keys: keys}
+	if desc {
+		sort.Stable(sort.Reverse(s))
+	} else {
+		sort.Stable(s)
+	}
+	return nil
+}
+
+type itemSorter struct {
+	items reflect.Value
+	keys  []string
+}
+
+func (s *itemSorter) Len() int {
+	return len(s.keys)
+}
+
+func (s *itemSorter) Less(i, j int) bool {
+	ni, erri := strconv.ParseFloat(s.keys[i], 64)
+	nj, errj := strconv.ParseFloat(s.keys[j], 64)
+	if erri == nil && errj == nil {
+		return ni < nj
+	}
+	return s.keys[i] < s.keys[j]
+}
+
+func (s *itemSorter) Swap(i, j int) {
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+	vi := reflect.ValueOf(s.items.Index(i).Interface())
+	s.items.Index(i).Set(s.items.Index(j))
+	s.items.Index(j).Set(vi)
+}
+
+// field returns the value of the item's field with the name, ignoring case.
+func field(item reflect.Value, name string) (reflect.Value, error) {
+	for item.Kind() == reflect.Ptr {
+		if item.IsNil() {
+			return reflect.Value{}, nil
+		}
+		item = item.Elem()
+	}
+	if item.Kind() != reflect.Struct {
+		return reflect.Value{}, goof.New("output item is not a struct")
+	}
+	fv := item.FieldByNameFunc(func(n string) bool {
+		return strings.EqualFold(n, name)
+	})
+	if !fv.IsValid() {
+		return reflect.Value{}, goof.WithField("column", name, "invalid column")
+	}
+	return fv, nil
+}
+
+// cell returns the text with which a value is printed in a table.
+func cell(v reflect.Value) string {
+	if !v.IsValid() {
+		return ""
+	}
+	if f, ok := cellFormatters[v.Type()]; ok {
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			return ""
+		}
+		return f(v)
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return ""
+		}
+		return cell(v.Elem())
+	case reflect.Slice, reflect.Array:
+		var vals []string
+		for i := 0; i < v.Len(); i++ {
+			vals = append(vals, cell(v.Index(i)))
+		}
+		return strings.Join(vals, ",")
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/emccode/rexray/core"
+)
+
+func getOutputVolumes() []*core.Volume {
+	return []*core.Volume{
+		&core.Volume{Name: "b", VolumeID: "2", Size: "100", Status: "in-use",
+			Attachments: []*core.VolumeAttachment{
+				&core.VolumeAttachment{InstanceID: "i-1"},
+				&core.VolumeAttachment{InstanceID: "i-2"},
+			}},
+		&core.Volume{Name: "a", VolumeID: "1", Size: "20", Status: "available"},
+		&core.Volume{Name: "c", VolumeID: "3", Size: "3", Status: "available"},
+	}
+}
+
+func TestOutputTable(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeOutput(&buf, getOutputVolumes(), "table",
+		[]string{"name", "Status", "Attachments"}, ""); err != nil {
+		t.Fatal(err)
+	}
+	exp := "NAME  STATUS     ATTACHMENTS\n" +
+		"b     in-use     i-1,i-2\n" +
+		"a     available  \n" +
+		"c     available  \n"
+	if buf.String() != exp {
+		t.Fatalf("unexpected table\n%s", buf.String())
+	}
+
+	if err := writeOutput(&buf, getOutputVolumes(), "table",
+		[]string{"Invalid"}, ""); err == nil {
+		t.Fatal("printed an invalid column")
+	}
+}
+
+func TestOutputSort(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeOutput(
+		&buf, getOutputVolumes(), "{{.Name}}", nil, "size"); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "c\na\nb\n" {
+		t.Fatalf("unexpected order %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := writeOutput(
+		&buf, getOutputVolumes(), "{{.VolumeID}}", nil, "-name"); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "3\n2\n1\n" {
+		t.Fatalf("unexpected order %q", buf.String())
+	}
+}
+
+func TestOutputFormats(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeOutput(&buf, getOutputVolumes(), "json", nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() == 0 || buf.Bytes()[0] != '[' {
+		t.Fatalf("unexpected json %s", buf.String())
+	}
+
+	if err := writeOutput(&buf, getOutputVolumes(), "xml", nil, ""); err == nil {
+		t.Fatal("printed an invalid format")
+	}
+	if err := writeOutput(&buf, getOutputVolumes(), "{{.Name", nil, ""); err == nil {
+		t.Fatal("printed an invalid template")
+	}
+}
+
+func TestFilterVolumes(t *testing.T) {
+	c := &CLI{attachedTo: "i-2"}
+	vols, err := c.filterVolumes(getOutputVolumes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vols) != 1 || vols[0].Name != "b" {
+		t.Fatalf("unexpected volumes %v", vols)
+	}
+
+	c = &CLI{status: "AVAILABLE", nameGlob: "[ab]"}
+	if vols, err = c.filterVolumes(getOutputVolumes()); err != nil {
+		t.Fatal(err)
+	}
+	if len(vols) != 1 || vols[0].Name != "a" {
+		t.Fatalf("unexpected volumes %v", vols)
+	}
+
+	c = &CLI{nameGlob: "["}
+	if _, err := c.filterVolumes(getOutputVolumes()); err == nil {
+		t.Fatal("filtered with an invalid pattern")
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/akutz/gotil"
 
 	rrdaemon "github.com/emccode/rexray/daemon"
+	"github.com/emccode/rexray/daemon/module"
 	"github.com/emccode/rexray/util"
 )
 
@@ -134,9 +135,19 @@ func (c *CLI) startDaemon() {
 		return
 	}
 
-	sigv := <-sigc
-	log.Printf("received shutdown signal %v", sigv)
-	stop <- sigv
+	for sigv := range sigc {
+		if sigv == syscall.SIGHUP {
+			log.Print("received reload signal")
+			if err := rrdaemon.Reload(); err != nil {
+				log.WithField("error", err).Error(
+					"error reloading configuration")
+			}
+			continue
+		}
+		log.Printf("received shutdown signal %v", sigv)
+		stop <- sigv
+		return
+	}
 }
 
 func (c *CLI) tryToStartDaemon() {
@@ -214,12 +225,34 @@ func stop() {
 	proc, procErr := os.FindProcess(pid)
 	failOnError(procErr)
 
-	killErr := proc.Signal(syscall.SIGHUP)
+	killErr := proc.Signal(syscall.SIGTERM)
 	failOnError(killErr)
 
 	fmt.Println("SUCCESS!")
 }
 
+func reload() {
+	checkOpPerms("reloaded")
+
+	if !gotil.FileExists(util.PidFilePath()) {
+		fmt.Println("REX-Ray is stopped")
+		panic(1)
+	}
+
+	fmt.Print("Reloading REX-Ray...")
+
+	pid, pidErr := util.ReadPidFile()
+	failOnError(pidErr)
+
+	proc, procErr := os.FindProcess(pid)
+	failOnError(procErr)
+
+	sigErr := proc.Signal(syscall.SIGHUP)
+	failOnError(sigErr)
+
+	fmt.Println("SUCCESS!")
+}
+
 func (c *CLI) status() {
 	if !gotil.FileExists(util.PidFilePath()) {
 		fmt.Println("REX-Ray is stopped")
@@ -227,6 +260,30 @@ func (c *CLI) status() {
 	}
 	pid, _ := util.ReadPidFile()
 	fmt.Printf("REX-Ray is running at pid %d\n", pid)
+
+	hr := &module.HealthReport{}
+	ok, err := c.doServiceRequest("GET", "/healthz", nil, hr)
+	if err != nil {
+		fmt.Printf("REX-Ray health is unknown: %v\n", err)
+		return
+	}
+	if !ok {
+		fmt.Printf("REX-Ray is not responding at %s\n", c.host())
+		return
+	}
+
+	if hr.Ready {
+		fmt.Println("REX-Ray is ready")
+	} else {
+		fmt.Println("REX-Ray is not ready")
+	}
+	fmt.Println()
+
+	out, err := c.marshalOutput(hr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(out)
 }
 
 func (c *CLI) restart() {
@@ -0,0 +1,237 @@
+package test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/emccode/rexray/core"
+	"github.com/emccode/rexray/core/audit"
+	"github.com/emccode/rexray/drivers/mock"
+)
+
+func newAuditLogPath(t *testing.T) (string, func()) {
+	d, err := ioutil.TempDir("", "rexray-audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(d, "audit.log"), func() { os.RemoveAll(d) }
+}
+
+func queryAudit(t *testing.T, path string, f *audit.Filter) []*audit.Record {
+	var records []*audit.Record
+	if err := audit.Query(path, f, func(r *audit.Record) error {
+		records = append(records, r)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return records
+}
+
+func TestAuditLogRotation(t *testing.T) {
+	path, cleanup := newAuditLogPath(t)
+	defer cleanup()
+
+	l, err := audit.Open(path, 300, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	for i := 0; i < 10; i++ {
+		if err := l.Write(&audit.Record{
+			Time:   time.Unix(int64(i), 0).UTC(),
+			Op:     "RemoveVolume",
+			Args:   map[string]interface{}{"volumeID": "vol-1"},
+			Result: audit.ResultSuccess,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := audit.Files(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 ||
+		files[0] != path+".2" || files[1] != path+".1" || files[2] != path {
+		t.Fatalf("unexpected files %v", files)
+	}
+
+	records := queryAudit(t, path, nil)
+	if len(records) == 0 || len(records) >= 10 {
+		t.Fatalf("unexpected number of records %d", len(records))
+	}
+	for i := 1; i < len(records); i++ {
+		if !records[i].Time.After(records[i-1].Time) {
+			t.Fatal("records out of order")
+		}
+	}
+	if last := records[len(records)-1]; last.Time.Unix() != 9 {
+		t.Fatalf("unexpected last record %+v", last)
+	}
+
+	tail, err := audit.Tail(path, 2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tail) != 2 || tail[1].Time.Unix() != 9 {
+		t.Fatalf("unexpected tail %+v", tail)
+	}
+}
+
+func TestAuditFilter(t *testing.T) {
+	r := &audit.Record{
+		Time:   time.Unix(100, 0),
+		Op:     "DetachVolume",
+		Caller: &audit.Caller{Type: audit.CallerCLI, User: "alice"},
+		Args:   map[string]interface{}{"volumeID": "vol-1"},
+		Result: audit.ResultFailure,
+	}
+
+	tests := []struct {
+		f     *audit.Filter
+		match bool
+	}{
+		{nil, true},
+		{&audit.Filter{}, true},
+		{&audit.Filter{Ops: []string{"detachvolume"}}, true},
+		{&audit.Filter{Ops: []string{"RemoveVolume"}}, false},
+		{&audit.Filter{Volume: "vol-1"}, true},
+		{&audit.Filter{Volume: "vol-2"}, false},
+		{&audit.Filter{Caller: "alice"}, true},
+		{&audit.Filter{Caller: audit.CallerDocker}, false},
+		{&audit.Filter{Failed: true}, true},
+		{&audit.Filter{Since: time.Unix(100, 0)}, true},
+		{&audit.Filter{Since: time.Unix(101, 0)}, false},
+		{&audit.Filter{Until: time.Unix(100, 0)}, false},
+	}
+
+	for i, tt := range tests {
+		if m := tt.f.Match(r); m != tt.match {
+			t.Fatalf("%d: match %v != %v", i, m, tt.match)
+		}
+	}
+}
+
+func TestAuditManagers(t *testing.T) {
+	path, cleanup := newAuditLogPath(t)
+	defer cleanup()
+
+	r, err := getRexRay()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Config.Set("rexray.audit.path", path)
+
+	caller := &audit.Caller{Type: audit.CallerCLI, User: "alice", UID: "1000"}
+	ctx := core.WithCaller(testCtx, caller)
+
+	if err := r.Storage.DetachVolume(
+		ctx, false, "vol-1", "i-1", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.OS.Format(ctx, "/dev/xvdb", "ext4", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Storage.GetVolume(ctx, "vol-1", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	records := queryAudit(t, path, nil)
+	if len(records) != 2 {
+		t.Fatalf("len(records) %d != 2", len(records))
+	}
+
+	rec := records[0]
+	if rec.Op != "DetachVolume" ||
+		rec.Type != "storage" ||
+		rec.RequestID != "test" ||
+		rec.Result != audit.ResultSuccess ||
+		rec.Caller == nil || rec.Caller.User != "alice" ||
+		rec.Args["volumeID"] != "vol-1" ||
+		rec.Args["force"] != true {
+		t.Fatalf("unexpected record %+v", rec)
+	}
+
+	if rec = records[1]; rec.Op != "Format" || rec.Type != "os" {
+		t.Fatalf("unexpected record %+v", rec)
+	}
+
+	r.Config.Set("rexray.audit.enabled", false)
+	if err := r.Storage.RemoveVolume(ctx, "vol-1"); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(queryAudit(t, path, nil)); n != 2 {
+		t.Fatalf("audited while disabled; len(records) %d != 2", n)
+	}
+}
+
+func TestAuditDriverInstances(t *testing.T) {
+	path, cleanup := newAuditLogPath(t)
+	defer cleanup()
+
+	r, err := getRexRayInstances()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Config.Set("rexray.audit.path", path)
+
+	ctx := core.WithStorageDriverName(testCtx, mock.MockStorDriverName+"/b")
+	if err := r.Storage.DetachVolume(
+		ctx, false, "vol-1", "i-1", true); err != nil {
+		t.Fatal(err)
+	}
+
+	records := queryAudit(t, path, nil)
+	if len(records) != 1 {
+		t.Fatalf("len(records) %d != 1", len(records))
+	}
+	if d := records[0].Driver; d != mock.MockStorDriverName+"/b" {
+		t.Fatalf("driver %s != %s", d, mock.MockStorDriverName+"/b")
+	}
+}
+
+func TestAuditFollow(t *testing.T) {
+	path, cleanup := newAuditLogPath(t)
+	defer cleanup()
+
+	l, err := audit.Open(path, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	l.Write(&audit.Record{Op: "CreateVolume"})
+
+	stop := make(chan struct{})
+	received := make(chan *audit.Record, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- audit.Follow(path, nil, 10*time.Millisecond, stop,
+			func(r *audit.Record) error {
+				received <- r
+				return nil
+			})
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	l.Write(&audit.Record{Op: "RemoveVolume"})
+
+	select {
+	case r := <-received:
+		if r.Op != "RemoveVolume" {
+			t.Fatalf("unexpected record %+v", r)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for record")
+	}
+
+	close(stop)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,181 @@
+package test
+
+import (
+	goerrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akutz/gofig"
+	"golang.org/x/net/context"
+
+	"github.com/emccode/rexray/core"
+	"github.com/emccode/rexray/core/errors"
+	"github.com/emccode/rexray/daemon/module"
+)
+
+// testCtx is the context passed to the driver and manager methods under test.
+var testCtx = core.WithRequestID(context.Background(), "test")
+
+func TestRequestID(t *testing.T) {
+	if id := core.RequestID(context.Background()); id != "" {
+		t.Fatalf("unexpected request ID %s", id)
+	}
+	if id := core.RequestID(testCtx); id != "test" {
+		t.Fatalf("unexpected request ID %s", id)
+	}
+}
+
+func TestVolumeOpts(t *testing.T) {
+	if opts := core.GetVolumeOpts(testCtx); opts != nil {
+		t.Fatalf("unexpected volume opts %v", opts)
+	}
+	ctx := core.WithVolumeOpts(testCtx, core.VolumeOpts{"encrypted": "true"})
+	if opts := core.GetVolumeOpts(ctx); opts["encrypted"] != "true" {
+		t.Fatalf("unexpected volume opts %v", opts)
+	}
+	if v, ok := core.GetVolumeOpt(ctx, "Encrypted"); !ok || v != "true" {
+		t.Fatalf("unexpected volume opt %s", v)
+	}
+	if _, ok := core.GetVolumeOpt(ctx, "kmsKeyID"); ok {
+		t.Fatal("unexpected volume opt kmsKeyID")
+	}
+}
+
+func TestNewRequestID(t *testing.T) {
+	id1 := core.NewRequestID()
+	id2 := core.NewRequestID()
+	if id1 == "" || id1 == id2 {
+		t.Fatalf("invalid request IDs %s %s", id1, id2)
+	}
+}
+
+func TestNewContext(t *testing.T) {
+	ctx, cancel := core.NewContext(nil, gofig.New(), "")
+	defer cancel()
+
+	if core.RequestID(ctx) == "" {
+		t.Fatal("missing request ID")
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		t.Fatal("missing deadline")
+	}
+}
+
+func TestNewContextWithRequestID(t *testing.T) {
+	ctx, cancel := core.NewContext(context.Background(), gofig.New(), "abc")
+	defer cancel()
+
+	if id := core.RequestID(ctx); id != "abc" {
+		t.Fatalf("unexpected request ID %s", id)
+	}
+}
+
+func TestNewContextTimeout(t *testing.T) {
+	c := gofig.New()
+	c.Set("rexray.request.timeout", "10ms")
+
+	ctx, cancel := core.NewContext(context.Background(), c, "")
+	defer cancel()
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("context deadline not honored")
+	}
+
+	if ctx.Err() != context.DeadlineExceeded {
+		t.Fatalf("unexpected error %v", ctx.Err())
+	}
+}
+
+func TestNewContextNoTimeout(t *testing.T) {
+	c := gofig.New()
+	c.Set("rexray.request.timeout", "")
+
+	ctx, cancel := core.NewContext(context.Background(), c, "")
+	if _, ok := ctx.Deadline(); ok {
+		t.Fatal("unexpected deadline")
+	}
+
+	cancel()
+	if ctx.Err() != context.Canceled {
+		t.Fatalf("unexpected error %v", ctx.Err())
+	}
+}
+
+func TestLogger(t *testing.T) {
+	e := core.Logger(testCtx)
+	if v := e.Data[core.RequestIDField]; v != "test" {
+		t.Fatalf("unexpected request ID field %v", v)
+	}
+
+	e = core.Logger(context.Background())
+	if _, ok := e.Data[core.RequestIDField]; ok {
+		t.Fatal("unexpected request ID field")
+	}
+}
+
+func TestRequestError(t *testing.T) {
+	if err := core.RequestError(testCtx, nil); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	cause := goerrors.New("failed")
+
+	if err := core.RequestError(context.Background(), cause); err != cause {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	err := core.RequestError(testCtx, cause)
+	rerr, ok := err.(*errors.RequestError)
+	if !ok {
+		t.Fatalf("unexpected error type %T", err)
+	}
+	if rerr.RequestID != "test" || rerr.Err != cause {
+		t.Fatalf("unexpected error %v", rerr)
+	}
+	if !strings.Contains(err.Error(), "test") {
+		t.Fatalf("request ID missing from error message %s", err.Error())
+	}
+
+	if err2 := core.RequestError(testCtx, err); err2 != err {
+		t.Fatalf("error annotated twice %v", err2)
+	}
+}
+
+func TestRequestIDHandler(t *testing.T) {
+	var id string
+	h := module.RequestIDHandler(http.HandlerFunc(
+		func(w http.ResponseWriter, req *http.Request) {
+			ctx, cancel := module.NewRequestContext(nil, w, req)
+			defer cancel()
+			id = core.RequestID(ctx)
+		}))
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set(module.RequestIDHeader, "abc")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if id != "abc" {
+		t.Fatalf("unexpected request ID %s", id)
+	}
+	if v := w.Header().Get(module.RequestIDHeader); v != "abc" {
+		t.Fatalf("unexpected response header %s", v)
+	}
+
+	req, _ = http.NewRequest("GET", "/", nil)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if id == "" || id == "abc" {
+		t.Fatalf("unexpected request ID %s", id)
+	}
+	if v := w.Header().Get(module.RequestIDHeader); v != id {
+		t.Fatalf("unexpected response header %s", v)
+	}
+}
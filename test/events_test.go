@@ -0,0 +1,250 @@
+package test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emccode/rexray/core/events"
+	"github.com/emccode/rexray/drivers/mock"
+)
+
+func receiveEvent(t *testing.T, c <-chan *events.Event) *events.Event {
+	select {
+	case e := <-c:
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+	return nil
+}
+
+func TestEventsFilter(t *testing.T) {
+	f := &events.Filter{
+		Volumes: []string{"vol-1"},
+		Types:   []events.Type{events.VolumeAttached},
+	}
+
+	tests := []struct {
+		e     *events.Event
+		match bool
+	}{
+		{&events.Event{Type: events.VolumeAttached, VolumeID: "vol-1"}, true},
+		{&events.Event{Type: events.VolumeAttached, VolumeName: "vol-1"}, true},
+		{&events.Event{Type: events.VolumeAttached, VolumeID: "vol-2"}, false},
+		{&events.Event{Type: events.VolumeDetached, VolumeID: "vol-1"}, false},
+		{&events.Event{
+			Type: events.Error, Op: events.VolumeAttached, VolumeID: "vol-1"},
+			true},
+	}
+
+	for i, tt := range tests {
+		if m := f.Match(tt.e); m != tt.match {
+			t.Fatalf("%d: match %v != %v", i, m, tt.match)
+		}
+	}
+
+	if !(&events.Filter{}).Match(tests[0].e) {
+		t.Fatal("empty filter did not match")
+	}
+}
+
+func TestEventsBus(t *testing.T) {
+	b := events.NewBus()
+
+	all := b.Subscribe(nil)
+	defer all.Close()
+
+	removed := b.Subscribe(
+		&events.Filter{Types: []events.Type{events.VolumeRemoved}})
+
+	b.Publish(&events.Event{Type: events.VolumeCreated})
+	b.Publish(&events.Event{Type: events.VolumeRemoved})
+
+	if e := receiveEvent(t, all.C); e.ID != 1 || e.Time.IsZero() {
+		t.Fatalf("unexpected event %+v", e)
+	}
+	if e := receiveEvent(t, all.C); e.ID != 2 {
+		t.Fatalf("unexpected event %+v", e)
+	}
+	if e := receiveEvent(t, removed.C); e.Type != events.VolumeRemoved {
+		t.Fatalf("unexpected event %+v", e)
+	}
+
+	removed.Close()
+	removed.Close()
+	if _, ok := <-removed.C; ok {
+		t.Fatal("subscription not closed")
+	}
+}
+
+func TestEventsManagers(t *testing.T) {
+	r, err := getRexRay()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sub := events.Default.Subscribe(&events.Filter{Volumes: []string{"vol-1"}})
+	defer sub.Close()
+
+	if _, err := r.Storage.AttachVolume(
+		testCtx, false, "vol-1", "i-1", false); err != nil {
+		t.Fatal(err)
+	}
+
+	e := receiveEvent(t, sub.C)
+	if e.Type != events.VolumeAttached ||
+		e.Driver != mock.MockStorDriverName ||
+		e.InstanceID != "i-1" ||
+		e.RequestID != "test" {
+		t.Fatalf("unexpected event %+v", e)
+	}
+
+	if _, err := r.Volume.Mount(
+		testCtx, "vol-1", "", false, "", false); err != nil {
+		t.Fatal(err)
+	}
+
+	e = receiveEvent(t, sub.C)
+	if e.Type != events.VolumeMounted || e.VolumeName != "vol-1" {
+		t.Fatalf("unexpected event %+v", e)
+	}
+}
+
+func TestEventsHandler(t *testing.T) {
+	b := events.NewBus()
+	s := httptest.NewServer(events.Handler(b))
+	defer s.Close()
+
+	res, err := http.Get(s.URL + "?type=volume.removed,volume.created")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if ct := res.Header.Get("Content-Type"); ct != events.ContentTypeNDJSON {
+		t.Fatalf("unexpected content type %s", ct)
+	}
+
+	b.Publish(&events.Event{Type: events.VolumeAttached, VolumeID: "vol-1"})
+	b.Publish(&events.Event{Type: events.VolumeRemoved, VolumeID: "vol-1"})
+
+	line, err := bufio.NewReader(res.Body).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := &events.Event{}
+	if err := json.Unmarshal([]byte(line), e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Type != events.VolumeRemoved || e.ID != 2 {
+		t.Fatalf("unexpected event %+v", e)
+	}
+}
+
+func TestEventsHandlerSSE(t *testing.T) {
+	b := events.NewBus()
+	s := httptest.NewServer(events.Handler(b))
+	defer s.Close()
+
+	req, _ := http.NewRequest("GET", s.URL+"?volume=vol-1", nil)
+	req.Header.Set("Accept", events.ContentTypeSSE)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	if ct := res.Header.Get("Content-Type"); ct != events.ContentTypeSSE {
+		t.Fatalf("unexpected content type %s", ct)
+	}
+
+	b.Publish(&events.Event{Type: events.VolumeMounted, VolumeName: "vol-1"})
+
+	br := bufio.NewReader(res.Body)
+	var lines []string
+	for i := 0; i < 3; i++ {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, line)
+	}
+
+	if lines[0] != "id: 1\n" ||
+		lines[1] != "event: volume.mounted\n" ||
+		!strings.HasPrefix(lines[2], "data: {") {
+		t.Fatalf("unexpected event %q", lines)
+	}
+}
+
+func TestEventsWebhook(t *testing.T) {
+	received := make(chan *events.Event, 1)
+	s := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, req *http.Request) {
+			e := &events.Event{}
+			if err := json.NewDecoder(req.Body).Decode(e); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			received <- e
+		}))
+	defer s.Close()
+
+	b := events.NewBus()
+	w := events.NewWebhook(s.URL, nil, 5*time.Second)
+	w.Start(b)
+
+	b.Publish(&events.Event{Type: events.SnapshotCreated, SnapshotID: "snap-1"})
+
+	if e := receiveEvent(t, received); e.SnapshotID != "snap-1" {
+		t.Fatalf("unexpected event %+v", e)
+	}
+	w.Stop()
+}
+
+func TestEventsWebhookStop(t *testing.T) {
+	posted := make(chan struct{}, 1)
+	block := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, req *http.Request) {
+			posted <- struct{}{}
+			<-block
+		}))
+	defer s.Close()
+	defer close(block)
+
+	b := events.NewBus()
+	w := events.NewWebhook(s.URL, nil, time.Minute)
+	w.Start(b)
+
+	for i := 0; i < 10; i++ {
+		b.Publish(&events.Event{Type: events.SnapshotCreated})
+	}
+	select {
+	case <-posted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for post")
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		w.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop waited for the pending posts")
+	}
+	select {
+	case <-posted:
+		t.Fatal("posted an event after the webhook was stopped")
+	default:
+	}
+}
@@ -0,0 +1,225 @@
+package test
+
+import (
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/akutz/gofig"
+	"github.com/akutz/goof"
+	"golang.org/x/net/context"
+
+	"github.com/emccode/rexray/core"
+	"github.com/emccode/rexray/daemon/module"
+	"github.com/emccode/rexray/drivers/mock"
+)
+
+const (
+	healthyStorDriverName   = "healthyMockStorageDriver"
+	unhealthyStorDriverName = "unhealthyMockStorageDriver"
+	hungStorDriverName      = "hungMockStorageDriver"
+	countedStorDriverName   = "countedMockStorageDriver"
+)
+
+// countedHealthChecks is the number of health checks of the driver
+// countedMockStorageDriver.
+var countedHealthChecks int32
+
+// healthStorDriver is a mock storage driver that implements a health check.
+// Only the Driver and HealthChecker methods may be invoked.
+type healthStorDriver struct {
+	core.StorageDriver
+	name        string
+	healthCheck func(ctx context.Context) error
+}
+
+func (d *healthStorDriver) Name() string {
+	return d.name
+}
+
+func (d *healthStorDriver) Init(r *core.RexRay) error {
+	return nil
+}
+
+func (d *healthStorDriver) HealthCheck(ctx context.Context) error {
+	return d.healthCheck(ctx)
+}
+
+func registerHealthStorDriver(
+	name string, healthCheck func(ctx context.Context) error) {
+
+	registerTestDriver(name, func() core.Driver {
+		var d core.StorageDriver = &healthStorDriver{
+			name:        name,
+			healthCheck: healthCheck,
+		}
+		return d
+	})
+}
+
+func init() {
+	registerHealthStorDriver(healthyStorDriverName,
+		func(ctx context.Context) error {
+			return nil
+		})
+	registerHealthStorDriver(unhealthyStorDriverName,
+		func(ctx context.Context) error {
+			return goof.New("login failed")
+		})
+	registerHealthStorDriver(countedStorDriverName,
+		func(ctx context.Context) error {
+			atomic.AddInt32(&countedHealthChecks, 1)
+			return nil
+		})
+	registerHealthStorDriver(hungStorDriverName,
+		func(ctx context.Context) error {
+			time.Sleep(time.Minute)
+			return nil
+		})
+}
+
+func getHealthRexRay(storDrivers ...string) (*core.RexRay, error) {
+	r := core.New(nil)
+	r.Config.Set("rexray.osDrivers", []string{mock.MockOSDriverName})
+	r.Config.Set("rexray.volumeDrivers", []string{mock.MockVolDriverName})
+	r.Config.Set("rexray.storageDrivers", storDrivers)
+	r.Config.Set("rexray.health.timeout", "100ms")
+	if err := r.InitDrivers(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func getDriverHealth(
+	t *testing.T, health []*core.DriverHealth, name string) *core.DriverHealth {
+
+	for _, h := range health {
+		if h.Name == name {
+			return h
+		}
+	}
+	t.Fatalf("no health for driver %s", name)
+	return nil
+}
+
+func TestHealthNotChecked(t *testing.T) {
+	r, err := getRexRay()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	health := r.Health(testCtx)
+	if len(health) != 3 {
+		t.Fatalf("len(health) %d != 3", len(health))
+	}
+
+	for _, h := range health {
+		if !h.Healthy || h.Checked {
+			t.Fatalf("driver %s healthy=%v checked=%v",
+				h.Name, h.Healthy, h.Checked)
+		}
+	}
+}
+
+func TestHealthCheck(t *testing.T) {
+	r, err := getHealthRexRay(
+		healthyStorDriverName, unhealthyStorDriverName, hungStorDriverName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	health := r.Health(testCtx)
+
+	h := getDriverHealth(t, health, healthyStorDriverName)
+	if !h.Healthy || !h.Checked || h.Type != "storage" {
+		t.Fatalf("unexpected health %+v", h)
+	}
+
+	h = getDriverHealth(t, health, unhealthyStorDriverName)
+	if h.Healthy || !h.Checked || h.Error != "login failed" {
+		t.Fatalf("unexpected health %+v", h)
+	}
+
+	h = getDriverHealth(t, health, hungStorDriverName)
+	if h.Healthy || !strings.Contains(h.Error, "timed out") {
+		t.Fatalf("unexpected health %+v", h)
+	}
+}
+
+func TestHealthInterval(t *testing.T) {
+	r, err := getHealthRexRay(countedStorDriverName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	atomic.StoreInt32(&countedHealthChecks, 0)
+
+	r.Health(testCtx)
+	r.Health(testCtx)
+	if n := atomic.LoadInt32(&countedHealthChecks); n != 1 {
+		t.Fatalf("checked %d times within the interval", n)
+	}
+
+	r.Config.Set("rexray.health.interval", "0s")
+	r.Health(testCtx)
+	if n := atomic.LoadInt32(&countedHealthChecks); n != 2 {
+		t.Fatalf("checked %d times without an interval", n)
+	}
+}
+
+func TestModulePlatform(t *testing.T) {
+	c := gofig.New()
+	c.Set("rexray.osDrivers", []string{mock.MockOSDriverName})
+	c.Set("rexray.volumeDrivers", []string{mock.MockVolDriverName})
+	c.Set("rexray.storageDrivers", []string{mock.MockStorDriverName})
+
+	r1, err := module.Platform(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2, err := module.Platform(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r1 != r2 {
+		t.Fatal("module instances do not share the platform")
+	}
+	if _, err := r1.Storage.GetVolume(testCtx, "", ""); err != nil {
+		t.Fatalf("platform drivers not initialized: %v", err)
+	}
+}
+
+func TestHealthLastSuccess(t *testing.T) {
+	r, err := getRexRay()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Storage.GetVolumeMapping(testCtx); err != nil {
+		t.Fatal(err)
+	}
+
+	h := getDriverHealth(t, r.Health(testCtx), mock.MockStorDriverName)
+	if h.LastSuccess == nil || h.SinceLastSuccess == "" {
+		t.Fatalf("unexpected health %+v", h)
+	}
+}
+
+func TestHealthReport(t *testing.T) {
+	r, _ := getRexRayNoDrivers()
+	if hr := module.NewHealthReport(testCtx, r); hr.Ready {
+		t.Fatal("ready without drivers")
+	}
+
+	r, err := getHealthRexRay(unhealthyStorDriverName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hr := module.NewHealthReport(testCtx, r)
+	if hr.Ready {
+		t.Fatal("ready with unhealthy driver")
+	}
+	if len(hr.Drivers) != 3 {
+		t.Fatalf("len(hr.Drivers) %d != 3", len(hr.Drivers))
+	}
+}
@@ -0,0 +1,327 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/akutz/gofig"
+	"golang.org/x/net/context"
+
+	"github.com/emccode/rexray/core"
+	"github.com/emccode/rexray/drivers/mock"
+)
+
+func getRexRayInstances() (*core.RexRay, error) {
+	c := gofig.New()
+	c.Set("rexray.osDrivers", []string{mock.MockOSDriverName})
+	c.Set("rexray.volumeDrivers", []string{mock.MockVolDriverName})
+	c.Set("rexray.storageDrivers", []string{
+		mock.MockStorDriverName + "/a",
+		mock.MockStorDriverName + "/b"})
+	r := core.New(c)
+
+	if err := r.InitDrivers(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func TestParseDriverName(t *testing.T) {
+	if dn, in := core.ParseDriverName("scaleio/prod"); dn != "scaleio" ||
+		in != "prod" {
+		t.Fatalf("unexpected names %s %s", dn, in)
+	}
+	if dn, in := core.ParseDriverName("scaleio"); dn != "scaleio" || in != "" {
+		t.Fatalf("unexpected names %s %s", dn, in)
+	}
+}
+
+func TestScopeConfig(t *testing.T) {
+	c := gofig.New()
+	c.Set("scaleio.endpoint", "https://root")
+	c.Set("scaleio.systemName", "root")
+	c.Set("prod.scaleio.endpoint", "https://prod")
+
+	sc := core.ScopeConfig(c, "prod")
+	if v := sc.GetString("scaleio.endpoint"); v != "https://prod" {
+		t.Fatalf("unexpected endpoint %s", v)
+	}
+	if v := sc.GetString("scaleio.systemName"); v != "root" {
+		t.Fatalf("unexpected system name %s", v)
+	}
+
+	sc.Set("scaleio.systemName", "prod")
+	if v := sc.GetString("scaleio.systemName"); v != "prod" {
+		t.Fatalf("unexpected system name %s", v)
+	}
+	if v := c.GetString("scaleio.systemName"); v != "root" {
+		t.Fatalf("scoped property set at the root %s", v)
+	}
+}
+
+func TestScopeConfigKeys(t *testing.T) {
+	c := gofig.New()
+	c.Set("scaleio.endpoint", "https://root")
+	c.Set("mockProvider.password", "root-password")
+	c.Set("prod.mockProvider.password", "prod-password")
+	c.Set("prod.scaleio.systemName", "prod")
+
+	sc := core.ScopeConfig(c, "prod")
+
+	keys := map[string]bool{}
+	for _, k := range sc.AllKeys() {
+		if keys[k] {
+			t.Fatalf("duplicate key %s", k)
+		}
+		keys[k] = true
+	}
+	if !keys["scaleio.endpoint"] || !keys["mockprovider.password"] ||
+		!keys["scaleio.systemname"] || keys["prod.mockprovider.password"] {
+		t.Fatalf("unexpected keys %v", keys)
+	}
+
+	evs := map[string]string{}
+	for _, ev := range sc.EnvVars() {
+		parts := strings.SplitN(ev, "=", 2)
+		evs[parts[0]] = parts[1]
+	}
+	if evs["MOCKPROVIDER_PASSWORD"] != "prod-password" ||
+		evs["SCALEIO_SYSTEMNAME"] != "prod" ||
+		evs["SCALEIO_ENDPOINT"] != "https://root" {
+		t.Fatalf("unexpected environment variables %v", evs)
+	}
+	if _, ok := evs["PROD_MOCKPROVIDER_PASSWORD"]; ok {
+		t.Fatalf("unscoped environment variables %v", evs)
+	}
+
+	for _, ev := range core.RedactEnvVars(sc) {
+		if strings.Contains(ev, "password") {
+			t.Fatalf("secret not redacted %s", ev)
+		}
+	}
+}
+
+func TestInitDriverInstances(t *testing.T) {
+	r, err := getRexRayInstances()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := 0
+	for _ = range r.Storage.Drivers() {
+		n++
+	}
+	if n != 2 {
+		t.Fatalf("expected 2 storage drivers, got %d", n)
+	}
+
+	name := r.Storage.Name()
+	if !strings.Contains(name, mock.MockStorDriverName+"/a") ||
+		!strings.Contains(name, mock.MockStorDriverName+"/b") {
+		t.Fatalf("unexpected storage driver name %s", name)
+	}
+}
+
+func TestStorageDriverInstanceSelection(t *testing.T) {
+	r, err := getRexRayInstances()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bds, err := r.Storage.GetVolumeMapping(testCtx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bds) != 2 {
+		t.Fatalf("expected 2 block devices, got %d", len(bds))
+	}
+
+	ctx := core.WithStorageDriverName(testCtx, mock.MockStorDriverName+"/b")
+	if bds, err = r.Storage.GetVolumeMapping(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(bds) != 1 {
+		t.Fatalf("expected 1 block device, got %d", len(bds))
+	}
+	if _, err := r.Storage.GetInstance(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx = core.WithStorageDriverName(testCtx, mock.MockStorDriverName+"/c")
+	if _, err := r.Storage.GetInstance(ctx); err == nil {
+		t.Fatal("selected an unknown storage driver")
+	}
+}
+
+func TestDriverNamesCaseInsensitive(t *testing.T) {
+	lower := strings.ToLower
+	c := gofig.New()
+	c.Set("rexray.osDrivers", []string{lower(mock.MockOSDriverName)})
+	c.Set("rexray.volumeDrivers", []string{lower(mock.MockVolDriverName)})
+	c.Set("rexray.storageDrivers", []string{
+		lower(mock.MockStorDriverName),
+		lower(mock.MockStorDriverName) + "/a"})
+	r := core.New(c)
+	if err := r.InitDrivers(); err != nil {
+		t.Fatal(err)
+	}
+
+	bds, err := r.Storage.GetVolumeMapping(testCtx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bds) != 2 {
+		t.Fatalf("expected 2 block devices, got %d", len(bds))
+	}
+
+	for _, n := range []string{
+		mock.MockStorDriverName,
+		strings.ToUpper(mock.MockStorDriverName) + "/A",
+	} {
+		ctx := core.WithStorageDriverName(testCtx, n)
+		if bds, err = r.Storage.GetVolumeMapping(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if len(bds) != 1 {
+			t.Fatalf("%s: expected 1 block device, got %d", n, len(bds))
+		}
+	}
+
+	rc, err := r.GetConfig().Copy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc.Set("mockProvider.userName", "root")
+	rep, err := r.Reload(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rep.Reinitialized) != 4 || len(rep.Added) > 0 ||
+		len(rep.Removed) > 0 || len(rep.Failed) > 0 {
+		t.Fatalf("unexpected report %+v", rep)
+	}
+}
+
+func TestValidateConfigInstances(t *testing.T) {
+	c := gofig.New()
+	c.Set("rexray.storageDrivers", []string{
+		schemaTestDriverName + "/prod",
+		schemaTestDriverName + "/dr"})
+	c.Set("schemaTest.endpoint", "https://localhost")
+	c.Set("schemaTest.systemID", "1")
+	c.Set("dr.schemaTest.systemName", "dr")
+
+	errs := core.ValidateConfig(c)
+	if len(errs) != 1 ||
+		!strings.Contains(errs[0].Schema, schemaTestDriverName+"/dr") {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+
+	c.Set("dr.schemaTest.systemID", "")
+	if errs := core.ValidateConfig(c); len(errs) > 0 {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+}
+
+const (
+	locateStorDriverName = "locateStorDriver"
+	locateVolDriverName  = "locateVolDriver"
+)
+
+// locateStorDriver is a storage driver whose volumes are named by the
+// locateStorDriver.volumes property. Only the Driver and GetVolume methods
+// may be invoked.
+type locateStorDriver struct {
+	core.StorageDriver
+	volumes []string
+}
+
+func (d *locateStorDriver) Name() string {
+	return locateStorDriverName
+}
+
+func (d *locateStorDriver) Init(r *core.RexRay) error {
+	d.volumes = r.Config.GetStringSlice("locateStorDriver.volumes")
+	return nil
+}
+
+func (d *locateStorDriver) GetVolume(
+	ctx context.Context, volumeID, volumeName string) ([]*core.Volume, error) {
+	for _, v := range d.volumes {
+		if v == volumeName {
+			return []*core.Volume{{Name: v, VolumeID: v}}, nil
+		}
+	}
+	return nil, nil
+}
+
+// locateVolDriver is a volume driver whose paths are the names of the storage
+// drivers selected by the calls. Only the Driver and Path methods may be
+// invoked.
+type locateVolDriver struct {
+	core.VolumeDriver
+}
+
+func (d *locateVolDriver) Name() string {
+	return locateVolDriverName
+}
+
+func (d *locateVolDriver) Init(r *core.RexRay) error {
+	return nil
+}
+
+func (d *locateVolDriver) Path(
+	ctx context.Context, volumeName, volumeID string) (string, error) {
+	return core.StorageDriverName(ctx), nil
+}
+
+func init() {
+	registerTestDriver(locateStorDriverName, func() core.Driver {
+		var d core.StorageDriver = &locateStorDriver{}
+		return d
+	})
+	registerTestDriver(locateVolDriverName, func() core.Driver {
+		var d core.VolumeDriver = &locateVolDriver{}
+		return d
+	})
+}
+
+func TestVolumeStorageDriverLocated(t *testing.T) {
+	c := gofig.New()
+	c.Set("rexray.osDrivers", []string{mock.MockOSDriverName})
+	c.Set("rexray.volumeDrivers", []string{locateVolDriverName})
+	c.Set("rexray.storageDrivers", []string{
+		locateStorDriverName + "/a",
+		locateStorDriverName + "/b"})
+	c.Set("a.locateStorDriver.volumes", []string{"vol-a"})
+	c.Set("b.locateStorDriver.volumes", []string{"vol-b"})
+	r := core.New(c)
+	if err := r.InitDrivers(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ctx        context.Context
+		volumeName string
+		driverName string
+	}{
+		{testCtx, "vol-a", locateStorDriverName + "/a"},
+		{testCtx, "vol-b", locateStorDriverName + "/b"},
+		{testCtx, locateStorDriverName + "/a/vol-b",
+			locateStorDriverName + "/a"},
+		{core.WithStorageDriverName(testCtx, locateStorDriverName+"/a"),
+			"vol-b", locateStorDriverName + "/a"},
+		{testCtx, "vol-c", ""},
+	}
+
+	for _, tt := range tests {
+		n, err := r.Volume.Path(tt.ctx, tt.volumeName, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != tt.driverName {
+			t.Fatalf("%s: driver %q != %q", tt.volumeName, n, tt.driverName)
+		}
+	}
+}
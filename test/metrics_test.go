@@ -0,0 +1,213 @@
+package test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/emccode/rexray/core"
+	"github.com/emccode/rexray/core/errors"
+	"github.com/emccode/rexray/core/metrics"
+	"github.com/emccode/rexray/drivers/mock"
+)
+
+func TestMetricsRegistry(t *testing.T) {
+	r := metrics.NewRegistry()
+
+	c := r.Counter("test_calls_total", "The number of calls.", "method")
+	c.Inc("get")
+	c.Add(2, "get")
+	c.Inc("put")
+
+	if c != r.Counter("test_calls_total", "") {
+		t.Fatal("counter registered twice")
+	}
+	if v := c.Value("get"); v != 3 {
+		t.Fatalf("counter value %v != 3", v)
+	}
+
+	g := r.Gauge("test_mounts", "The number of \"mounts\".", "volume")
+	g.Set(2, `vol"1`)
+
+	h := r.Histogram("test_duration_seconds", "The duration.",
+		[]float64{1, 0.5})
+	h.Observe(0.25)
+	h.Observe(0.75)
+	h.Observe(2)
+
+	if n := h.Count(); n != 3 {
+		t.Fatalf("histogram count %d != 3", n)
+	}
+
+	buf := &bytes.Buffer{}
+	if _, err := r.WriteTo(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `# HELP test_calls_total The number of calls.
+# TYPE test_calls_total counter
+test_calls_total{method="get"} 3
+test_calls_total{method="put"} 1
+# HELP test_duration_seconds The duration.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{le="0.5"} 1
+test_duration_seconds_bucket{le="1"} 2
+test_duration_seconds_bucket{le="+Inf"} 3
+test_duration_seconds_sum 3
+test_duration_seconds_count 3
+# HELP test_mounts The number of "mounts".
+# TYPE test_mounts gauge
+test_mounts{volume="vol\"1"} 2
+`
+	if buf.String() != expected {
+		t.Fatalf("unexpected output\n%s", buf.String())
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	r := metrics.NewRegistry()
+	r.Gauge("test_up", "Up.").Set(1)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/metrics", nil)
+	metrics.Handler(r).ServeHTTP(w, req)
+
+	if ct := w.Header().Get("Content-Type"); ct != metrics.ContentType {
+		t.Fatalf("unexpected content type %s", ct)
+	}
+	if !strings.Contains(w.Body.String(), "test_up 1\n") {
+		t.Fatalf("unexpected body\n%s", w.Body.String())
+	}
+}
+
+func TestMetricsInstrumentedManagers(t *testing.T) {
+	r, err := getRexRay()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := metrics.Default.Counter("rexray_driver_calls_total", "")
+	latency := metrics.Default.Histogram(
+		"rexray_driver_call_duration_seconds", "", nil)
+
+	labels := []string{"storage", mock.MockStorDriverName, "AttachVolume"}
+	callsBefore := calls.Value(labels...)
+	countBefore := latency.Count(labels...)
+
+	if _, err := r.Storage.AttachVolume(
+		testCtx, false, "", "", false); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := calls.Value(labels...); v != callsBefore+1 {
+		t.Fatalf("calls %v != %v", v, callsBefore+1)
+	}
+	if n := latency.Count(labels...); n != countBefore+1 {
+		t.Fatalf("observations %d != %d", n, countBefore+1)
+	}
+
+	labels = []string{"os", mock.MockOSDriverName, "Mounted"}
+	callsBefore = calls.Value(labels...)
+	countBefore = latency.Count(labels...)
+
+	if _, err := r.OS.Mounted(testCtx, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := calls.Value(labels...); v != callsBefore+1 {
+		t.Fatalf("calls %v != %v", v, callsBefore+1)
+	}
+	if n := latency.Count(labels...); n != countBefore {
+		t.Fatal("unexpected latency observation")
+	}
+}
+
+func TestMetricsVolumeMounts(t *testing.T) {
+	r, err := getRexRay()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mounts := metrics.Default.Gauge("rexray_volume_mounts", "")
+	for _, n := range []string{"vol-1", "vol-1", "vol-2"} {
+		if _, err := r.Volume.Mount(
+			testCtx, n, "", false, "", false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if v := mounts.Value(mock.MockVolDriverName); v != 3 {
+		t.Fatalf("mounts %v != 3", v)
+	}
+
+	if err := r.Volume.Unmount(testCtx, "vol-1", ""); err != nil {
+		t.Fatal(err)
+	}
+	if v := mounts.Value(mock.MockVolDriverName); v != 2 {
+		t.Fatalf("mounts %v != 2", v)
+	}
+}
+
+func TestMetricsDriverInstances(t *testing.T) {
+	r, err := getRexRayInstances()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := metrics.Default.Counter("rexray_driver_calls_total", "")
+	labels := []string{"storage", mock.MockStorDriverName + "/b", "GetVolume"}
+	joined := []string{"storage", r.Storage.Name(), "GetVolume"}
+	before := calls.Value(labels...)
+	joinedBefore := calls.Value(joined...)
+
+	ctx := core.WithStorageDriverName(testCtx, mock.MockStorDriverName+"/b")
+	if _, err := r.Storage.GetVolume(ctx, "", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := calls.Value(labels...); v != before+1 {
+		t.Fatalf("calls %v != %v", v, before+1)
+	}
+	if v := calls.Value(joined...); v != joinedBefore {
+		t.Fatal("call labeled with the names of all of the drivers")
+	}
+}
+
+func TestMetricsInstrumentedManagersErrors(t *testing.T) {
+	r, _ := getRexRayNoDrivers()
+
+	errs := metrics.Default.Counter("rexray_driver_errors_total", "")
+	labels := []string{"storage", "", "GetVolumeMapping",
+		strconv.Itoa(int(errors.ErrCodeNoStorageDetected))}
+	before := errs.Value(labels...)
+
+	if _, err := r.Storage.GetVolumeMapping(
+		testCtx); err != errors.ErrNoStorageDetected {
+		t.Fatal(err)
+	}
+
+	if v := errs.Value(labels...); v != before+1 {
+		t.Fatalf("errors %v != %v", v, before+1)
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	if c := errors.Code(nil); c != errors.ErrCodeUnknown {
+		t.Fatalf("unexpected code %d", c)
+	}
+	if c := errors.Code(
+		errors.ErrNoStorageDetected); c != errors.ErrCodeNoStorageDetected {
+		t.Fatalf("unexpected code %d", c)
+	}
+	if c := errors.Code(&errors.TimeoutError{}); c != errors.ErrCodeTimeout {
+		t.Fatalf("unexpected code %d", c)
+	}
+	if c := errors.Code(&errors.RequestError{
+		RequestID: "test",
+		Err:       errors.ErrNotImplemented,
+	}); c != errors.ErrCodeNotImplemented {
+		t.Fatalf("unexpected code %d", c)
+	}
+}
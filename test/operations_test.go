@@ -0,0 +1,301 @@
+package test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/emccode/rexray/core"
+	"github.com/emccode/rexray/util"
+)
+
+func prefixTempDir(t *testing.T) func() {
+	oldPrefix := util.GetPrefix()
+	d, err := ioutil.TempDir("", "rexray-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	util.Prefix(d)
+	return func() {
+		os.RemoveAll(d)
+		if oldPrefix != "" {
+			util.Prefix(oldPrefix)
+		}
+	}
+}
+
+func waitOperation(
+	t *testing.T, r *core.RexRay, id string) *core.Operation {
+	ctx, cancel := context.WithTimeout(testCtx, 5*time.Second)
+	defer cancel()
+	op, err := r.Operations.Wait(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return op
+}
+
+func TestOperationCreateVolume(t *testing.T) {
+	defer prefixTempDir(t)()
+
+	r, err := getRexRay()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	op, err := r.Operations.Start(testCtx, &core.OperationRequest{
+		Type:       core.OperationCreateVolume,
+		VolumeName: "test",
+		Size:       1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if op.ID == "" {
+		t.Fatal("operation ID is empty")
+	}
+
+	op = waitOperation(t, r, op.ID)
+
+	if op.State != core.OperationSucceeded {
+		t.Fatalf("op.State != %s; value=%s", core.OperationSucceeded, op.State)
+	}
+
+	if op.CompletedTime.IsZero() {
+		t.Fatal("op.CompletedTime is zero")
+	}
+}
+
+func TestOperationPersisted(t *testing.T) {
+	defer prefixTempDir(t)()
+
+	r, err := getRexRay()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	op, err := r.Operations.Start(testCtx, &core.OperationRequest{
+		Type:     core.OperationDetachVolume,
+		VolumeID: "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	waitOperation(t, r, op.ID)
+
+	// a new instance has no in-memory record and must read from disk
+	r2, err := getRexRay()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	op2, err := r2.Operations.Get(op.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if op2.State != core.OperationSucceeded {
+		t.Fatalf("op2.State != %s; value=%s", core.OperationSucceeded, op2.State)
+	}
+
+	ops, err := r2.Operations.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ops) != 1 {
+		t.Fatalf("len(ops) != 1; value=%d", len(ops))
+	}
+}
+
+func TestOperationFailedNoDrivers(t *testing.T) {
+	defer prefixTempDir(t)()
+
+	r, err := getRexRayNoDrivers()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	op, err := r.Operations.Start(testCtx, &core.OperationRequest{
+		Type:     core.OperationAttachVolume,
+		VolumeID: "test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	op = waitOperation(t, r, op.ID)
+
+	if op.State != core.OperationFailed {
+		t.Fatalf("op.State != %s; value=%s", core.OperationFailed, op.State)
+	}
+
+	if op.Error == "" {
+		t.Fatal("op.Error is empty")
+	}
+}
+
+func TestOperationUnknownType(t *testing.T) {
+	r, err := getRexRay()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Operations.Start(testCtx,
+		&core.OperationRequest{Type: "unknown"}); err == nil {
+		t.Fatal("expected unknown operation type error")
+	}
+}
+
+func TestOperationNotFound(t *testing.T) {
+	defer prefixTempDir(t)()
+
+	r, err := getRexRay()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Operations.Get("missing"); err == nil {
+		t.Fatal("expected operation not found error")
+	}
+}
+
+func TestOperationAsyncCall(t *testing.T) {
+	defer prefixTempDir(t)()
+
+	r, err := getRexRay()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Storage.AttachVolume(
+		testCtx, false, "vol-1", "i-1", false); err != nil {
+		t.Fatal(err)
+	}
+	if ops, _ := r.Operations.List(); len(ops) != 0 {
+		t.Fatalf("len(ops) != 0; value=%d", len(ops))
+	}
+
+	attachments, err := r.Storage.AttachVolume(
+		testCtx, true, "vol-1", "i-1", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attachments) != 1 || attachments[0].OperationID == "" {
+		t.Fatalf("unexpected attachments %+v", attachments)
+	}
+
+	op := waitOperation(t, r, attachments[0].OperationID)
+	if op.Type != core.OperationAttachVolume ||
+		op.State != core.OperationSucceeded ||
+		op.RequestID != "test" ||
+		op.Request.VolumeID != "vol-1" ||
+		op.Request.InstanceID != "i-1" {
+		t.Fatalf("unexpected operation %+v", op)
+	}
+
+	var detachOp *core.Operation
+	ctx := core.WithOperationReceiver(testCtx,
+		func(op *core.Operation) { detachOp = op })
+	if err := r.Storage.DetachVolume(
+		ctx, true, "vol-1", "i-1", false); err != nil {
+		t.Fatal(err)
+	}
+	if detachOp == nil {
+		t.Fatal("detach operation not received")
+	}
+	if op = waitOperation(t, r, detachOp.ID); op.Type !=
+		core.OperationDetachVolume || op.State != core.OperationSucceeded {
+		t.Fatalf("unexpected operation %+v", op)
+	}
+}
+
+func TestOperationWaitCanceled(t *testing.T) {
+	defer prefixTempDir(t)()
+
+	r, err := getRexRay()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a pending operation of this process never completes on its own
+	dir := util.LibFilePath("operations")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	buf, err := json.Marshal(&core.Operation{
+		ID:    "pending",
+		Type:  core.OperationDetachVolume,
+		State: core.OperationPending,
+		PID:   os.Getpid(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(
+		filepath.Join(dir, "pending.json"), buf, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(testCtx, 50*time.Millisecond)
+	defer cancel()
+
+	op, err := r.Operations.Wait(ctx, "pending")
+	if err == nil {
+		t.Fatal("waited for an operation that never completes")
+	}
+	if op == nil || op.State != core.OperationPending {
+		t.Fatalf("unexpected operation %+v", op)
+	}
+}
+
+func TestOperationPrune(t *testing.T) {
+	defer prefixTempDir(t)()
+
+	r, err := getRexRay()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Config.Set("rexray.operations.max", 2)
+
+	detach := func() {
+		op, err := r.Operations.Start(testCtx, &core.OperationRequest{
+			Type:     core.OperationDetachVolume,
+			VolumeID: "test",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		waitOperation(t, r, op.ID)
+	}
+
+	for i := 0; i < 4; i++ {
+		detach()
+	}
+
+	// the finished operations are pruned before a new operation is saved
+	ops, err := r.Operations.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) != 3 {
+		t.Fatalf("len(ops) != 3; value=%d", len(ops))
+	}
+
+	r.Config.Set("rexray.operations.ttl", "1ns")
+	detach()
+
+	if ops, err = r.Operations.List(); err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) != 1 {
+		t.Fatalf("len(ops) != 1; value=%d", len(ops))
+	}
+}
@@ -41,7 +41,7 @@ func TestOSDriverGetMounts(t *testing.T) {
 		t.Fatal(err)
 	}
 	d := <-r.OS.Drivers()
-	if v, err := d.GetMounts("", ""); v != nil || err != nil {
+	if v, err := d.GetMounts(testCtx, "", ""); v != nil || err != nil {
 		t.Fail()
 	}
 }
@@ -51,7 +51,7 @@ func TestOSDriverManagerGetMounts(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if v, err := r.OS.GetMounts("", ""); v != nil || err != nil {
+	if v, err := r.OS.GetMounts(testCtx, "", ""); v != nil || err != nil {
 		t.Fail()
 	}
 }
@@ -61,7 +61,7 @@ func TestOSDriverManagerGetMountsNoDrivers(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if _, err := r.OS.GetMounts("", ""); err != errors.ErrNoOSDetected {
+	if _, err := r.OS.GetMounts(testCtx, "", ""); err != errors.ErrNoOSDetected {
 		t.Fatal(err)
 	}
 }
@@ -72,7 +72,7 @@ func TestOSDriverMounted(t *testing.T) {
 		t.Fatal(err)
 	}
 	d := <-r.OS.Drivers()
-	if v, err := d.Mounted(""); v || err != nil {
+	if v, err := d.Mounted(testCtx, ""); v || err != nil {
 		t.Fail()
 	}
 }
@@ -82,7 +82,7 @@ func TestOSDriverManagerMounted(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if v, err := r.OS.Mounted(""); v || err != nil {
+	if v, err := r.OS.Mounted(testCtx, ""); v || err != nil {
 		t.Fail()
 	}
 }
@@ -92,7 +92,7 @@ func TestOSDriverManagerMountedNoDrivers(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if _, err := r.OS.Mounted(""); err != errors.ErrNoOSDetected {
+	if _, err := r.OS.Mounted(testCtx, ""); err != errors.ErrNoOSDetected {
 		t.Fatal(err)
 	}
 }
@@ -103,7 +103,7 @@ func TestOSDriverUnmount(t *testing.T) {
 		t.Fatal(err)
 	}
 	d := <-r.OS.Drivers()
-	if err := d.Unmount(""); err != nil {
+	if err := d.Unmount(testCtx, ""); err != nil {
 		t.Fail()
 	}
 }
@@ -113,7 +113,7 @@ func TestOSDriverManagerUnmount(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if err := r.OS.Unmount(""); err != nil {
+	if err := r.OS.Unmount(testCtx, ""); err != nil {
 		t.Fail()
 	}
 }
@@ -123,7 +123,7 @@ func TestOSDriverManagerUnmountNoDrivers(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if err := r.OS.Unmount(""); err != errors.ErrNoOSDetected {
+	if err := r.OS.Unmount(testCtx, ""); err != errors.ErrNoOSDetected {
 		t.Fatal(err)
 	}
 }
@@ -134,7 +134,7 @@ func TestOSDriverMount(t *testing.T) {
 		t.Fatal(err)
 	}
 	d := <-r.OS.Drivers()
-	if err := d.Mount("", "", "", ""); err != nil {
+	if err := d.Mount(testCtx, "", "", "", ""); err != nil {
 		t.Fail()
 	}
 }
@@ -144,7 +144,7 @@ func TestOSDriverManagerMount(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if err := r.OS.Mount("", "", "", ""); err != nil {
+	if err := r.OS.Mount(testCtx, "", "", "", ""); err != nil {
 		t.Fail()
 	}
 }
@@ -154,7 +154,7 @@ func TestOSDriverManagerMountNoDrivers(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if err := r.OS.Mount("", "", "", ""); err != errors.ErrNoOSDetected {
+	if err := r.OS.Mount(testCtx, "", "", "", ""); err != errors.ErrNoOSDetected {
 		t.Fatal(err)
 	}
 }
@@ -165,7 +165,7 @@ func TestOSDriverFormat(t *testing.T) {
 		t.Fatal(err)
 	}
 	d := <-r.OS.Drivers()
-	if err := d.Format("", "", false); err != nil {
+	if err := d.Format(testCtx, "", "", false); err != nil {
 		t.Fail()
 	}
 }
@@ -175,7 +175,7 @@ func TestOSDriverManagerFormat(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if err := r.OS.Format("", "", false); err != nil {
+	if err := r.OS.Format(testCtx, "", "", false); err != nil {
 		t.Fail()
 	}
 }
@@ -185,7 +185,7 @@ func TestOSDriverManagerFormatNoDrivers(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if err := r.OS.Format("", "", false); err != errors.ErrNoOSDetected {
+	if err := r.OS.Format(testCtx, "", "", false); err != errors.ErrNoOSDetected {
 		t.Fatal(err)
 	}
 }
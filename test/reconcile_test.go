@@ -0,0 +1,397 @@
+package test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/docker/docker/pkg/mount"
+	"golang.org/x/net/context"
+
+	"github.com/emccode/rexray/core"
+	"github.com/emccode/rexray/drivers/mock"
+	"github.com/emccode/rexray/util"
+)
+
+const (
+	reconcileOSDriverName   = "reconcileMockOSDriver"
+	reconcileVolDriverName  = "reconcileMockVolumeDriver"
+	reconcileStorDriverName = "reconcileMockStorageDriver"
+)
+
+// reconcileHost is the state shared by the reconcile mock drivers. The
+// volume with the ID vol-N is named vN.
+type reconcileHost struct {
+	sync.Mutex
+	mounts   core.MountInfoArray
+	attached map[string]string
+}
+
+var host = &reconcileHost{}
+
+// reconcileOSDriver is a mock OS driver whose mounts are kept in memory. Only
+// the Driver, GetMounts, Mount, and Unmount methods may be invoked.
+type reconcileOSDriver struct {
+	core.OSDriver
+}
+
+func (d *reconcileOSDriver) Name() string {
+	return reconcileOSDriverName
+}
+
+func (d *reconcileOSDriver) Init(r *core.RexRay) error {
+	return nil
+}
+
+func (d *reconcileOSDriver) GetMounts(
+	ctx context.Context,
+	deviceName, mountPoint string) (core.MountInfoArray, error) {
+	host.Lock()
+	defer host.Unlock()
+	return append(core.MountInfoArray{}, host.mounts...), nil
+}
+
+func (d *reconcileOSDriver) Mount(
+	ctx context.Context,
+	device, target, mountOptions, mountLabel string) error {
+	host.Lock()
+	defer host.Unlock()
+	host.mounts = append(host.mounts,
+		&mount.Info{Source: device, Mountpoint: target, Fstype: "ext4"})
+	return nil
+}
+
+func (d *reconcileOSDriver) Unmount(
+	ctx context.Context, mountPoint string) error {
+	host.Lock()
+	defer host.Unlock()
+	var mounts core.MountInfoArray
+	for _, m := range host.mounts {
+		if m.Mountpoint != mountPoint {
+			mounts = append(mounts, m)
+		}
+	}
+	host.mounts = mounts
+	return nil
+}
+
+// reconcileStorDriver is a mock storage driver whose attachments are kept in
+// memory. Only the Driver, GetInstance, GetVolumeMapping, GetVolume,
+// GetVolumeAttach, and DetachVolume methods may be invoked.
+type reconcileStorDriver struct {
+	core.StorageDriver
+}
+
+func (d *reconcileStorDriver) Name() string {
+	return reconcileStorDriverName
+}
+
+func (d *reconcileStorDriver) Init(r *core.RexRay) error {
+	return nil
+}
+
+func (d *reconcileStorDriver) GetInstance(
+	ctx context.Context) (*core.Instance, error) {
+	return &core.Instance{InstanceID: "i-1"}, nil
+}
+
+func (d *reconcileStorDriver) GetVolumeMapping(
+	ctx context.Context) ([]*core.BlockDevice, error) {
+	host.Lock()
+	defer host.Unlock()
+	var bds []*core.BlockDevice
+	for id, dev := range host.attached {
+		bds = append(bds, &core.BlockDevice{
+			InstanceID: "i-1",
+			VolumeID:   id,
+			DeviceName: dev,
+		})
+	}
+	return bds, nil
+}
+
+func (d *reconcileStorDriver) GetVolume(
+	ctx context.Context, volumeID, volumeName string) ([]*core.Volume, error) {
+	return []*core.Volume{{
+		VolumeID: volumeID,
+		Name:     strings.Replace(volumeID, "vol-", "v", 1),
+	}}, nil
+}
+
+func (d *reconcileStorDriver) GetVolumeAttach(
+	ctx context.Context,
+	volumeID, instanceID string) ([]*core.VolumeAttachment, error) {
+	host.Lock()
+	defer host.Unlock()
+	dev, ok := host.attached[volumeID]
+	if !ok {
+		return nil, nil
+	}
+	return []*core.VolumeAttachment{{
+		VolumeID:   volumeID,
+		InstanceID: instanceID,
+		DeviceName: dev,
+	}}, nil
+}
+
+func (d *reconcileStorDriver) DetachVolume(
+	ctx context.Context,
+	runAsync bool, volumeID, instanceID string, force bool) error {
+	host.Lock()
+	defer host.Unlock()
+	delete(host.attached, volumeID)
+	return nil
+}
+
+// reconcileVolDriver is a mock volume driver whose mounts block until they
+// are released. Only the Driver and Mount methods may be invoked.
+type reconcileVolDriver struct {
+	core.VolumeDriver
+}
+
+// mounting receives the name of each volume that is being mounted, and
+// releaseMount releases the mount.
+var (
+	mounting     = make(chan string)
+	releaseMount = make(chan struct{})
+)
+
+func (d *reconcileVolDriver) Name() string {
+	return reconcileVolDriverName
+}
+
+func (d *reconcileVolDriver) Init(r *core.RexRay) error {
+	return nil
+}
+
+func (d *reconcileVolDriver) Mount(
+	ctx context.Context,
+	volumeName, volumeID string,
+	overwriteFs bool, newFsType string, preempt bool) (string, error) {
+	mounting <- volumeName
+	<-releaseMount
+	return "", nil
+}
+
+func init() {
+	registerTestDriver(reconcileVolDriverName, func() core.Driver {
+		var d core.VolumeDriver = &reconcileVolDriver{}
+		return d
+	})
+	registerTestDriver(reconcileOSDriverName, func() core.Driver {
+		var d core.OSDriver = &reconcileOSDriver{}
+		return d
+	})
+	registerTestDriver(reconcileStorDriverName, func() core.Driver {
+		var d core.StorageDriver = &reconcileStorDriver{}
+		return d
+	})
+}
+
+func TestReconcile(t *testing.T) {
+	devDir, err := ioutil.TempDir("", "rexray-devices")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(devDir)
+
+	volsDir := util.VolumesDirPath()
+	defer os.RemoveAll(volsDir)
+
+	dev := func(name string) string {
+		p := filepath.Join(devDir, name)
+		if err := ioutil.WriteFile(p, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	dir := func(name string) string {
+		p := filepath.Join(volsDir, name)
+		if err := os.MkdirAll(p, 0755); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	host.Lock()
+	host.attached = map[string]string{
+		// mounted and in use
+		"vol-1": dev("xvdb"),
+		// attached, not mounted, and not in use
+		"vol-2": dev("xvdc"),
+		// attached, not mounted, and in use
+		"vol-3": dev("xvdd"),
+		// attached, but not managed by REX-Ray
+		"vol-8": dev("xvdf"),
+		// the root disk, whose name differs from the name of its device
+		"vol-9": "/dev/sda",
+	}
+	dir("v2")
+	dir("v9")
+	host.mounts = core.MountInfoArray{
+		{Source: "/dev/xvda1", Mountpoint: "/"},
+		{Source: host.attached["vol-1"], Mountpoint: dir("v1")},
+		// the device no longer exists
+		{Source: filepath.Join(devDir, "xvde"), Mountpoint: dir("v5")},
+		// a network share
+		{Source: "nfs:/exports/v6", Mountpoint: dir("v6"), Fstype: "nfs"},
+	}
+	host.Unlock()
+
+	// not a mount point
+	dir("v7")
+
+	r := core.New(nil)
+	r.Config.Set("rexray.osDrivers", []string{reconcileOSDriverName})
+	r.Config.Set("rexray.volumeDrivers", []string{mock.MockVolDriverName})
+	r.Config.Set("rexray.storageDrivers", []string{reconcileStorDriverName})
+	if err := r.InitDrivers(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"v1", "v3", "v4"} {
+		if _, err := r.Volume.Mount(
+			testCtx, name, "", false, "", false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := &core.ReconcileOptions{CheckUsedCounts: true}
+	report, err := r.Reconcile(testCtx, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.InstanceID != "i-1" {
+		t.Fatalf("instanceID %s != i-1", report.InstanceID)
+	}
+
+	expected := map[string]string{
+		"vol-2": core.RepairDetach,
+		"vol-3": core.RepairRemount,
+		"v5":    core.RepairUnmount,
+		"v7":    core.RepairRemoveDir,
+		"v4":    core.RepairSetUsedCount,
+	}
+	assertDrift(t, report, expected)
+	for _, d := range report.Drift {
+		if d.Repaired {
+			t.Fatalf("repaired without fix %+v", d)
+		}
+	}
+
+	opts.Fix = true
+	if report, err = r.Reconcile(testCtx, opts); err != nil {
+		t.Fatal(err)
+	}
+	assertDrift(t, report, expected)
+	for _, d := range report.Drift {
+		if !d.Repaired {
+			t.Fatalf("not repaired %+v", d)
+		}
+	}
+
+	host.Lock()
+	if _, ok := host.attached["vol-2"]; ok {
+		t.Fatal("vol-2 not detached")
+	}
+	for _, id := range []string{"vol-8", "vol-9"} {
+		if _, ok := host.attached[id]; !ok {
+			t.Fatalf("%s detached", id)
+		}
+	}
+	host.Unlock()
+	for _, name := range []string{"v2", "v5", "v7"} {
+		if _, err := os.Stat(filepath.Join(volsDir, name)); !os.IsNotExist(err) {
+			t.Fatalf("%s not removed", name)
+		}
+	}
+
+	if report, err = r.Reconcile(testCtx, opts); err != nil {
+		t.Fatal(err)
+	}
+	assertDrift(t, report, map[string]string{})
+}
+
+func TestReconcileMounting(t *testing.T) {
+	devDir, err := ioutil.TempDir("", "rexray-devices")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(devDir)
+
+	volsDir := util.VolumesDirPath()
+	defer os.RemoveAll(volsDir)
+	if err := os.MkdirAll(filepath.Join(volsDir, "v1"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// the volume is attached, but its mount is in flight
+	host.Lock()
+	host.attached = map[string]string{"vol-1": filepath.Join(devDir, "xvdb")}
+	host.mounts = nil
+	host.Unlock()
+
+	r := core.New(nil)
+	r.Config.Set("rexray.osDrivers", []string{reconcileOSDriverName})
+	r.Config.Set("rexray.volumeDrivers", []string{reconcileVolDriverName})
+	r.Config.Set("rexray.storageDrivers", []string{reconcileStorDriverName})
+	if err := r.InitDrivers(); err != nil {
+		t.Fatal(err)
+	}
+
+	errs := make(chan error)
+	go func() {
+		_, err := r.Volume.Mount(testCtx, "v1", "", false, "", false)
+		errs <- err
+	}()
+	if name := <-mounting; name != "v1" {
+		t.Fatalf("mounting %s != v1", name)
+	}
+
+	opts := &core.ReconcileOptions{Fix: true, CheckUsedCounts: true}
+	report, err := r.Reconcile(testCtx, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDrift(t, report, map[string]string{})
+
+	close(releaseMount)
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+
+	// the mount is no longer in flight, but the mock did not mount it
+	if report, err = r.Reconcile(testCtx, opts); err != nil {
+		t.Fatal(err)
+	}
+	assertDrift(t, report, map[string]string{"vol-1": core.RepairRemount})
+}
+
+// assertDrift asserts that the report contains exactly the expected drift,
+// which is keyed by the drift's volume ID or, if it has none, the base name
+// of its mount point or its volume name.
+func assertDrift(
+	t *testing.T, report *core.ReconcileReport, expected map[string]string) {
+
+	if len(report.Drift) != len(expected) {
+		for _, d := range report.Drift {
+			t.Logf("%+v", d)
+		}
+		t.Fatalf("len(drift) %d != %d", len(report.Drift), len(expected))
+	}
+	for _, d := range report.Drift {
+		key := d.VolumeID
+		if key == "" && d.MountPoint != "" {
+			key = filepath.Base(d.MountPoint)
+		}
+		if key == "" {
+			key = d.VolumeName
+		}
+		if repair, ok := expected[key]; !ok || repair != d.Repair {
+			t.Fatalf("unexpected drift %+v", d)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/akutz/gofig"
+	"github.com/akutz/gotil"
+
+	"github.com/emccode/rexray/core"
+	"github.com/emccode/rexray/core/errors"
+	"github.com/emccode/rexray/drivers/mock"
+)
+
+func getReloadConfig(t *testing.T, r *core.RexRay) gofig.Config {
+	c, err := r.GetConfig().Copy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func getStorageDriver(r *core.RexRay) core.StorageDriver {
+	var sd core.StorageDriver
+	for d := range r.Storage.Drivers() {
+		if d.Name() == mock.MockStorDriverName {
+			sd = d
+		}
+	}
+	return sd
+}
+
+func TestReloadUnchanged(t *testing.T) {
+	r, err := getRexRay()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sd := getStorageDriver(r)
+
+	c := getReloadConfig(t, r)
+	rep, err := r.Reload(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rep.Unchanged) != 3 || len(rep.Reinitialized) > 0 {
+		t.Fatalf("unexpected report %+v", rep)
+	}
+	if getStorageDriver(r) != sd {
+		t.Fatal("replaced an unchanged driver")
+	}
+	if r.GetConfig() != c {
+		t.Fatal("configuration not replaced")
+	}
+}
+
+func TestReloadChanged(t *testing.T) {
+	r, err := getRexRay()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sd := getStorageDriver(r)
+
+	c := getReloadConfig(t, r)
+	c.Set("mockProvider.userName", "root")
+	rep, err := r.Reload(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !gotil.StringInSlice(mock.MockStorDriverName, rep.Reinitialized) {
+		t.Fatalf("driver not reinitialized %+v", rep)
+	}
+	if nsd := getStorageDriver(r); nsd == nil || nsd == sd {
+		t.Fatal("changed driver not replaced")
+	}
+
+	if _, err := sd.GetInstance(testCtx); err != nil {
+		t.Fatalf("replaced driver not usable %v", err)
+	}
+}
+
+func TestReloadSharedChanged(t *testing.T) {
+	r, err := getRexRay()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sd := getStorageDriver(r)
+
+	// drivers read the waiter's properties through their own configuration
+	c := getReloadConfig(t, r)
+	c.Set("rexray.waiter.timeout", "1m")
+	rep, err := r.Reload(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !gotil.StringInSlice(mock.MockStorDriverName, rep.Reinitialized) {
+		t.Fatalf("driver not reinitialized %+v", rep)
+	}
+	if nsd := getStorageDriver(r); nsd == nil || nsd == sd {
+		t.Fatal("driver not replaced")
+	}
+}
+
+func TestReloadFailedDriver(t *testing.T) {
+	r, err := getRexRay()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sd := getStorageDriver(r)
+
+	c := getReloadConfig(t, r)
+	c.Set("rexray.storageDrivers", []string{
+		mock.MockStorDriverName, mock.BadMockStorDriverName})
+	rep, err := r.Reload(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rep.Failed) != 1 ||
+		rep.Failed[0].Name != mock.BadMockStorDriverName {
+		t.Fatalf("unexpected report %+v", rep)
+	}
+	if getStorageDriver(r) != sd {
+		t.Fatal("replaced an unchanged driver")
+	}
+
+	c = getReloadConfig(t, r)
+	c.Set("rexray.strict", true)
+	c.Set("mockProvider.userName", "root")
+	if _, err := r.Reload(c); err == nil {
+		t.Fatal("reloaded a strict configuration with a failed driver")
+	}
+	if r.GetConfig() == c || getStorageDriver(r) != sd {
+		t.Fatal("reload not refused")
+	}
+}
+
+func TestReloadNoDrivers(t *testing.T) {
+	r, err := getRexRay()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sd := getStorageDriver(r)
+
+	c := getReloadConfig(t, r)
+	c.Set("rexray.storageDrivers", []string{mock.BadMockStorDriverName})
+	if _, err := r.Reload(c); err != errors.ErrNoStorageDrivers {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if r.GetConfig() == c || getStorageDriver(r) != sd {
+		t.Fatal("reload not refused")
+	}
+}
+
+func TestReloadNotInitialized(t *testing.T) {
+	r := core.New(nil)
+	if _, err := r.Reload(gofig.New()); err == nil {
+		t.Fatal("reloaded an uninitialized platform")
+	}
+}
@@ -0,0 +1,228 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/emccode/rexray/core"
+	"github.com/emccode/rexray/core/secrets"
+	"github.com/emccode/rexray/daemon/module"
+	"github.com/emccode/rexray/drivers/mock"
+)
+
+const testVaultToken = "vault-token"
+
+// newVaultServer returns a stub Vault server with a version 1 secret at
+// secret/rexray and a version 2 secret at secret/data/rexray.
+func newVaultServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, req *http.Request) {
+			if req.Header.Get("X-Vault-Token") != testVaultToken {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			var body interface{}
+			switch req.URL.Path {
+			case "/v1/secret/rexray":
+				body = map[string]interface{}{
+					"data": map[string]interface{}{"password": "v1-password"},
+				}
+			case "/v1/secret/data/rexray":
+				body = map[string]interface{}{
+					"data": map[string]interface{}{
+						"data":     map[string]interface{}{"password": "v2-password"},
+						"metadata": map[string]interface{}{"version": 1},
+					},
+				}
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			json.NewEncoder(w).Encode(body)
+		}))
+}
+
+func TestVaultProvider(t *testing.T) {
+	srv := newVaultServer()
+	defer srv.Close()
+
+	v := secrets.NewVault(srv.URL, testVaultToken, time.Second)
+
+	tests := []struct {
+		path, val string
+		ok        bool
+	}{
+		{"secret/rexray#password", "v1-password", true},
+		{"secret/data/rexray#password", "v2-password", true},
+		{"secret/rexray#userName", "", false},
+		{"secret/rexray", "", false},
+		{"secret/missing#password", "", false},
+	}
+	for _, tt := range tests {
+		val, err := v.Secret(tt.path)
+		if (err == nil) != tt.ok || val != tt.val {
+			t.Fatalf("%s: %q, %v", tt.path, val, err)
+		}
+	}
+
+	v.Token = "bad-token"
+	if _, err := v.Secret("secret/rexray#password"); err == nil {
+		t.Fatal("read a secret with a bad token")
+	}
+}
+
+func TestResolveSecrets(t *testing.T) {
+	srv := newVaultServer()
+	defer srv.Close()
+
+	d, err := ioutil.TempDir("", "rexray-secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d)
+
+	tokenPath := filepath.Join(d, "token")
+	if err := ioutil.WriteFile(
+		tokenPath, []byte(testVaultToken+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("REXRAY_TEST_USER", "env-user")
+	defer os.Unsetenv("REXRAY_TEST_USER")
+
+	r := core.New(nil)
+	r.Config.Set("rexray.osDrivers", []string{mock.MockOSDriverName})
+	r.Config.Set("rexray.volumeDrivers", []string{mock.MockVolDriverName})
+	r.Config.Set("rexray.storageDrivers", []string{mock.MockStorDriverName})
+	r.Config.Set("rexray.secrets.vault.address", srv.URL)
+	r.Config.Set("rexray.secrets.vault.token", "secret://file"+tokenPath)
+	r.Config.Set("mockProvider.userName", "secret://env/REXRAY_TEST_USER")
+	r.Config.Set("mockProvider.password",
+		"secret://vault/secret/data/rexray#password")
+
+	if err := r.InitDrivers(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the secrets are resolved for the drivers without being written back
+	// into the platform's configuration
+	if v := r.Config.GetString(
+		"mockProvider.password"); v != "secret://vault/secret/data/rexray#password" {
+		t.Fatalf("password resolved in the platform configuration: %q", v)
+	}
+	if !secrets.IsSecret("env-user") || !secrets.IsSecret("v2-password") ||
+		!secrets.IsSecret(testVaultToken) {
+		t.Fatal("secrets not resolved")
+	}
+
+	r.Config.Set("mockProvider.password", "v2-password")
+	rc, err := core.RedactConfig(r.Config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := rc.GetString("mockProvider.password"); v != secrets.Redacted {
+		t.Fatalf("password not redacted: %q", v)
+	}
+	if v := rc.GetString(
+		"mockProvider.userName"); v != "secret://env/REXRAY_TEST_USER" {
+		t.Fatalf("reference redacted: %q", v)
+	}
+	if v := r.Config.GetString("mockProvider.password"); v != "v2-password" {
+		t.Fatal("redacted the original configuration")
+	}
+
+	buf, err := json.Marshal(
+		&module.Config{Address: "tcp://:7979", Config: r.Config})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(buf), "v2-password") {
+		t.Fatalf("module config not redacted %s", buf)
+	}
+
+	out := &bytes.Buffer{}
+	log.SetOutput(out)
+	log.WithField("password", "v2-password").Error("logged in as env-user")
+	log.SetOutput(os.Stderr)
+	if s := out.String(); strings.Contains(s, "v2-password") ||
+		strings.Contains(s, "env-user") || !strings.Contains(s, "logged in") {
+		t.Fatalf("log not redacted %s", s)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	secrets.Reset("pass", "password", "password-1")
+	defer secrets.Reset()
+
+	tests := []struct{ s, redacted string }{
+		{"password-1", secrets.Redacted},
+		{"a password for the pass", "a " + secrets.Redacted + " for the pass"},
+		{"password-12", secrets.Redacted + "2"},
+	}
+	for _, tt := range tests {
+		if s := secrets.Redact(tt.s); s != tt.redacted {
+			t.Fatalf("%q redacted as %q", tt.s, s)
+		}
+	}
+
+	secrets.Reset("password-2")
+	if s := secrets.Redact("password-1"); s != "password-1" {
+		t.Fatalf("redacted a forgotten secret %q", s)
+	}
+}
+
+func TestResolveSecretsReload(t *testing.T) {
+	os.Setenv("REXRAY_TEST_PASSWORD", "old-password")
+	defer os.Unsetenv("REXRAY_TEST_PASSWORD")
+
+	r := core.New(nil)
+	r.Config.Set("rexray.osDrivers", []string{mock.MockOSDriverName})
+	r.Config.Set("rexray.volumeDrivers", []string{mock.MockVolDriverName})
+	r.Config.Set("rexray.storageDrivers", []string{mock.MockStorDriverName})
+	r.Config.Set("mockProvider.password", "secret://env/REXRAY_TEST_PASSWORD")
+	if err := r.InitDrivers(); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv("REXRAY_TEST_PASSWORD", "new-password")
+	c, err := r.Config.Copy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep, err := r.Reload(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rep.Reinitialized) != 3 {
+		t.Fatalf("rotated secret did not reinitialize the drivers %+v", rep)
+	}
+	if secrets.IsSecret("old-password") || !secrets.IsSecret("new-password") {
+		t.Fatal("secrets not replaced on reload")
+	}
+}
+
+func TestResolveSecretsStrict(t *testing.T) {
+	r := core.New(nil)
+	r.Config.Set("rexray.osDrivers", []string{mock.MockOSDriverName})
+	r.Config.Set("rexray.volumeDrivers", []string{mock.MockVolDriverName})
+	r.Config.Set("rexray.storageDrivers", []string{mock.MockStorDriverName})
+	r.Config.Set("mockProvider.password", "secret://env/REXRAY_TEST_MISSING")
+
+	if err := r.InitDrivers(); err != nil {
+		t.Fatal(err)
+	}
+
+	r.Config.Set("rexray.strict", true)
+	if err := r.InitDrivers(); err == nil {
+		t.Fatal("strict InitDrivers succeeded with an unresolved secret")
+	}
+}
@@ -41,7 +41,7 @@ func TestStorageDriverGetVolumeMapping(t *testing.T) {
 		t.Fatal(err)
 	}
 	d := <-r.Storage.Drivers()
-	if _, err := d.GetVolumeMapping(); err != nil {
+	if _, err := d.GetVolumeMapping(testCtx); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -51,7 +51,7 @@ func TestStorageDriverManagerGetVolumeMapping(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if _, err := r.Storage.GetVolumeMapping(); err != nil {
+	if _, err := r.Storage.GetVolumeMapping(testCtx); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -61,7 +61,7 @@ func TestStorageDriverManagerGetVolumeMappingNoDrivers(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if _, err := r.Storage.GetVolumeMapping(); err != errors.ErrNoStorageDetected {
+	if _, err := r.Storage.GetVolumeMapping(testCtx); err != errors.ErrNoStorageDetected {
 		t.Fatal(err)
 	}
 }
@@ -72,7 +72,7 @@ func TestStorageDriverGetInstance(t *testing.T) {
 		t.Fatal(err)
 	}
 	d := <-r.Storage.Drivers()
-	if _, err := d.GetInstance(); err != nil {
+	if _, err := d.GetInstance(testCtx); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -82,7 +82,7 @@ func TestStorageDriverManagerGetInstance(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if _, err := r.Storage.GetInstance(); err != nil {
+	if _, err := r.Storage.GetInstance(testCtx); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -92,7 +92,7 @@ func TestStorageDriverManagerGetInstanceNoDrivers(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if _, err := r.Storage.GetInstance(); err != errors.ErrNoStorageDetected {
+	if _, err := r.Storage.GetInstance(testCtx); err != errors.ErrNoStorageDetected {
 		t.Fatal(err)
 	}
 }
@@ -102,7 +102,7 @@ func TestGetInstances(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if _, err := r.Storage.GetInstances(); err != nil {
+	if _, err := r.Storage.GetInstances(testCtx); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -112,7 +112,7 @@ func TestGetInstancesNoDrivers(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if _, err := r.Storage.GetInstances(); err != errors.ErrNoStorageDetected {
+	if _, err := r.Storage.GetInstances(testCtx); err != errors.ErrNoStorageDetected {
 		t.Fatal(err)
 	}
 }
@@ -124,7 +124,7 @@ func TestStorageDriverGetVolume(t *testing.T) {
 	}
 	d := <-r.Storage.Drivers()
 	if _, err := d.GetVolume(
-		"", ""); err != nil {
+		testCtx, "", ""); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -135,7 +135,7 @@ func TestStorageDriverManagerGetVolume(t *testing.T) {
 		t.Fatal(err)
 	}
 	if _, err := r.Storage.GetVolume(
-		"", ""); err != nil {
+		testCtx, "", ""); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -146,7 +146,7 @@ func TestStorageDriverManagerGetVolumeNoDrivers(t *testing.T) {
 		t.Fatal(err)
 	}
 	if _, err := r.Storage.GetVolume(
-		"", ""); err != errors.ErrNoStorageDetected {
+		testCtx, "", ""); err != errors.ErrNoStorageDetected {
 		t.Fatal(err)
 	}
 }
@@ -158,7 +158,7 @@ func TestStorageDriverGetVolumeAttach(t *testing.T) {
 	}
 	d := <-r.Storage.Drivers()
 	if _, err := d.GetVolumeAttach(
-		"", ""); err != nil {
+		testCtx, "", ""); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -169,7 +169,7 @@ func TestStorageDriverManagerGetVolumeAttach(t *testing.T) {
 		t.Fatal(err)
 	}
 	if _, err := r.Storage.GetVolumeAttach(
-		"", ""); err != nil {
+		testCtx, "", ""); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -180,7 +180,7 @@ func TestStorageDriverManagerGetVolumeAttachNoDrivers(t *testing.T) {
 		t.Fatal(err)
 	}
 	if _, err := r.Storage.GetVolumeAttach(
-		"", ""); err != errors.ErrNoStorageDetected {
+		testCtx, "", ""); err != errors.ErrNoStorageDetected {
 		t.Fatal(err)
 	}
 }
@@ -192,7 +192,7 @@ func TestStorageDriverCreateSnapshot(t *testing.T) {
 	}
 	d := <-r.Storage.Drivers()
 	if _, err := d.CreateSnapshot(
-		false, "", "", ""); err != nil {
+		testCtx, false, "", "", ""); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -203,7 +203,7 @@ func TestStorageDriverManagerCreateSnapshot(t *testing.T) {
 		t.Fatal(err)
 	}
 	if _, err := r.Storage.CreateSnapshot(
-		false, "", "", ""); err != nil {
+		testCtx, false, "", "", ""); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -214,7 +214,7 @@ func TestStorageDriverManagerCreateSnapshotNoDrivers(t *testing.T) {
 		t.Fatal(err)
 	}
 	if _, err := r.Storage.CreateSnapshot(
-		false, "", "", ""); err != errors.ErrNoStorageDetected {
+		testCtx, false, "", "", ""); err != errors.ErrNoStorageDetected {
 		t.Fatal(err)
 	}
 }
@@ -226,7 +226,7 @@ func TestStorageDriverGetSnapshot(t *testing.T) {
 	}
 	d := <-r.Storage.Drivers()
 	if _, err := d.GetSnapshot(
-		"", "", ""); err != nil {
+		testCtx, "", "", ""); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -237,7 +237,7 @@ func TestStorageDriverManagerGetSnapshot(t *testing.T) {
 		t.Fatal(err)
 	}
 	if _, err := r.Storage.GetSnapshot(
-		"", "", ""); err != nil {
+		testCtx, "", "", ""); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -248,7 +248,7 @@ func TestStorageDriverManagerGetSnapshotNoDrivers(t *testing.T) {
 		t.Fatal(err)
 	}
 	if _, err := r.Storage.GetSnapshot(
-		"", "", ""); err != errors.ErrNoStorageDetected {
+		testCtx, "", "", ""); err != errors.ErrNoStorageDetected {
 		t.Fatal(err)
 	}
 }
@@ -259,7 +259,7 @@ func TestStorageDriverRemoveSnapshot(t *testing.T) {
 		t.Fatal(err)
 	}
 	d := <-r.Storage.Drivers()
-	if err := d.RemoveSnapshot(""); err != nil {
+	if err := d.RemoveSnapshot(testCtx, ""); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -269,7 +269,7 @@ func TestStorageDriverManagerRemoveSnapshot(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if err := r.Storage.RemoveSnapshot(""); err != nil {
+	if err := r.Storage.RemoveSnapshot(testCtx, ""); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -279,7 +279,7 @@ func TestStorageDriverManagerRemoveSnapshotNoDrivers(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if err := r.Storage.RemoveSnapshot(""); err != errors.ErrNoStorageDetected {
+	if err := r.Storage.RemoveSnapshot(testCtx, ""); err != errors.ErrNoStorageDetected {
 		t.Fatal(err)
 	}
 }
@@ -291,7 +291,7 @@ func TestStorageDriverCreateVolume(t *testing.T) {
 	}
 	d := <-r.Storage.Drivers()
 	if _, err := d.CreateVolume(
-		false, "", "", "", "", 0, 0, ""); err != nil {
+		testCtx, false, "", "", "", "", 0, 0, ""); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -302,7 +302,7 @@ func TestStorageDriverManagerCreateVolume(t *testing.T) {
 		t.Fatal(err)
 	}
 	if _, err := r.Storage.CreateVolume(
-		false, "", "", "", "", 0, 0, ""); err != nil {
+		testCtx, false, "", "", "", "", 0, 0, ""); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -313,7 +313,43 @@ func TestStorageDriverManagerCreateVolumeNoDrivers(t *testing.T) {
 		t.Fatal(err)
 	}
 	if _, err := r.Storage.CreateVolume(
-		false, "", "", "", "", 0, 0, ""); err != errors.ErrNoStorageDetected {
+		testCtx, false, "", "", "", "", 0, 0, ""); err != errors.ErrNoStorageDetected {
+		t.Fatal(err)
+	}
+}
+
+func TestStorageDriverCloneVolume(t *testing.T) {
+	r, err := getRexRay()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := <-r.Storage.Drivers()
+	v, err := d.CloneVolume(testCtx, "test", "clone", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "clone" {
+		t.Fatalf("volume name != clone; value=%s", v.Name)
+	}
+}
+
+func TestStorageDriverManagerCloneVolume(t *testing.T) {
+	r, err := getRexRay()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Storage.CloneVolume(testCtx, "test", "clone", nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStorageDriverManagerCloneVolumeNoDrivers(t *testing.T) {
+	r, err := getRexRayNoDrivers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Storage.CloneVolume(
+		testCtx, "test", "clone", nil); err != errors.ErrNoStorageDetected {
 		t.Fatal(err)
 	}
 }
@@ -324,7 +360,7 @@ func TestStorageDriverRemoveVolume(t *testing.T) {
 		t.Fatal(err)
 	}
 	d := <-r.Storage.Drivers()
-	if err := d.RemoveVolume(""); err != nil {
+	if err := d.RemoveVolume(testCtx, ""); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -334,7 +370,7 @@ func TestStorageDriverManagerRemoveVolume(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if err := r.Storage.RemoveVolume(""); err != nil {
+	if err := r.Storage.RemoveVolume(testCtx, ""); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -344,7 +380,7 @@ func TestStorageDriverManagerRemoveVolumeNoDrivers(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if err := r.Storage.RemoveVolume(""); err != errors.ErrNoStorageDetected {
+	if err := r.Storage.RemoveVolume(testCtx, ""); err != errors.ErrNoStorageDetected {
 		t.Fatal(err)
 	}
 }
@@ -355,7 +391,7 @@ func TestStorageDriverGetDeviceNextAvailable(t *testing.T) {
 		t.Fatal(err)
 	}
 	d := <-r.Storage.Drivers()
-	if _, err := d.GetDeviceNextAvailable(); err != nil {
+	if _, err := d.GetDeviceNextAvailable(testCtx); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -365,7 +401,7 @@ func TestStorageDriverManagerGetDeviceNextAvailable(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if _, err := r.Storage.GetDeviceNextAvailable(); err != nil {
+	if _, err := r.Storage.GetDeviceNextAvailable(testCtx); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -375,7 +411,7 @@ func TestStorageDriverManagerGetDeviceNextAvailableNoDrivers(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if _, err := r.Storage.GetDeviceNextAvailable(); err != errors.ErrNoStorageDetected {
+	if _, err := r.Storage.GetDeviceNextAvailable(testCtx); err != errors.ErrNoStorageDetected {
 		t.Fatal(err)
 	}
 }
@@ -387,7 +423,7 @@ func TestStorageDriverAttachVolume(t *testing.T) {
 	}
 	d := <-r.Storage.Drivers()
 	if _, err := d.AttachVolume(
-		false, "", "", false); err != nil {
+		testCtx, false, "", "", false); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -398,7 +434,7 @@ func TestStorageDriverManagerAttachVolume(t *testing.T) {
 		t.Fatal(err)
 	}
 	if _, err := r.Storage.AttachVolume(
-		false, "", "", false); err != nil {
+		testCtx, false, "", "", false); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -409,7 +445,7 @@ func TestStorageDriverManagerAttachVolumeNoDrivers(t *testing.T) {
 		t.Fatal(err)
 	}
 	if _, err := r.Storage.AttachVolume(
-		false, "", "", false); err != errors.ErrNoStorageDetected {
+		testCtx, false, "", "", false); err != errors.ErrNoStorageDetected {
 		t.Fatal(err)
 	}
 }
@@ -421,7 +457,7 @@ func TestStorageDriverDetachVolume(t *testing.T) {
 	}
 	d := <-r.Storage.Drivers()
 	if err := d.DetachVolume(
-		false, "", "", false); err != nil {
+		testCtx, false, "", "", false); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -432,7 +468,7 @@ func TestStorageDriverManagerDetachVolume(t *testing.T) {
 		t.Fatal(err)
 	}
 	if err := r.Storage.DetachVolume(
-		false, "", "", false); err != nil {
+		testCtx, false, "", "", false); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -443,7 +479,7 @@ func TestStorageDriverManagerDetachVolumeNoDrivers(t *testing.T) {
 		t.Fatal(err)
 	}
 	if err := r.Storage.DetachVolume(
-		false, "", "", false); err != errors.ErrNoStorageDetected {
+		testCtx, false, "", "", false); err != errors.ErrNoStorageDetected {
 		t.Fatal(err)
 	}
 }
@@ -454,7 +490,7 @@ func TestStorageDriverCopySnapshot(t *testing.T) {
 		t.Fatal(err)
 	}
 	d := <-r.Storage.Drivers()
-	if _, err := d.CopySnapshot(false, "", "", "", "", ""); err != nil {
+	if _, err := d.CopySnapshot(testCtx, false, "", "", "", "", ""); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -464,7 +500,7 @@ func TestStorageDriverManagerCopySnapshot(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if _, err := r.Storage.CopySnapshot(false, "", "", "", "", ""); err != nil {
+	if _, err := r.Storage.CopySnapshot(testCtx, false, "", "", "", "", ""); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -475,7 +511,7 @@ func TestStorageDriverManagerCopySnapshotNoDrivers(t *testing.T) {
 		t.Fatal(err)
 	}
 	if _, err := r.Storage.CopySnapshot(
-		false, "", "", "", "", ""); err != errors.ErrNoStorageDetected {
+		testCtx, false, "", "", "", "", ""); err != errors.ErrNoStorageDetected {
 		t.Fatal(err)
 	}
 }
@@ -14,12 +14,33 @@ import (
 	"github.com/emccode/rexray/core"
 	"github.com/emccode/rexray/core/errors"
 	"github.com/emccode/rexray/drivers/mock"
+	"github.com/emccode/rexray/util"
 )
 
 func TestMain(m *testing.M) {
 	mock.RegisterMockDrivers()
 	mock.RegisterBadMockDrivers()
-	os.Exit(m.Run())
+
+	// keep the files written by the tests, such as the audit log, out of the
+	// host's REX-Ray directories
+	d, err := ioutil.TempDir("", "rexray-test")
+	if err != nil {
+		panic(err)
+	}
+	util.Prefix(d)
+
+	ec := m.Run()
+	os.RemoveAll(d)
+	os.Exit(ec)
+}
+
+// testDriverNames are the names of the drivers registered by the tests.
+var testDriverNames []string
+
+// registerTestDriver registers a driver that is used by the tests.
+func registerTestDriver(name string, ctor core.NewDriver) {
+	testDriverNames = append(testDriverNames, name)
+	core.RegisterDriver(name, ctor)
 }
 
 func getRexRay() (*core.RexRay, error) {
@@ -204,6 +225,9 @@ func TestDriverNames(t *testing.T) {
 		"vmax",
 		"xtremio",
 	}
+	for _, n := range testDriverNames {
+		allDriverNames = append(allDriverNames, strings.ToLower(n))
+	}
 
 	var regDriverNames []string
 	for dn := range core.DriverNames() {
@@ -0,0 +1,138 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/akutz/gofig"
+
+	"github.com/emccode/rexray/core"
+	"github.com/emccode/rexray/drivers/mock"
+)
+
+const schemaTestDriverName = "schemaTestDriver"
+
+func init() {
+	s := core.NewConfigSchema("Schema Test", schemaTestDriverName)
+	s.Key(gofig.String, "", "", "", "schemaTest.endpoint")
+	s.Key(gofig.String, "", "", "", "schemaTest.systemID")
+	s.Key(gofig.String, "", "", "", "schemaTest.systemName")
+	s.Key(gofig.Int, "", 0, "", "schemaTest.size")
+	s.Key(gofig.Bool, "", false, "", "schemaTest.insecure")
+	s.Key(gofig.String, "", "", "", "schemaTest.userID")
+	s.Key(gofig.String, "", "admin", "", "schemaTest.userName")
+	s.Require("schemaTest.endpoint")
+	s.Require("schemaTest.systemID", "schemaTest.systemName")
+	s.Exclude("schemaTest.systemID", "schemaTest.systemName")
+	s.Exclude("schemaTest.userID", "schemaTest.userName")
+	core.RegisterConfigSchema(s)
+}
+
+func getConfigErrors(errs core.ConfigErrors) map[string]string {
+	m := map[string]string{}
+	for _, e := range errs {
+		if e.Schema == "Schema Test" {
+			m[e.Keys[0]] = e.Message
+		}
+	}
+	return m
+}
+
+func TestValidateConfig(t *testing.T) {
+	c := gofig.New()
+	c.Set("rexray.storageDrivers", []string{mock.MockStorDriverName})
+	if errs := getConfigErrors(core.ValidateConfig(c)); len(errs) > 0 {
+		t.Fatalf("validated a disabled driver's schema %v", errs)
+	}
+
+	c.Set("rexray.storageDrivers", []string{schemaTestDriverName})
+	errs := getConfigErrors(core.ValidateConfig(c))
+	if len(errs) != 2 ||
+		errs["schemaTest.endpoint"] != "required" ||
+		errs["schemaTest.systemID"] != "one is required" {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+
+	c.Set("schemaTest.endpoint", "https://localhost")
+	c.Set("schemaTest.systemID", "1")
+	c.Set("schemaTest.systemName", "system")
+	c.Set("schemaTest.size", "large")
+	c.Set("schemaTest.insecure", "yes please")
+	errs = getConfigErrors(core.ValidateConfig(c))
+	if len(errs) != 3 ||
+		errs["schemaTest.systemID"] != "only one may be set" ||
+		errs["schemaTest.size"] == "" ||
+		errs["schemaTest.insecure"] == "" {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+
+	c.Set("schemaTest.systemName", "")
+	c.Set("schemaTest.size", 16)
+	c.Set("schemaTest.insecure", "true")
+	c.Set("schemaTest.userID", "1")
+	if errs := getConfigErrors(core.ValidateConfig(c)); len(errs) > 0 {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+
+	c.Set("schemaTest.userName", "user")
+	errs = getConfigErrors(core.ValidateConfig(c))
+	if len(errs) != 1 || errs["schemaTest.userID"] != "only one may be set" {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	r := core.New(nil)
+	r.Config.Set("rexray.osDrivers", []string{mock.MockOSDriverName})
+	r.Config.Set("rexray.volumeDrivers", []string{mock.MockVolDriverName})
+	r.Config.Set("rexray.storageDrivers",
+		[]string{mock.BadMockStorDriverName, "unknownStorageDriver"})
+
+	v := r.Validate()
+	if v.Valid() {
+		t.Fatal("valid")
+	}
+
+	results := map[string]*core.DriverValidation{}
+	for _, dv := range v.Drivers {
+		results[dv.Name] = dv
+	}
+	if len(results) != 4 {
+		t.Fatalf("len(drivers) %d != 4", len(results))
+	}
+	if dv := results[mock.MockOSDriverName]; !dv.Passed || dv.Type != "os" {
+		t.Fatalf("unexpected result %+v", dv)
+	}
+	if dv := results[mock.MockVolDriverName]; !dv.Passed {
+		t.Fatalf("unexpected result %+v", dv)
+	}
+	if dv := results[mock.BadMockStorDriverName]; dv.Passed ||
+		dv.Type != "storage" || dv.Error != "init error" {
+		t.Fatalf("unexpected result %+v", dv)
+	}
+	if dv := results["unknownStorageDriver"]; dv.Passed || dv.Error == "" {
+		t.Fatalf("unexpected result %+v", dv)
+	}
+}
+
+func TestInitDriversStrict(t *testing.T) {
+	r := core.New(nil)
+	r.Config.Set("rexray.osDrivers", []string{mock.MockOSDriverName})
+	r.Config.Set("rexray.volumeDrivers", []string{mock.MockVolDriverName})
+	r.Config.Set("rexray.storageDrivers",
+		[]string{mock.MockStorDriverName, mock.BadMockStorDriverName})
+
+	if err := r.InitDrivers(); err != nil {
+		t.Fatal(err)
+	}
+
+	r.Config.Set("rexray.strict", true)
+	if err := r.InitDrivers(); err == nil {
+		t.Fatal("strict InitDrivers succeeded with a failed driver")
+	}
+
+	r.Config.Set("rexray.storageDrivers", []string{schemaTestDriverName})
+	err := r.InitDrivers()
+	if _, ok := err.(core.ConfigErrors); !ok {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
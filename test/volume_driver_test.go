@@ -40,7 +40,7 @@ func TestUnmountAll(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if err := r.Volume.UnmountAll(); err != nil {
+	if err := r.Volume.UnmountAll(testCtx); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -50,7 +50,7 @@ func TestUnmountAllNoDrivers(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if err := r.Volume.UnmountAll(); err != errors.ErrNoVolumesDetected {
+	if err := r.Volume.UnmountAll(testCtx); err != errors.ErrNoVolumesDetected {
 		t.Fatal(err)
 	}
 }
@@ -60,7 +60,7 @@ func TestRemoveAll(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if err := r.Volume.RemoveAll(); err != nil {
+	if err := r.Volume.RemoveAll(testCtx); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -70,7 +70,7 @@ func TestRemoveAllNoDrivers(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if err := r.Volume.RemoveAll(); err != errors.ErrNoVolumesDetected {
+	if err := r.Volume.RemoveAll(testCtx); err != errors.ErrNoVolumesDetected {
 		t.Fatal(err)
 	}
 }
@@ -80,7 +80,7 @@ func TestDetachAll(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if err := r.Volume.DetachAll(""); err != nil {
+	if err := r.Volume.DetachAll(testCtx, ""); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -90,7 +90,7 @@ func TestDetachAllNoDrivers(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if err := r.Volume.DetachAll(""); err != errors.ErrNoVolumesDetected {
+	if err := r.Volume.DetachAll(testCtx, ""); err != errors.ErrNoVolumesDetected {
 		t.Fatal(err)
 	}
 }
@@ -101,7 +101,7 @@ func TestVolumeDriverMount(t *testing.T) {
 		t.Fatal(err)
 	}
 	d := <-r.Volume.Drivers()
-	if _, err := d.Mount("", "", false, "", false); err != nil {
+	if _, err := d.Mount(testCtx, "", "", false, "", false); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -111,7 +111,7 @@ func TestVolumeDriverManagerMount(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if _, err := r.Volume.Mount("", "", false, "", false); err != nil {
+	if _, err := r.Volume.Mount(testCtx, "", "", false, "", false); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -121,7 +121,7 @@ func TestVolumeDriverManagerMountNoDrivers(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if _, err := r.Volume.Mount("", "", false, "", false); err != errors.ErrNoVolumesDetected {
+	if _, err := r.Volume.Mount(testCtx, "", "", false, "", false); err != errors.ErrNoVolumesDetected {
 		t.Fatal(err)
 	}
 }
@@ -132,7 +132,7 @@ func TestVolumeDriverUnmount(t *testing.T) {
 		t.Fatal(err)
 	}
 	d := <-r.Volume.Drivers()
-	if err := d.Unmount("", ""); err != nil {
+	if err := d.Unmount(testCtx, "", ""); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -142,7 +142,7 @@ func TestVolumeDriverManagerUnmount(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if err := r.Volume.Unmount("", ""); err != nil {
+	if err := r.Volume.Unmount(testCtx, "", ""); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -152,7 +152,7 @@ func TestVolumeDriverManagerUnmountNoDrivers(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if err := r.Volume.Unmount("", ""); err != errors.ErrNoVolumesDetected {
+	if err := r.Volume.Unmount(testCtx, "", ""); err != errors.ErrNoVolumesDetected {
 		t.Fatal(err)
 	}
 }
@@ -163,7 +163,7 @@ func TestVolumeDriverPath(t *testing.T) {
 		t.Fatal(err)
 	}
 	d := <-r.Volume.Drivers()
-	if _, err := d.Path("", ""); err != nil {
+	if _, err := d.Path(testCtx, "", ""); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -173,7 +173,7 @@ func TestVolumeDriverManagerPath(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if _, err := r.Volume.Path("", ""); err != nil {
+	if _, err := r.Volume.Path(testCtx, "", ""); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -183,7 +183,7 @@ func TestVolumeDriverManagerPathNoDrivers(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if _, err := r.Volume.Path("", ""); err != errors.ErrNoVolumesDetected {
+	if _, err := r.Volume.Path(testCtx, "", ""); err != errors.ErrNoVolumesDetected {
 		t.Fatal(err)
 	}
 }
@@ -194,7 +194,7 @@ func TestVolumeDriverCreate(t *testing.T) {
 		t.Fatal(err)
 	}
 	d := <-r.Volume.Drivers()
-	if err := d.Create("", nil); err != nil {
+	if err := d.Create(testCtx, "", nil); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -204,7 +204,7 @@ func TestVolumeDriverManagerCreate(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if err := r.Volume.Create("", nil); err != nil {
+	if err := r.Volume.Create(testCtx, "", nil); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -214,7 +214,7 @@ func TestVolumeDriverManagerCreateNoDrivers(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if err := r.Volume.Create("", nil); err != errors.ErrNoVolumesDetected {
+	if err := r.Volume.Create(testCtx, "", nil); err != errors.ErrNoVolumesDetected {
 		t.Fatal(err)
 	}
 }
@@ -225,7 +225,7 @@ func TestVolumeDriverRemove(t *testing.T) {
 		t.Fatal(err)
 	}
 	d := <-r.Volume.Drivers()
-	if err := d.Remove(""); err != nil {
+	if err := d.Remove(testCtx, ""); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -235,7 +235,7 @@ func TestVolumeDriverManagerRemove(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if err := r.Volume.Remove(""); err != nil {
+	if err := r.Volume.Remove(testCtx, ""); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -245,7 +245,7 @@ func TestVolumeDriverManagerRemoveNoDrivers(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if err := r.Volume.Remove(""); err != errors.ErrNoVolumesDetected {
+	if err := r.Volume.Remove(testCtx, ""); err != errors.ErrNoVolumesDetected {
 		t.Fatal(err)
 	}
 }
@@ -256,7 +256,7 @@ func TestVolumeDriverAttach(t *testing.T) {
 		t.Fatal(err)
 	}
 	d := <-r.Volume.Drivers()
-	if _, err := d.Attach("", "", false); err != nil {
+	if _, err := d.Attach(testCtx, "", "", false); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -266,7 +266,7 @@ func TestVolumeDriverManagerAttach(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if _, err := r.Volume.Attach("", "", false); err != nil {
+	if _, err := r.Volume.Attach(testCtx, "", "", false); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -276,7 +276,7 @@ func TestVolumeDriverManagerAttachNoDrivers(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if _, err := r.Volume.Attach("", "", false); err != errors.ErrNoVolumesDetected {
+	if _, err := r.Volume.Attach(testCtx, "", "", false); err != errors.ErrNoVolumesDetected {
 		t.Fatal(err)
 	}
 }
@@ -287,7 +287,7 @@ func TestVolumeDriverDetach(t *testing.T) {
 		t.Fatal(err)
 	}
 	d := <-r.Volume.Drivers()
-	if err := d.Detach("", "", false); err != nil {
+	if err := d.Detach(testCtx, "", "", false); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -297,7 +297,7 @@ func TestVolumeDriverManagerDetach(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if err := r.Volume.Detach("", "", false); err != nil {
+	if err := r.Volume.Detach(testCtx, "", "", false); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -307,7 +307,7 @@ func TestVolumeDriverManagerDetachNoDrivers(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if err := r.Volume.Detach("", "", false); err != errors.ErrNoVolumesDetected {
+	if err := r.Volume.Detach(testCtx, "", "", false); err != errors.ErrNoVolumesDetected {
 		t.Fatal(err)
 	}
 }
@@ -318,7 +318,7 @@ func TestVolumeDriverNetworkName(t *testing.T) {
 		t.Fatal(err)
 	}
 	d := <-r.Volume.Drivers()
-	if _, err := d.NetworkName("", ""); err != nil {
+	if _, err := d.NetworkName(testCtx, "", ""); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -328,7 +328,7 @@ func TestVolumeDriverManagerNetworkName(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if _, err := r.Volume.NetworkName("", ""); err != nil {
+	if _, err := r.Volume.NetworkName(testCtx, "", ""); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -338,7 +338,7 @@ func TestVolumeDriverManagerNetworkNameNoDrivers(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if _, err := r.Volume.NetworkName("", ""); err != errors.ErrNoVolumesDetected {
+	if _, err := r.Volume.NetworkName(testCtx, "", ""); err != errors.ErrNoVolumesDetected {
 		t.Fatal(err)
 	}
 }
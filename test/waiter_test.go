@@ -0,0 +1,112 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/akutz/gofig"
+	"golang.org/x/net/context"
+
+	"github.com/emccode/rexray/core/errors"
+	"github.com/emccode/rexray/core/waiter"
+)
+
+func newWaiterOptions(timeout time.Duration) *waiter.Options {
+	return &waiter.Options{
+		Op:          "test",
+		Timeout:     timeout,
+		Interval:    10 * time.Millisecond,
+		MaxInterval: 50 * time.Millisecond,
+		Multiplier:  2,
+		Jitter:      0.2,
+	}
+}
+
+func TestWaiterUntil(t *testing.T) {
+	i := 0
+	err := waiter.Until(context.Background(), newWaiterOptions(time.Second),
+		func() (bool, error) {
+			i++
+			return i == 3, nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i != 3 {
+		t.Fatalf("i != 3; value=%d", i)
+	}
+}
+
+func TestWaiterUntilError(t *testing.T) {
+	expected := errors.ErrNotImplemented
+	err := waiter.Until(context.Background(), newWaiterOptions(time.Second),
+		func() (bool, error) {
+			return false, expected
+		})
+	if err != expected {
+		t.Fatalf("err != %v; value=%v", expected, err)
+	}
+}
+
+func TestWaiterUntilTimeout(t *testing.T) {
+	err := waiter.Until(
+		context.Background(), newWaiterOptions(100*time.Millisecond),
+		func() (bool, error) {
+			return false, nil
+		})
+	if !errors.IsTimeout(err) {
+		t.Fatalf("expected timeout error; value=%v", err)
+	}
+}
+
+func TestWaiterUntilCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	err := waiter.Until(ctx, newWaiterOptions(time.Minute),
+		func() (bool, error) {
+			return false, nil
+		})
+	if !errors.IsCanceled(err) {
+		t.Fatalf("expected canceled error; value=%v", err)
+	}
+}
+
+func TestWaiterUntilBlockedPoll(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+
+	start := time.Now()
+	err := waiter.Until(
+		context.Background(), newWaiterOptions(100*time.Millisecond),
+		func() (bool, error) {
+			<-block
+			return true, nil
+		})
+	if !errors.IsTimeout(err) {
+		t.Fatalf("expected timeout error; value=%v", err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Fatalf("blocked poll not bounded by timeout; waited %v", d)
+	}
+}
+
+func TestWaiterNewOptions(t *testing.T) {
+	c := gofig.New()
+	c.Set("rexray.waiter.timeout", "2m")
+	c.Set("rexray.waiter.timeouts.attach", "45s")
+
+	if o := waiter.NewOptions(c, waiter.OpAttach); o.Timeout != 45*time.Second {
+		t.Fatalf("attach timeout != 45s; value=%v", o.Timeout)
+	}
+
+	if o := waiter.NewOptions(c, waiter.OpDetach); o.Timeout != 2*time.Minute {
+		t.Fatalf("detach timeout != 2m; value=%v", o.Timeout)
+	}
+
+	if o := waiter.NewOptions(c, waiter.OpMount); o.Timeout != 10*time.Second {
+		t.Fatalf("mount timeout != 10s; value=%v", o.Timeout)
+	}
+}
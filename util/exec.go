@@ -0,0 +1,52 @@
+package util
+
+import (
+	"bytes"
+	"os/exec"
+
+	"golang.org/x/net/context"
+)
+
+// RunCommand starts the command and waits for it to complete. If the context
+// is canceled or its deadline elapses before the command completes then the
+// command's process is killed and the context's error is returned.
+func RunCommand(ctx context.Context, cmd *exec.Cmd) error {
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	select {
+	case <-ctx.Done():
+		cmd.Process.Kill()
+		<-done
+		return ctx.Err()
+	case err := <-done:
+		return err
+	}
+}
+
+// CommandOutput runs the command with RunCommand and returns its standard
+// output.
+func CommandOutput(ctx context.Context, cmd *exec.Cmd) ([]byte, error) {
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	err := RunCommand(ctx, cmd)
+	return stdout.Bytes(), err
+}
+
+// CommandCombinedOutput runs the command with RunCommand and returns its
+// combined standard output and standard error.
+func CommandCombinedOutput(
+	ctx context.Context, cmd *exec.Cmd) ([]byte, error) {
+	var b bytes.Buffer
+	cmd.Stdout = &b
+	cmd.Stderr = &b
+	err := RunCommand(ctx, cmd)
+	return b.Bytes(), err
+}
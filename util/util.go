@@ -142,6 +142,12 @@ func LibFilePath(fileName string) string {
 	return fmt.Sprintf("%s/%s", LibDirPath(), fileName)
 }
 
+// VolumesDirPath returns the path to the directory inside the REX-Ray lib
+// directory in which volumes are mounted.
+func VolumesDirPath() string {
+	return LibFilePath("volumes")
+}
+
 // BinDirPath returns the path to the REX-Ray bin directory.
 func BinDirPath() string {
 	if binDirPath == "" {
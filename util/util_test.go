@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"testing"
 	"time"
 
 	"github.com/akutz/gotil"
+	"golang.org/x/net/context"
 
 	"github.com/emccode/rexray/core/version"
 )
@@ -252,3 +254,36 @@ func TestInstallChownRoot(t *testing.T) {
 func TestInstallDirChownRoot(t *testing.T) {
 	InstallDirChownRoot("--help")
 }
+
+func TestRunCommand(t *testing.T) {
+	if err := RunCommand(
+		context.Background(), exec.Command("true")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunCommandCanceled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(
+		context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := RunCommand(ctx, exec.Command("sleep", "10"))
+	if err != context.DeadlineExceeded {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatal("command not killed when context deadline elapsed")
+	}
+}
+
+func TestCommandOutput(t *testing.T) {
+	out, err := CommandOutput(
+		context.Background(), exec.Command("echo", "hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "hello\n" {
+		t.Fatalf("unexpected output %q", out)
+	}
+}